@@ -34,6 +34,7 @@ func main() {
 	logger.InitLog.Infof("%+v", conf)
 
 	pfcpi := pfcpiface.NewPFCPIface(conf)
+	pfcpi.SetConfigPath(*configPath)
 
 	// blocking
 	pfcpi.Run()
@@ -46,6 +46,9 @@ class Parser:
         self.interfaces = dict()
         self.notify_sockaddr = "/tmp/notifycp"
         self.endmarker_sockaddr = "/tmp/pfcpport"
+        self.enable_error_indication = False
+        self.error_indication_sockaddr = "/tmp/errorindication"
+        self.enable_ipv6 = False
         self.enable_slice_metering = False
         self.enable_gtpu_path_monitoring = False
         self.measure_flow = False
@@ -188,6 +191,29 @@ class Parser:
                     "/tmp/pfcpport"
                 )
             )
+
+        # GTP-U Error Indications
+        try:
+            self.enable_error_indication = bool(self.conf["enable_error_indication"])
+        except KeyError:
+            print("GTP-U Error Indication reporting disabled")
+
+        # UnixPort Paths
+        try:
+            self.error_indication_sockaddr = self.conf["error_indication_sockaddr"]
+        except KeyError:
+            print(
+                "Can't parse unix socket paths for error indication! Setting it to default values ({})".format(
+                    "/tmp/errorindication"
+                )
+            )
+
+        # IPv6 tables
+        try:
+            self.enable_ipv6 = bool(self.conf["enable_ipv6"])
+        except KeyError:
+            print("IPv6 disabled")
+
         # Flow measurements
         try:
             self.measure_flow = bool(self.conf["measure_flow"])
@@ -5,9 +5,13 @@ go 1.25.0
 require (
 	github.com/libp2p/go-reuseport v0.4.0
 	github.com/omec-project/pfcpsim v1.5.0
+	github.com/p4lang/p4runtime v1.5.0
 	github.com/prometheus/client_golang v1.24.1
 	github.com/wmnsk/go-pfcp v0.0.24
+	go.etcd.io/bbolt v1.4.3
 	go.uber.org/zap v1.28.0
+	golang.org/x/sys v0.47.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260818201246-1b0934165a6f
 	google.golang.org/grpc v1.83.0
 	google.golang.org/protobuf v1.36.12
 )
@@ -21,7 +25,5 @@ require (
 	github.com/prometheus/procfs v0.21.1 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/net v0.58.0 // indirect
-	golang.org/x/sys v0.47.0 // indirect
 	golang.org/x/text v0.41.0 // indirect
-	google.golang.org/genproto/googleapis/rpc v0.0.0-20260818201246-1b0934165a6f // indirect
 )
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+)
+
+// orphanedAssociation holds the sessions of a lost PFCP association during the
+// association loss grace period.
+type orphanedAssociation struct {
+	pConn *PFCPConn
+	timer *time.Timer
+}
+
+// orphanSessions keeps the sessions of the lost association pConn for the grace
+// period. If the peer does not set up a new association in the meantime, they
+// are purged.
+func (node *PFCPNode) orphanSessions(pConn *PFCPConn, grace time.Duration) {
+	nodeID := pConn.remoteNodeID()
+
+	n := len(pConn.store.GetAllSessions())
+	if nodeID == "" || n == 0 {
+		pConn.purgeAllSessions()
+		return
+	}
+
+	o := &orphanedAssociation{pConn: pConn}
+
+	node.orphansMu.Lock()
+
+	if node.orphans == nil {
+		node.orphans = make(map[string]*orphanedAssociation)
+	}
+
+	prev := node.orphans[nodeID]
+	node.orphans[nodeID] = o
+
+	o.timer = time.AfterFunc(grace, func() {
+		if node.takeOrphan(nodeID, o) == nil {
+			return
+		}
+
+		logger.PfcpLog.Infof("association with %v not recovered within %v, purging its sessions", nodeID, grace)
+		pConn.purgeAllSessions()
+	})
+
+	node.orphansMu.Unlock()
+
+	logger.PfcpLog.Infof("keeping %d sessions of lost association with %v for %v", n, nodeID, grace)
+
+	// Only the sessions of the last association with the peer can be adopted.
+	if prev != nil {
+		prev.timer.Stop()
+		prev.pConn.purgeAllSessions()
+	}
+}
+
+// takeOrphan removes and returns the orphaned association of the peer. If o is
+// not nil, the association is only removed if it is still o.
+func (node *PFCPNode) takeOrphan(nodeID string, o *orphanedAssociation) *orphanedAssociation {
+	node.orphansMu.Lock()
+	defer node.orphansMu.Unlock()
+
+	cur, ok := node.orphans[nodeID]
+	if !ok || (o != nil && cur != o) {
+		return nil
+	}
+
+	delete(node.orphans, nodeID)
+
+	return cur
+}
+
+// purgeOrphans purges the sessions of all the lost associations.
+func (node *PFCPNode) purgeOrphans() {
+	node.orphansMu.Lock()
+	orphans := node.orphans
+	node.orphans = nil
+	node.orphansMu.Unlock()
+
+	for _, o := range orphans {
+		o.timer.Stop()
+		o.pConn.purgeAllSessions()
+	}
+}
+
+// adoptOrphanedSessions takes over the sessions of a lost association with the
+// same peer, once the association is set up.
+func (pConn *PFCPConn) adoptOrphanedSessions() {
+	node := pConn.node
+	if node == nil {
+		return
+	}
+
+	o := node.takeOrphan(pConn.remoteNodeID(), nil)
+	if o == nil {
+		return
+	}
+
+	o.timer.Stop()
+
+	old := o.pConn
+	adopted := 0
+
+	pConn.upf.sessionMu.Lock()
+	defer pConn.upf.sessionMu.Unlock()
+
+	for _, s := range old.store.GetAllSessions() {
+		// Persistent stores share the session records, so the session is
+		// removed from the lost association before being stored again.
+		if err := old.store.DeleteSession(s.localSEID); err != nil {
+			logger.PfcpLog.Warnf("failed to delete adopted session %v: %v", s.localSEID, err)
+		}
+
+		if err := pConn.store.PutSession(s); err != nil {
+			logger.PfcpLog.Errorf("failed to adopt session %v: %v", s.localSEID, err)
+			old.purgeSession(s)
+
+			continue
+		}
+
+		node.sessions.Store(s.localSEID, pConn)
+
+		adopted++
+	}
+
+	logger.PfcpLog.Infof("adopted %d sessions of the previous association with %v", adopted, pConn.remoteNodeID())
+
+	old.restart.mu.Lock()
+	oldTS := old.ts.remote
+	old.restart.mu.Unlock()
+
+	pConn.restart.mu.Lock()
+	newTS := pConn.ts.remote
+	pConn.restart.mu.Unlock()
+
+	// The peer restarted while the association was down.
+	if !oldTS.IsZero() && newTS.After(oldTS) {
+		logger.PfcpLog.Warnln("peer", pConn.remoteNodeID(), "restarted while the association was down")
+		pConn.handlePeerRestart()
+	}
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAssociationLoss(t *testing.T) {
+	t.Run("association release purges sessions", func(t *testing.T) {
+		p := newRestartTestConn(t, peerRestartPolicyPurge, 0)
+		p.upf.assocLossGrace = time.Hour
+		s := newRestartTestSession(t, p)
+
+		p.Shutdown()
+
+		if _, ok := p.store.GetSession(s.localSEID); ok {
+			t.Error("session not purged after association release")
+		}
+
+		if p.upf.fteidGenerator.IsAllocated(s.pdrs[0].tunnelTEID) {
+			t.Error("F-TEID not released after association release")
+		}
+	})
+
+	t.Run("new association adopts sessions", func(t *testing.T) {
+		lost := newRestartTestConn(t, peerRestartPolicyPurge, 0)
+		lost.upf.assocLossGrace = time.Hour
+		s := newRestartTestSession(t, lost)
+
+		lost.shutdownOnPeerLoss()
+
+		if _, ok := lost.store.GetSession(s.localSEID); !ok {
+			t.Fatal("session purged during the grace period")
+		}
+
+		p := newTestPFCPConn(lost.node, 2)
+		p.nodeID.remote = lost.nodeID.remote
+
+		p.adoptOrphanedSessions()
+
+		if _, ok := p.store.GetSession(s.localSEID); !ok {
+			t.Error("session not adopted by the new association")
+		}
+
+		if owner, ok := p.node.sessionOwner(s.localSEID); !ok || owner != p {
+			t.Error("adopted session not owned by the new association")
+		}
+
+		if n := len(lost.store.GetAllSessions()); n != 0 {
+			t.Errorf("expected no session left in the lost association, got %d", n)
+		}
+	})
+
+	t.Run("sessions purged after grace period", func(t *testing.T) {
+		lost := newRestartTestConn(t, peerRestartPolicyPurge, 0)
+		lost.upf.assocLossGrace = 10 * time.Millisecond
+		s := newRestartTestSession(t, lost)
+
+		lost.shutdownOnPeerLoss()
+
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if _, ok := lost.store.GetSession(s.localSEID); !ok {
+				break
+			}
+
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		if _, ok := lost.store.GetSession(s.localSEID); ok {
+			t.Fatal("session not purged after the grace period")
+		}
+
+		if err := lost.upf.ippool.DeallocIP(s.localSEID); err == nil {
+			t.Error("UE IP not released after the grace period")
+		}
+
+		if o := lost.node.takeOrphan(lost.nodeID.remote, nil); o != nil {
+			t.Error("lost association still adoptable after the grace period")
+		}
+	})
+}
@@ -10,7 +10,9 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/omec-project/upf-epc/logger"
@@ -20,6 +22,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
@@ -30,6 +33,8 @@ const (
 	SockAddr = "/tmp/notifycp"
 	// PfcpAddr : Unix Socket path to send end marker packet.
 	PfcpAddr = "/tmp/pfcpport"
+	// ErrorIndicationAddr : Unix Socket path to read GTP-U Error Indications from.
+	ErrorIndicationAddr = "/tmp/errorindication"
 	// AppQerLookup: Application Qos table Name.
 	AppQerLookup = "appQERLookup"
 	// SessQerLookup: Session Qos table Name.
@@ -80,6 +85,35 @@ var intEnc = func(u uint64) *pb.FieldData {
 	return &pb.FieldData{Encoding: &pb.FieldData_ValueInt{ValueInt: u}}
 }
 
+// sameFieldData tells whether the fields hold the same values.
+func sameFieldData(a, b []*pb.FieldData) bool {
+	return slices.EqualFunc(a, b, func(x, y *pb.FieldData) bool { return proto.Equal(x, y) })
+}
+
+// ip6Enc encodes a 128-bit IPv6 address or mask as two 64-bit fields, the
+// widest supported by the BESS match tables.
+func ip6Enc(b []byte) []*pb.FieldData {
+	var ip [net.IPv6len]byte
+
+	copy(ip[:], b)
+
+	return []*pb.FieldData{
+		intEnc(binary.BigEndian.Uint64(ip[:8])),
+		intEnc(binary.BigEndian.Uint64(ip[8:])),
+	}
+}
+
+const (
+	// pdrLookupIPv4 and pdrLookupIPv6 are the PDR tables matching IPv4 and
+	// IPv6 traffic respectively.
+	pdrLookupIPv4 = "pdrLookup"
+	pdrLookupIPv6 = "pdrLookup6"
+	// farLookupIPv4 and farLookupIPv6 are the FAR tables of GTP-U tunnels
+	// over IPv4 and IPv6 respectively.
+	farLookupIPv4 = "farLookup"
+	farLookupIPv6 = "farLookup6"
+)
+
 var bessIP = flag.String("bess", "localhost:10514", "BESS IP/port combo")
 
 var enableGtpuPathMonitoring = false
@@ -89,8 +123,33 @@ type bess struct {
 	conn             *grpc.ClientConn
 	endMarkerSocket  net.Conn
 	notifyBessSocket net.Conn
+	errIndSocket     net.Conn
 	endMarkerChan    chan []byte
-	qciQosMap        map[uint8]*QosConfigVal
+	// qciQosMu guards qciQosMap, which is rebuilt by config reloads.
+	qciQosMu  sync.RWMutex
+	qciQosMap map[uint8]*QosConfigVal
+	// statsMu serializes flow measurement reads, which flip and clear
+	// the measurement buffers, and guards pdrCounters and pendingStats.
+	statsMu     sync.Mutex
+	pdrCounters map[pdrCounterKey]pdrCounters
+	// pendingStats are the statistics read since the last session stats
+	// collection, by flow measurement module.
+	pendingStats map[string]flowStats
+	// gtpuMu serializes GTP-U path monitoring reads and guards the echo
+	// response counters used for path failure detection.
+	gtpuMu     sync.Mutex
+	gtpuEchoes map[uint32]uint64
+	// echo responses read since the module was last cleared.
+	gtpuSeen map[uint32]uint64
+	// enableIPv6 is set when the pipeline has a PDR table for IPv6 traffic.
+	enableIPv6 bool
+	// sliceMeter is the last slice meter configuration, replayed after BESS
+	// restarts.
+	sliceMeterMu sync.Mutex
+	sliceMeter   *SliceMeterConfig
+	// batcher groups the rule commands into batches, nil if they are sent
+	// one by one.
+	batcher *bessBatcher
 }
 
 func (b *bess) IsConnected(accessIP *net.IP) bool {
@@ -101,112 +160,136 @@ func (b *bess) IsConnected(accessIP *net.IP) bool {
 	return true
 }
 
-func (b *bess) SendEndMarkers(endMarkerList *[][]byte) error {
-	for _, eMarker := range *endMarkerList {
-		b.endMarkerChan <- eMarker
-	}
+// pipelineReadyInterval is the period at which the pipeline of a restarted BESS
+// is checked before the rules are replayed.
+const pipelineReadyInterval = time.Second
 
-	return nil
-}
+// watchConnection watches the state of the gRPC connection to BESS. Once a
+// lost connection is re-established, BESS may have restarted with empty tables:
+// the slice meters are replayed and resync is signaled so that the rules of all
+// the sessions are replayed.
+func (b *bess) watchConnection(resync chan<- struct{}) {
+	ctx := context.Background()
+	ready, lost := false, false
 
-func (b *bess) AddSliceInfo(sliceInfo *SliceInfo) error {
-	var sliceMeterConfig SliceMeterConfig
-	sliceMeterConfig.N6RateBps = sliceInfo.uplinkMbr
-	sliceMeterConfig.N3RateBps = sliceInfo.downlinkMbr
-	sliceMeterConfig.N6BurstBytes = sliceInfo.ulBurstBytes
-	sliceMeterConfig.N3BurstBytes = sliceInfo.dlBurstBytes
+	for {
+		state := b.conn.GetState()
 
-	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
-	defer cancel()
+		switch state {
+		case connectivity.Ready:
+			if lost {
+				logger.BessLog.Infoln("connection to BESS re-established, resyncing datapath")
 
-	done := make(chan bool)
+				if !b.waitForPipeline() {
+					return
+				}
 
-	b.addSliceMeter(ctx, done, sliceMeterConfig)
+				b.replaySliceMeter()
 
-	rc := b.GRPCJoin(1, Timeout, done)
+				select {
+				case resync <- struct{}{}:
+				default:
+					// A resync is already pending.
+				}
+			}
 
-	if !rc {
-		logger.BessLog.Errorln(errGRPCCallFailed)
-	}
+			ready, lost = true, false
+		case connectivity.Shutdown:
+			return
+		default:
+			if ready {
+				logger.BessLog.Warnln("lost connection to BESS:", state)
+			}
 
-	return nil
+			lost = lost || ready
+			ready = false
+
+			if state == connectivity.Idle {
+				b.conn.Connect()
+			}
+		}
+
+		if !b.conn.WaitForStateChange(ctx, state) {
+			return
+		}
+	}
 }
 
-func (b *bess) SendMsgToUPF(
-	method upfMsgType, rules PacketForwardingRules, updated PacketForwardingRules,
-) uint8 {
-	// create context
-	cause := ie.CauseRequestAccepted
+// waitForPipeline waits until the pipeline of BESS is set up again. It returns
+// false if the connection is closed in the meantime.
+func (b *bess) waitForPipeline() bool {
+	for b.conn.GetState() != connectivity.Shutdown {
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		_, err := b.client.GetModuleInfo(ctx, &pb.GetModuleInfoRequest{Name: pdrLookupIPv4})
+		cancel()
 
-	pdrs := rules.pdrs
-	fars := rules.fars
-	qers := rules.qers
+		if err == nil {
+			return true
+		}
 
-	if method == upfMsgTypeMod {
-		pdrs = updated.pdrs
-		fars = updated.fars
-		qers = updated.qers
+		logger.BessLog.Debugln("waiting for BESS pipeline:", err)
+		time.Sleep(pipelineReadyInterval)
 	}
 
-	calls := len(pdrs) + len(fars) + len(qers)
-	if calls == 0 {
-		return cause
-	}
+	return false
+}
+
+// setSliceMeter installs the slice meters and remembers their configuration.
+func (b *bess) setSliceMeter(meterConfig SliceMeterConfig) {
+	b.sliceMeterMu.Lock()
+	b.sliceMeter = &meterConfig
+	b.sliceMeterMu.Unlock()
 
 	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
 	defer cancel()
 
 	done := make(chan bool)
 
-	for _, pdr := range pdrs {
-		logger.BessLog.Debugln(method, pdr)
+	b.addSliceMeter(ctx, done, meterConfig)
 
-		switch method {
-		case upfMsgTypeAdd:
-			fallthrough
-		case upfMsgTypeMod:
-			b.addPDR(ctx, done, pdr)
-		case upfMsgTypeDel:
-			b.delPDR(ctx, done, pdr)
-		}
+	rc := b.GRPCJoin(1, Timeout, done)
+	if !rc {
+		logger.BessLog.Errorln(errGRPCCallFailed)
 	}
+}
 
-	for _, far := range fars {
-		logger.BessLog.Debugln(method, far)
+func (b *bess) replaySliceMeter() {
+	b.sliceMeterMu.Lock()
+	meterConfig := b.sliceMeter
+	b.sliceMeterMu.Unlock()
 
-		switch method {
-		case upfMsgTypeAdd:
-			fallthrough
-		case upfMsgTypeMod:
-			b.addFAR(ctx, done, far)
-		case upfMsgTypeDel:
-			b.delFAR(ctx, done, far)
-		}
+	if meterConfig != nil {
+		b.setSliceMeter(*meterConfig)
 	}
+}
 
-	for _, qer := range qers {
-		logger.BessLog.Debugln(method, qer)
-
-		switch method {
-		case upfMsgTypeAdd:
-			fallthrough
-		case upfMsgTypeMod:
-			b.addQER(ctx, done, qer)
-		case upfMsgTypeDel:
-			b.delQER(ctx, done, qer)
-		}
+func (b *bess) SendEndMarkers(endMarkerList *[][]byte) error {
+	for _, eMarker := range *endMarkerList {
+		b.endMarkerChan <- eMarker
 	}
 
-	rc := b.GRPCJoin(calls, Timeout, done)
-	if !rc {
-		logger.BessLog.Errorln(errGRPCCallFailed)
-	}
+	return nil
+}
+
+func (b *bess) AddSliceInfo(sliceInfo *SliceInfo) error {
+	var sliceMeterConfig SliceMeterConfig
+	sliceMeterConfig.N6RateBps = sliceInfo.uplinkMbr
+	sliceMeterConfig.N3RateBps = sliceInfo.downlinkMbr
+	sliceMeterConfig.N6BurstBytes = sliceInfo.ulBurstBytes
+	sliceMeterConfig.N3BurstBytes = sliceInfo.dlBurstBytes
+
+	b.setSliceMeter(sliceMeterConfig)
 
-	return cause
+	return nil
 }
 
 func (b *bess) Exit() {
 	logger.BessLog.Infoln("exit function Bess")
+
+	if b.batcher != nil {
+		b.batcher.stop()
+	}
+
 	b.conn.Close()
 }
 
@@ -360,7 +443,7 @@ func (b *bess) SummaryGtpuLatency(uc *upfCollector, ch chan<- prometheus.Metric)
 	if !uc.upf.enableGtpuMonitor {
 		return
 	}
-	gtpuPathStatsResp := b.readGtpuPathMonitoringStats(GtpuPathMonitoringMeasure, true)
+	gtpuPathStatsResp := b.readGtpuPathStats(true)
 	if gtpuPathStatsResp == nil {
 		logger.BessLog.Errorln(GtpuPathMonitoringMeasure, "read failed")
 		return
@@ -517,11 +600,119 @@ func (b *bess) readGtpuPathMonitoringStats(
 	return &res
 }
 
-func (b *bess) SessionStats(pc *PfcpNodeCollector, ch chan<- prometheus.Metric) (err error) {
-	// Clearing table data with large tables is slow, let's wait for a little longer since this is
-	// non-blocking for the dataplane anyway.
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancel()
+// readGtpuPathStats reads the GTP-U path monitoring module and accumulates the
+// echo responses received from every remote GTP-U peer, whether the read
+// clears the module or not.
+func (b *bess) readGtpuPathStats(isClear bool) *pb.GtpuPathMonitoringCommandReadResponse {
+	b.gtpuMu.Lock()
+	defer b.gtpuMu.Unlock()
+
+	res := b.readGtpuPathMonitoringStats(GtpuPathMonitoringMeasure, isClear)
+	if res == nil {
+		return nil
+	}
+
+	if b.gtpuEchoes == nil {
+		b.gtpuEchoes = make(map[uint32]uint64)
+		b.gtpuSeen = make(map[uint32]uint64)
+	}
+
+	present := make(map[uint32]struct{}, len(res.Statistics))
+
+	for _, s := range res.Statistics {
+		present[s.GnbIp] = struct{}{}
+
+		if seen := b.gtpuSeen[s.GnbIp]; s.Count >= seen {
+			b.gtpuEchoes[s.GnbIp] += s.Count - seen
+		} else {
+			// The module was cleared by other means.
+			b.gtpuEchoes[s.GnbIp] += s.Count
+		}
+
+		if isClear {
+			delete(b.gtpuSeen, s.GnbIp)
+		} else {
+			b.gtpuSeen[s.GnbIp] = s.Count
+		}
+	}
+
+	// Peers are removed from the module with their last FAR.
+	for ip := range b.gtpuEchoes {
+		if _, ok := present[ip]; !ok {
+			delete(b.gtpuEchoes, ip)
+			delete(b.gtpuSeen, ip)
+		}
+	}
+
+	return res
+}
+
+// GtpuPathCounters returns the echo responses received from every monitored
+// remote GTP-U peer.
+func (b *bess) GtpuPathCounters() (map[uint32]uint64, error) {
+	if !enableGtpuPathMonitoring {
+		return nil, nil
+	}
+
+	if b.readGtpuPathStats(false) == nil {
+		return nil, ErrOperationFailedWithReason("GTP-U path monitoring read", "no statistics")
+	}
+
+	b.gtpuMu.Lock()
+	defer b.gtpuMu.Unlock()
+
+	counters := make(map[uint32]uint64, len(b.gtpuEchoes))
+	for k, v := range b.gtpuEchoes {
+		counters[k] = v
+	}
+
+	return counters, nil
+}
+
+// flowStatsPercentiles are the latency and jitter percentiles read from the
+// flow measurement modules.
+var flowStatsPercentiles = []float64{50, 90, 99}
+
+// flowStats holds the flow measurement statistics of one module by FSEID and PDR.
+type flowStats map[pdrCounterKey]*pb.FlowMeasureReadResponse_Statistic
+
+// add accumulates the packets and bytes of s. Latency and jitter percentiles
+// can't be merged, the ones of the latest read with traffic are kept.
+func (f flowStats) add(s *pb.FlowMeasureReadResponse_Statistic) {
+	key := pdrCounterKey{fseID: s.Fseid, pdrID: uint32(s.Pdr)}
+
+	prev, ok := f[key]
+	if !ok {
+		f[key] = s
+		return
+	}
+
+	prev.TotalPackets += s.TotalPackets
+	prev.TotalBytes += s.TotalBytes
+
+	if s.TotalPackets > 0 {
+		prev.Latency = s.Latency
+		prev.Jitter = s.Jitter
+	}
+}
+
+func (f flowStats) response() *pb.FlowMeasureReadResponse {
+	resp := &pb.FlowMeasureReadResponse{}
+	for _, s := range f {
+		resp.Statistics = append(resp.Statistics, s)
+	}
+
+	return resp
+}
+
+// readFlowStats flips and reads (clearing) all flow measurement modules. It is
+// the only reader of the modules: since every read clears the counters, post
+// QoS values are accumulated into pdrCounters for usage reporting and all
+// values are kept in pendingStats until the next session stats collection.
+func (b *bess) readFlowStats(ctx context.Context) error {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
 	// Flips the buffer flag, automatically waits for in-flight packets to drain.
 	flip, err := b.flipFlowMeasurementBufferFlag(ctx, PreQosFlowMeasure)
 	if err != nil {
@@ -529,42 +720,102 @@ func (b *bess) SessionStats(pc *PfcpNodeCollector, ch chan<- prometheus.Metric)
 		return err
 	}
 
-	q := []float64{50, 90, 99}
+	if b.pendingStats == nil {
+		b.pendingStats = make(map[string]flowStats)
+	}
+
+	if b.pdrCounters == nil {
+		b.pdrCounters = make(map[pdrCounterKey]pdrCounters)
+	}
 
 	// Read stats from the now inactive side, and clear if needed.
-	qosStatsInResp, err := b.readFlowMeasurement(ctx, PreQosFlowMeasure, flip.OldFlag, true, q)
-	if err != nil {
-		logger.BessLog.Errorln(PreQosFlowMeasure, errReadFailed, err)
-		return err
+	for _, module := range []string{PreQosFlowMeasure, PostDlQosFlowMeasure, PostUlQosFlowMeasure} {
+		resp, err := b.readFlowMeasurement(ctx, module, flip.OldFlag, true, flowStatsPercentiles)
+		if err != nil {
+			logger.BessLog.Errorln(module, errReadFailed, err)
+			return err
+		}
+
+		pending, ok := b.pendingStats[module]
+		if !ok {
+			pending = make(flowStats)
+			b.pendingStats[module] = pending
+		}
+
+		for _, stat := range resp.Statistics {
+			pending.add(stat)
+
+			if module == PreQosFlowMeasure {
+				continue
+			}
+
+			key := pdrCounterKey{fseID: stat.Fseid, pdrID: uint32(stat.Pdr)}
+			c := b.pdrCounters[key]
+			c.packets += stat.TotalPackets
+			c.bytes += stat.TotalBytes
+			b.pdrCounters[key] = c
+		}
 	}
 
-	postDlQosStatsResp, err := b.readFlowMeasurement(ctx, PostDlQosFlowMeasure, flip.OldFlag, true, q)
-	if err != nil {
-		logger.BessLog.Errorln(PostDlQosFlowMeasure, errReadFailed, err)
-		return err
+	return nil
+}
+
+// takeFlowStats reads the flow measurement modules and returns the statistics
+// accumulated since the previous call, including the ones read by usage polls.
+func (b *bess) takeFlowStats(ctx context.Context) (pre, postDl, postUl *pb.FlowMeasureReadResponse, err error) {
+	if err = b.readFlowStats(ctx); err != nil {
+		return nil, nil, nil, err
 	}
 
-	postUlQosStatsResp, err := b.readFlowMeasurement(ctx, PostUlQosFlowMeasure, flip.OldFlag, true, q)
-	if err != nil {
-		logger.BessLog.Errorln(PostUlQosFlowMeasure, errReadFailed, err)
-		return err
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	pre = b.pendingStats[PreQosFlowMeasure].response()
+	postDl = b.pendingStats[PostDlQosFlowMeasure].response()
+	postUl = b.pendingStats[PostUlQosFlowMeasure].response()
+	b.pendingStats = nil
+
+	return pre, postDl, postUl, nil
+}
+
+// UsageCounters returns the packets and bytes forwarded by every PDR since it was installed.
+func (b *bess) UsageCounters() (map[pdrCounterKey]pdrCounters, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err := b.readFlowStats(ctx); err != nil {
+		return nil, err
 	}
 
-	// TODO: pick first connection for now
-	var con *PFCPConn
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
 
-	pc.node.pConns.Range(func(key, value interface{}) bool {
-		pConn, ok := value.(*PFCPConn)
-		if !ok {
-			return false
-		}
+	counters := make(map[pdrCounterKey]pdrCounters, len(b.pdrCounters))
+	for k, v := range b.pdrCounters {
+		counters[k] = v
+	}
 
-		con = pConn
-		return false
-	})
+	return counters, nil
+}
+
+func (b *bess) forgetPDRCounters(p pdr) {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	delete(b.pdrCounters, pdrCounterKey{fseID: p.fseID, pdrID: p.pdrID})
+}
+
+func (b *bess) SessionStats(pc *PfcpNodeCollector, ch chan<- prometheus.Metric) (err error) {
+	// Clearing table data with large tables is slow, let's wait for a little longer since this is
+	// non-blocking for the dataplane anyway.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
 
-	if con == nil {
-		logger.BessLog.Warnln("no active PFCP connection, UE IP lookup disabled")
+	q := flowStatsPercentiles
+
+	qosStatsInResp, postDlQosStatsResp, postUlQosStatsResp, err := b.takeFlowStats(ctx)
+	if err != nil {
+		return err
 	}
 
 	// Prepare session stats.
@@ -590,7 +841,7 @@ func (b *bess) SessionStats(pc *PfcpNodeCollector, ch chan<- prometheus.Metric)
 			pdrString := strconv.FormatUint(pre.Pdr, 10)
 			ueIpString := UnknownString
 
-			if con != nil {
+			if con, found := pc.node.sessionOwner(pre.Fseid); found {
 				session, ok := con.store.GetSession(pre.Fseid)
 				if !ok {
 					logger.BessLog.Errorln("invalid or unknown FSEID", pre.Fseid)
@@ -605,6 +856,10 @@ func (b *bess) SessionStats(pc *PfcpNodeCollector, ch chan<- prometheus.Metric)
 
 						break
 					}
+
+					if p.IsUplink() && p.ueAddress6 != nil {
+						ueIpString = p.ueAddress6.String()
+					}
 				}
 			}
 
@@ -694,8 +949,61 @@ func (b *bess) notifyListen(reportNotifyChan chan<- uint64) {
 	}
 }
 
+// errorIndicationListen reads the GTP-U Error Indications received by BESS.
+// Each notification carries the Ethernet frame of the Error Indication, as
+// received on the access interface.
+func (b *bess) errorIndicationListen(errorIndicationChan chan<- uint64) {
+	// Rate limits the reports of the same remote F-TEID.
+	notifier := NewDownlinkDataNotifier(errorIndicationChan, 20*time.Second)
+
+	for {
+		buf := make([]byte, 2048)
+
+		n, err := b.errIndSocket.Read(buf)
+		if err != nil {
+			return
+		}
+
+		teid, peerIP, err := parseErrorIndicationFrame(buf[:n])
+		if err != nil {
+			logger.BessLog.Warnln("ignoring error indication notification:", err)
+			continue
+		}
+
+		notifier.Notify(packRemoteFTEID(teid, peerIP))
+	}
+}
+
+// parseErrorIndicationFrame returns the remote F-TEID reported by the GTP-U
+// Error Indication carried in an IPv4 Ethernet frame.
+func parseErrorIndicationFrame(frame []byte) (teid, peerIP uint32, err error) {
+	if len(frame) < ethHeaderSize+ipv4HeaderSize+udpHeaderSize {
+		return 0, 0, ErrInvalidArgumentWithReason("error indication frame", len(frame), "truncated")
+	}
+
+	if binary.BigEndian.Uint16(frame[ethTypeOffset:]) != 0x0800 {
+		return 0, 0, ErrUnsupported("error indication EtherType", binary.BigEndian.Uint16(frame[ethTypeOffset:]))
+	}
+
+	ihl := int(frame[ipOffset]&0x0f) * 4
+	if ihl < ipv4HeaderSize || ipOffset+ihl+udpHeaderSize > len(frame) {
+		return 0, 0, ErrInvalidArgumentWithReason("IPv4 header length", ihl, "invalid")
+	}
+
+	h, ies, err := parseGTPU(frame[ipOffset+ihl+udpHeaderSize:])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if h.msgType != gtpuErrorIndication {
+		return 0, 0, ErrUnsupported("GTP-U message type", h.msgType)
+	}
+
+	return parseErrorIndication(ies)
+}
+
 func (b *bess) readQciQosMap(conf *Conf) {
-	b.qciQosMap = make(map[uint8]*QosConfigVal)
+	qciQosMap := make(map[uint8]*QosConfigVal)
 
 	for _, qosVal := range conf.QciQosConfig {
 		qosConfigVal := &QosConfigVal{
@@ -705,11 +1013,11 @@ func (b *bess) readQciQosMap(conf *Conf) {
 			burstDurationMs:  qosVal.BurstDurationMs,
 			schedulePriority: qosVal.SchedulingPriority,
 		}
-		b.qciQosMap[qosVal.QCI] = qosConfigVal
+		qciQosMap[qosVal.QCI] = qosConfigVal
 	}
 
-	if _, ok := b.qciQosMap[0]; !ok {
-		b.qciQosMap[0] = &QosConfigVal{
+	if _, ok := qciQosMap[0]; !ok {
+		qciQosMap[0] = &QosConfigVal{
 			cbs:              DefaultBurstSize,
 			ebs:              DefaultBurstSize,
 			pbs:              DefaultBurstSize,
@@ -717,6 +1025,39 @@ func (b *bess) readQciQosMap(conf *Conf) {
 			schedulePriority: 7,
 		}
 	}
+	b.qciQosMu.Lock()
+	b.qciQosMap = qciQosMap
+	b.qciQosMu.Unlock()
+}
+
+// qosConfig returns the QoS config of the QCI, or the default one.
+func (b *bess) qosConfig(qfi uint8) *QosConfigVal {
+	b.qciQosMu.RLock()
+	defer b.qciQosMu.RUnlock()
+
+	qosVal, ok := b.qciQosMap[qfi]
+	if !ok {
+		logger.BessLog.Debugf("number of config for qfi/qci: %v using default burst size", qfi)
+
+		qosVal = b.qciQosMap[0]
+	}
+
+	return qosVal
+}
+
+// SetQciQosConfig rebuilds the QoS config of the QCIs, which applies to the
+// QERs installed from then on.
+func (b *bess) SetQciQosConfig(conf *Conf) error {
+	b.readQciQosMap(conf)
+
+	return nil
+}
+
+// SetSliceMeterConfig re-programs the slice meters.
+func (b *bess) SetSliceMeterConfig(meterConfig SliceMeterConfig) error {
+	b.setSliceMeter(meterConfig)
+
+	return nil
 }
 
 // clearState removes all rules from pdrLookup, farLookup, appQerLookup and sessQerLookup.
@@ -728,6 +1069,11 @@ func (b *bess) clearState() {
 
 	logger.BessLog.Debugln("clearing all the state in BESS")
 
+	b.statsMu.Lock()
+	b.pdrCounters = nil
+	b.pendingStats = nil
+	b.statsMu.Unlock()
+
 	clearWildcardCmd := &pb.WildcardMatchCommandClearArg{}
 
 	anyWildcardClear, err := anypb.New(clearWildcardCmd)
@@ -736,7 +1082,11 @@ func (b *bess) clearState() {
 		return
 	}
 
-	b.processPDR(ctx, anyWildcardClear, upfMsgTypeClear)
+	b.processPDR(ctx, pdrLookupIPv4, anyWildcardClear, upfMsgTypeClear)
+
+	if b.enableIPv6 {
+		b.processPDR(ctx, pdrLookupIPv6, anyWildcardClear, upfMsgTypeClear)
+	}
 
 	clearExactCmd := &pb.ExactMatchCommandClearArg{}
 
@@ -746,7 +1096,11 @@ func (b *bess) clearState() {
 		return
 	}
 
-	b.processFAR(ctx, anyExactClear, upfMsgTypeClear)
+	b.processFAR(ctx, farLookupIPv4, anyExactClear, upfMsgTypeClear)
+
+	if b.enableIPv6 {
+		b.processFAR(ctx, farLookupIPv6, anyExactClear, upfMsgTypeClear)
+	}
 
 	clearGtpuPathMonitoringCmd := &pb.GtpuPathMonitoringCommandClearArg{}
 
@@ -779,13 +1133,14 @@ func (b *bess) clearState() {
 }
 
 // setUpfInfo is only called at pfcp-agent's startup
-// it clears all the state in BESS
+// it clears all the state in BESS, unless sessions are restored
 func (b *bess) SetUpfInfo(u *upf, conf *Conf) {
 	var err error
 
 	logger.BessLog.Errorln("SetUpfInfo bess")
 
 	b.readQciQosMap(conf)
+	b.enableIPv6 = conf.EnableIPv6
 	// get bess grpc client
 	logger.BessLog.Errorln("bessIP", *bessIP)
 
@@ -798,9 +1153,22 @@ func (b *bess) SetUpfInfo(u *upf, conf *Conf) {
 
 	b.client = pb.NewBESSControlClient(b.conn)
 
+	if conf.BessBatchSize > 1 {
+		window, err := time.ParseDuration(conf.BessBatchWindow)
+		if err != nil {
+			logger.BessLog.Fatalln("invalid BESS batch window:", err)
+		}
+
+		b.batcher = newBessBatcher(b.client, int(conf.BessBatchSize), window)
+		b.batcher.start()
+	}
+
+	go b.watchConnection(u.datapathResyncChan)
+
 	// Skip clearing state when in `simulate delete` mode, so previously
 	// created rules remain in BESS and can be individually deleted.
-	if !simulate.delete() {
+	// Restored sessions are re-programmed over the existing rules.
+	if !simulate.delete() && !conf.RestoreSessions {
 		b.clearState()
 	}
 
@@ -820,6 +1188,22 @@ func (b *bess) SetUpfInfo(u *upf, conf *Conf) {
 		go b.notifyListen(u.reportNotifyChan)
 	}
 
+	if conf.EnableErrorIndication {
+		errIndSockAddr := conf.ErrorIndicationSockAddr
+		if errIndSockAddr == "" {
+			errIndSockAddr = ErrorIndicationAddr
+		}
+
+		var d net.Dialer
+		b.errIndSocket, err = d.DialContext(context.Background(), "unixpacket", errIndSockAddr)
+		if err != nil {
+			// The other notifications and monitoring do not depend on it.
+			logger.BessLog.Errorln("dial error, GTP-U Error Indications are not reported:", err)
+		} else {
+			go b.errorIndicationListen(u.errorIndicationChan)
+		}
+	}
+
 	if conf.EnableEndMarker {
 		pfcpCommAddr := conf.EndMarkerSockAddr
 		if pfcpCommAddr == "" {
@@ -840,17 +1224,7 @@ func (b *bess) SetUpfInfo(u *upf, conf *Conf) {
 
 	if (conf.SliceMeterConfig.N6RateBps > 0) ||
 		(conf.SliceMeterConfig.N3RateBps > 0) {
-		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
-		defer cancel()
-
-		done := make(chan bool)
-
-		b.addSliceMeter(ctx, done, conf.SliceMeterConfig)
-
-		rc := b.GRPCJoin(1, Timeout, done)
-		if !rc {
-			logger.BessLog.Errorln(errGRPCCallFailed)
-		}
+		b.setSliceMeter(conf.SliceMeterConfig)
 	}
 
 	if conf.EnableGtpuPathMonitoring {
@@ -858,74 +1232,151 @@ func (b *bess) SetUpfInfo(u *upf, conf *Conf) {
 	}
 }
 
-func (b *bess) processPDR(ctx context.Context, arg *anypb.Any, method upfMsgType) {
+// ruleCommand runs a rule command on a table of the pipeline. Deleting a rule
+// that is not installed succeeds.
+func (b *bess) ruleCommand(ctx context.Context, module string, arg *anypb.Any, method upfMsgType) error {
 	if method != upfMsgTypeAdd && method != upfMsgTypeDel && method != upfMsgTypeClear {
-		logger.BessLog.Infoln(errInvalidMethodName, method)
-		return
+		logger.BessLog.Errorln(errInvalidMethodName, method)
+		return ErrInvalidArgument("method name", method)
 	}
 
 	methods := [...]string{"add", "add", "delete", "clear"}
 
-	resp, err := b.client.ModuleCommand(ctx, &pb.CommandRequest{
-		Name: "pdrLookup",
+	req := &pb.CommandRequest{
+		Name: module,
 		Cmd:  methods[method],
 		Arg:  arg,
-	})
+	}
 
-	logger.BessLog.Debugf("pdrlookup resp: %v", resp)
+	var (
+		resp *pb.CommandResponse
+		err  error
+	)
 
-	if err != nil || resp.GetError() != nil {
-		logger.BessLog.Errorf("pdrLookup method failed with resp: %v, err: %v", resp, err)
+	if b.batcher != nil {
+		resp, err = b.batcher.command(ctx, req)
+	} else {
+		resp, err = b.client.ModuleCommand(ctx, req)
 	}
-}
 
-func (b *bess) addPDR(ctx context.Context, done chan<- bool, p pdr) {
-	go func() {
-		var (
-			arg *anypb.Any
-			err error
-		)
+	logger.BessLog.Debugf("%s resp: %v", module, resp)
 
-		var qerID uint32
+	if err == nil && resp.GetError().GetCode() != 0 {
+		err = &moduleError{module: module, code: resp.GetError().GetCode(), msg: resp.GetError().GetErrmsg()}
+	}
 
-		for _, qer := range p.qerIDList {
-			qerID = qer
-			break
-		}
+	if err != nil && method == upfMsgTypeDel && isRuleNotFound(err) {
+		logger.BessLog.Debugf("%s rule to delete not found", module)
+		return nil
+	}
 
-		// Translate port ranges into ternary rule(s) and insert them one-by-one.
-		portRules, err := CreatePortRangeCartesianProduct(p.appFilter.srcPortRange, p.appFilter.dstPortRange)
-		if err != nil {
-			logger.BessLog.Errorln(err)
-			return
+	if err != nil {
+		logger.BessLog.Errorf("%s method failed with resp: %v, err: %v", module, resp, err)
+	}
+
+	return err
+}
+
+func (b *bess) processPDR(ctx context.Context, module string, arg *anypb.Any, method upfMsgType) error {
+	return b.ruleCommand(ctx, module, arg, method)
+}
+
+// pdrTables returns the PDR tables the PDR is installed in, or an error if the
+// PDR has IPv6 matches and the IPv6 tables are not enabled.
+func (b *bess) pdrTables(p pdr) ([]string, error) {
+	var tables []string
+
+	if p.hasIPv4() {
+		tables = append(tables, pdrLookupIPv4)
+	}
+
+	if p.hasIPv6() {
+		if !b.enableIPv6 {
+			return nil, ErrUnsupported("IPv6 PDR without enable_ipv6", p.pdrID)
 		}
 
-		logger.BessLog.Debugf("PDR rules %+v", portRules)
+		tables = append(tables, pdrLookupIPv6)
+	}
+
+	return tables, nil
+}
+
+// pdrRuleMatch returns the values and masks of a PDR rule in the given table.
+func pdrRuleMatch(p pdr, r portRangeTernaryCartesianProduct, table string) (values, masks []*pb.FieldData) {
+	values = []*pb.FieldData{
+		intEnc(uint64(p.srcIface)),     /* src_iface */
+		intEnc(uint64(p.tunnelIP4Dst)), /* tunnel_ipv4_dst */
+		intEnc(uint64(p.tunnelTEID)),   /* enb_teid */
+	}
+	masks = []*pb.FieldData{
+		intEnc(uint64(p.srcIfaceMask)),     /* src_iface-mask */
+		intEnc(uint64(p.tunnelIP4DstMask)), /* tunnel_ipv4_dst-mask */
+		intEnc(uint64(p.tunnelTEIDMask)),   /* enb_teid-mask */
+	}
+
+	if table == pdrLookupIPv6 {
+		values = append(values, ip6Enc(p.appFilter.srcIP6)...)   /* ueaddr ip6 hi, lo */
+		values = append(values, ip6Enc(p.appFilter.dstIP6)...)   /* inet ip6 hi, lo */
+		masks = append(masks, ip6Enc(p.appFilter.srcIP6Mask)...) /* ueaddr ip6-mask hi, lo */
+		masks = append(masks, ip6Enc(p.appFilter.dstIP6Mask)...) /* inet ip6-mask hi, lo */
+	} else {
+		values = append(values,
+			intEnc(uint64(p.appFilter.srcIP)), /* ueaddr ip*/
+			intEnc(uint64(p.appFilter.dstIP)), /* inet ip */
+		)
+		masks = append(masks,
+			intEnc(uint64(p.appFilter.srcIPMask)), /* ueaddr ip-mask */
+			intEnc(uint64(p.appFilter.dstIPMask)), /* inet ip-mask */
+		)
+	}
+
+	values = append(values,
+		intEnc(uint64(r.srcPort)),         /* ue port */
+		intEnc(uint64(r.dstPort)),         /* inet port */
+		intEnc(uint64(p.appFilter.proto)), /* proto id */
+	)
+	masks = append(masks,
+		intEnc(uint64(r.srcMask)),             /* ue port-mask */
+		intEnc(uint64(r.dstMask)),             /* inet port-mask */
+		intEnc(uint64(p.appFilter.protoMask)), /* proto id-mask */
+	)
+
+	return values, masks
+}
 
+// pdrRules returns the rules of the PDR, by PDR table.
+func (b *bess) pdrRules(p pdr) (map[string][]*pb.WildcardMatchCommandAddArg, error) {
+	var qerID uint32
+
+	for _, qer := range p.qerIDList {
+		qerID = qer
+		break
+	}
+
+	// Translate port ranges into ternary rule(s).
+	portRules, err := CreatePortRangeCartesianProduct(p.appFilter.srcPortRange, p.appFilter.dstPortRange)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.BessLog.Debugf("PDR rules %+v", portRules)
+
+	tables, err := b.pdrTables(p)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(map[string][]*pb.WildcardMatchCommandAddArg)
+
+	for _, table := range tables {
 		for _, r := range portRules {
-			f := &pb.WildcardMatchCommandAddArg{
+			values, masks := pdrRuleMatch(p, r, table)
+
+			rules[table] = append(rules[table], &pb.WildcardMatchCommandAddArg{
 				Gate:     uint64(p.needDecap),
 				Priority: int64(math.MaxUint32 - p.precedence),
-				Values: []*pb.FieldData{
-					intEnc(uint64(p.srcIface)),        /* src_iface */
-					intEnc(uint64(p.tunnelIP4Dst)),    /* tunnel_ipv4_dst */
-					intEnc(uint64(p.tunnelTEID)),      /* enb_teid */
-					intEnc(uint64(p.appFilter.srcIP)), /* ueaddr ip*/
-					intEnc(uint64(p.appFilter.dstIP)), /* inet ip */
-					intEnc(uint64(r.srcPort)),         /* ue port */
-					intEnc(uint64(r.dstPort)),         /* inet port */
-					intEnc(uint64(p.appFilter.proto)), /* proto id */
-				},
-				Masks: []*pb.FieldData{
-					intEnc(uint64(p.srcIfaceMask)),        /* src_iface-mask */
-					intEnc(uint64(p.tunnelIP4DstMask)),    /* tunnel_ipv4_dst-mask */
-					intEnc(uint64(p.tunnelTEIDMask)),      /* enb_teid-mask */
-					intEnc(uint64(p.appFilter.srcIPMask)), /* ueaddr ip-mask */
-					intEnc(uint64(p.appFilter.dstIPMask)), /* inet ip-mask */
-					intEnc(uint64(r.srcMask)),             /* ue port-mask */
-					intEnc(uint64(r.dstMask)),             /* inet port-mask */
-					intEnc(uint64(p.appFilter.protoMask)), /* proto id-mask */
-				},
+				Values:   values,
+				Masks:    masks,
 				Valuesv: []*pb.FieldData{
 					intEnc(uint64(p.pdrID)), /* pdr-id */
 					intEnc(p.fseID),         /* fseid */
@@ -933,153 +1384,202 @@ func (b *bess) addPDR(ctx context.Context, done chan<- bool, p pdr) {
 					intEnc(uint64(qerID)),   /* qer_id */
 					intEnc(uint64(p.farID)), /* far_id */
 				},
-			}
+			})
+		}
+	}
+
+	return rules, nil
+}
+
+// CreatePDR installs the rules of the PDR.
+func (b *bess) CreatePDR(ctx context.Context, p pdr) error {
+	return b.installPDR(ctx, p)
+}
+
+// UpdatePDR installs the rules of the PDR, overwriting the existing ones, then
+// removes the rules of its previous version that match other packets.
+func (b *bess) UpdatePDR(ctx context.Context, old, p pdr) error {
+	if err := b.installPDR(ctx, p); err != nil {
+		return err
+	}
+
+	// A new PDR has no previous version.
+	if old.fseID != p.fseID || old.pdrID != p.pdrID {
+		return nil
+	}
+
+	kept, err := b.pdrRules(p)
+	if err != nil {
+		return err
+	}
+
+	return b.removePDR(ctx, old, kept)
+}
+
+func (b *bess) installPDR(ctx context.Context, p pdr) error {
+	rules, err := b.pdrRules(p)
+	if err != nil {
+		logger.BessLog.Errorln(err)
+		return err
+	}
 
-			arg, err = anypb.New(f)
+	// Insert the rules one-by-one.
+	for table, tableRules := range rules {
+		for _, f := range tableRules {
+			arg, err := anypb.New(f)
 			if err != nil {
 				logger.BessLog.Infoln(errMarshalRule, f, err)
-				return
+				return err
 			}
 
-			b.processPDR(ctx, arg, upfMsgTypeAdd)
+			if err = b.processPDR(ctx, table, arg, upfMsgTypeAdd); err != nil {
+				return err
+			}
 		}
-		done <- true
-	}()
+	}
+
+	return nil
 }
 
-func (b *bess) delPDR(ctx context.Context, done chan<- bool, p pdr) {
-	go func() {
-		var (
-			arg *anypb.Any
-			err error
-		)
+// DeletePDR removes the rules of the PDR.
+func (b *bess) DeletePDR(ctx context.Context, p pdr) error {
+	b.forgetPDRCounters(p)
 
-		// Translate port ranges into ternary rule(s) and insert them one-by-one.
-		portRules, err := CreatePortRangeCartesianProduct(p.appFilter.srcPortRange, p.appFilter.dstPortRange)
-		if err != nil {
-			logger.BessLog.Errorln(err)
-			return
-		}
+	return b.removePDR(ctx, p, nil)
+}
+
+// removePDR removes the rules of the PDR, except those overwritten by the
+// kept rules, as they match the same packets.
+func (b *bess) removePDR(ctx context.Context, p pdr, kept map[string][]*pb.WildcardMatchCommandAddArg) error {
+	// Translate port ranges into ternary rule(s) and delete them one-by-one.
+	portRules, err := CreatePortRangeCartesianProduct(p.appFilter.srcPortRange, p.appFilter.dstPortRange)
+	if err != nil {
+		logger.BessLog.Errorln(err)
+		return err
+	}
 
+	tables, err := b.pdrTables(p)
+	if err != nil {
+		logger.BessLog.Errorln(err)
+		return err
+	}
+
+	for _, table := range tables {
 		for _, r := range portRules {
+			values, masks := pdrRuleMatch(p, r, table)
+
+			if slices.ContainsFunc(kept[table], func(k *pb.WildcardMatchCommandAddArg) bool {
+				return sameFieldData(k.Values, values) && sameFieldData(k.Masks, masks)
+			}) {
+				continue
+			}
+
 			f := &pb.WildcardMatchCommandDeleteArg{
-				Values: []*pb.FieldData{
-					intEnc(uint64(p.srcIface)),        /* src_iface */
-					intEnc(uint64(p.tunnelIP4Dst)),    /* tunnel_ipv4_dst */
-					intEnc(uint64(p.tunnelTEID)),      /* enb_teid */
-					intEnc(uint64(p.appFilter.srcIP)), /* ueaddr ip*/
-					intEnc(uint64(p.appFilter.dstIP)), /* inet ip */
-					intEnc(uint64(r.srcPort)),         /* ue port */
-					intEnc(uint64(r.dstPort)),         /* inet port */
-					intEnc(uint64(p.appFilter.proto)), /* proto id */
-				},
-				Masks: []*pb.FieldData{
-					intEnc(uint64(p.srcIfaceMask)),        /* src_iface-mask */
-					intEnc(uint64(p.tunnelIP4DstMask)),    /* tunnel_ipv4_dst-mask */
-					intEnc(uint64(p.tunnelTEIDMask)),      /* enb_teid-mask */
-					intEnc(uint64(p.appFilter.srcIPMask)), /* ueaddr ip-mask */
-					intEnc(uint64(p.appFilter.dstIPMask)), /* inet ip-mask */
-					intEnc(uint64(r.srcMask)),             /* ue port-mask */
-					intEnc(uint64(r.dstMask)),             /* inet port-mask */
-					intEnc(uint64(p.appFilter.protoMask)), /* proto id-mask */
-				},
+				Values: values,
+				Masks:  masks,
 			}
 
-			arg, err = anypb.New(f)
+			arg, err := anypb.New(f)
 			if err != nil {
 				logger.BessLog.Errorln(errMarshalRule, f, err)
-				return
+				return err
 			}
 
-			b.processPDR(ctx, arg, upfMsgTypeDel)
+			if err = b.processPDR(ctx, table, arg, upfMsgTypeDel); err != nil {
+				return err
+			}
 		}
-		done <- true
-	}()
+	}
+
+	return nil
 }
 
-func (b *bess) addQER(ctx context.Context, done chan<- bool, qer qer) {
-	go func() {
-		var (
-			cir, pir, cbs, ebs, pbs, gate uint64
-			srcIface                      uint8
-		)
+// CreateQER installs the meters of the QER.
+func (b *bess) CreateQER(ctx context.Context, q qer) error {
+	return b.installQER(ctx, q)
+}
 
-		// Uplink QER
-		srcIface = access
+// UpdateQER installs the meters of the QER, overwriting the existing ones.
+func (b *bess) UpdateQER(ctx context.Context, q qer) error {
+	return b.installQER(ctx, q)
+}
 
-		// Lookup QCI from QFI, else try default QCI.
-		qosVal, ok := b.qciQosMap[qer.qfi]
-		if !ok {
-			logger.BessLog.Debugf("number of config for qfi/qci: %v using default burst size", qer.qfi)
+func (b *bess) installQER(ctx context.Context, qer qer) error {
+	var (
+		cir, pir, cbs, ebs, pbs, gate uint64
+		srcIface                      uint8
+	)
 
-			qosVal = b.qciQosMap[0]
-		}
+	// Uplink QER
+	srcIface = access
 
-		cbs = maxUint64(calcBurstSizeFromRate(qer.ulGbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.cbs))
-		ebs = maxUint64(calcBurstSizeFromRate(qer.ulMbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.ebs))
-		pbs = maxUint64(calcBurstSizeFromRate(qer.ulMbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.ebs))
-
-		if qer.ulStatus != ie.GateStatusOpen {
-			gate = qerGateStatusDrop
-		} else if qer.ulMbr != 0 || qer.ulGbr != 0 {
-			/* MBR/GBR is received in Kilobits/sec.
-			   CIR/PIR is sent in bytes */
-			cir = maxUint64(((qer.ulGbr * 1000) / 8), 1)
-			pir = maxUint64(((qer.ulMbr * 1000) / 8), cir)
-			gate = qerGateMeter
-		} else {
-			gate = qerGateUnmeter
-		}
+	// Lookup QCI from QFI, else try default QCI.
+	qosVal := b.qosConfig(qer.qfi)
 
-		switch qer.qosLevel {
-		case ApplicationQos:
-			b.addApplicationQER(ctx, gate, srcIface, cir, pir, cbs, pbs, ebs, qer)
-		case SessionQos:
-			b.addSessionQER(ctx, gate, srcIface, cir, pir, cbs, pbs, ebs, qer)
-		}
+	cbs = maxUint64(calcBurstSizeFromRate(qer.ulGbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.cbs))
+	ebs = maxUint64(calcBurstSizeFromRate(qer.ulMbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.ebs))
+	pbs = maxUint64(calcBurstSizeFromRate(qer.ulMbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.ebs))
 
-		// Downlink QER
-		srcIface = core
+	if qer.ulStatus != ie.GateStatusOpen {
+		gate = qerGateStatusDrop
+	} else if qer.ulMbr != 0 || qer.ulGbr != 0 {
+		/* MBR/GBR is received in Kilobits/sec.
+		   CIR/PIR is sent in bytes */
+		cir = maxUint64(((qer.ulGbr * 1000) / 8), 1)
+		pir = maxUint64(((qer.ulMbr * 1000) / 8), cir)
+		gate = qerGateMeter
+	} else {
+		gate = qerGateUnmeter
+	}
 
-		// Lookup QCI from QFI, else try default QCI.
-		qosVal, ok = b.qciQosMap[qer.qfi]
-		if !ok {
-			logger.BessLog.Debugf("number of config for qfi/qci: %v using default burst size", qer.qfi)
+	if err := b.addQERRule(ctx, gate, srcIface, cir, pir, cbs, pbs, ebs, qer); err != nil {
+		return err
+	}
 
-			qosVal = b.qciQosMap[0]
-		}
+	// Downlink QER
+	srcIface = core
 
-		cbs = maxUint64(calcBurstSizeFromRate(qer.dlGbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.cbs))
-		ebs = maxUint64(calcBurstSizeFromRate(qer.dlMbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.ebs))
-		pbs = maxUint64(calcBurstSizeFromRate(qer.dlMbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.ebs))
-
-		if qer.dlStatus != ie.GateStatusOpen {
-			gate = qerGateStatusDrop
-		} else if qer.dlMbr != 0 || qer.dlGbr != 0 {
-			/* MBR/GBR is received in Kilobits/sec.
-			   CIR/PIR is sent in bytes */
-			cir = maxUint64(((qer.dlGbr * 1000) / 8), 1)
-			pir = maxUint64(((qer.dlMbr * 1000) / 8), cir)
-			gate = qerGateMeter
-		} else {
-			gate = qerGateUnmeter
-		}
+	// Lookup QCI from QFI, else try default QCI.
+	qosVal = b.qosConfig(qer.qfi)
 
-		switch qer.qosLevel {
-		case ApplicationQos:
-			b.addApplicationQER(ctx, gate, srcIface, cir, pir, cbs, pbs, ebs, qer)
-		case SessionQos:
-			b.addSessionQER(ctx, gate, srcIface, cir, pir, cbs, pbs, ebs, qer)
-		}
+	cbs = maxUint64(calcBurstSizeFromRate(qer.dlGbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.cbs))
+	ebs = maxUint64(calcBurstSizeFromRate(qer.dlMbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.ebs))
+	pbs = maxUint64(calcBurstSizeFromRate(qer.dlMbr, uint64(qosVal.burstDurationMs)), uint64(qosVal.ebs))
 
-		done <- true
-	}()
+	if qer.dlStatus != ie.GateStatusOpen {
+		gate = qerGateStatusDrop
+	} else if qer.dlMbr != 0 || qer.dlGbr != 0 {
+		/* MBR/GBR is received in Kilobits/sec.
+		   CIR/PIR is sent in bytes */
+		cir = maxUint64(((qer.dlGbr * 1000) / 8), 1)
+		pir = maxUint64(((qer.dlMbr * 1000) / 8), cir)
+		gate = qerGateMeter
+	} else {
+		gate = qerGateUnmeter
+	}
+
+	return b.addQERRule(ctx, gate, srcIface, cir, pir, cbs, pbs, ebs, qer)
+}
+
+// addQERRule installs the rule of the QER for one direction.
+func (b *bess) addQERRule(ctx context.Context, gate uint64, srcIface uint8,
+	cir uint64, pir uint64, cbs uint64, pbs uint64,
+	ebs uint64, qer qer,
+) error {
+	switch qer.qosLevel {
+	case ApplicationQos:
+		return b.addApplicationQER(ctx, gate, srcIface, cir, pir, cbs, pbs, ebs, qer)
+	case SessionQos:
+		return b.addSessionQER(ctx, gate, srcIface, cir, pir, cbs, pbs, ebs, qer)
+	}
+
+	return nil
 }
 
 func (b *bess) addApplicationQER(ctx context.Context, gate uint64, srcIface uint8,
 	cir uint64, pir uint64, cbs uint64, pbs uint64,
 	ebs uint64, qer qer,
-) {
+) error {
 	var (
 		arg *anypb.Any
 		err error
@@ -1105,7 +1605,7 @@ func (b *bess) addApplicationQER(ctx context.Context, gate uint64, srcIface uint
 	arg, err = anypb.New(q)
 	if err != nil {
 		logger.BessLog.Errorln(errMarshalRule, q, err)
-		return
+		return err
 	}
 
 	qosTableName := AppQerLookup
@@ -1114,39 +1614,38 @@ func (b *bess) addApplicationQER(ctx context.Context, gate uint64, srcIface uint
 	if err != nil {
 		logger.BessLog.Errorln("process QER failed for appQERLookup add operation")
 	}
+
+	return err
 }
 
-func (b *bess) delQER(ctx context.Context, done chan<- bool, qer qer) {
-	go func() {
-		var srcIface uint8
+// DeleteQER removes the meters of the QER.
+func (b *bess) DeleteQER(ctx context.Context, q qer) error {
+	return b.removeQER(ctx, q)
+}
 
-		// Uplink QER
-		srcIface = access
+func (b *bess) removeQER(ctx context.Context, qer qer) error {
+	// Uplink and downlink QER
+	for _, srcIface := range []uint8{access, core} {
+		var err error
 
 		switch qer.qosLevel {
 		case ApplicationQos:
-			b.delApplicationQER(ctx, srcIface, qer)
+			err = b.delApplicationQER(ctx, srcIface, qer)
 		case SessionQos:
-			b.delSessionQER(ctx, srcIface, qer)
+			err = b.delSessionQER(ctx, srcIface, qer)
 		}
 
-		// Downlink QER
-		srcIface = core
-
-		switch qer.qosLevel {
-		case ApplicationQos:
-			b.delApplicationQER(ctx, srcIface, qer)
-		case SessionQos:
-			b.delSessionQER(ctx, srcIface, qer)
+		if err != nil {
+			return err
 		}
+	}
 
-		done <- true
-	}()
+	return nil
 }
 
 func (b *bess) delApplicationQER(
 	ctx context.Context, srcIface uint8, qer qer,
-) {
+) error {
 	var (
 		arg *anypb.Any
 		err error
@@ -1163,7 +1662,7 @@ func (b *bess) delApplicationQER(
 	arg, err = anypb.New(q)
 	if err != nil {
 		logger.BessLog.Infoln(errMarshalRule, q, err)
-		return
+		return err
 	}
 
 	qosTableName := AppQerLookup
@@ -1172,27 +1671,26 @@ func (b *bess) delApplicationQER(
 	if err != nil {
 		logger.BessLog.Errorln("process QER failed for appQERLookup del operation")
 	}
-}
 
-func (b *bess) processFAR(ctx context.Context, arg *anypb.Any, method upfMsgType) {
-	if method != upfMsgTypeAdd && method != upfMsgTypeDel && method != upfMsgTypeClear {
-		logger.BessLog.Errorln(errInvalidMethodName, method)
-		return
-	}
-
-	methods := [...]string{"add", "add", "delete", "clear"}
+	return err
+}
 
-	resp, err := b.client.ModuleCommand(ctx, &pb.CommandRequest{
-		Name: "farLookup",
-		Cmd:  methods[method],
-		Arg:  arg,
-	})
+func (b *bess) processFAR(ctx context.Context, module string, arg *anypb.Any, method upfMsgType) error {
+	return b.ruleCommand(ctx, module, arg, method)
+}
 
-	logger.BessLog.Debugf("farlookup resp: %v", resp)
+// farTable returns the FAR table the FAR is installed in, or an error if the
+// FAR has an IPv6 tunnel and the IPv6 tables are not enabled.
+func (b *bess) farTable(f far) (string, error) {
+	if !f.hasIPv6Tunnel() {
+		return farLookupIPv4, nil
+	}
 
-	if err != nil || resp.GetError() != nil {
-		logger.BessLog.Errorf("farLookup method failed with resp: %v, err: %v", resp, err)
+	if !b.enableIPv6 {
+		return "", ErrUnsupported("FAR with IPv6 tunnel without enable_ipv6", f.farID)
 	}
+
+	return farLookupIPv6, nil
 }
 
 func (b *bess) processGtpuPathMonitoring(ctx context.Context, arg *anypb.Any, method upfMsgType) {
@@ -1236,94 +1734,129 @@ func (b *bess) setActionValue(f far) uint8 {
 	return farDrop
 }
 
-func (b *bess) addFAR(ctx context.Context, done chan<- bool, far far) {
-	go func() {
-		var (
-			arg *anypb.Any
-			err error
+// farRule returns the FAR table and the rule of the FAR.
+func (b *bess) farRule(far far) (string, *pb.ExactMatchCommandAddArg, error) {
+	table, err := b.farTable(far)
+	if err != nil {
+		return "", nil, err
+	}
+
+	action := b.setActionValue(far)
+	values := []*pb.FieldData{
+		intEnc(uint64(action)),         /* action */
+		intEnc(uint64(far.tunnelType)), /* tunnel_out_type */
+	}
+
+	if table == farLookupIPv6 {
+		values = append(values, ip6Enc(far.tunnelIP6Src)...) /* access-ip6 hi, lo */
+		values = append(values, ip6Enc(far.tunnelIP6Dst)...) /* enb ip6 hi, lo */
+	} else {
+		values = append(values,
+			intEnc(uint64(far.tunnelIP4Src)), /* access-ip */
+			intEnc(uint64(far.tunnelIP4Dst)), /* enb ip */
 		)
+	}
 
-		action := b.setActionValue(far)
-		f := &pb.ExactMatchCommandAddArg{
-			Gate: uint64(far.tunnelType),
-			Fields: []*pb.FieldData{
-				intEnc(uint64(far.farID)), /* far_id */
-				intEnc(far.fseID),         /* fseid */
-			},
-			Values: []*pb.FieldData{
-				intEnc(uint64(action)),           /* action */
-				intEnc(uint64(far.tunnelType)),   /* tunnel_out_type */
-				intEnc(uint64(far.tunnelIP4Src)), /* access-ip */
-				intEnc(uint64(far.tunnelIP4Dst)), /* enb ip */
-				intEnc(uint64(far.tunnelTEID)),   /* enb teid */
-				intEnc(uint64(far.tunnelPort)),   /* udp gtpu port */
-			},
-		}
+	values = append(values,
+		intEnc(uint64(far.tunnelTEID)), /* enb teid */
+		intEnc(uint64(far.tunnelPort)), /* udp gtpu port */
+	)
 
-		arg, err = anypb.New(f)
-		if err != nil {
-			logger.BessLog.Infoln(errMarshalRule, f, err)
-			return
-		}
+	return table, &pb.ExactMatchCommandAddArg{
+		Gate: uint64(far.tunnelType),
+		Fields: []*pb.FieldData{
+			intEnc(uint64(far.farID)), /* far_id */
+			intEnc(far.fseID),         /* fseid */
+		},
+		Values: values,
+	}, nil
+}
 
-		b.processFAR(ctx, arg, upfMsgTypeAdd)
+// CreateFAR installs the rule of the FAR.
+func (b *bess) CreateFAR(ctx context.Context, f far) error {
+	return b.installFAR(ctx, f)
+}
 
-		if enableGtpuPathMonitoring {
-			g := &pb.GtpuPathMonitoringCommandAddDeleteArg{
-				GnbIp: far.tunnelIP4Dst, /* gnb ip */
-			}
+// UpdateFAR installs the rule of the FAR, overwriting the existing one.
+func (b *bess) UpdateFAR(ctx context.Context, f far) error {
+	return b.installFAR(ctx, f)
+}
 
-			arg, err = anypb.New(g)
-			if err != nil {
-				logger.BessLog.Infoln("error marshalling data", g, err)
-				return
-			}
+func (b *bess) installFAR(ctx context.Context, far far) error {
+	table, f, err := b.farRule(far)
+	if err != nil {
+		logger.BessLog.Errorln(err)
+		return err
+	}
 
-			b.processGtpuPathMonitoring(ctx, arg, upfMsgTypeAdd)
-		}
+	arg, err := anypb.New(f)
+	if err != nil {
+		logger.BessLog.Infoln(errMarshalRule, f, err)
+		return err
+	}
 
-		done <- true
-	}()
+	if err = b.processFAR(ctx, table, arg, upfMsgTypeAdd); err != nil {
+		return err
+	}
+
+	// GTP-U path monitoring only supports IPv4 peers.
+	if enableGtpuPathMonitoring && table == farLookupIPv4 {
+		b.monitorGtpuPath(ctx, far, upfMsgTypeAdd)
+	}
+
+	return nil
 }
 
-func (b *bess) delFAR(ctx context.Context, done chan<- bool, far far) {
-	go func() {
-		var (
-			arg *anypb.Any
-			err error
-		)
+// DeleteFAR removes the rule of the FAR.
+func (b *bess) DeleteFAR(ctx context.Context, f far) error {
+	return b.removeFAR(ctx, f)
+}
 
-		f := &pb.ExactMatchCommandDeleteArg{
-			Fields: []*pb.FieldData{
-				intEnc(uint64(far.farID)), /* far_id */
-				intEnc(far.fseID),         /* fseid */
-			},
-		}
+func (b *bess) removeFAR(ctx context.Context, far far) error {
+	table, err := b.farTable(far)
+	if err != nil {
+		logger.BessLog.Errorln(err)
+		return err
+	}
 
-		arg, err = anypb.New(f)
-		if err != nil {
-			logger.BessLog.Infoln(errMarshalRule, f, err)
-			return
-		}
+	f := &pb.ExactMatchCommandDeleteArg{
+		Fields: []*pb.FieldData{
+			intEnc(uint64(far.farID)), /* far_id */
+			intEnc(far.fseID),         /* fseid */
+		},
+	}
 
-		b.processFAR(ctx, arg, upfMsgTypeDel)
+	arg, err := anypb.New(f)
+	if err != nil {
+		logger.BessLog.Infoln(errMarshalRule, f, err)
+		return err
+	}
 
-		if enableGtpuPathMonitoring {
-			g := &pb.GtpuPathMonitoringCommandAddDeleteArg{
-				GnbIp: far.tunnelIP4Dst, /* gnb ip */
-			}
+	if err = b.processFAR(ctx, table, arg, upfMsgTypeDel); err != nil {
+		return err
+	}
 
-			arg, err = anypb.New(g)
-			if err != nil {
-				logger.BessLog.Infoln("error marshalling data", g, err)
-				return
-			}
+	if enableGtpuPathMonitoring && table == farLookupIPv4 {
+		b.monitorGtpuPath(ctx, far, upfMsgTypeDel)
+	}
 
-			b.processGtpuPathMonitoring(ctx, arg, upfMsgTypeDel)
-		}
+	return nil
+}
 
-		done <- true
-	}()
+// monitorGtpuPath adds or removes the remote GTP-U peer of the FAR from the
+// path monitoring. Failures do not fail the FAR.
+func (b *bess) monitorGtpuPath(ctx context.Context, far far, method upfMsgType) {
+	g := &pb.GtpuPathMonitoringCommandAddDeleteArg{
+		GnbIp: far.tunnelIP4Dst, /* gnb ip */
+	}
+
+	arg, err := anypb.New(g)
+	if err != nil {
+		logger.BessLog.Infoln("error marshalling data", g, err)
+		return
+	}
+
+	b.processGtpuPathMonitoring(ctx, arg, method)
 }
 
 func (b *bess) processSliceMeter(ctx context.Context, arg *anypb.Any, method upfMsgType) {
@@ -1444,32 +1977,13 @@ func (b *bess) addSliceMeter(ctx context.Context, done chan<- bool, meterConfig
 }
 
 func (b *bess) processQER(ctx context.Context, arg *anypb.Any, method upfMsgType, qosTableName string) error {
-	if method != upfMsgTypeAdd && method != upfMsgTypeDel && method != upfMsgTypeClear {
-		return ErrInvalidArgument("method name", method)
-	}
-
-	methods := [...]string{"add", "add", "delete", "clear"}
-
-	resp, err := b.client.ModuleCommand(ctx, &pb.CommandRequest{
-		Name: qosTableName,
-		Cmd:  methods[method],
-		Arg:  arg,
-	})
-
-	logger.BessLog.Debugf("qerlookup resp: %v", resp)
-
-	if err != nil || resp.GetError() != nil {
-		logger.BessLog.Errorf("%v for qer %v failed with resp: %v, error: %v", qosTableName, methods[method], resp, err)
-		return err
-	}
-
-	return nil
+	return b.ruleCommand(ctx, qosTableName, arg, method)
 }
 
 func (b *bess) addSessionQER(ctx context.Context, gate uint64, srcIface uint8,
 	cir uint64, pir uint64, cbs uint64,
 	pbs uint64, ebs uint64, qer qer,
-) {
+) error {
 	var (
 		arg *anypb.Any
 		err error
@@ -1491,7 +2005,7 @@ func (b *bess) addSessionQER(ctx context.Context, gate uint64, srcIface uint8,
 	arg, err = anypb.New(q)
 	if err != nil {
 		logger.BessLog.Errorln(errMarshalRule, q, err)
-		return
+		return err
 	}
 
 	qosTableName := SessQerLookup
@@ -1500,9 +2014,11 @@ func (b *bess) addSessionQER(ctx context.Context, gate uint64, srcIface uint8,
 	if err != nil {
 		logger.BessLog.Errorln("process QER failed for sessionQERLookup add operation")
 	}
+
+	return err
 }
 
-func (b *bess) delSessionQER(ctx context.Context, srcIface uint8, qer qer) {
+func (b *bess) delSessionQER(ctx context.Context, srcIface uint8, qer qer) error {
 	var (
 		arg *anypb.Any
 		err error
@@ -1518,7 +2034,7 @@ func (b *bess) delSessionQER(ctx context.Context, srcIface uint8, qer qer) {
 	arg, err = anypb.New(q)
 	if err != nil {
 		logger.BessLog.Errorln(errMarshalRule, q, err)
-		return
+		return err
 	}
 
 	qosTableName := SessQerLookup
@@ -1527,6 +2043,8 @@ func (b *bess) delSessionQER(ctx context.Context, srcIface uint8, qer qer) {
 	if err != nil {
 		logger.BessLog.Errorln("process QER failed for sessionQERLookup del operation")
 	}
+
+	return err
 }
 
 func (b *bess) GRPCJoin(calls int, timeout time.Duration, done chan bool) bool {
@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	pb "github.com/omec-project/upf-epc/pfcpiface/bess_pb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// runtimeConfigCmd reads back the rules of WildcardMatch and ExactMatch modules.
+const runtimeConfigCmd = "get_runtime_config"
+
+// fieldUint returns the integer value of a field, as set by intEnc or as
+// returned in binary form by the modules. The modules return the values in
+// host byte order, little endian on the platforms BESS runs on.
+func fieldUint(f *pb.FieldData) uint64 {
+	if b := f.GetValueBin(); len(b) > 0 {
+		var v [8]byte
+
+		copy(v[:], b)
+
+		return binary.LittleEndian.Uint64(v[:])
+	}
+
+	return f.GetValueInt()
+}
+
+func fieldsString(fields []*pb.FieldData) string {
+	s := make([]string, len(fields))
+	for i, f := range fields {
+		s[i] = fmt.Sprintf("%x", fieldUint(f))
+	}
+
+	return strings.Join(s, ",")
+}
+
+// wildcardRule returns the audited form of a rule of a PDR table. Bits of the
+// values outside of the masks are ignored, as the modules do.
+func wildcardRule(table string, r *pb.WildcardMatchCommandAddArg) datapathRule {
+	values := make([]string, len(r.Values))
+
+	for i, v := range r.Values {
+		var mask uint64
+		if i < len(r.Masks) {
+			mask = fieldUint(r.Masks[i])
+		}
+
+		values[i] = fmt.Sprintf("%x", fieldUint(v)&mask)
+	}
+
+	var fseID uint64
+	if len(r.Valuesv) > 1 {
+		fseID = fieldUint(r.Valuesv[1])
+	}
+
+	return datapathRule{
+		table: table,
+		key:   strings.Join(values, ",") + "/" + fieldsString(r.Masks),
+		value: fmt.Sprintf("gate=%d,priority=%d,%s", r.Gate, r.Priority, fieldsString(r.Valuesv)),
+		fseID: fseID,
+		arg:   r,
+	}
+}
+
+// exactMatchRule returns the audited form of a rule of a FAR table.
+func exactMatchRule(table string, r *pb.ExactMatchCommandAddArg) datapathRule {
+	var fseID uint64
+	if len(r.Fields) > 1 {
+		fseID = fieldUint(r.Fields[1])
+	}
+
+	return datapathRule{
+		table: table,
+		key:   fieldsString(r.Fields),
+		value: fmt.Sprintf("gate=%d,%s", r.Gate, fieldsString(r.Values)),
+		fseID: fseID,
+		arg:   r,
+	}
+}
+
+// qerRules returns the keys of the rules of the QER. The QoS modules cannot be
+// read back, the rules are only counted.
+func qerRules(q qer) []datapathRule {
+	var rules []datapathRule
+
+	for _, srcIface := range []uint8{access, core} {
+		switch q.qosLevel {
+		case ApplicationQos:
+			rules = append(rules, datapathRule{
+				table: AppQerLookup,
+				key:   fmt.Sprintf("%x,%x,%x", srcIface, q.qerID, q.fseID),
+				fseID: q.fseID,
+			})
+		case SessionQos:
+			rules = append(rules, datapathRule{
+				table: SessQerLookup,
+				key:   fmt.Sprintf("%x,%x", srcIface, q.fseID),
+				fseID: q.fseID,
+			})
+		}
+	}
+
+	return rules
+}
+
+func (b *bess) ExpectedRules(rules PacketForwardingRules) []datapathRule {
+	var expected []datapathRule
+
+	for _, p := range rules.pdrs {
+		tables, err := b.pdrRules(p)
+		if err != nil {
+			continue
+		}
+
+		for table, tableRules := range tables {
+			for _, r := range tableRules {
+				expected = append(expected, wildcardRule(table, r))
+			}
+		}
+	}
+
+	for _, f := range rules.fars {
+		table, r, err := b.farRule(f)
+		if err != nil {
+			continue
+		}
+
+		expected = append(expected, exactMatchRule(table, r))
+	}
+
+	for _, q := range rules.qers {
+		expected = append(expected, qerRules(q)...)
+	}
+
+	return expected
+}
+
+func (b *bess) readRuntimeConfig(ctx context.Context, module string) (*anypb.Any, error) {
+	arg, err := anypb.New(&pb.EmptyArg{})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.client.ModuleCommand(ctx, &pb.CommandRequest{
+		Name: module,
+		Cmd:  runtimeConfigCmd,
+		Arg:  arg,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.GetError() != nil && resp.GetError().GetCode() != 0 {
+		return nil, ErrOperationFailedWithReason("read "+module, resp.GetError().GetErrmsg())
+	}
+
+	return resp.GetData(), nil
+}
+
+func (b *bess) readWildcardTable(ctx context.Context, table string) datapathTable {
+	t := datapathTable{name: table}
+
+	data, err := b.readRuntimeConfig(ctx, table)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	var cfg pb.WildcardMatchConfig
+	if data != nil {
+		if err := data.UnmarshalTo(&cfg); err != nil {
+			t.err = err
+			return t
+		}
+	}
+
+	for _, r := range cfg.Rules {
+		t.rules = append(t.rules, wildcardRule(table, r))
+	}
+
+	return t
+}
+
+func (b *bess) readExactMatchTable(ctx context.Context, table string) datapathTable {
+	t := datapathTable{name: table}
+
+	data, err := b.readRuntimeConfig(ctx, table)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	var cfg pb.ExactMatchConfig
+	if data != nil {
+		if err := data.UnmarshalTo(&cfg); err != nil {
+			t.err = err
+			return t
+		}
+	}
+
+	for _, r := range cfg.Rules {
+		t.rules = append(t.rules, exactMatchRule(table, r))
+	}
+
+	return t
+}
+
+func (b *bess) ReadRules() []datapathTable {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	tables := []datapathTable{
+		b.readWildcardTable(ctx, pdrLookupIPv4),
+		b.readExactMatchTable(ctx, farLookupIPv4),
+	}
+
+	if b.enableIPv6 {
+		tables = append(tables,
+			b.readWildcardTable(ctx, pdrLookupIPv6),
+			b.readExactMatchTable(ctx, farLookupIPv6),
+		)
+	}
+
+	for _, table := range []string{AppQerLookup, SessQerLookup} {
+		tables = append(tables, datapathTable{
+			name: table,
+			err:  ErrUnsupported("read back of module", table),
+		})
+	}
+
+	return tables
+}
+
+func (b *bess) DeleteRule(r datapathRule) error {
+	var (
+		arg *anypb.Any
+		err error
+	)
+
+	switch rule := r.arg.(type) {
+	case *pb.WildcardMatchCommandAddArg:
+		arg, err = anypb.New(&pb.WildcardMatchCommandDeleteArg{Values: rule.Values, Masks: rule.Masks})
+	case *pb.ExactMatchCommandAddArg:
+		arg, err = anypb.New(&pb.ExactMatchCommandDeleteArg{Fields: rule.Fields})
+	default:
+		return ErrUnsupported("rule of table", r.table)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	resp, err := b.client.ModuleCommand(ctx, &pb.CommandRequest{
+		Name: r.table,
+		Cmd:  "delete",
+		Arg:  arg,
+	})
+	if err != nil {
+		return err
+	}
+
+	if resp.GetError() != nil && resp.GetError().GetCode() != 0 {
+		return ErrOperationFailedWithReason("delete rule from "+r.table, resp.GetError().GetErrmsg())
+	}
+
+	return nil
+}
@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/omec-project/upf-epc/pfcpiface/bess_pb"
+	"github.com/omec-project/upf-epc/pkg/fake_bess"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// newFakeBess returns a bess datapath connected to a fake BESS.
+func newFakeBess(t *testing.T) (*bess, *fake_bess.FakeBESS) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+
+	addr := l.Addr().String()
+	l.Close()
+
+	fb := fake_bess.NewFakeBESS()
+
+	go func() {
+		if err := fb.Run(addr); err != nil {
+			t.Errorf("fake BESS failed: %v", err)
+		}
+	}()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect to fake BESS: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		fb.Stop()
+	})
+
+	// Wait for the fake BESS to serve.
+	b := &bess{client: pb.NewBESSControlClient(conn), conn: conn}
+	for i := 0; i < 50; i++ {
+		if t := b.ReadRules(); t[0].err == nil {
+			return b, fb
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("fake BESS not ready")
+
+	return nil, nil
+}
+
+func auditedTable(t *testing.T, r auditReport, name string) tableAudit {
+	t.Helper()
+
+	for _, ta := range r.Tables {
+		if ta.Table == name {
+			return ta
+		}
+	}
+
+	t.Fatalf("table %v not audited", name)
+
+	return tableAudit{}
+}
+
+func TestAuditDatapath(t *testing.T) {
+	b, _ := newFakeBess(t)
+	node := &PFCPNode{upf: &upf{datapath: b, usage: newUsageTracker()}}
+	pConn := newTestPFCPConn(node, 1)
+
+	var sessions []PFCPSession
+
+	for i := uint32(1); i <= 3; i++ {
+		s, ok := pConn.NewPFCPSession(uint64(i))
+		if !ok {
+			t.Fatal("failed to allocate session")
+		}
+
+		s.CreatePDR(pdr{
+			pdrID: 1, fseID: s.localSEID, srcIface: access, srcIfaceMask: 0xFF,
+			tunnelTEID: i, tunnelTEIDMask: 0xFFFFFFFF, farID: 1,
+		})
+		s.CreateFAR(far{farID: 1, fseID: s.localSEID, applyAction: ActionForward, dstIntf: 1})
+
+		if err := pConn.store.PutSession(s); err != nil {
+			t.Fatalf("failed to store session: %v", err)
+		}
+
+		if err := writeRules(b, upfMsgTypeAdd, PacketForwardingRules{}, s.PacketForwardingRules); err != nil {
+			t.Fatalf("failed to install session: %v", err)
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	// The FAR of the first session is lost, the PDR of the second one
+	// points to another FAR, and the third session is deleted from the
+	// store only.
+	table, r, err := b.farRule(sessions[0].fars[0])
+	if err != nil {
+		t.Fatalf("failed to build FAR rule: %v", err)
+	}
+
+	if err := b.DeleteRule(exactMatchRule(table, r)); err != nil {
+		t.Fatalf("failed to delete FAR: %v", err)
+	}
+
+	p := sessions[1].pdrs[0]
+	p.farID = 2
+	if err := writeRules(b, upfMsgTypeAdd, PacketForwardingRules{}, PacketForwardingRules{pdrs: []pdr{p}}); err != nil {
+		t.Fatalf("failed to install PDR: %v", err)
+	}
+
+	pConn.RemoveSession(sessions[2])
+
+	t.Run("report", func(t *testing.T) {
+		r := node.auditDatapath(false)
+
+		pdrs := auditedTable(t, r, pdrLookupIPv4)
+		if pdrs.Expected != 2 || pdrs.Installed != 3 || pdrs.Mismatched != 1 || pdrs.Orphaned != 1 || pdrs.Missing != 0 {
+			t.Errorf("unexpected audit of %v: %+v", pdrLookupIPv4, pdrs)
+		}
+
+		fars := auditedTable(t, r, farLookupIPv4)
+		if fars.Expected != 2 || fars.Installed != 2 || fars.Missing != 1 || fars.Orphaned != 1 {
+			t.Errorf("unexpected audit of %v: %+v", farLookupIPv4, fars)
+		}
+
+		if qers := auditedTable(t, r, AppQerLookup); qers.Error == "" {
+			t.Errorf("expected %v not to be auditable", AppQerLookup)
+		}
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		r := node.auditDatapath(true)
+
+		if pdrs := auditedTable(t, r, pdrLookupIPv4); pdrs.Repaired != 2 {
+			t.Errorf("expected 2 repaired rules in %v, got %+v", pdrLookupIPv4, pdrs)
+		}
+
+		r = node.auditDatapath(false)
+
+		for _, name := range []string{pdrLookupIPv4, farLookupIPv4} {
+			ta := auditedTable(t, r, name)
+			if ta.Missing != 0 || ta.Orphaned != 0 || ta.Mismatched != 0 || ta.Installed != ta.Expected {
+				t.Errorf("unexpected audit of %v after repair: %+v", name, ta)
+			}
+		}
+
+		if _, audits, repaired := node.audit.lastReport(); audits != 3 || repaired != 4 {
+			t.Errorf("expected 3 audits and 4 repaired rules, got %d and %d", audits, repaired)
+		}
+	})
+}
+
+func TestRestoreSessionsRemovesStaleRules(t *testing.T) {
+	b, _ := newFakeBess(t)
+	db := openTestSessionDB(t, filepath.Join(t.TempDir(), "sessions.db"))
+	u := &upf{datapath: b, usage: newUsageTracker(), sessionDB: db, restoreSessions: true, restoreGrace: time.Hour}
+
+	// The previous pfcp-agent instance stored the first session only, the
+	// rules of the second one were left in the datapath.
+	prev := newTestPFCPConn(&PFCPNode{upf: u}, 1)
+	prev.nodeID.remote = "smf"
+	stored := NewBoltStore(db, func() string { return "smf" })
+
+	for i := uint32(1); i <= 2; i++ {
+		s, ok := prev.NewPFCPSession(uint64(i))
+		if !ok {
+			t.Fatal("failed to allocate session")
+		}
+
+		s.CreatePDR(pdr{
+			pdrID: 1, fseID: s.localSEID, srcIface: access, srcIfaceMask: 0xFF,
+			tunnelTEID: i, tunnelTEIDMask: 0xFFFFFFFF, farID: 1,
+		})
+		s.CreateFAR(far{farID: 1, fseID: s.localSEID, applyAction: ActionForward, dstIntf: 1})
+
+		if err := writeRules(b, upfMsgTypeAdd, PacketForwardingRules{}, s.PacketForwardingRules); err != nil {
+			t.Fatalf("failed to install session: %v", err)
+		}
+
+		if i == 1 {
+			if err := stored.PutSession(s); err != nil {
+				t.Fatalf("failed to store session: %v", err)
+			}
+		}
+	}
+
+	node := &PFCPNode{upf: u, metrics: noopPFCPMetrics{}}
+	node.restoreSessions()
+
+	r := node.auditDatapath(false)
+
+	for _, name := range []string{pdrLookupIPv4, farLookupIPv4} {
+		if ta := auditedTable(t, r, name); ta.Expected != 1 || ta.Installed != 1 || ta.Orphaned != 0 {
+			t.Errorf("expected the rules of the restored session only in %v, got %+v", name, ta)
+		}
+	}
+}
+
+func TestFieldUint(t *testing.T) {
+	if v := fieldUint(intEnc(0x1234)); v != 0x1234 {
+		t.Errorf("expected 0x1234, got %x", v)
+	}
+
+	// The modules return the values in host byte order.
+	bin := &pb.FieldData{Encoding: &pb.FieldData_ValueBin{ValueBin: []byte{0x34, 0x12, 0, 0}}}
+	if v := fieldUint(bin); v != 0x1234 {
+		t.Errorf("expected 0x1234, got %x", v)
+	}
+}
+
+func TestAuditRepairWaitsForSessionChanges(t *testing.T) {
+	b, _ := newFakeBess(t)
+	node := &PFCPNode{upf: &upf{datapath: b, usage: newUsageTracker()}}
+	pConn := newTestPFCPConn(node, 1)
+
+	// The session is being established: its rules are installed before it
+	// is stored.
+	node.upf.sessionMu.Lock()
+
+	s, ok := pConn.NewPFCPSession(1)
+	if !ok {
+		t.Fatal("failed to allocate session")
+	}
+
+	s.CreatePDR(pdr{
+		pdrID: 1, fseID: s.localSEID, srcIface: access, srcIfaceMask: 0xFF,
+		tunnelTEID: 1, tunnelTEIDMask: 0xFFFFFFFF, farID: 1,
+	})
+	s.CreateFAR(far{farID: 1, fseID: s.localSEID, applyAction: ActionForward, dstIntf: 1})
+
+	if err := writeRules(b, upfMsgTypeAdd, PacketForwardingRules{}, s.PacketForwardingRules); err != nil {
+		t.Fatalf("failed to install session: %v", err)
+	}
+
+	done := make(chan auditReport)
+
+	go func() {
+		done <- node.auditDatapath(true)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	if err := pConn.store.PutSession(s); err != nil {
+		t.Fatalf("failed to store session: %v", err)
+	}
+
+	node.upf.sessionMu.Unlock()
+
+	r := <-done
+
+	for _, name := range []string{pdrLookupIPv4, farLookupIPv4} {
+		if ta := auditedTable(t, r, name); ta.Installed != 1 || ta.Orphaned != 0 || ta.Repaired != 0 {
+			t.Errorf("expected the rules of the established session to be kept in %v, got %+v", name, ta)
+		}
+	}
+}
+
+func TestUpdatePDRRemovesPreviousRules(t *testing.T) {
+	b, _ := newFakeBess(t)
+
+	prev := PacketForwardingRules{pdrs: []pdr{{
+		pdrID: 1, fseID: 1, srcIface: access, srcIfaceMask: 0xFF,
+		tunnelTEID: 1, tunnelTEIDMask: 0xFFFFFFFF, farID: 1,
+	}}}
+
+	if err := writeRules(b, upfMsgTypeAdd, PacketForwardingRules{}, prev); err != nil {
+		t.Fatalf("failed to install PDR: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		update func(p *pdr)
+	}{
+		{name: "new match", update: func(p *pdr) { p.tunnelTEID = 2 }},
+		{name: "same match", update: func(p *pdr) { p.farID = 2 }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			updated := prev.clone()
+			tc.update(&updated.pdrs[0])
+
+			if err := writeRules(b, upfMsgTypeMod, prev, updated); err != nil {
+				t.Fatalf("failed to update PDR: %v", err)
+			}
+
+			if rules := b.ReadRules()[0].rules; len(rules) != 1 {
+				t.Errorf("expected the rule of the updated PDR only, got %v", rules)
+			}
+
+			prev = updated
+		})
+	}
+}
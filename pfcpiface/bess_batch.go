@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+	pb "github.com/omec-project/upf-epc/pfcpiface/bess_pb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// bessPauseTimeout bounds the calls pausing and resuming the BESS workers.
+	bessPauseTimeout = time.Second
+	// bessResumeRetryInterval is the delay between the attempts to resume the
+	// BESS workers.
+	bessResumeRetryInterval = 100 * time.Millisecond
+)
+
+var errBatcherStopped = ErrOperationFailedWithReason("BESS rule command", "batcher stopped")
+
+type bessCommandResult struct {
+	resp *pb.CommandResponse
+	err  error
+}
+
+// bessCommand is a rule command waiting for its batch to be flushed.
+type bessCommand struct {
+	ctx    context.Context
+	req    *pb.CommandRequest
+	result chan bessCommandResult
+}
+
+// bessBatcher groups the rule commands of all the sessions into batches of at
+// most maxSize commands, collected for at most window. BESS pauses its workers
+// around every rule command: the workers are rather paused once per batch,
+// whose commands are pipelined on the connection.
+type bessBatcher struct {
+	client  pb.BESSControlClient
+	maxSize int
+	window  time.Duration
+	queue   chan *bessCommand
+	done    chan struct{}
+
+	size    prometheus.Histogram
+	latency prometheus.Histogram
+}
+
+func newBessBatcher(client pb.BESSControlClient, maxSize int, window time.Duration) *bessBatcher {
+	return &bessBatcher{
+		client:  client,
+		maxSize: maxSize,
+		window:  window,
+		queue:   make(chan *bessCommand, maxSize),
+		done:    make(chan struct{}),
+		size: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "upf_bess_batch_size",
+			Help:    "Number of rule commands per batch sent to BESS",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "upf_bess_batch_flush_duration_seconds",
+			Help:    "Time taken to flush a batch of rule commands to BESS",
+			Buckets: []float64{1e-4, 1e-3, 1e-2, 1e-1, 1, 1e1},
+		}),
+	}
+}
+
+// start registers the metrics of the batcher and starts collecting batches.
+func (bb *bessBatcher) start() {
+	for _, c := range []prometheus.Collector{bb.size, bb.latency} {
+		if err := prometheus.Register(c); err != nil {
+			logger.BessLog.Warnln("failed to register BESS batch metrics:", err)
+		}
+	}
+
+	go bb.run()
+}
+
+func (bb *bessBatcher) stop() {
+	close(bb.done)
+
+	prometheus.Unregister(bb.size)
+	prometheus.Unregister(bb.latency)
+}
+
+// command queues the rule command into the next batch, and waits until the
+// batch is flushed.
+func (bb *bessBatcher) command(ctx context.Context, req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	c := &bessCommand{ctx: ctx, req: req, result: make(chan bessCommandResult, 1)}
+
+	select {
+	case bb.queue <- c:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-bb.done:
+		return nil, errBatcherStopped
+	}
+
+	select {
+	case r := <-c.result:
+		return r.resp, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-bb.done:
+		return nil, errBatcherStopped
+	}
+}
+
+func (bb *bessBatcher) run() {
+	for {
+		var batch []*bessCommand
+
+		select {
+		case c := <-bb.queue:
+			batch = append(batch, c)
+		case <-bb.done:
+			return
+		}
+
+		timer := time.NewTimer(bb.window)
+
+	collect:
+		for len(batch) < bb.maxSize {
+			select {
+			case c := <-bb.queue:
+				batch = append(batch, c)
+			case <-timer.C:
+				break collect
+			case <-bb.done:
+				timer.Stop()
+				return
+			}
+		}
+
+		timer.Stop()
+		bb.flush(batch)
+	}
+}
+
+// flush sends the commands of the batch with the workers paused. The results
+// are returned once the workers are resumed, so that the rules are applied
+// when the callers learn about them.
+func (bb *bessBatcher) flush(batch []*bessCommand) {
+	start := time.Now()
+
+	// Without paused workers, every command pauses them.
+	paused := false
+
+	if len(batch) > 1 {
+		ctx, cancel := context.WithTimeout(context.Background(), bessPauseTimeout)
+		_, err := bb.client.PauseAll(ctx, &pb.EmptyRequest{})
+
+		cancel()
+
+		if err != nil {
+			logger.BessLog.Debugln("failed to pause BESS workers:", err)
+		}
+
+		paused = err == nil
+	}
+
+	results := make([]bessCommandResult, len(batch))
+
+	// The callers issued the commands of a batch concurrently, so they are
+	// sent without waiting for each other's responses.
+	var wg sync.WaitGroup
+
+	for i, c := range batch {
+		if err := c.ctx.Err(); err != nil {
+			results[i].err = err
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			results[i].resp, results[i].err = bb.client.ModuleCommand(c.ctx, c.req)
+		}()
+	}
+
+	wg.Wait()
+
+	if paused {
+		bb.resume()
+	}
+
+	for i, c := range batch {
+		c.result <- results[i]
+	}
+
+	bb.size.Observe(float64(len(batch)))
+	bb.latency.Observe(time.Since(start).Seconds())
+}
+
+// resume resumes the BESS workers, retrying until it succeeds, since paused
+// workers don't forward any traffic. It only gives up once the batcher is
+// stopped.
+func (bb *bessBatcher) resume() {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), bessPauseTimeout)
+		_, err := bb.client.ResumeAll(ctx, &pb.EmptyRequest{})
+
+		cancel()
+
+		if err == nil {
+			return
+		}
+
+		logger.BessLog.Errorln("failed to resume BESS workers, retrying:", err)
+
+		select {
+		case <-time.After(bessResumeRetryInterval):
+		case <-bb.done:
+			logger.BessLog.Errorln("BESS batcher stopped with the workers paused")
+			return
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBessBatcher(t *testing.T) {
+	b, fb := newFakeBess(t)
+
+	b.batcher = newBessBatcher(b.client, 64, 50*time.Millisecond)
+	b.batcher.start()
+	t.Cleanup(b.batcher.stop)
+
+	// Every session has a PDR with two ternary rules and a FAR.
+	sessionRules := func(fseID uint64) PacketForwardingRules {
+		p := pdr{
+			pdrID: 1, fseID: fseID, srcIface: access, srcIfaceMask: 0xFF,
+			tunnelTEID: uint32(fseID), tunnelTEIDMask: 0xFFFFFFFF, farID: 1,
+		}
+		p.appFilter.dstPortRange = newRangeMatchPortRange(80, 81)
+
+		return PacketForwardingRules{
+			pdrs: []pdr{p},
+			fars: []far{{farID: 1, fseID: fseID, applyAction: ActionForward, dstIntf: 1}},
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	for fseID := uint64(1); fseID <= 8; fseID++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := writeRules(b, upfMsgTypeAdd, PacketForwardingRules{}, sessionRules(fseID)); err != nil {
+				t.Errorf("failed to install session %v: %v", fseID, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if pdrs := fb.GetPdrTableEntries(); len(pdrs) != 1 || len(pdrs[1]) != 16 {
+		t.Errorf("expected 16 rules of PDR 1, got %v", pdrs)
+	}
+
+	if fars := fb.GetFarTableEntries(); len(fars) != 1 {
+		t.Errorf("expected FAR 1, got %v", fars)
+	}
+
+	// The 24 commands are sent in much fewer batches.
+	pauses, paused := fb.Pauses()
+	if pauses == 0 || pauses >= 12 {
+		t.Errorf("expected the commands to be batched, got %v pauses", pauses)
+	}
+
+	if paused {
+		t.Error("expected the workers to be resumed")
+	}
+
+	t.Run("failed resume", func(t *testing.T) {
+		before, _ := fb.Pauses()
+		fb.FailResumes(2)
+
+		for fseID := uint64(9); fseID <= 10; fseID++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				if err := writeRules(b, upfMsgTypeAdd, PacketForwardingRules{}, sessionRules(fseID)); err != nil {
+					t.Errorf("failed to install session %v: %v", fseID, err)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		pauses, paused := fb.Pauses()
+		if pauses == before {
+			t.Fatal("expected the workers to be paused")
+		}
+
+		if paused {
+			t.Error("expected the workers to be resumed after failing to")
+		}
+	})
+}
@@ -0,0 +1,246 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+var sessionsBucket = []byte("sessions")
+
+// SessionDB is an embedded key/value file holding the PFCP sessions of all the
+// associations, so that they survive pfcp-agent restarts.
+//
+// Writes are queued and committed by a background writer, so that the PFCP
+// message handlers do not wait for the fsync of every change. Each commit
+// writes all the changes queued since the previous one. The changes queued
+// when pfcp-agent crashes are lost, and the sessions are restored as of the
+// last commit.
+type SessionDB struct {
+	db *bolt.DB
+	// flushMu keeps the commits in the order the changes were queued.
+	flushMu sync.Mutex
+
+	mu sync.Mutex
+	// pending holds the encoded sessions to write by F-SEID, nil for the
+	// sessions to delete.
+	pending   map[uint64][]byte
+	kick      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	stopped   chan struct{}
+}
+
+// OpenSessionDB opens the session file at path, creating it if needed.
+func OpenSessionDB(path string) (*SessionDB, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, ErrOperationFailedWithReason("open session store "+path, err.Error())
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sessionsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	d := &SessionDB{
+		db:      db,
+		pending: make(map[uint64][]byte),
+		kick:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go d.writeLoop()
+
+	return d, nil
+}
+
+// Close commits the queued changes and closes the session file.
+func (d *SessionDB) Close() error {
+	d.closeOnce.Do(func() { close(d.done) })
+	<-d.stopped
+
+	return d.db.Close()
+}
+
+func seidKey(fseid uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, fseid)
+
+	return key
+}
+
+// writeLoop commits the queued changes until the session file is closed.
+func (d *SessionDB) writeLoop() {
+	defer close(d.stopped)
+
+	for {
+		select {
+		case <-d.kick:
+		case <-d.done:
+			if err := d.flush(); err != nil {
+				logger.PfcpLog.Errorf("failed to write sessions to session store: %v", err)
+			}
+
+			return
+		}
+
+		if err := d.flush(); err != nil {
+			logger.PfcpLog.Errorf("failed to write sessions to session store: %v", err)
+		}
+	}
+}
+
+// flush commits the queued changes in a single transaction.
+func (d *SessionDB) flush() error {
+	d.flushMu.Lock()
+	defer d.flushMu.Unlock()
+
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = make(map[uint64][]byte)
+	d.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sessionsBucket)
+
+		for fseid, v := range pending {
+			var err error
+
+			if v == nil {
+				err = b.Delete(seidKey(fseid))
+			} else {
+				err = b.Put(seidKey(fseid), v)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// queue records the change of the session, replacing the queued one if any.
+func (d *SessionDB) queue(fseid uint64, v []byte) {
+	d.mu.Lock()
+	d.pending[fseid] = v
+	d.mu.Unlock()
+
+	select {
+	case d.kick <- struct{}{}:
+	default:
+	}
+}
+
+// loadSessions returns all the stored sessions, by node ID of the CP function
+// owning them.
+func (d *SessionDB) loadSessions() (map[string][]PFCPSession, error) {
+	if err := d.flush(); err != nil {
+		return nil, err
+	}
+
+	sessions := make(map[string][]PFCPSession)
+
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(k, v []byte) error {
+			s, nodeID, err := decodeSession(v)
+			if err != nil {
+				logger.PfcpLog.Errorf("skipping undecodable session %x: %v", k, err)
+				return nil
+			}
+
+			sessions[nodeID] = append(sessions[nodeID], s)
+
+			return nil
+		})
+	})
+
+	return sessions, err
+}
+
+func (d *SessionDB) put(s PFCPSession, nodeID string) error {
+	v, err := encodeSession(s, nodeID)
+	if err != nil {
+		return err
+	}
+
+	d.queue(s.localSEID, v)
+
+	return nil
+}
+
+func (d *SessionDB) delete(fseids ...uint64) {
+	for _, fseid := range fseids {
+		d.queue(fseid, nil)
+	}
+}
+
+// BoltStore is the SessionsStore of a PFCP association backed by a SessionDB.
+// Sessions are read from memory and written through to the session file.
+type BoltStore struct {
+	*InMemoryStore
+
+	db *SessionDB
+	// nodeID returns the node ID of the CP function owning the sessions.
+	nodeID func() string
+}
+
+func NewBoltStore(db *SessionDB, nodeID func() string) *BoltStore {
+	return &BoltStore{
+		InMemoryStore: NewInMemoryStore(),
+		db:            db,
+		nodeID:        nodeID,
+	}
+}
+
+func (b *BoltStore) PutSession(session PFCPSession) error {
+	if session.localSEID == 0 {
+		return ErrInvalidArgument("session.localSEID", session.localSEID)
+	}
+
+	if err := b.db.put(session, b.nodeID()); err != nil {
+		return ErrOperationFailedWithReason("save PFCP session to session store", err.Error())
+	}
+
+	return b.InMemoryStore.PutSession(session)
+}
+
+func (b *BoltStore) DeleteSession(fseid uint64) error {
+	if _, ok := b.InMemoryStore.sessions.Load(fseid); !ok {
+		return nil
+	}
+
+	b.db.delete(fseid)
+
+	return b.InMemoryStore.DeleteSession(fseid)
+}
+
+func (b *BoltStore) DeleteAllSessions() bool {
+	var fseids []uint64
+
+	b.InMemoryStore.sessions.Range(func(key, value interface{}) bool {
+		fseids = append(fseids, key.(uint64))
+		return true
+	})
+
+	b.db.delete(fseids...)
+
+	return b.InMemoryStore.DeleteAllSessions()
+}
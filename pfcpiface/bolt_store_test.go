@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"bytes"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func openTestSessionDB(t *testing.T, path string) *SessionDB {
+	t.Helper()
+
+	db, err := OpenSessionDB(path)
+	if err != nil {
+		t.Fatalf("failed to open session store: %v", err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestSessionEncoding(t *testing.T) {
+	_, ue6, _ := net.ParseCIDR("2001:db8:1::/64")
+
+	s := PFCPSession{
+		localSEID:  1,
+		remoteSEID: 2,
+		remoteIP:   net.ParseIP("2001:db8::10"),
+		PacketForwardingRules: PacketForwardingRules{
+			pdrs: []pdr{{
+				pdrID: 1, fseID: 1, srcIface: core, srcIfaceMask: 0xFF, ueAddress6: ue6, allocIPFlag: true,
+				appFilter: applicationFilter{
+					dstIP6: ue6.IP, dstIP6Mask: ue6.Mask, srcPortRange: portRange{low: 80, high: 80},
+					proto: 17, protoMask: 0xFF, ipv6Only: true,
+				},
+				farID: 1, qerIDList: []uint32{1}, urrIDList: []uint32{1},
+			}},
+			fars: []far{{
+				farID: 1, fseID: 1, applyAction: ActionForward | ActionBuffer, tunnelTEID: 10,
+				tunnelIP6Src: net.ParseIP("2001:db8::1"), tunnelIP6Dst: net.ParseIP("2001:db8::2"), hasBAR: true, barID: 1,
+			}},
+			qers: []qer{{qerID: 1, fseID: 1, qosLevel: SessionQos, qfi: 9, ulMbr: 1000, dlMbr: 2000}},
+			urrs: []urr{{
+				urrID: 1, fseID: 1, measureVolume: true, triggers: triggerVOLTH | triggerPERIO,
+				measurementPeriod: time.Minute, volumeThreshold: volumeLimit{total: 1000},
+			}},
+			bars: []bar{{barID: 1, fseID: 1, ddnDelay: 100 * time.Millisecond, suggestedPktCount: 10}},
+		},
+	}
+
+	data, err := encodeSession(s, "smf")
+	if err != nil {
+		t.Fatalf("failed to encode session: %v", err)
+	}
+
+	got, nodeID, err := decodeSession(data)
+	if err != nil {
+		t.Fatalf("failed to decode session: %v", err)
+	}
+
+	if nodeID != "smf" {
+		t.Errorf("expected node ID smf, got %v", nodeID)
+	}
+
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("decoded session differs:\n got: %v\nwant: %v", got, s)
+	}
+
+	// Records of newer schema versions are not restored.
+	newer := bytes.Replace(data, []byte(`"version":1`), []byte(`"version":2`), 1)
+	if _, _, err := decodeSession(newer); err == nil {
+		t.Error("expected session of newer version to be rejected")
+	}
+}
+
+func TestBoltStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	db := openTestSessionDB(t, path)
+
+	store := NewBoltStore(db, func() string { return "smf1" })
+	other := NewBoltStore(db, func() string { return "smf2" })
+
+	for _, seid := range []uint64{1, 2} {
+		if err := store.PutSession(PFCPSession{localSEID: seid}); err != nil {
+			t.Fatalf("failed to store session: %v", err)
+		}
+	}
+
+	if err := other.PutSession(PFCPSession{localSEID: 3}); err != nil {
+		t.Fatalf("failed to store session: %v", err)
+	}
+
+	if err := store.DeleteSession(1); err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+
+	// Sessions of other associations are not affected.
+	if err := store.DeleteSession(3); err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+
+	if _, ok := store.GetSession(2); !ok {
+		t.Error("session 2 not found")
+	}
+
+	db.Close()
+
+	db = openTestSessionDB(t, path)
+
+	sessions, err := db.loadSessions()
+	if err != nil {
+		t.Fatalf("failed to load sessions: %v", err)
+	}
+
+	for nodeID, seids := range map[string][]uint64{"smf1": {2}, "smf2": {3}} {
+		if len(sessions[nodeID]) != len(seids) || sessions[nodeID][0].localSEID != seids[0] {
+			t.Errorf("expected sessions %v of %v, got %v", seids, nodeID, sessions[nodeID])
+		}
+	}
+}
+
+func TestRestoreSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+
+	prev := newRestartTestConn(t, peerRestartPolicyPurge, 0)
+	prev.upf.sessionDB = openTestSessionDB(t, path)
+	prev.store = NewBoltStore(prev.upf.sessionDB, func() string { return prev.nodeID.remote })
+	s := newRestartTestSession(t, prev)
+
+	prev.upf.sessionDB.Close()
+
+	// A new pfcp-agent instance, with empty pools.
+	p := newRestartTestConn(t, peerRestartPolicyPurge, 0)
+	node := p.node
+	node.metrics = noopPFCPMetrics{}
+	node.upf.sessionDB = openTestSessionDB(t, path)
+	node.upf.restoreSessions = true
+	node.upf.restoreGrace = time.Hour
+	p.store = NewBoltStore(node.upf.sessionDB, func() string { return p.nodeID.remote })
+
+	node.restoreSessions()
+
+	if !node.upf.fteidGenerator.IsAllocated(s.pdrs[0].tunnelTEID) {
+		t.Error("F-TEID of restored session not reserved")
+	}
+
+	ueIP, err := node.upf.ippool.LookupOrAllocIP(s.localSEID)
+	if err != nil || ip2int(ueIP) != s.pdrs[1].ueAddress {
+		t.Errorf("expected UE IP %v of restored session, got %v (err: %v)", int2ip(s.pdrs[1].ueAddress), ueIP, err)
+	}
+
+	// The restored session has no association to send its reports to.
+	owner, ok := node.sessionOwner(s.localSEID)
+	if !ok || owner.Conn != nil {
+		t.Fatalf("expected restored session without association, got %v", owner)
+	}
+
+	restored, _ := owner.store.GetSession(s.localSEID)
+	owner.sendDownlinkDataReport(restored, 2)
+	owner.sendErrorIndicationReport(restored, 1, 1)
+
+	if _, timeout := owner.sendPFCPRequestMessage(newRequest(heartbeatRequest(time.Now()))); !timeout {
+		t.Error("expected request without association to fail")
+	}
+
+	p.adoptOrphanedSessions()
+
+	if _, ok := p.store.GetSession(s.localSEID); !ok {
+		t.Fatal("restored session not adopted by the new association")
+	}
+
+	sessions, err := node.upf.sessionDB.loadSessions()
+	if err != nil || len(sessions["smf"]) != 1 {
+		t.Errorf("expected adopted session in session store, got %v (err: %v)", sessions, err)
+	}
+}
@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrCapacityExceeded is returned for the sessions whose rules do not fit in
+// the capacity of the datapath.
+var ErrCapacityExceeded = errors.New("datapath capacity exceeded")
+
+// capacityTables are the datapath tables whose capacity can be configured.
+var capacityTables = []string{
+	pdrLookupIPv4, pdrLookupIPv6, farLookupIPv4, farLookupIPv6, AppQerLookup, SessQerLookup,
+}
+
+// tableUsage is the number of entries of a datapath table, and its capacity,
+// 0 if unlimited.
+type tableUsage struct {
+	Table string
+	Used  uint64
+	Limit uint64
+}
+
+// capacityUsage is the utilization of the capacity of the datapath.
+type capacityUsage struct {
+	Sessions    int
+	MaxSessions uint32
+	Tables      []tableUsage
+	Rejected    uint64
+}
+
+// capacityTracker accounts the sessions, and the entries their rules take in
+// the datapath tables, against the configured capacities, so that the rules
+// which would overflow the datapath are rejected before being written.
+type capacityTracker struct {
+	mu sync.Mutex
+	// maximum number of sessions, unlimited if 0.
+	maxSessions uint32
+	// maximum number of entries by table, unlimited for the tables not set.
+	limits map[string]uint64
+	// entries by table of every session, by local SEID.
+	sessions map[uint64]map[string]uint64
+	used     map[string]uint64
+	// number of reservations rejected for lack of capacity.
+	rejected uint64
+}
+
+func newCapacityTracker(maxSessions uint32, limits map[string]uint64) *capacityTracker {
+	return &capacityTracker{
+		maxSessions: maxSessions,
+		limits:      limits,
+		sessions:    make(map[uint64]map[string]uint64),
+		used:        make(map[string]uint64),
+	}
+}
+
+// tableEntries returns the number of entries the rules take in every table of
+// the datapath. Rules sharing an entry, like the versions of a rule being
+// updated, count once.
+func tableEntries(dp datapath, rules ...PacketForwardingRules) map[string]uint64 {
+	keys := make(map[string]map[string]struct{})
+
+	for _, r := range rules {
+		for _, e := range dp.ExpectedRules(r) {
+			if keys[e.table] == nil {
+				keys[e.table] = make(map[string]struct{})
+			}
+
+			keys[e.table][e.key] = struct{}{}
+		}
+	}
+
+	entries := make(map[string]uint64, len(keys))
+	for table, k := range keys {
+		entries[table] = uint64(len(k))
+	}
+
+	return entries
+}
+
+// reserve sets the entries taken by the session, unless a new session exceeds
+// the maximum number of sessions, or the session takes more entries of a table
+// than are left.
+func (c *capacityTracker) reserve(fseID uint64, entries map[string]uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev, ok := c.sessions[fseID]
+	if !ok && c.maxSessions != 0 && len(c.sessions) >= int(c.maxSessions) {
+		c.rejected++
+		return fmt.Errorf("%w: %v sessions", ErrCapacityExceeded, c.maxSessions)
+	}
+
+	for table, n := range entries {
+		limit := c.limits[table]
+		if limit == 0 || n <= prev[table] {
+			continue
+		}
+
+		if c.used[table]-prev[table]+n > limit {
+			c.rejected++
+
+			return fmt.Errorf("%w: %v more entries of %v with %v of %v in use",
+				ErrCapacityExceeded, n-prev[table], table, c.used[table], limit)
+		}
+	}
+
+	c.set(fseID, entries)
+
+	return nil
+}
+
+// track sets the entries taken by the session regardless of the capacity.
+func (c *capacityTracker) track(fseID uint64, entries map[string]uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(fseID, entries)
+}
+
+func (c *capacityTracker) set(fseID uint64, entries map[string]uint64) {
+	for table, n := range c.sessions[fseID] {
+		c.used[table] -= n
+	}
+
+	for table, n := range entries {
+		c.used[table] += n
+	}
+
+	c.sessions[fseID] = entries
+}
+
+// release frees the entries taken by the session.
+func (c *capacityTracker) release(fseID uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for table, n := range c.sessions[fseID] {
+		c.used[table] -= n
+	}
+
+	delete(c.sessions, fseID)
+}
+
+func (c *capacityTracker) usage() capacityUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	u := capacityUsage{
+		Sessions:    len(c.sessions),
+		MaxSessions: c.maxSessions,
+		Rejected:    c.rejected,
+	}
+
+	tables := make(map[string]struct{})
+	for table := range c.used {
+		tables[table] = struct{}{}
+	}
+
+	for table := range c.limits {
+		tables[table] = struct{}{}
+	}
+
+	for table := range tables {
+		u.Tables = append(u.Tables, tableUsage{Table: table, Used: c.used[table], Limit: c.limits[table]})
+	}
+
+	sort.Slice(u.Tables, func(i, j int) bool { return u.Tables[i].Table < u.Tables[j].Table })
+
+	return u
+}
+
+// reserveCapacity accounts the entries of all the given rules of the session,
+// failing with ErrCapacityExceeded if they do not fit in the datapath.
+func (u *upf) reserveCapacity(fseID uint64, rules ...PacketForwardingRules) error {
+	if u.capacity == nil {
+		return nil
+	}
+
+	return u.capacity.reserve(fseID, tableEntries(u.datapath, rules...))
+}
+
+// trackCapacity accounts the entries of the rules the session holds in the
+// datapath.
+func (u *upf) trackCapacity(fseID uint64, rules PacketForwardingRules) {
+	if u.capacity == nil {
+		return
+	}
+
+	u.capacity.track(fseID, tableEntries(u.datapath, rules))
+}
+
+func (u *upf) releaseCapacity(fseID uint64) {
+	if u.capacity == nil {
+		return
+	}
+
+	u.capacity.release(fseID)
+}
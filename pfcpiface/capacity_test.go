@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"net"
+	"testing"
+
+	"github.com/wmnsk/go-pfcp/ie"
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+func TestCapacity(t *testing.T) {
+	b, _ := newFakeBess(t)
+
+	upf := &upf{
+		datapath:       b,
+		usage:          newUsageTracker(),
+		fteidGenerator: NewFTEIDGenerator(),
+		capacity:       newCapacityTracker(2, map[string]uint64{pdrLookupIPv4: 2}),
+	}
+	pConn := newTestPFCPConn(&PFCPNode{upf: upf}, 1)
+	pConn.Conn = listenUDP(t)
+	pConn.nodeID.remote = "smf"
+	pConn.nodeID.localIE = ie.NewNodeID("", "", "upf")
+
+	uplinkPDR := func(id uint16, teid uint32, ies ...*ie.IE) *ie.IE {
+		pdi := append([]*ie.IE{
+			ie.NewSourceInterface(ie.SrcInterfaceAccess),
+			ie.NewFTEID(0x01, teid, net.ParseIP("198.18.0.1"), nil, 0),
+		}, ies...)
+
+		return ie.NewCreatePDR(ie.NewPDRID(id), ie.NewPrecedence(100), ie.NewPDI(pdi...), ie.NewFARID(1))
+	}
+
+	establish := func(t *testing.T, cpSEID uint64, pdrs ...*ie.IE) (uint8, uint64) {
+		t.Helper()
+
+		ies := append([]*ie.IE{
+			ie.NewNodeID("", "", "smf"),
+			ie.NewFSEID(cpSEID, net.ParseIP("10.0.0.1"), nil),
+			ie.NewCreateFAR(ie.NewFARID(1), ie.NewApplyAction(ActionDrop)),
+		}, pdrs...)
+
+		resp, _ := pConn.handleSessionEstablishmentRequest(
+			message.NewSessionEstablishmentRequest(0, 0, 0, uint32(cpSEID), 0, ies...))
+
+		seres := resp.(*message.SessionEstablishmentResponse)
+		cause, _ := seres.Cause.Cause()
+
+		if seres.UPFSEID == nil {
+			return cause, 0
+		}
+
+		fseid, _ := seres.UPFSEID.FSEID()
+
+		return cause, fseid.SEID
+	}
+
+	entries := func(t *testing.T, sessions int, pdrs uint64) {
+		t.Helper()
+
+		u := upf.capacity.usage()
+		if u.Sessions != sessions {
+			t.Errorf("expected %v sessions, got %v", sessions, u.Sessions)
+		}
+
+		for _, tu := range u.Tables {
+			if tu.Table == pdrLookupIPv4 && tu.Used != pdrs {
+				t.Errorf("expected %v PDR entries, got %v", pdrs, tu.Used)
+			}
+		}
+	}
+
+	cause, seid := establish(t, 1, uplinkPDR(1, 0x10))
+	if cause != ie.CauseRequestAccepted {
+		t.Fatalf("expected session to be accepted, got cause %d", cause)
+	}
+
+	entries(t, 1, 1)
+
+	t.Run("expanded rules exceed table", func(t *testing.T) {
+		// The port range expands to two entries of the PDR table.
+		portRange := ie.NewSDFFilter("permit out udp from 192.0.2.1 80-81 to assigned", "", "", "", 0)
+
+		if cause, _ := establish(t, 2, uplinkPDR(1, 0x20, portRange)); cause != ie.CauseNoResourcesAvailable {
+			t.Errorf("expected cause %d, got %d", ie.CauseNoResourcesAvailable, cause)
+		}
+
+		if n := len(pConn.store.GetAllSessions()); n != 1 {
+			t.Errorf("expected 1 stored session, got %d", n)
+		}
+
+		entries(t, 1, 1)
+	})
+
+	t.Run("modification within capacity", func(t *testing.T) {
+		req := message.NewSessionModificationRequest(0, 0, seid, 3, 0, uplinkPDR(2, 0x11))
+
+		if _, err := pConn.handleSessionModificationRequest(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		entries(t, 1, 2)
+	})
+
+	t.Run("modification exceeding capacity", func(t *testing.T) {
+		req := message.NewSessionModificationRequest(0, 0, seid, 4, 0, uplinkPDR(3, 0x12))
+
+		resp, err := pConn.handleSessionModificationRequest(req)
+		if err == nil {
+			t.Fatal("expected modification to fail")
+		}
+
+		if cause, _ := resp.(*message.SessionModificationResponse).Cause.Cause(); cause != ie.CauseNoResourcesAvailable {
+			t.Errorf("expected cause %d, got %d", ie.CauseNoResourcesAvailable, cause)
+		}
+
+		entries(t, 1, 2)
+	})
+
+	t.Run("maximum sessions", func(t *testing.T) {
+		if cause, _ := establish(t, 5); cause != ie.CauseRequestAccepted {
+			t.Fatalf("expected session to be accepted, got cause %d", cause)
+		}
+
+		if cause, _ := establish(t, 6); cause != ie.CauseNoResourcesAvailable {
+			t.Errorf("expected cause %d, got %d", ie.CauseNoResourcesAvailable, cause)
+		}
+
+		entries(t, 2, 2)
+	})
+
+	t.Run("deletion", func(t *testing.T) {
+		if _, err := pConn.handleSessionDeletionRequest(message.NewSessionDeletionRequest(0, 0, seid, 7, 0)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		entries(t, 1, 0)
+
+		if u := upf.capacity.usage(); u.Rejected != 3 {
+			t.Errorf("expected 3 rejections, got %v", u.Rejected)
+		}
+	})
+}
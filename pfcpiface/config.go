@@ -8,6 +8,8 @@ import (
 	"net"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"time"
 
 	"go.uber.org/zap"
@@ -16,35 +18,69 @@ import (
 
 const (
 	// Default values
-	maxReqRetriesDefault = 5
-	respTimeoutDefault   = 2 * time.Second
-	hbIntervalDefault    = 5 * time.Second
-	readTimeoutDefault   = 15 * time.Second
+	maxReqRetriesDefault   = 5
+	respTimeoutDefault     = 2 * time.Second
+	hbIntervalDefault      = 5 * time.Second
+	drainTimeoutDefault    = 30 * time.Second
+	readTimeoutDefault     = 15 * time.Second
+	usagePollDefault       = 1 * time.Second
+	gtpuPathFailDefault    = 30 * time.Second
+	restoreGraceDefault    = 60 * time.Second
+	p4rtcPortDefault       = "9559"
+	goDatapathTunDefault   = "upf0"
+	bessBatchWindowDefault = 2 * time.Millisecond
+
+	// Policies applied to the sessions of a PFCP peer that restarted.
+	peerRestartPolicyPurge = "purge"
+	peerRestartPolicyKeep  = "keep"
 )
 
 // Conf : Json conf struct.
 type Conf struct {
-	Mode                     string           `json:"mode"`
-	AccessIface              IfaceType        `json:"access"`
-	CoreIface                IfaceType        `json:"core"`
-	CPIface                  CPIfaceInfo      `json:"cpiface"`
-	EnableGtpuPathMonitoring bool             `json:"enable_gtpu_path_monitoring"`
-	EnableFlowMeasure        bool             `json:"measure_flow"`
-	SimInfo                  SimModeInfo      `json:"sim"`
-	ConnTimeout              uint32           `json:"conn_timeout"` // TODO(max): unused, remove
-	ReadTimeout              uint32           `json:"read_timeout"` // TODO(max): convert to duration string
-	EnableNotifyBess         bool             `json:"enable_notify_bess"`
-	EnableEndMarker          bool             `json:"enable_end_marker"`
-	NotifySockAddr           string           `json:"notify_sockaddr"`
-	EndMarkerSockAddr        string           `json:"endmarker_sockaddr"`
-	LogLevel                 zapcore.Level    `json:"log_level"`
-	QciQosConfig             []QciQosConfig   `json:"qci_qos_config"`
-	SliceMeterConfig         SliceMeterConfig `json:"slice_rate_limit_config"`
-	MaxReqRetries            uint8            `json:"max_req_retries"`
-	RespTimeout              string           `json:"resp_timeout"`
-	EnableHBTimer            bool             `json:"enable_hbTimer"`
-	HeartBeatInterval        string           `json:"heart_beat_interval"`
-	N4Addr                   string           `json:"n4_addr"`
+	Mode                       string            `json:"mode"`
+	AccessIface                IfaceType         `json:"access"`
+	CoreIface                  IfaceType         `json:"core"`
+	CPIface                    CPIfaceInfo       `json:"cpiface"`
+	EnableGtpuPathMonitoring   bool              `json:"enable_gtpu_path_monitoring"`
+	GtpuPathFailureTimeout     string            `json:"gtpu_path_failure_timeout"`
+	EnableFlowMeasure          bool              `json:"measure_flow"`
+	SimInfo                    SimModeInfo       `json:"sim"`
+	ConnTimeout                uint32            `json:"conn_timeout"` // TODO(max): unused, remove
+	ReadTimeout                uint32            `json:"read_timeout"` // TODO(max): convert to duration string
+	EnableNotifyBess           bool              `json:"enable_notify_bess"`
+	EnableEndMarker            bool              `json:"enable_end_marker"`
+	NotifySockAddr             string            `json:"notify_sockaddr"`
+	EndMarkerSockAddr          string            `json:"endmarker_sockaddr"`
+	EnableErrorIndication      bool              `json:"enable_error_indication"`
+	EnableIPv6                 bool              `json:"enable_ipv6"`
+	ErrorIndicationSockAddr    string            `json:"error_indication_sockaddr"`
+	LogLevel                   zapcore.Level     `json:"log_level"`
+	QciQosConfig               []QciQosConfig    `json:"qci_qos_config"`
+	SliceMeterConfig           SliceMeterConfig  `json:"slice_rate_limit_config"`
+	MaxReqRetries              uint8             `json:"max_req_retries"`
+	RespTimeout                string            `json:"resp_timeout"`
+	EnableHBTimer              bool              `json:"enable_hbTimer"`
+	HeartBeatInterval          string            `json:"heart_beat_interval"`
+	N4Addr                     string            `json:"n4_addr"`
+	UsagePollInterval          string            `json:"usage_poll_interval"`
+	PeerRestartPolicy          string            `json:"peer_restart_policy"`
+	PeerRestartGracePeriod     string            `json:"peer_restart_grace_period"`
+	AssociationLossGracePeriod string            `json:"association_loss_grace_period"`
+	SessionStorePath           string            `json:"session_store_path"`
+	RestoreSessions            bool              `json:"restore_sessions"`
+	SessionRestoreGracePeriod  string            `json:"session_restore_grace_period"`
+	DatapathAuditInterval      string            `json:"datapath_audit_interval"`
+	DatapathAuditRepair        bool              `json:"datapath_audit_repair"`
+	EnableP4rt                 bool              `json:"enable_p4rt"`
+	P4rtcIface                 P4rtcInfo         `json:"p4rtciface"`
+	EnableGoDatapath           bool              `json:"enable_go_datapath"`
+	GoDatapath                 GoDatapathInfo    `json:"go_datapath"`
+	BessBatchSize              uint32            `json:"bess_batch_size"`
+	BessBatchWindow            string            `json:"bess_batch_window"`
+	MaxSessions                uint32            `json:"max_sessions"`
+	DatapathCapacity           map[string]uint64 `json:"datapath_capacity"`
+	DrainOnShutdown            bool              `json:"drain_on_shutdown"`
+	DrainTimeout               string            `json:"drain_timeout"`
 }
 
 // QciQosConfig : Qos configured attributes.
@@ -89,6 +125,27 @@ type CPIfaceInfo struct {
 	Dnn             string   `json:"dnn"`
 	EnableUeIPAlloc bool     `json:"enable_ue_ip_alloc"`
 	UEIPPool        string   `json:"ue_ip_pool"`
+	UEIPPoolV6      string   `json:"ue_ip_pool_v6"`
+}
+
+// P4rtcInfo : P4 runtime interface settings.
+type P4rtcInfo struct {
+	SliceID     uint8           `json:"slice_id"`
+	AccessIP    string          `json:"access_ip"`
+	P4rtcServer string          `json:"p4rtc_server"`
+	P4rtcPort   string          `json:"p4rtc_port"`
+	DeviceID    uint64          `json:"device_id"`
+	QFIToTC     map[uint8]uint8 `json:"qfi_tc_mapping"`
+	DefaultTC   uint8           `json:"default_tc"`
+}
+
+// GoDatapathInfo : Go datapath settings.
+type GoDatapathInfo struct {
+	N3Addr  string `json:"n3_addr"`
+	N6Mode  string `json:"n6_mode"`
+	TunName string `json:"tun_name"`
+	N6Addr  string `json:"n6_addr"`
+	N6Peer  string `json:"n6_peer"`
 }
 
 // IfaceType : Gateway interface struct.
@@ -96,6 +153,22 @@ type IfaceType struct {
 	IfName string `json:"ifname"`
 }
 
+// setGoDatapathDefaults terminates GTP-U on the standard port unless N3Addr
+// has one, and exchanges the N6 packets through a TUN device.
+func setGoDatapathDefaults(godp *GoDatapathInfo) {
+	if _, _, err := net.SplitHostPort(godp.N3Addr); err != nil && godp.N3Addr != "" {
+		godp.N3Addr = net.JoinHostPort(godp.N3Addr, strconv.Itoa(tunnelGTPUPort))
+	}
+
+	if godp.N6Mode == "" {
+		godp.N6Mode = godpN6ModeTun
+	}
+
+	if godp.N6Mode == godpN6ModeTun && godp.TunName == "" {
+		godp.TunName = goDatapathTunDefault
+	}
+}
+
 // validateConf checks that the given config reaches a baseline of correctness.
 func validateConf(conf Conf) error {
 	// Mode is only relevant in a BESS deployment.
@@ -105,7 +178,20 @@ func validateConf(conf Conf) error {
 		"dpdk":      {},
 		"sim":       {},
 	}
-	if _, ok := validModes[conf.Mode]; !ok {
+	if conf.EnableP4rt && conf.EnableGoDatapath {
+		return ErrInvalidArgumentWithReason("conf.EnableGoDatapath", conf.EnableGoDatapath,
+			"the P4Runtime and Go datapaths are exclusive")
+	}
+
+	if conf.EnableP4rt {
+		if err := validateP4rtc(conf.P4rtcIface); err != nil {
+			return err
+		}
+	} else if conf.EnableGoDatapath {
+		if err := validateGoDatapath(conf.GoDatapath); err != nil {
+			return err
+		}
+	} else if _, ok := validModes[conf.Mode]; !ok {
 		return ErrInvalidArgumentWithReason("conf.Mode", conf.Mode, "invalid mode")
 	}
 
@@ -115,6 +201,12 @@ func validateConf(conf Conf) error {
 	if err := validateTimeouts(conf); err != nil {
 		return err
 	}
+	if err := validateSessionRecovery(conf); err != nil {
+		return err
+	}
+	if err := validateCapacity(conf); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -125,6 +217,18 @@ func validateUEIPPoolAndPeers(conf Conf) error {
 		if err != nil {
 			return ErrInvalidArgumentWithReason("conf.UEIPPool", conf.CPIface.UEIPPool, err.Error())
 		}
+
+		if conf.CPIface.UEIPPoolV6 != "" {
+			_, ipnet, err := net.ParseCIDR(conf.CPIface.UEIPPoolV6)
+			if err != nil {
+				return ErrInvalidArgumentWithReason("conf.UEIPPoolV6", conf.CPIface.UEIPPoolV6, err.Error())
+			}
+
+			if ones, bits := ipnet.Mask.Size(); bits != net.IPv6len*8 || ones > defaultIPv6PrefixLen {
+				return ErrInvalidArgumentWithReason("conf.UEIPPoolV6", conf.CPIface.UEIPPoolV6,
+					"must be an IPv6 subnet of at most /64")
+			}
+		}
 	}
 
 	for _, peer := range conf.CPIface.Peers {
@@ -136,6 +240,62 @@ func validateUEIPPoolAndPeers(conf Conf) error {
 	return nil
 }
 
+func validateP4rtc(p4rtc P4rtcInfo) error {
+	if p4rtc.P4rtcServer == "" {
+		return ErrInvalidArgumentWithReason("conf.P4rtcIface.P4rtcServer", p4rtc.P4rtcServer, "missing P4Runtime server")
+	}
+
+	if _, _, err := net.ParseCIDR(p4rtc.AccessIP); err != nil {
+		return ErrInvalidArgumentWithReason("conf.P4rtcIface.AccessIP", p4rtc.AccessIP, err.Error())
+	}
+
+	if p4rtc.SliceID > maxP4SliceID {
+		return ErrInvalidArgumentWithReason("conf.P4rtcIface.SliceID", p4rtc.SliceID, "invalid slice ID")
+	}
+
+	if p4rtc.DefaultTC > maxP4TC {
+		return ErrInvalidArgumentWithReason("conf.P4rtcIface.DefaultTC", p4rtc.DefaultTC, "invalid traffic class")
+	}
+
+	for qfi, tc := range p4rtc.QFIToTC {
+		if tc > maxP4TC {
+			return ErrInvalidArgumentWithReason("conf.P4rtcIface.QFIToTC", qfi, "invalid traffic class")
+		}
+	}
+
+	return nil
+}
+
+func validateGoDatapath(godp GoDatapathInfo) error {
+	host, _, err := net.SplitHostPort(godp.N3Addr)
+	if err != nil {
+		return ErrInvalidArgumentWithReason("conf.GoDatapath.N3Addr", godp.N3Addr, err.Error())
+	}
+
+	if ip := net.ParseIP(host); ip == nil || ip.To4() == nil || ip.IsUnspecified() {
+		return ErrInvalidArgumentWithReason("conf.GoDatapath.N3Addr", godp.N3Addr, "must be a unicast IPv4 address")
+	}
+
+	switch godp.N6Mode {
+	case godpN6ModeTun:
+		if godp.TunName == "" {
+			return ErrInvalidArgumentWithReason("conf.GoDatapath.TunName", godp.TunName, "missing TUN device name")
+		}
+	case godpN6ModeUDP:
+		if _, _, err := net.SplitHostPort(godp.N6Addr); err != nil {
+			return ErrInvalidArgumentWithReason("conf.GoDatapath.N6Addr", godp.N6Addr, err.Error())
+		}
+
+		if _, _, err := net.SplitHostPort(godp.N6Peer); err != nil {
+			return ErrInvalidArgumentWithReason("conf.GoDatapath.N6Peer", godp.N6Peer, err.Error())
+		}
+	default:
+		return ErrInvalidArgumentWithReason("conf.GoDatapath.N6Mode", godp.N6Mode, "invalid N6 mode")
+	}
+
+	return nil
+}
+
 func validateTimeouts(conf Conf) error {
 	if _, err := time.ParseDuration(conf.RespTimeout); err != nil {
 		return ErrInvalidArgumentWithReason("conf.RespTimeout", conf.RespTimeout, "invalid duration")
@@ -154,34 +314,88 @@ func validateTimeouts(conf Conf) error {
 			return err
 		}
 	}
+
+	if d, err := time.ParseDuration(conf.UsagePollInterval); err != nil || d <= 0 {
+		return ErrInvalidArgumentWithReason("conf.UsagePollInterval", conf.UsagePollInterval, "invalid duration")
+	}
+
+	if conf.DatapathAuditInterval != "" {
+		if d, err := time.ParseDuration(conf.DatapathAuditInterval); err != nil || d < 0 {
+			return ErrInvalidArgumentWithReason("conf.DatapathAuditInterval", conf.DatapathAuditInterval, "invalid duration")
+		}
+	}
+
+	if conf.EnableGtpuPathMonitoring {
+		if d, err := time.ParseDuration(conf.GtpuPathFailureTimeout); err != nil || d <= 0 {
+			return ErrInvalidArgumentWithReason("conf.GtpuPathFailureTimeout", conf.GtpuPathFailureTimeout, "invalid duration")
+		}
+	}
+
+	if conf.BessBatchSize > 1 {
+		if d, err := time.ParseDuration(conf.BessBatchWindow); err != nil || d <= 0 {
+			return ErrInvalidArgumentWithReason("conf.BessBatchWindow", conf.BessBatchWindow, "invalid duration")
+		}
+	}
+
+	if d, err := time.ParseDuration(conf.DrainTimeout); err != nil || d < 0 {
+		return ErrInvalidArgumentWithReason("conf.DrainTimeout", conf.DrainTimeout, "invalid duration")
+	}
+
 	return nil
 }
 
-// Remove comments from JSONC file
-func removeComments(jsonc string) string {
-	commentRegex := regexp.MustCompile(`(?m)//.*$|/\*.*?\*/`)
-	return commentRegex.ReplaceAllString(jsonc, "")
-}
+func validateSessionRecovery(conf Conf) error {
+	switch conf.PeerRestartPolicy {
+	case peerRestartPolicyPurge, peerRestartPolicyKeep:
+	default:
+		return ErrInvalidArgumentWithReason("conf.PeerRestartPolicy", conf.PeerRestartPolicy, "invalid policy")
+	}
 
-// LoadConfigFile : parse jsonc file and populate corresponding struct
-func LoadConfigFile(filepath string) (Conf, error) {
-	// Open up file.
-	jsoncFile, err := os.ReadFile(filepath)
-	if err != nil {
-		return Conf{}, err
+	if d, err := time.ParseDuration(conf.PeerRestartGracePeriod); err != nil || d < 0 {
+		return ErrInvalidArgumentWithReason("conf.PeerRestartGracePeriod", conf.PeerRestartGracePeriod, "invalid duration")
 	}
 
-	jsonData := removeComments(string(jsoncFile))
+	if d, err := time.ParseDuration(conf.AssociationLossGracePeriod); err != nil || d < 0 {
+		return ErrInvalidArgumentWithReason("conf.AssociationLossGracePeriod", conf.AssociationLossGracePeriod, "invalid duration")
+	}
 
-	var conf Conf
-	conf.LogLevel = zap.InfoLevel
+	if conf.RestoreSessions {
+		if conf.SessionStorePath == "" {
+			return ErrInvalidArgumentWithReason("conf.RestoreSessions", conf.RestoreSessions, "session_store_path is not set")
+		}
 
-	err = json.Unmarshal([]byte(jsonData), &conf)
-	if err != nil {
-		return Conf{}, err
+		if d, err := time.ParseDuration(conf.SessionRestoreGracePeriod); err != nil || d <= 0 {
+			return ErrInvalidArgumentWithReason("conf.SessionRestoreGracePeriod", conf.SessionRestoreGracePeriod, "invalid duration")
+		}
 	}
 
-	// Set defaults, when missing.
+	return nil
+}
+
+// validateCapacity checks that capacities are only set for the datapath
+// tables whose entries are accounted, which only the BESS datapath does.
+func validateCapacity(conf Conf) error {
+	if len(conf.DatapathCapacity) > 0 {
+		switch {
+		case conf.EnableP4rt:
+			return ErrUnsupported("conf.DatapathCapacity", "P4Runtime datapath")
+		case conf.EnableGoDatapath:
+			return ErrUnsupported("conf.DatapathCapacity", "Go datapath")
+		}
+	}
+
+	for table := range conf.DatapathCapacity {
+		if !slices.Contains(capacityTables, table) {
+			return ErrInvalidArgumentWithReason("conf.DatapathCapacity", table, "unknown table")
+		}
+	}
+
+	return nil
+}
+
+// setConfDefaults sets the defaults of the missing values. It is applied both
+// to the config files loaded and to the configs built directly.
+func setConfDefaults(conf *Conf) {
 	if conf.RespTimeout == "" {
 		conf.RespTimeout = respTimeoutDefault.String()
 	}
@@ -194,12 +408,79 @@ func LoadConfigFile(filepath string) (Conf, error) {
 		conf.MaxReqRetries = maxReqRetriesDefault
 	}
 
+	if conf.UsagePollInterval == "" {
+		conf.UsagePollInterval = usagePollDefault.String()
+	}
+
+	if conf.PeerRestartPolicy == "" {
+		conf.PeerRestartPolicy = peerRestartPolicyPurge
+	}
+
+	if conf.PeerRestartGracePeriod == "" {
+		conf.PeerRestartGracePeriod = "0s"
+	}
+
+	if conf.AssociationLossGracePeriod == "" {
+		conf.AssociationLossGracePeriod = "0s"
+	}
+
+	if conf.RestoreSessions && conf.SessionRestoreGracePeriod == "" {
+		conf.SessionRestoreGracePeriod = restoreGraceDefault.String()
+	}
+
+	if conf.EnableGtpuPathMonitoring && conf.GtpuPathFailureTimeout == "" {
+		conf.GtpuPathFailureTimeout = gtpuPathFailDefault.String()
+	}
+
 	if conf.EnableHBTimer {
 		if conf.HeartBeatInterval == "" {
 			conf.HeartBeatInterval = hbIntervalDefault.String()
 		}
 	}
 
+	if conf.EnableP4rt && conf.P4rtcIface.P4rtcPort == "" {
+		conf.P4rtcIface.P4rtcPort = p4rtcPortDefault
+	}
+
+	if conf.EnableGoDatapath {
+		setGoDatapathDefaults(&conf.GoDatapath)
+	}
+
+	if conf.BessBatchSize > 1 && conf.BessBatchWindow == "" {
+		conf.BessBatchWindow = bessBatchWindowDefault.String()
+	}
+
+	if conf.DrainTimeout == "" {
+		conf.DrainTimeout = drainTimeoutDefault.String()
+	}
+}
+
+// Remove comments from JSONC file
+func removeComments(jsonc string) string {
+	commentRegex := regexp.MustCompile(`(?m)//.*$|/\*.*?\*/`)
+	return commentRegex.ReplaceAllString(jsonc, "")
+}
+
+// LoadConfigFile : parse jsonc file and populate corresponding struct
+func LoadConfigFile(filepath string) (Conf, error) {
+	// Open up file.
+	jsoncFile, err := os.ReadFile(filepath)
+	if err != nil {
+		return Conf{}, err
+	}
+
+	jsonData := removeComments(string(jsoncFile))
+
+	var conf Conf
+	conf.LogLevel = zap.InfoLevel
+
+	err = json.Unmarshal([]byte(jsonData), &conf)
+	if err != nil {
+		return Conf{}, err
+	}
+
+	setConfDefaults(&conf)
+
 	// Perform basic validation.
 	err = validateConf(conf)
 	if err != nil {
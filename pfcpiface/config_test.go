@@ -4,6 +4,7 @@
 package pfcpiface
 
 import (
+	"errors"
 	"os"
 	"testing"
 
@@ -83,6 +84,112 @@ func TestLoadConfigFile(t *testing.T) {
 		}
 	})
 
+	t.Run("P4Runtime config does not need a mode", func(t *testing.T) {
+		s := `{
+			"enable_p4rt": true,
+			"p4rtciface": {
+				"access_ip": "198.18.0.1/32",
+				"p4rtc_server": "onos",
+				"qfi_tc_mapping": {"9": 1}
+			}
+		}`
+		confPath := t.TempDir() + "/conf.jsonc"
+		mustWriteStringToDisk(s, confPath)
+
+		conf, err := LoadConfigFile(confPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if conf.P4rtcIface.P4rtcPort != p4rtcPortDefault || conf.P4rtcIface.QFIToTC[9] != 1 {
+			t.Fatalf("unexpected P4Runtime config %+v", conf.P4rtcIface)
+		}
+	})
+
+	t.Run("P4Runtime config needs the access IP", func(t *testing.T) {
+		s := `{
+			"enable_p4rt": true,
+			"p4rtciface": {
+				"p4rtc_server": "onos"
+			}
+		}`
+		confPath := t.TempDir() + "/conf.jsonc"
+		mustWriteStringToDisk(s, confPath)
+
+		if _, err := LoadConfigFile(confPath); err == nil {
+			t.Fatal("expected error for missing access IP")
+		}
+	})
+
+	t.Run("Go datapath config defaults to GTP-U port and TUN device", func(t *testing.T) {
+		s := `{
+			"enable_go_datapath": true,
+			"go_datapath": {
+				"n3_addr": "198.18.0.1"
+			}
+		}`
+		confPath := t.TempDir() + "/conf.jsonc"
+		mustWriteStringToDisk(s, confPath)
+
+		conf, err := LoadConfigFile(confPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if conf.GoDatapath.N3Addr != "198.18.0.1:2152" || conf.GoDatapath.TunName != goDatapathTunDefault {
+			t.Fatalf("unexpected Go datapath config %+v", conf.GoDatapath)
+		}
+	})
+
+	t.Run("Go datapath UDP N6 needs a peer", func(t *testing.T) {
+		s := `{
+			"enable_go_datapath": true,
+			"go_datapath": {
+				"n3_addr": "198.18.0.1",
+				"n6_mode": "udp",
+				"n6_addr": "127.0.0.1:9000"
+			}
+		}`
+		confPath := t.TempDir() + "/conf.jsonc"
+		mustWriteStringToDisk(s, confPath)
+
+		if _, err := LoadConfigFile(confPath); err == nil {
+			t.Fatal("expected error for missing N6 peer")
+		}
+	})
+
+	t.Run("datapath capacity of unknown table", func(t *testing.T) {
+		s := `{
+			"mode": "dpdk",
+			"max_sessions": 1000,
+			"datapath_capacity": {
+				"flowMeasure": 4000
+			}
+		}`
+		confPath := t.TempDir() + "/conf.jsonc"
+		mustWriteStringToDisk(s, confPath)
+
+		if _, err := LoadConfigFile(confPath); err == nil {
+			t.Fatal("expected error for unknown table")
+		}
+	})
+
+	t.Run("datapath capacity of the Go datapath", func(t *testing.T) {
+		s := `{
+			"enable_go_datapath": true,
+			"go_datapath": {
+				"n3_addr": "198.18.0.1"
+			},
+			"datapath_capacity": {
+				"pdrLookup": 4000
+			}
+		}`
+		confPath := t.TempDir() + "/conf.jsonc"
+		mustWriteStringToDisk(s, confPath)
+
+		if _, err := LoadConfigFile(confPath); !errors.Is(err, errUnsupported) {
+			t.Fatalf("expected capacity to be unsupported, got %v", err)
+		}
+	})
+
 	t.Run("all sample configs must be valid", func(t *testing.T) {
 		paths := []string{
 			"../conf/upf.jsonc",
@@ -97,3 +204,22 @@ func TestLoadConfigFile(t *testing.T) {
 		}
 	})
 }
+
+func TestSetConfDefaults(t *testing.T) {
+	// Configs built without a config file, as the integration tests do, must
+	// get the same defaults.
+	var conf Conf
+
+	setConfDefaults(&conf)
+
+	u := &upf{}
+	initTimersAndIPPool(u, &conf)
+
+	if u.usagePollInterval != usagePollDefault {
+		t.Errorf("expected usage poll interval %v, got %v", usagePollDefault, u.usagePollInterval)
+	}
+
+	if u.drainTimeout != drainTimeoutDefault {
+		t.Errorf("expected drain timeout %v, got %v", drainTimeoutDefault, u.drainTimeout)
+	}
+}
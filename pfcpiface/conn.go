@@ -39,7 +39,27 @@ type recoveryTS struct {
 type nodeID struct {
 	localIE *ie.IE
 	local   string
-	remote  string
+
+	// remote is set on association setup, and read by the API handlers and
+	// the node, hence guarded by mu.
+	mu     sync.RWMutex
+	remote string
+}
+
+// remoteNodeID returns the node ID of the peer, empty until the association
+// is set up.
+func (pConn *PFCPConn) remoteNodeID() string {
+	pConn.nodeID.mu.RLock()
+	defer pConn.nodeID.mu.RUnlock()
+
+	return pConn.nodeID.remote
+}
+
+func (pConn *PFCPConn) setRemoteNodeID(id string) {
+	pConn.nodeID.mu.Lock()
+	defer pConn.nodeID.mu.Unlock()
+
+	pConn.nodeID.remote = id
 }
 
 // PFCPConn represents a PFCP connection with a unique PFCP peer.
@@ -64,8 +84,16 @@ type PFCPConn struct {
 
 	hbReset     chan struct{}
 	hbCtxCancel context.CancelFunc
+	// unix time, in nanoseconds, of the last heartbeat received from the peer.
+	lastHeartbeat atomic.Int64
 
 	pendingReqs sync.Map
+	// responses sent to the peer, replayed for retransmitted requests.
+	responses responseCache
+
+	buffering downlinkBuffering
+
+	restart peerRestart
 
 	shutdownOnce sync.Once
 	isShutdown   atomic.Bool
@@ -86,9 +114,11 @@ func (pConn *PFCPConn) startHeartBeatMonitor() {
 	hbCtx, hbCancel := context.WithCancel(pConn.ctx)
 	pConn.hbCtxCancel = hbCancel
 
-	logger.PfcpLog.With("interval", pConn.upf.hbInterval).Infoln("starting Heartbeat timer")
+	interval := pConn.upf.heartbeatInterval()
+
+	logger.PfcpLog.With("interval", interval).Infoln("starting Heartbeat timer")
 
-	heartBeatExpiryTimer := time.NewTicker(pConn.upf.hbInterval)
+	heartBeatExpiryTimer := time.NewTicker(interval)
 
 	for {
 		select {
@@ -102,7 +132,8 @@ func (pConn *PFCPConn) startHeartBeatMonitor() {
 				heartBeatExpiryTimer.Stop()
 				return
 			}
-			heartBeatExpiryTimer.Reset(pConn.upf.hbInterval)
+			interval = pConn.upf.heartbeatInterval()
+			heartBeatExpiryTimer.Reset(interval)
 		case <-heartBeatExpiryTimer.C:
 			// Check if shutdown before sending heartbeat
 			if pConn.IsShutdown() {
@@ -112,11 +143,20 @@ func (pConn *PFCPConn) startHeartBeatMonitor() {
 
 			logger.PfcpLog.Debugln("HeartBeat Interval Timer Expired", pConn.RemoteAddr().String())
 
+			// The interval may have been changed by a config reload.
+			if current := pConn.upf.heartbeatInterval(); current != interval {
+				interval = current
+				heartBeatExpiryTimer.Reset(interval)
+			}
+
 			r := pConn.getHeartBeatRequest()
 
-			if _, timeout := pConn.sendPFCPRequestMessage(r); timeout {
+			reply, timeout := pConn.sendPFCPRequestMessage(r)
+			if timeout {
 				heartBeatExpiryTimer.Stop()
-				pConn.Shutdown()
+				pConn.shutdownOnPeerLoss()
+			} else if reply != nil {
+				pConn.handleHeartbeatResponse(reply)
 			}
 		}
 	}
@@ -154,6 +194,10 @@ func (node *PFCPNode) NewPFCPConn(lAddr, rAddr string, buf []byte) *PFCPConn {
 		hbCtxCancel:    nil,
 	}
 
+	if node.upf.sessionDB != nil {
+		p.store = NewBoltStore(node.upf.sessionDB, func() string { return p.remoteNodeID() })
+	}
+
 	p.setLocalNodeID(node.upf.nodeID)
 
 	if buf != nil {
@@ -260,7 +304,7 @@ func (pConn *PFCPConn) waitForShutdown(connTimeout chan struct{}) {
 	for {
 		select {
 		case <-connTimeout:
-			pConn.Shutdown()
+			pConn.shutdownOnPeerLoss()
 			return
 		case <-pConn.ctx.Done():
 			pConn.Shutdown()
@@ -272,14 +316,23 @@ func (pConn *PFCPConn) waitForShutdown(connTimeout chan struct{}) {
 	}
 }
 
-// Shutdown stops connection backing PFCPConn.
+// Shutdown stops connection backing PFCPConn and purges its sessions.
 func (pConn *PFCPConn) Shutdown() {
 	pConn.shutdownOnce.Do(func() {
-		pConn.executeShutdown()
+		pConn.executeShutdown(false)
+	})
+}
+
+// shutdownOnPeerLoss stops connection backing PFCPConn once the peer stopped
+// responding. Its sessions are kept for the association loss grace period, so
+// that the peer can take them over with a new association.
+func (pConn *PFCPConn) shutdownOnPeerLoss() {
+	pConn.shutdownOnce.Do(func() {
+		pConn.executeShutdown(true)
 	})
 }
 
-func (pConn *PFCPConn) executeShutdown() {
+func (pConn *PFCPConn) executeShutdown(peerLost bool) {
 	// Mark as shutdown atomically
 	pConn.isShutdown.Store(true)
 
@@ -291,9 +344,13 @@ func (pConn *PFCPConn) executeShutdown() {
 	}
 
 	// Cleanup all sessions in this conn
-	for _, sess := range pConn.store.GetAllSessions() {
-		pConn.upf.SendMsgToUPF(upfMsgTypeDel, sess.PacketForwardingRules, PacketForwardingRules{})
-		pConn.RemoveSession(sess)
+	switch grace := pConn.upf.assocLossGrace; {
+	case pConn.keepSessionsForRestore():
+		logger.PfcpLog.Infoln("keeping sessions of", pConn.remoteNodeID(), "to restore them on restart")
+	case peerLost && grace > 0 && pConn.node != nil:
+		pConn.node.orphanSessions(pConn, grace)
+	default:
+		pConn.purgeAllSessions()
 	}
 
 	rAddr := pConn.RemoteAddr().String()
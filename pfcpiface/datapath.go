@@ -4,6 +4,7 @@
 package pfcpiface
 
 import (
+	"context"
 	"net"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -33,6 +34,18 @@ func (u upfMsgType) String() string {
 	}
 }
 
+// pdrCounterKey identifies the datapath counters of a single PDR.
+type pdrCounterKey struct {
+	fseID uint64
+	pdrID uint32
+}
+
+// pdrCounters holds the cumulative number of packets and bytes forwarded by a PDR.
+type pdrCounters struct {
+	packets uint64
+	bytes   uint64
+}
+
 type datapath interface {
 	/* Close any pending sessions */
 	Exit()
@@ -40,17 +53,39 @@ type datapath interface {
 	SetUpfInfo(u *upf, conf *Conf)
 	/* set up slice info */
 	AddSliceInfo(sliceInfo *SliceInfo) error
+	/* apply the QoS configs changed by a config reload */
+	SetQciQosConfig(conf *Conf) error
+	SetSliceMeterConfig(meterConfig SliceMeterConfig) error
 	/* write endMarker to datapath */
 	SendEndMarkers(endMarkerList *[][]byte) error
-	/* write pdr/far/qer to datapath */
-	// "master" function to send create/update/delete messages to UPF.
-	// "newRules" PacketForwardingRules are only used for update messages to UPF.
-	// TODO: we should have better CRUD API, with a single function per message type.
-	SendMsgToUPF(method upfMsgType, all PacketForwardingRules, newRules PacketForwardingRules) uint8
+	/* create, update and delete single PDRs, FARs and QERs in the datapath */
+	// Updates replace the rule with the same ID, an updated PDR replaces its
+	// previous version old, the zero pdr if it is new. Deleting a rule that is
+	// not installed is not an error. URRs and BARs are enforced by the PFCP
+	// agent and are not written to the datapath.
+	CreatePDR(ctx context.Context, p pdr) error
+	UpdatePDR(ctx context.Context, old, p pdr) error
+	DeletePDR(ctx context.Context, p pdr) error
+	CreateFAR(ctx context.Context, f far) error
+	UpdateFAR(ctx context.Context, f far) error
+	DeleteFAR(ctx context.Context, f far) error
+	CreateQER(ctx context.Context, q qer) error
+	UpdateQER(ctx context.Context, q qer) error
+	DeleteQER(ctx context.Context, q qer) error
 	/* check of communication channel to datapath is setup */
 	IsConnected(accessIP *net.IP) bool
 	SummaryLatencyJitter(uc *upfCollector, ch chan<- prometheus.Metric)
 	PortStats(uc *upfCollector, ch chan<- prometheus.Metric)
 	SummaryGtpuLatency(uc *upfCollector, ch chan<- prometheus.Metric)
 	SessionStats(pc *PfcpNodeCollector, ch chan<- prometheus.Metric) error
+	/* read cumulative per-PDR counters used for usage reporting */
+	UsageCounters() (map[pdrCounterKey]pdrCounters, error)
+	/* read cumulative echo responses per remote GTP-U peer IPv4 address */
+	GtpuPathCounters() (map[uint32]uint64, error)
+	/* read back the rules installed in the datapath tables */
+	ReadRules() []datapathTable
+	/* rules the datapath holds for the given forwarding rules */
+	ExpectedRules(rules PacketForwardingRules) []datapathRule
+	/* remove a single rule read back from the datapath */
+	DeleteRule(r datapathRule) error
 }
@@ -0,0 +1,289 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+)
+
+// datapathRule is a rule of a table of the datapath.
+type datapathRule struct {
+	table string
+	// key identifies the rule in its table.
+	key string
+	// value is the action of the rule.
+	value string
+	// fseID is the local SEID of the session owning the rule.
+	fseID uint64
+	// arg is the datapath specific representation of the rule.
+	arg any
+}
+
+// datapathTable holds the rules read back from a table of the datapath.
+type datapathTable struct {
+	name  string
+	rules []datapathRule
+	// err is set if the rules of the table cannot be read.
+	err error
+}
+
+// tableAudit is the result of the audit of a table of the datapath.
+type tableAudit struct {
+	Table      string `json:"table"`
+	Expected   int    `json:"expected"`
+	Installed  int    `json:"installed"`
+	Missing    int    `json:"missing"`
+	Orphaned   int    `json:"orphaned"`
+	Mismatched int    `json:"mismatched"`
+	Repaired   int    `json:"repaired"`
+	Error      string `json:"error,omitempty"`
+}
+
+// auditReport is the result of an audit of the datapath.
+type auditReport struct {
+	Time     time.Time    `json:"time"`
+	Duration string       `json:"duration"`
+	Repair   bool         `json:"repair"`
+	Sessions int          `json:"sessions"`
+	Tables   []tableAudit `json:"tables"`
+}
+
+// datapathAudit holds the result of the last audit of the datapath.
+type datapathAudit struct {
+	// running serializes audits.
+	running sync.Mutex
+
+	mu       sync.Mutex
+	audits   uint64
+	repaired uint64
+	last     *auditReport
+}
+
+func (a *datapathAudit) record(r auditReport) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.audits++
+
+	for _, t := range r.Tables {
+		a.repaired += uint64(t.Repaired)
+	}
+
+	a.last = &r
+}
+
+// lastReport returns the result of the last audit, if any, and the cumulative
+// number of audits and repaired rules.
+func (a *datapathAudit) lastReport() (*auditReport, uint64, uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.last, a.audits, a.repaired
+}
+
+// liveSession returns the current rules of the session owning fseID, if any.
+func (node *PFCPNode) liveSession(fseID uint64) (PFCPSession, bool) {
+	pConn, ok := node.sessionOwner(fseID)
+	if !ok {
+		return PFCPSession{}, false
+	}
+
+	return pConn.store.GetSession(fseID)
+}
+
+// expectedRules returns the rules the datapath should hold for the sessions of
+// the node, including those of lost associations, by table and key.
+func (node *PFCPNode) expectedRules() (map[string]map[string]datapathRule, int) {
+	expected := make(map[string]map[string]datapathRule)
+	sessions := 0
+
+	node.sessions.Range(func(key, _ any) bool {
+		s, ok := node.liveSession(key.(uint64))
+		if !ok {
+			return true
+		}
+
+		sessions++
+
+		for _, r := range node.upf.ExpectedRules(s.PacketForwardingRules) {
+			if expected[r.table] == nil {
+				expected[r.table] = make(map[string]datapathRule)
+			}
+
+			expected[r.table][r.key] = r
+		}
+
+		return true
+	})
+
+	return expected, sessions
+}
+
+// diffTable compares the rules installed in a table with the expected ones.
+// It returns the rules missing or differing from the expected ones, and the
+// rules not owned by any session.
+func diffTable(expected map[string]datapathRule, installed []datapathRule) (missing, mismatched, orphaned []datapathRule) {
+	seen := make(map[string]bool, len(installed))
+
+	for _, r := range installed {
+		seen[r.key] = true
+
+		e, ok := expected[r.key]
+
+		switch {
+		case !ok:
+			orphaned = append(orphaned, r)
+		case e.value != r.value:
+			mismatched = append(mismatched, e)
+		}
+	}
+
+	for k, e := range expected {
+		if !seen[k] {
+			missing = append(missing, e)
+		}
+	}
+
+	return missing, mismatched, orphaned
+}
+
+// auditDatapath reads back the rules installed in the datapath and compares
+// them with the rules of the sessions. Drifts are reported and, if repair is
+// set, corrected: the rules of the sessions with missing or differing rules
+// are re-installed and the rules not owned by any session are removed.
+//
+// A repairing audit holds the session lock from the read of the tables to the
+// end of the repair, so that the rules of the sessions being established,
+// modified or deleted are neither removed as orphans nor overwritten. The
+// sessions wait for the audit to complete.
+func (node *PFCPNode) auditDatapath(repair bool) auditReport {
+	node.audit.running.Lock()
+	defer node.audit.running.Unlock()
+
+	if repair {
+		node.upf.sessionMu.Lock()
+		defer node.upf.sessionMu.Unlock()
+	}
+
+	start := time.Now()
+
+	// The tables are read before the sessions, so that the rules of the
+	// sessions created in between are not reported as orphaned.
+	tables := node.upf.ReadRules()
+	expected, sessions := node.expectedRules()
+
+	report := auditReport{
+		Time:     start,
+		Repair:   repair,
+		Sessions: sessions,
+	}
+
+	for _, t := range tables {
+		res := tableAudit{
+			Table:     t.name,
+			Expected:  len(expected[t.name]),
+			Installed: len(t.rules),
+		}
+
+		if t.err != nil {
+			res.Error = t.err.Error()
+			report.Tables = append(report.Tables, res)
+
+			continue
+		}
+
+		missing, mismatched, orphaned := diffTable(expected[t.name], t.rules)
+		res.Missing, res.Mismatched, res.Orphaned = len(missing), len(mismatched), len(orphaned)
+
+		for _, r := range missing {
+			logger.PfcpLog.Warnf("audit: rule %v of session %v missing in %v", r.key, r.fseID, t.name)
+		}
+
+		for _, r := range mismatched {
+			logger.PfcpLog.Warnf("audit: rule %v of session %v differs in %v", r.key, r.fseID, t.name)
+		}
+
+		for _, r := range orphaned {
+			logger.PfcpLog.Warnf("audit: rule %v of session %v in %v not owned by any session", r.key, r.fseID, t.name)
+		}
+
+		if repair {
+			res.Repaired = node.repairRules(append(missing, mismatched...), orphaned)
+		}
+
+		report.Tables = append(report.Tables, res)
+	}
+
+	report.Duration = time.Since(start).String()
+
+	node.audit.record(report)
+
+	logger.PfcpLog.Infof("datapath audit of %d sessions done in %v", sessions, report.Duration)
+
+	return report
+}
+
+// repairRules re-installs the rules of the sessions owning the drifted rules,
+// and removes the orphaned rules that are still not owned by any session. It
+// returns the number of repaired rules.
+func (node *PFCPNode) repairRules(drifted, orphaned []datapathRule) int {
+	repaired := 0
+
+	bySession := make(map[uint64]int)
+	for _, r := range drifted {
+		bySession[r.fseID]++
+	}
+
+	fseIDs := make([]uint64, 0, len(bySession))
+	for fseID := range bySession {
+		fseIDs = append(fseIDs, fseID)
+	}
+
+	sort.Slice(fseIDs, func(i, j int) bool { return fseIDs[i] < fseIDs[j] })
+
+	for _, fseID := range fseIDs {
+		s, ok := node.liveSession(fseID)
+		if !ok {
+			continue
+		}
+
+		if err := node.upf.installRules(s.PacketForwardingRules); err != nil {
+			logger.PfcpLog.Errorf("audit: failed to re-install the rules of session %v: %v", fseID, err)
+			continue
+		}
+
+		repaired += bySession[fseID]
+	}
+
+	for _, r := range orphaned {
+		// The session may have been created or modified since the table was
+		// read.
+		if s, ok := node.liveSession(r.fseID); ok && ruleExpected(node.upf.ExpectedRules(s.PacketForwardingRules), r) {
+			continue
+		}
+
+		if err := node.upf.DeleteRule(r); err != nil {
+			logger.PfcpLog.Errorf("audit: failed to remove rule %v from %v: %v", r.key, r.table, err)
+			continue
+		}
+
+		repaired++
+	}
+
+	return repaired
+}
+
+func ruleExpected(rules []datapathRule, r datapathRule) bool {
+	for _, e := range rules {
+		if e.table == r.table && e.key == r.key {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"github.com/wmnsk/go-pfcp/ie"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errDatapathTimeout = errors.New("datapath write timed out")
+
+// ruleError is the failure of the datapath to apply a PFCP rule.
+type ruleError struct {
+	// ruleType is one of the ie.RuleIDType values.
+	ruleType uint8
+	ruleID   uint32
+	err      error
+}
+
+func (e *ruleError) Error() string {
+	names := [...]string{"PDR", "FAR", "QER", "URR", "BAR"}
+
+	name := UnknownString
+	if int(e.ruleType) < len(names) {
+		name = names[e.ruleType]
+	}
+
+	return fmt.Sprintf("%s %d: %v", name, e.ruleID, e.err)
+}
+
+func (e *ruleError) Unwrap() error {
+	return e.err
+}
+
+// newRuleError returns the failure of the rule, or nil if err is nil.
+func newRuleError(ruleType uint8, ruleID uint32, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &ruleError{ruleType: ruleType, ruleID: ruleID, err: err}
+}
+
+// moduleError is an error returned by a datapath module, with an errno code.
+type moduleError struct {
+	module string
+	code   int32
+	msg    string
+}
+
+func (e *moduleError) Error() string {
+	return fmt.Sprintf("%s: %s (errno %d)", e.module, e.msg, e.code)
+}
+
+// isRuleNotFound returns true if the error reports that the rule to delete is
+// not installed.
+func isRuleNotFound(err error) bool {
+	var me *moduleError
+	if errors.As(err, &me) {
+		return syscall.Errno(me.code) == syscall.ENOENT
+	}
+
+	return status.Code(err) == codes.NotFound
+}
+
+// datapathCause maps a datapath write failure to a PFCP cause.
+func datapathCause(err error) uint8 {
+	if errors.Is(err, errUnsupported) {
+		return ie.CauseRuleCreationModificationFailure
+	}
+
+	var me *moduleError
+	if errors.As(err, &me) {
+		switch syscall.Errno(me.code) {
+		case syscall.ENOMEM, syscall.ENOSPC, syscall.ENOBUFS:
+			return ie.CauseNoResourcesAvailable
+		case syscall.EINVAL, syscall.EEXIST, syscall.ENOENT:
+			return ie.CauseRuleCreationModificationFailure
+		default:
+			return ie.CauseSystemFailure
+		}
+	}
+
+	switch status.Code(err) {
+	case codes.ResourceExhausted:
+		return ie.CauseNoResourcesAvailable
+	case codes.InvalidArgument, codes.AlreadyExists, codes.NotFound, codes.FailedPrecondition:
+		return ie.CauseRuleCreationModificationFailure
+	default:
+		return ie.CauseSystemFailure
+	}
+}
+
+// errWriteToDatapath wraps the failure of the datapath to apply the rules of a
+// PFCP request.
+func errWriteToDatapath(err error) error {
+	return fmt.Errorf("%w: %w", ErrWriteToDatapath, err)
+}
+
+// datapathFailure returns the cause of the response to a PFCP request whose
+// rules the datapath failed to apply, and the Failed Rule ID IE if the failed
+// rule is known.
+func datapathFailure(err error) (uint8, []*ie.IE) {
+	var re *ruleError
+	if errors.As(err, &re) {
+		return datapathCause(re.err), []*ie.IE{ie.NewFailedRuleID(re.ruleType, re.ruleID)}
+	}
+
+	return datapathCause(err), nil
+}
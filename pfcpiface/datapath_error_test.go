@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/wmnsk/go-pfcp/ie"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDatapathFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		cause    uint8
+		ruleType uint8
+		ruleID   uint32
+		hasRule  bool
+	}{
+		{
+			name:     "table full",
+			err:      newRuleError(ie.RuleIDTypePDR, 3, &moduleError{module: "pdrLookup", code: int32(syscall.ENOSPC)}),
+			cause:    ie.CauseNoResourcesAvailable,
+			ruleType: ie.RuleIDTypePDR,
+			ruleID:   3,
+			hasRule:  true,
+		},
+		{
+			name:     "invalid rule",
+			err:      newRuleError(ie.RuleIDTypeFAR, 2, &moduleError{module: "farLookup", code: int32(syscall.EINVAL)}),
+			cause:    ie.CauseRuleCreationModificationFailure,
+			ruleType: ie.RuleIDTypeFAR,
+			ruleID:   2,
+			hasRule:  true,
+		},
+		{
+			name:     "unsupported rule",
+			err:      newRuleError(ie.RuleIDTypePDR, 5, ErrUnsupported("IPv6 PDR without enable_ipv6", 5)),
+			cause:    ie.CauseRuleCreationModificationFailure,
+			ruleType: ie.RuleIDTypePDR,
+			ruleID:   5,
+			hasRule:  true,
+		},
+		{
+			name:     "unreachable datapath",
+			err:      newRuleError(ie.RuleIDTypeQER, 1, status.Error(codes.Unavailable, "connection refused")),
+			cause:    ie.CauseSystemFailure,
+			ruleType: ie.RuleIDTypeQER,
+			ruleID:   1,
+			hasRule:  true,
+		},
+		{
+			name:  "timeout",
+			err:   errors.Join(errDatapathTimeout),
+			cause: ie.CauseSystemFailure,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cause, ies := datapathFailure(errWriteToDatapath(tc.err))
+			if cause != tc.cause {
+				t.Errorf("expected cause %d, got %d", tc.cause, cause)
+			}
+
+			if !tc.hasRule {
+				if len(ies) != 0 {
+					t.Errorf("expected no Failed Rule ID, got %v", ies)
+				}
+
+				return
+			}
+
+			if len(ies) != 1 {
+				t.Fatalf("expected a Failed Rule ID, got %v", ies)
+			}
+
+			ruleType, err := ies[0].RuleIDType()
+			if err != nil || ruleType != tc.ruleType {
+				t.Errorf("expected rule type %d, got %d (err: %v)", tc.ruleType, ruleType, err)
+			}
+
+			ruleID, err := ies[0].FailedRuleID()
+			if err != nil || ruleID != tc.ruleID {
+				t.Errorf("expected rule ID %d, got %d (err: %v)", tc.ruleID, ruleID, err)
+			}
+		})
+	}
+}
+
+func TestBessRuleErrors(t *testing.T) {
+	b, _ := newFakeBess(t)
+
+	rules := PacketForwardingRules{
+		pdrs: []pdr{{pdrID: 7, fseID: 1, srcIface: access, srcIfaceMask: 0xFF, farID: 1}},
+		fars: []far{{farID: 1, fseID: 1, applyAction: ActionForward, dstIntf: 1}},
+	}
+
+	t.Run("delete missing rules", func(t *testing.T) {
+		if err := writeRules(b, upfMsgTypeDel, PacketForwardingRules{}, rules); err != nil {
+			t.Errorf("expected deletion of missing rules to succeed, got %v", err)
+		}
+	})
+
+	t.Run("IPv6 not enabled", func(t *testing.T) {
+		p := rules.pdrs[0]
+		p.appFilter.srcIP6 = net.ParseIP("2001:db8::1")
+		p.appFilter.srcIP6Mask = net.CIDRMask(128, 128)
+
+		f := rules.fars[0]
+		f.tunnelIP6Dst = net.ParseIP("2001:db8::2")
+
+		for _, r := range []PacketForwardingRules{{pdrs: []pdr{p}}, {fars: []far{f}}} {
+			for _, method := range []upfMsgType{upfMsgTypeAdd, upfMsgTypeMod} {
+				err := writeRules(b, method, PacketForwardingRules{}, r)
+				if !errors.Is(err, errUnsupported) {
+					t.Fatalf("expected unsupported rule, got %v", err)
+				}
+
+				if cause, ies := datapathFailure(err); cause != ie.CauseRuleCreationModificationFailure || len(ies) != 1 {
+					t.Errorf("expected Rule Creation/Modification Failure with Failed Rule ID, got cause %d and %v", cause, ies)
+				}
+			}
+		}
+	})
+
+	t.Run("datapath failure", func(t *testing.T) {
+		b.conn.Close()
+
+		err := writeRules(b, upfMsgTypeAdd, PacketForwardingRules{}, rules)
+
+		var re *ruleError
+		if !errors.As(err, &re) {
+			t.Fatalf("expected rule error, got %v", err)
+		}
+
+		if cause, ies := datapathFailure(err); cause != ie.CauseSystemFailure || len(ies) != 1 {
+			t.Errorf("expected System Failure with Failed Rule ID, got cause %d and %v", cause, ies)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"sync"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+)
+
+// datapathResync tracks the progress of the replay of the sessions to the
+// datapath, after the datapath lost its rules.
+type datapathResync struct {
+	// running serializes resyncs.
+	running sync.Mutex
+
+	mu         sync.Mutex
+	inProgress bool
+	resyncs    uint64
+	// sessions of the current or last resync.
+	total    int
+	replayed int
+	failed   int
+	duration time.Duration
+}
+
+type resyncStatus struct {
+	inProgress bool
+	resyncs    uint64
+	total      int
+	replayed   int
+	failed     int
+	duration   time.Duration
+}
+
+func (r *datapathResync) start(total int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inProgress = true
+	r.resyncs++
+	r.total, r.replayed, r.failed = total, 0, 0
+}
+
+func (r *datapathResync) progress(ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ok {
+		r.replayed++
+	} else {
+		r.failed++
+	}
+}
+
+func (r *datapathResync) finish(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inProgress = false
+	r.duration = d
+}
+
+func (r *datapathResync) status() resyncStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return resyncStatus{
+		inProgress: r.inProgress,
+		resyncs:    r.resyncs,
+		total:      r.total,
+		replayed:   r.replayed,
+		failed:     r.failed,
+		duration:   r.duration,
+	}
+}
+
+// resyncDatapath replays the rules of all the sessions of the node, including
+// those of lost associations, to the datapath.
+func (node *PFCPNode) resyncDatapath() {
+	node.resync.running.Lock()
+	defer node.resync.running.Unlock()
+
+	var seids []uint64
+
+	node.sessions.Range(func(key, _ any) bool {
+		seids = append(seids, key.(uint64))
+		return true
+	})
+
+	start := time.Now()
+	node.resync.start(len(seids))
+
+	logger.PfcpLog.Infof("resyncing %d sessions to the datapath", len(seids))
+
+	for _, seid := range seids {
+		node.resync.progress(node.resyncSession(seid))
+	}
+
+	node.resync.finish(time.Since(start))
+
+	st := node.resync.status()
+	logger.PfcpLog.Infof("datapath resync done in %v: %d sessions replayed, %d failed",
+		st.duration, st.replayed, st.failed)
+}
+
+// resyncSession replays the rules of the session, if it still exists. It
+// returns false if the datapath failed to apply them.
+func (node *PFCPNode) resyncSession(seid uint64) bool {
+	upf := node.upf
+
+	// The session is read and replayed while its rules cannot change, so
+	// that a concurrent modification or deletion is not overwritten by the
+	// rules read before it.
+	upf.sessionMu.Lock()
+	defer upf.sessionMu.Unlock()
+
+	pConn, ok := node.sessionOwner(seid)
+	if !ok {
+		return true
+	}
+
+	s, ok := pConn.store.GetSession(seid)
+	if !ok {
+		return true
+	}
+
+	if err := upf.installRules(s.PacketForwardingRules); err != nil {
+		logger.PfcpLog.Errorf("failed to resync session %v to the datapath: %v", seid, err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// replayDP records the sessions added to the datapath.
+type replayDP struct {
+	fakeDP
+	mu    sync.Mutex
+	added map[uint64]int
+}
+
+// CreatePDR counts the sessions replayed, which have a single PDR.
+func (d *replayDP) CreatePDR(ctx context.Context, p pdr) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.added[p.fseID]++
+
+	return nil
+}
+
+func TestResyncDatapath(t *testing.T) {
+	dp := &replayDP{added: make(map[uint64]int)}
+	node := &PFCPNode{upf: &upf{datapath: dp, usage: newUsageTracker()}}
+
+	newSession := func(p *PFCPConn, rseid uint64) PFCPSession {
+		s, ok := p.NewPFCPSession(rseid)
+		if !ok {
+			t.Fatal("failed to allocate session")
+		}
+
+		s.CreatePDR(pdr{pdrID: 1, fseID: s.localSEID})
+
+		if err := p.store.PutSession(s); err != nil {
+			t.Fatalf("failed to store session: %v", err)
+		}
+
+		return s
+	}
+
+	active := newTestPFCPConn(node, 1)
+	s1 := newSession(active, 1)
+	s2 := newSession(active, 2)
+
+	// Sessions of lost associations are still installed in the datapath.
+	lost := newTestPFCPConn(node, 2)
+	lost.nodeID.remote = "smf"
+	s3 := newSession(lost, 3)
+	node.orphanSessions(lost, time.Hour)
+
+	defer node.purgeOrphans()
+
+	node.resyncDatapath()
+
+	for _, s := range []PFCPSession{s1, s2, s3} {
+		if n := dp.added[s.localSEID]; n != 1 {
+			t.Errorf("expected session %v to be replayed once, got %d", s.localSEID, n)
+		}
+	}
+
+	st := node.resync.status()
+	if st.inProgress || st.resyncs != 1 || st.total != 3 || st.replayed != 3 || st.failed != 0 {
+		t.Errorf("unexpected resync status %+v", st)
+	}
+}
+
+func TestResyncDatapathSerializedWithSessionChanges(t *testing.T) {
+	dp := &replayDP{added: make(map[uint64]int)}
+	node := &PFCPNode{upf: &upf{datapath: dp, usage: newUsageTracker()}}
+	p := newTestPFCPConn(node, 1)
+
+	s, ok := p.NewPFCPSession(1)
+	if !ok {
+		t.Fatal("failed to allocate session")
+	}
+
+	s.CreatePDR(pdr{pdrID: 1, fseID: s.localSEID})
+
+	if err := p.store.PutSession(s); err != nil {
+		t.Fatalf("failed to store session: %v", err)
+	}
+
+	// The session is deleted while the resync is waiting for it.
+	node.upf.sessionMu.Lock()
+
+	done := make(chan struct{})
+
+	go func() {
+		node.resyncDatapath()
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	p.RemoveSession(s)
+	node.upf.sessionMu.Unlock()
+
+	<-done
+
+	if n := dp.added[s.localSEID]; n != 0 {
+		t.Errorf("expected deleted session not to be replayed, got %d", n)
+	}
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+// writeRules creates, updates or deletes the PDRs, FARs and QERs of rules in
+// the datapath. Updated PDRs replace their versions in prev. The rules are
+// written concurrently, and the writes must complete within Timeout. A
+// failure to write a rule is returned as a ruleError.
+func writeRules(dp datapath, method upfMsgType, prev, rules PacketForwardingRules) error {
+	calls := len(rules.pdrs) + len(rules.fars) + len(rules.qers)
+	if calls == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	// Buffered, so that the writes complete after a timeout.
+	done := make(chan error, calls)
+
+	write := func(ruleType uint8, ruleID uint32, op func() error) {
+		go func() {
+			done <- newRuleError(ruleType, ruleID, op())
+		}()
+	}
+
+	old := make(map[uint32]pdr, len(prev.pdrs))
+	for _, p := range prev.pdrs {
+		old[p.pdrID] = p
+	}
+
+	for _, p := range rules.pdrs {
+		logger.PfcpLog.Debugln(method, p)
+
+		switch method {
+		case upfMsgTypeAdd:
+			write(ie.RuleIDTypePDR, p.pdrID, func() error { return dp.CreatePDR(ctx, p) })
+		case upfMsgTypeMod:
+			write(ie.RuleIDTypePDR, p.pdrID, func() error { return dp.UpdatePDR(ctx, old[p.pdrID], p) })
+		case upfMsgTypeDel:
+			write(ie.RuleIDTypePDR, p.pdrID, func() error { return dp.DeletePDR(ctx, p) })
+		}
+	}
+
+	for _, f := range rules.fars {
+		logger.PfcpLog.Debugln(method, f)
+
+		switch method {
+		case upfMsgTypeAdd:
+			write(ie.RuleIDTypeFAR, f.farID, func() error { return dp.CreateFAR(ctx, f) })
+		case upfMsgTypeMod:
+			write(ie.RuleIDTypeFAR, f.farID, func() error { return dp.UpdateFAR(ctx, f) })
+		case upfMsgTypeDel:
+			write(ie.RuleIDTypeFAR, f.farID, func() error { return dp.DeleteFAR(ctx, f) })
+		}
+	}
+
+	for _, q := range rules.qers {
+		logger.PfcpLog.Debugln(method, q)
+
+		switch method {
+		case upfMsgTypeAdd:
+			write(ie.RuleIDTypeQER, q.qerID, func() error { return dp.CreateQER(ctx, q) })
+		case upfMsgTypeMod:
+			write(ie.RuleIDTypeQER, q.qerID, func() error { return dp.UpdateQER(ctx, q) })
+		case upfMsgTypeDel:
+			write(ie.RuleIDTypeQER, q.qerID, func() error { return dp.DeleteQER(ctx, q) })
+		}
+	}
+
+	err := joinRuleWrites(calls, Timeout, done)
+	if err != nil {
+		logger.PfcpLog.Errorf("failed to %v rules in datapath: %v", method, err)
+	}
+
+	return err
+}
+
+// joinRuleWrites waits for the results of the rule writes and returns their
+// failures.
+func joinRuleWrites(calls int, timeout time.Duration, done <-chan error) error {
+	boom := time.After(timeout)
+
+	var errs []error
+
+	for ; calls > 0; calls-- {
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, err)
+			}
+		case <-boom:
+			logger.PfcpLog.Infoln("timed out writing rules")
+			return errors.Join(append(errs, errDatapathTimeout)...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// installRules creates the rules in the datapath.
+func (u *upf) installRules(rules PacketForwardingRules) error {
+	return writeRules(u.datapath, upfMsgTypeAdd, PacketForwardingRules{}, rules)
+}
+
+// updateRules replaces the versions in prev of the rules in the datapath with
+// the given versions.
+func (u *upf) updateRules(prev, rules PacketForwardingRules) error {
+	return writeRules(u.datapath, upfMsgTypeMod, prev, rules)
+}
+
+// removeRules deletes the rules from the datapath. Rules that are not
+// installed are not an error.
+func (u *upf) removeRules(rules PacketForwardingRules) error {
+	return writeRules(u.datapath, upfMsgTypeDel, PacketForwardingRules{}, rules)
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+var errQERRejected = errors.New("QER rejected")
+
+// opsDP records the rule operations of the datapath, and rejects the QERs.
+type opsDP struct {
+	fakeDP
+	mu  sync.Mutex
+	ops []string
+}
+
+func (d *opsDP) record(op string, id uint32) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.ops = append(d.ops, fmt.Sprintf("%s %d", op, id))
+}
+
+func (d *opsDP) CreatePDR(ctx context.Context, p pdr) error {
+	d.record("create PDR", p.pdrID)
+	return nil
+}
+
+func (d *opsDP) UpdateFAR(ctx context.Context, f far) error {
+	d.record("update FAR", f.farID)
+	return nil
+}
+
+func (d *opsDP) DeletePDR(ctx context.Context, p pdr) error {
+	d.record("delete PDR", p.pdrID)
+	return nil
+}
+
+func (d *opsDP) DeleteQER(ctx context.Context, q qer) error {
+	d.record("delete QER", q.qerID)
+	return errQERRejected
+}
+
+func TestWriteRules(t *testing.T) {
+	rules := PacketForwardingRules{
+		pdrs: []pdr{{pdrID: 1}, {pdrID: 2}},
+		fars: []far{{farID: 3}},
+		qers: []qer{{qerID: 4}},
+	}
+
+	for _, tc := range []struct {
+		name   string
+		method upfMsgType
+		rules  PacketForwardingRules
+		ops    []string
+		failed bool
+	}{
+		{
+			name:   "create",
+			method: upfMsgTypeAdd,
+			rules:  PacketForwardingRules{pdrs: rules.pdrs},
+			ops:    []string{"create PDR 1", "create PDR 2"},
+		},
+		{
+			name:   "update",
+			method: upfMsgTypeMod,
+			rules:  PacketForwardingRules{fars: rules.fars},
+			ops:    []string{"update FAR 3"},
+		},
+		{
+			name:   "delete",
+			method: upfMsgTypeDel,
+			rules:  rules,
+			ops:    []string{"delete PDR 1", "delete PDR 2", "delete QER 4"},
+			failed: true,
+		},
+		{
+			name:   "no rules",
+			method: upfMsgTypeAdd,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dp := &opsDP{}
+
+			err := writeRules(dp, tc.method, PacketForwardingRules{}, tc.rules)
+
+			slices.Sort(dp.ops)
+			if !slices.Equal(dp.ops, tc.ops) {
+				t.Errorf("expected operations %v, got %v", tc.ops, dp.ops)
+			}
+
+			if !tc.failed {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+
+				return
+			}
+
+			var re *ruleError
+			if !errors.As(err, &re) || re.ruleType != ie.RuleIDTypeQER || re.ruleID != 4 || !errors.Is(err, errQERRejected) {
+				t.Errorf("expected failure of QER 4, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+)
+
+// ErrDraining is returned for the sessions rejected while the node drains.
+var ErrDraining = errors.New("PFCP node is draining")
+
+// drainPollInterval is the period at which the sessions left are checked while
+// draining.
+const drainPollInterval = 100 * time.Millisecond
+
+// nodeDrain is the state of the drain of the node, which stops accepting new
+// sessions and releases its associations.
+type nodeDrain struct {
+	mu       sync.Mutex
+	draining bool
+	started  time.Time
+	deadline time.Time
+	// done is closed once the associations are released.
+	done chan struct{}
+}
+
+// drainState is the state of the drain served by the REST API.
+type drainState struct {
+	Draining     bool       `json:"draining"`
+	Done         bool       `json:"done"`
+	Started      *time.Time `json:"started,omitempty"`
+	Deadline     *time.Time `json:"deadline,omitempty"`
+	Associations int        `json:"associations"`
+	Sessions     int        `json:"sessions"`
+}
+
+// associatedConns returns the connections with an association established.
+func (node *PFCPNode) associatedConns() []*PFCPConn {
+	var conns []*PFCPConn
+
+	node.pConns.Range(func(_, value any) bool {
+		if pConn := value.(*PFCPConn); pConn.remoteNodeID() != "" && !pConn.IsShutdown() {
+			conns = append(conns, pConn)
+		}
+
+		return true
+	})
+
+	return conns
+}
+
+// activeSessions returns the number of sessions of the established
+// associations, excluding the sessions of the lost ones.
+func (node *PFCPNode) activeSessions() int {
+	n := 0
+
+	for pConn, sessions := range node.peerConns() {
+		if !pConn.IsShutdown() {
+			n += sessions
+		}
+	}
+
+	return n
+}
+
+func (node *PFCPNode) draining() bool {
+	node.drain.mu.Lock()
+	defer node.drain.mu.Unlock()
+
+	return node.drain.draining
+}
+
+func (node *PFCPNode) drainState() drainState {
+	s := drainState{
+		Associations: len(node.associatedConns()),
+		Sessions:     node.activeSessions(),
+	}
+
+	node.drain.mu.Lock()
+	defer node.drain.mu.Unlock()
+
+	if !node.drain.draining {
+		return s
+	}
+
+	started, deadline := node.drain.started, node.drain.deadline
+	s.Draining, s.Started, s.Deadline = true, &started, &deadline
+
+	select {
+	case <-node.drain.done:
+		s.Done = true
+	default:
+	}
+
+	return s
+}
+
+// startDrain starts draining the node, unless it is already draining. New
+// sessions are rejected from then on. The returned channel is closed once all
+// the associations are released.
+func (node *PFCPNode) startDrain() (<-chan struct{}, bool) {
+	d := &node.drain
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining {
+		return d.done, false
+	}
+
+	d.draining = true
+	d.started = time.Now()
+	d.deadline = d.started.Add(node.upf.drainTimeout)
+	d.done = make(chan struct{})
+
+	go node.runDrain(d.deadline, d.done)
+
+	return d.done, true
+}
+
+// runDrain asks the peers to delete their sessions and release their
+// associations before the deadline, with a graceful release period. The
+// associations left at the deadline, or once all the sessions are deleted,
+// are released by the node.
+func (node *PFCPNode) runDrain(deadline time.Time, done chan<- struct{}) {
+	defer close(done)
+
+	logger.PfcpLog.Infoln("draining PFCP node until", deadline)
+
+	var wg sync.WaitGroup
+
+	for _, pConn := range node.associatedConns() {
+		wg.Go(func() {
+			if err := pConn.requestAssociationRelease(time.Until(deadline)); err != nil {
+				logger.PfcpLog.Warnln("failed to request association release to", pConn.remoteNodeID(), err)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	for expired := false; !expired && node.activeSessions() > 0; {
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			expired = true
+
+			logger.PfcpLog.Warnln("drain deadline expired with", node.activeSessions(), "sessions left")
+		case <-node.ctx.Done():
+			return
+		}
+	}
+
+	for _, pConn := range node.associatedConns() {
+		wg.Go(func() {
+			if err := pConn.releaseAssociation(); err != nil {
+				logger.PfcpLog.Warnln("failed to release association with", pConn.remoteNodeID(), err)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	logger.PfcpLog.Infoln("PFCP node drained")
+}
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wmnsk/go-pfcp/ie"
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+func TestDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	node := &PFCPNode{
+		ctx:        ctx,
+		cancel:     cancel,
+		PacketConn: listenUDP(t),
+		upf: &upf{
+			datapath:       &fakeDP{},
+			usage:          newUsageTracker(),
+			fteidGenerator: NewFTEIDGenerator(),
+			respTimeout:    time.Second,
+			drainTimeout:   10 * time.Second,
+		},
+	}
+	node.listening.Store(true)
+
+	smf := listenUDP(t)
+
+	conn, err := net.DialUDP("udp", nil, smf.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatalf("failed to connect to SMF: %v", err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	pConn := newTestPFCPConn(node, 1)
+	pConn.Conn = conn
+	pConn.nodeID.remote = "smf"
+	pConn.nodeID.localIE = ie.NewNodeID("", "", "upf")
+	node.pConns.Store(conn.RemoteAddr().String(), pConn)
+
+	session, ok := pConn.NewPFCPSession(1)
+	if !ok {
+		t.Fatal("failed to allocate session")
+	}
+
+	if err := pConn.store.PutSession(session); err != nil {
+		t.Fatalf("failed to store session: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	setupDrainHandler(mux, node)
+
+	serve := func(t *testing.T, method string, status int) drainState {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, "/v1/drain", nil))
+
+		if w.Code != status {
+			t.Fatalf("expected status %v, got %v: %s", status, w.Code, w.Body)
+		}
+
+		var s drainState
+		if status != http.StatusConflict {
+			if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+				t.Fatalf("failed to decode %s: %v", w.Body, err)
+			}
+		}
+
+		return s
+	}
+
+	// receive returns the next request of the UPF to the SMF.
+	receive := func(t *testing.T) message.Message {
+		t.Helper()
+
+		if err := smf.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			t.Fatalf("failed to set deadline: %v", err)
+		}
+
+		buf := make([]byte, 1500)
+
+		n, err := smf.Read(buf)
+		if err != nil {
+			t.Fatalf("expected a request: %v", err)
+		}
+
+		msg, err := message.Parse(buf[:n])
+		if err != nil {
+			t.Fatalf("failed to parse request: %v", err)
+		}
+
+		return msg
+	}
+
+	reply := func(t *testing.T, msg message.Message) {
+		t.Helper()
+
+		b := make([]byte, msg.MarshalLen())
+		if err := msg.MarshalTo(b); err != nil {
+			t.Fatalf("failed to marshal response: %v", err)
+		}
+
+		pConn.HandlePFCPMsg(b)
+	}
+
+	if s := serve(t, http.MethodGet, http.StatusOK); s.Draining || s.Associations != 1 || s.Sessions != 1 {
+		t.Fatalf("unexpected drain state %+v", s)
+	}
+
+	if s := serve(t, http.MethodPost, http.StatusAccepted); !s.Draining || s.Deadline == nil {
+		t.Fatalf("unexpected drain state %+v", s)
+	}
+
+	serve(t, http.MethodPost, http.StatusConflict)
+
+	t.Run("association release requested", func(t *testing.T) {
+		aureq, ok := receive(t).(*message.AssociationUpdateRequest)
+		if !ok || aureq.PFCPAssociationReleaseRequest == nil || !aureq.PFCPAssociationReleaseRequest.HasSARR() {
+			t.Fatalf("expected an Association Update Request with release request, got %v", aureq)
+		}
+
+		if aureq.GracefulReleasePeriod == nil {
+			t.Fatal("expected a graceful release period")
+		}
+
+		if period, err := aureq.GracefulReleasePeriod.GracefulReleasePeriod(); err != nil || period <= 0 || period > 10*time.Second {
+			t.Errorf("unexpected graceful release period %v: %v", period, err)
+		}
+
+		reply(t, message.NewAssociationUpdateResponse(aureq.SequenceNumber, ie.NewNodeID("", "", "smf"),
+			ie.NewCause(ie.CauseRequestAccepted)))
+	})
+
+	t.Run("new sessions rejected", func(t *testing.T) {
+		req := message.NewSessionEstablishmentRequest(0, 0, 0, 1, 0,
+			ie.NewNodeID("", "", "smf"), ie.NewFSEID(2, net.ParseIP("10.0.0.1"), nil))
+
+		resp, err := pConn.handleSessionEstablishmentRequest(req)
+		if err == nil {
+			t.Fatal("expected establishment to fail")
+		}
+
+		if cause, _ := resp.(*message.SessionEstablishmentResponse).Cause.Cause(); cause != ie.CauseNoResourcesAvailable {
+			t.Errorf("expected cause %d, got %d", ie.CauseNoResourcesAvailable, cause)
+		}
+
+		if r := node.health(true); r.Status != healthUnavailable {
+			t.Errorf("expected UPF not to be ready while draining, got %+v", r)
+		}
+	})
+
+	t.Run("association released once sessions are deleted", func(t *testing.T) {
+		req := message.NewSessionDeletionRequest(0, 0, session.localSEID, 3, 0)
+		if _, err := pConn.handleSessionDeletionRequest(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		arreq, ok := receive(t).(*message.AssociationReleaseRequest)
+		if !ok {
+			t.Fatalf("expected an Association Release Request, got %v", arreq)
+		}
+
+		reply(t, message.NewAssociationReleaseResponse(arreq.SequenceNumber, ie.NewNodeID("", "", "smf"),
+			ie.NewCause(ie.CauseRequestAccepted)))
+
+		done, _ := node.startDrain()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("drain not done")
+		}
+
+		if !pConn.IsShutdown() {
+			t.Error("expected association to be shut down")
+		}
+
+		if s := serve(t, http.MethodGet, http.StatusOK); !s.Done || s.Associations != 0 {
+			t.Errorf("unexpected drain state %+v", s)
+		}
+	})
+}
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"sync"
+
+	"github.com/omec-project/upf-epc/logger"
+	"github.com/wmnsk/go-pfcp/ie"
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+// packRemoteFTEID packs the F-TEID of a remote GTP-U peer, as received in a
+// GTP-U Error Indication, into a single notification key.
+func packRemoteFTEID(teid, peerIP uint32) uint64 {
+	return uint64(peerIP)<<32 | uint64(teid)
+}
+
+func unpackRemoteFTEID(key uint64) (teid, peerIP uint32) {
+	return uint32(key), uint32(key >> 32)
+}
+
+// remoteFTEIDIndex maps the remote F-TEIDs the FARs tunnel packets to, to the
+// local SEIDs of their sessions.
+type remoteFTEIDIndex struct {
+	mu    sync.Mutex
+	seids map[uint64]map[uint64]struct{}
+	// keys holds the remote F-TEIDs indexed for each session.
+	keys map[uint64][]uint64
+}
+
+// update replaces the remote F-TEIDs indexed for the session with the ones of
+// its FARs.
+func (x *remoteFTEIDIndex) update(seid uint64, fars []far) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.removeLocked(seid)
+
+	if x.seids == nil {
+		x.seids = make(map[uint64]map[uint64]struct{})
+		x.keys = make(map[uint64][]uint64)
+	}
+
+	for _, f := range fars {
+		if f.tunnelTEID == 0 && f.tunnelIP4Dst == 0 {
+			continue
+		}
+
+		key := packRemoteFTEID(f.tunnelTEID, f.tunnelIP4Dst)
+		if _, ok := x.seids[key][seid]; ok {
+			continue
+		}
+
+		if x.seids[key] == nil {
+			x.seids[key] = make(map[uint64]struct{})
+		}
+
+		x.seids[key][seid] = struct{}{}
+		x.keys[seid] = append(x.keys[seid], key)
+	}
+}
+
+// remove drops the remote F-TEIDs indexed for the session.
+func (x *remoteFTEIDIndex) remove(seid uint64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.removeLocked(seid)
+}
+
+func (x *remoteFTEIDIndex) removeLocked(seid uint64) {
+	for _, key := range x.keys[seid] {
+		delete(x.seids[key], seid)
+
+		if len(x.seids[key]) == 0 {
+			delete(x.seids, key)
+		}
+	}
+
+	delete(x.keys, seid)
+}
+
+// lookup returns the local SEIDs of the sessions tunneling packets to the
+// remote F-TEID.
+func (x *remoteFTEIDIndex) lookup(key uint64) []uint64 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	seids := make([]uint64, 0, len(x.seids[key]))
+	for seid := range x.seids[key] {
+		seids = append(seids, seid)
+	}
+
+	return seids
+}
+
+// indexSession records the remote F-TEIDs of the stored session, so that the
+// Error Indications are matched without scanning every session.
+func (pConn *PFCPConn) indexSession(session PFCPSession) {
+	if pConn.node != nil {
+		pConn.node.remoteFTEIDs.update(session.localSEID, session.fars)
+	}
+}
+
+// hasRemoteFTEID returns true if a FAR of the session tunnels packets to the
+// remote F-TEID.
+func (s PFCPSession) hasRemoteFTEID(teid, peerIP uint32) bool {
+	for _, f := range s.fars {
+		if f.tunnelTEID == teid && f.tunnelIP4Dst == peerIP {
+			return true
+		}
+	}
+
+	return false
+}
+
+// handleErrorIndication reports a GTP-U Error Indication received from a remote
+// GTP-U peer to the CP functions of the sessions using the F-TEID.
+func (node *PFCPNode) handleErrorIndication(key uint64) {
+	teid, peerIP := unpackRemoteFTEID(key)
+	found := false
+
+	for _, seid := range node.remoteFTEIDs.lookup(key) {
+		pConn, ok := node.sessionOwner(seid)
+		if !ok {
+			continue
+		}
+
+		s, ok := pConn.store.GetSession(seid)
+		if !ok || !s.hasRemoteFTEID(teid, peerIP) {
+			continue
+		}
+
+		found = true
+
+		pConn.sendErrorIndicationReport(s, teid, peerIP)
+	}
+
+	if !found {
+		logger.PfcpLog.Warnf("no session found for error indication from %v, TEID: %v", int2ip(peerIP), teid)
+	}
+}
+
+func (pConn *PFCPConn) getErrorIndicationReport(session PFCPSession, teid, peerIP uint32) *message.SessionReportRequest {
+	srreq := message.NewSessionReportRequest(0, /* MO?? <-- what's this */
+		0,                            /* FO <-- what's this? */
+		0,                            /* seid */
+		pConn.getSeqNum(),            /* seq # */
+		0,                            /* priority */
+		ie.NewReportType(0, 1, 0, 0), /*upir, erir, usar, dldr int*/
+	)
+	srreq.Header.SEID = session.remoteSEID
+
+	// 0x01: V4 flag
+	srreq.ErrorIndicationReport = ie.NewErrorIndicationReport(
+		ie.NewFTEID(0x01, teid, int2ip(peerIP), nil, 0))
+
+	return srreq
+}
+
+func (pConn *PFCPConn) sendErrorIndicationReport(session PFCPSession, teid, peerIP uint32) {
+	logger.PfcpLog.With("F-SEID", session.localSEID, "TEID", teid, "peer", int2ip(peerIP)).Infoln(
+		"sending Error Indication Report")
+
+	pConn.SendPFCPMsg(pConn.getErrorIndicationReport(session, teid, peerIP))
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+func TestErrorIndicationReport(t *testing.T) {
+	node := &PFCPNode{upf: &upf{datapath: &fakeDP{}, usage: newUsageTracker()}}
+	p := newTestPFCPConn(node, 1)
+
+	gnb := ip2int(net.ParseIP("192.168.1.1"))
+	teid := uint32(0x1234)
+
+	if gotTEID, gotIP := unpackRemoteFTEID(packRemoteFTEID(teid, gnb)); gotTEID != teid || gotIP != gnb {
+		t.Fatalf("unexpected remote F-TEID %v/%v", gotTEID, gotIP)
+	}
+
+	s, ok := p.NewPFCPSession(42)
+	if !ok {
+		t.Fatal("failed to allocate session")
+	}
+
+	s.CreateFAR(far{farID: 1, fseID: s.localSEID, applyAction: ActionForward, tunnelIP4Dst: gnb, tunnelTEID: teid})
+
+	other, ok := p.NewPFCPSession(43)
+	if !ok {
+		t.Fatal("failed to allocate session")
+	}
+
+	other.CreateFAR(far{farID: 1, fseID: other.localSEID, applyAction: ActionForward, tunnelIP4Dst: gnb, tunnelTEID: teid + 1})
+
+	for _, sess := range []PFCPSession{s, other} {
+		if err := p.store.PutSession(sess); err != nil {
+			t.Fatalf("failed to store session: %v", err)
+		}
+
+		p.indexSession(sess)
+	}
+
+	seids := node.remoteFTEIDs.lookup(packRemoteFTEID(teid, gnb))
+	if len(seids) != 1 || seids[0] != s.localSEID {
+		t.Fatalf("expected session %v, got %v", s.localSEID, seids)
+	}
+
+	if !s.hasRemoteFTEID(teid, gnb) || other.hasRemoteFTEID(teid, gnb) {
+		t.Fatal("unexpected FAR match of the remote F-TEID")
+	}
+
+	p.RemoveSession(other)
+
+	if seids := node.remoteFTEIDs.lookup(packRemoteFTEID(teid+1, gnb)); len(seids) != 0 {
+		t.Fatalf("expected removed session to be unindexed, got %v", seids)
+	}
+
+	out, err := p.getErrorIndicationReport(s, teid, gnb).Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal report: %v", err)
+	}
+
+	msg, err := message.Parse(out)
+	if err != nil {
+		t.Fatalf("failed to parse report: %v", err)
+	}
+
+	srreq, ok := msg.(*message.SessionReportRequest)
+	if !ok {
+		t.Fatalf("expected a Session Report Request, got %v", msg.MessageTypeName())
+	}
+
+	if srreq.SEID() != s.remoteSEID {
+		t.Errorf("expected SEID %v, got %v", s.remoteSEID, srreq.SEID())
+	}
+
+	if !srreq.ReportType.HasERIR() || srreq.ReportType.HasDLDR() {
+		t.Errorf("expected ERIR report type, got %v", srreq.ReportType)
+	}
+
+	fteid, err := srreq.ErrorIndicationReport.FTEID()
+	if err != nil {
+		t.Fatalf("failed to read remote F-TEID: %v", err)
+	}
+
+	if fteid.TEID != teid || !fteid.IPv4Address.Equal(int2ip(gnb)) {
+		t.Errorf("unexpected remote F-TEID %+v", fteid)
+	}
+}
+
+func TestParseErrorIndicationFrame(t *testing.T) {
+	gnb := ip2int(net.ParseIP("192.168.1.1"))
+	teid := uint32(0x1234)
+
+	gtpu := errorIndication(teid, gnb)
+
+	frame := make([]byte, ethHeaderSize+ipv4HeaderSize+udpHeaderSize, ethHeaderSize+ipv4HeaderSize+udpHeaderSize+len(gtpu))
+	binary.BigEndian.PutUint16(frame[ethTypeOffset:], 0x0800)
+	frame[ipOffset] = 0x45
+	frame = append(frame, gtpu...)
+
+	gotTEID, gotIP, err := parseErrorIndicationFrame(frame)
+	if err != nil {
+		t.Fatalf("failed to parse error indication frame: %v", err)
+	}
+
+	if gotTEID != teid || gotIP != gnb {
+		t.Fatalf("unexpected remote F-TEID %v/%v", gotTEID, int2ip(gotIP))
+	}
+
+	if _, _, err := parseErrorIndicationFrame(frame[:ethHeaderSize+ipv4HeaderSize]); err == nil {
+		t.Fatal("expected truncated frame to be rejected")
+	}
+
+	frame[gtpOffset+1] = gtpuEchoRequest
+	if _, _, err := parseErrorIndicationFrame(frame); err == nil {
+		t.Fatal("expected other GTP-U messages to be rejected")
+	}
+}
@@ -59,6 +59,20 @@ func (idGenerator *FTEIDGenerator) FreeID(id uint32) {
 	delete(idGenerator.usedMap, id-minValue)
 }
 
+// Reserve marks the id as allocated. It returns false if the id is already in use.
+func (idGenerator *FTEIDGenerator) Reserve(id uint32) bool {
+	if id < minValue {
+		return false
+	}
+	idGenerator.lock.Lock()
+	defer idGenerator.lock.Unlock()
+	if _, ok := idGenerator.usedMap[id-minValue]; ok {
+		return false
+	}
+	idGenerator.usedMap[id-minValue] = true
+	return true
+}
+
 func (idGenerator *FTEIDGenerator) IsAllocated(id uint32) bool {
 	if id < minValue {
 		return false
@@ -0,0 +1,799 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+const (
+	// godpMaxPacketSize is the size of the packets read from N3 and N6.
+	godpMaxPacketSize = 1 << 16
+	// godpBufferSize is the number of packets buffered per FAR.
+	godpBufferSize = 64
+	// godpBurstDurationMs is the duration of the bursts allowed by the MBRs,
+	// which are at least godpMinBurstBytes.
+	godpBurstDurationMs = 100
+	godpMinBurstBytes   = 1 << 14
+	// godpEchoInterval is the period of the Echo Requests sent to the GTP-U
+	// peers when GTP-U path monitoring is enabled.
+	godpEchoInterval = 5 * time.Second
+)
+
+// godpPacket is an IP packet received from N6, or decapsulated from a G-PDU
+// received on N3 from a remote GTP-U peer.
+type godpPacket struct {
+	data   []byte
+	uplink bool
+	teid   uint32
+	from   *net.UDPAddr
+}
+
+type godpFARKey struct {
+	fseID uint64
+	farID uint32
+}
+
+type godpMeterKey struct {
+	qerID  uint32
+	uplink bool
+}
+
+type godpCounters struct {
+	packets atomic.Uint64
+	bytes   atomic.Uint64
+}
+
+// godpMeter is a token bucket enforcing the MBR of a QER in one direction.
+type godpMeter struct {
+	mu     sync.Mutex
+	rate   float64 // in bytes/sec
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newGodpMeter returns the meter of a MBR, nil if the MBR is not limited.
+func newGodpMeter(kbps uint64) *godpMeter {
+	if kbps == 0 {
+		return nil
+	}
+
+	burst := float64(max(calcBurstSizeFromRate(kbps, godpBurstDurationMs), godpMinBurstBytes))
+
+	return &godpMeter{
+		rate:   float64(kbps) * 1000 / 8,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// allow returns true if the packet of size bytes conforms to the MBR.
+func (m *godpMeter) allow(size int, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens = min(m.burst, m.tokens+now.Sub(m.last).Seconds()*m.rate)
+	m.last = now
+
+	if m.tokens < float64(size) {
+		return false
+	}
+
+	m.tokens -= float64(size)
+
+	return true
+}
+
+type godpSession struct {
+	pdrs     map[uint32]pdr
+	fars     map[uint32]far
+	qers     map[uint32]qer
+	counters map[uint32]*godpCounters
+	meters   map[godpMeterKey]*godpMeter
+}
+
+func (s *godpSession) empty() bool {
+	return len(s.pdrs) == 0 && len(s.fars) == 0 && len(s.qers) == 0
+}
+
+// police applies the QERs of the PDR to a packet of size bytes. It returns
+// the QFI of the packet, and whether the packet is forwarded.
+func (s *godpSession) police(p pdr, uplink bool, size int, now time.Time) (uint8, bool) {
+	var qfi uint8
+
+	for _, id := range p.qerIDList {
+		q, ok := s.qers[id]
+		if !ok {
+			continue
+		}
+
+		gate := q.dlStatus
+		if uplink {
+			gate = q.ulStatus
+		}
+
+		if gate != ie.GateStatusOpen {
+			return 0, false
+		}
+
+		if m := s.meters[godpMeterKey{qerID: id, uplink: uplink}]; m != nil && !m.allow(size, now) {
+			return 0, false
+		}
+
+		// The QFI of the application QER overrides the one of the session.
+		if q.qfi != 0 && (qfi == 0 || q.qosLevel == ApplicationQos) {
+			qfi = q.qfi
+		}
+	}
+
+	return qfi, true
+}
+
+// godp is a datapath implemented in Go. It terminates GTP-U on a UDP socket
+// (N3) and exchanges the packets of the UEs with the data network through a
+// TUN device or a UDP socket (N6). It is meant for labs and CI, where
+// correctness matters more than performance.
+type godp struct {
+	conf           GoDatapathInfo
+	n3             *net.UDPConn
+	n3IP           uint32
+	n6             godpN6
+	notifier       *downlinkDataNotifier
+	errIndNotifier *downlinkDataNotifier
+	done           chan struct{}
+
+	// mu guards the rules, read for every packet.
+	mu       sync.RWMutex
+	sessions map[uint64]*godpSession
+	// uplink PDRs by TEID, and downlink PDRs by UE address.
+	uplink   map[uint32][]pdrCounterKey
+	downlink map[uint32][]pdrCounterKey
+
+	bufMu   sync.Mutex
+	buffers map[godpFARKey][]godpPacket
+
+	echoMu sync.Mutex
+	echoes map[uint32]uint64
+}
+
+func (dp *godp) Exit() {
+	logger.PfcpLog.Infoln("exit function Go datapath")
+
+	close(dp.done)
+
+	if dp.n3 != nil {
+		dp.n3.Close()
+	}
+
+	if dp.n6 != nil {
+		dp.n6.close()
+	}
+}
+
+func (dp *godp) SetUpfInfo(u *upf, conf *Conf) {
+	dp.conf = conf.GoDatapath
+	dp.done = make(chan struct{})
+	dp.sessions = make(map[uint64]*godpSession)
+	dp.uplink = make(map[uint32][]pdrCounterKey)
+	dp.downlink = make(map[uint32][]pdrCounterKey)
+	dp.buffers = make(map[godpFARKey][]godpPacket)
+	dp.echoes = make(map[uint32]uint64)
+	dp.notifier = NewDownlinkDataNotifier(u.reportNotifyChan, 20*time.Second)
+
+	if conf.EnableErrorIndication {
+		// Rate limits the reports of the same remote F-TEID.
+		dp.errIndNotifier = NewDownlinkDataNotifier(u.errorIndicationChan, 20*time.Second)
+	}
+
+	n3Addr, err := net.ResolveUDPAddr("udp4", dp.conf.N3Addr)
+	if err != nil {
+		logger.PfcpLog.Fatalln("invalid N3 address:", err)
+	}
+
+	n3, err := net.ListenUDP("udp4", n3Addr)
+	if err != nil {
+		logger.PfcpLog.Errorln("failed to open N3 socket:", err)
+		return
+	}
+
+	var n6 godpN6
+
+	switch dp.conf.N6Mode {
+	case godpN6ModeUDP:
+		n6, err = newUDPN6(dp.conf.N6Addr, dp.conf.N6Peer)
+	default:
+		n6, err = newTunN6(dp.conf.TunName)
+	}
+
+	if err != nil {
+		logger.PfcpLog.Errorln("failed to open N6 interface:", err)
+		n3.Close()
+
+		return
+	}
+
+	dp.n3, dp.n3IP, dp.n6 = n3, ip2int(n3Addr.IP), n6
+
+	logger.PfcpLog.Infoln("Go datapath N3 on", n3.LocalAddr(), "N6 mode", dp.conf.N6Mode)
+
+	go dp.n3Loop()
+	go dp.n6Loop()
+
+	if u.enableGtpuMonitor {
+		go dp.echoLoop()
+	}
+}
+
+// n3Loop processes the GTP-U messages received on N3.
+func (dp *godp) n3Loop() {
+	buf := make([]byte, godpMaxPacketSize)
+
+	for {
+		n, from, err := dp.n3.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+
+		if err != nil {
+			logger.PfcpLog.Debugln("failed to read from N3:", err)
+			continue
+		}
+
+		h, payload, err := parseGTPU(buf[:n])
+		if err != nil {
+			logger.PfcpLog.Debugln("dropping GTP-U message from", from, ":", err)
+			continue
+		}
+
+		switch h.msgType {
+		case gtpuGPDU:
+			dp.process(godpPacket{data: payload, uplink: true, teid: h.teid, from: from})
+		case gtpuEchoRequest:
+			if _, err := dp.n3.WriteToUDP(echoResponse(h.seq), from); err != nil {
+				logger.PfcpLog.Debugln("failed to send echo response to", from, ":", err)
+			}
+		case gtpuEchoResponse:
+			dp.echoMu.Lock()
+			dp.echoes[ip2int(from.IP)]++
+			dp.echoMu.Unlock()
+		case gtpuErrorIndication:
+			dp.handleErrorIndication(payload)
+		default:
+			logger.PfcpLog.Debugln("ignoring GTP-U message of type", h.msgType, "from", from)
+		}
+	}
+}
+
+// n6Loop processes the packets received on N6.
+func (dp *godp) n6Loop() {
+	buf := make([]byte, godpMaxPacketSize)
+
+	for {
+		n, err := dp.n6.read(buf)
+		if errors.Is(err, net.ErrClosed) || errors.Is(err, os.ErrClosed) {
+			return
+		}
+
+		if err != nil {
+			logger.PfcpLog.Debugln("failed to read from N6:", err)
+			continue
+		}
+
+		dp.process(godpPacket{data: buf[:n]})
+	}
+}
+
+// echoLoop sends Echo Requests to the GTP-U peers of the FARs.
+func (dp *godp) echoLoop() {
+	ticker := time.NewTicker(godpEchoInterval)
+	defer ticker.Stop()
+
+	var seq uint16
+
+	for {
+		select {
+		case <-dp.done:
+			return
+		case <-ticker.C:
+		}
+
+		seq++
+
+		for ip, port := range dp.tunnelPeers() {
+			peer := &net.UDPAddr{IP: int2ip(ip), Port: int(port)}
+			if _, err := dp.n3.WriteToUDP(gtpuSignalling(gtpuEchoRequest, seq, nil), peer); err != nil {
+				logger.PfcpLog.Debugln("failed to send echo request to", peer, ":", err)
+			}
+		}
+	}
+}
+
+// tunnelPeers returns the GTP-U port of the remote peers of the FARs, by
+// IPv4 address.
+func (dp *godp) tunnelPeers() map[uint32]uint16 {
+	dp.mu.RLock()
+	defer dp.mu.RUnlock()
+
+	peers := make(map[uint32]uint16)
+
+	for _, s := range dp.sessions {
+		for _, f := range s.fars {
+			if f.tunnelIP4Dst != 0 {
+				peers[f.tunnelIP4Dst] = tunnelPort(f)
+			}
+		}
+	}
+
+	return peers
+}
+
+func tunnelPort(f far) uint16 {
+	if f.tunnelPort == 0 {
+		return tunnelGTPUPort
+	}
+
+	return f.tunnelPort
+}
+
+func (dp *godp) handleErrorIndication(ies []byte) {
+	if dp.errIndNotifier == nil {
+		return
+	}
+
+	teid, peerIP, err := parseErrorIndication(ies)
+	if err != nil {
+		logger.PfcpLog.Debugln("ignoring error indication:", err)
+		return
+	}
+
+	dp.errIndNotifier.Notify(packRemoteFTEID(teid, peerIP))
+}
+
+// matches returns true if the packet of the flow matches the PDR.
+func (p pdr) matches(flow ipv4Flow, uplink bool) bool {
+	ue := flow.dst
+	if uplink {
+		ue = flow.src
+	}
+
+	return (p.ueAddress == 0 || p.ueAddress == ue) && p.appFilter.matches(flow)
+}
+
+// process applies the PDR of highest precedence matching the packet.
+func (dp *godp) process(pkt godpPacket) {
+	dp.mu.RLock()
+	defer dp.mu.RUnlock()
+
+	dp.processLocked(pkt)
+}
+
+// processLocked is process with dp.mu held.
+func (dp *godp) processLocked(pkt godpPacket) {
+	flow, err := parseIPv4(pkt.data)
+	if err != nil {
+		logger.PfcpLog.Debugln("dropping packet:", err)
+		return
+	}
+
+	keys := dp.downlink[flow.dst]
+	if pkt.uplink {
+		keys = dp.uplink[pkt.teid]
+	}
+
+	var (
+		s     *godpSession
+		p     pdr
+		found bool
+	)
+
+	for _, k := range keys {
+		cs := dp.sessions[k.fseID]
+		if cp := cs.pdrs[k.pdrID]; cp.matches(flow, pkt.uplink) && (!found || cp.precedence < p.precedence) {
+			s, p, found = cs, cp, true
+		}
+	}
+
+	if !found {
+		if pkt.uplink && len(keys) == 0 && pkt.from != nil {
+			// The remote peer is told that the TEID is unknown.
+			if _, err := dp.n3.WriteToUDP(errorIndication(pkt.teid, dp.n3IP), pkt.from); err != nil {
+				logger.PfcpLog.Debugln("failed to send error indication to", pkt.from, ":", err)
+			}
+		}
+
+		return
+	}
+
+	f, ok := s.fars[p.farID]
+	if !ok {
+		return
+	}
+
+	switch {
+	case f.Forwards():
+	case f.Drops():
+		return
+	case f.Buffers() || f.applyAction&ActionNotify != 0:
+		if f.Buffers() {
+			dp.buffer(f, pkt)
+		}
+
+		dp.notifier.Notify(f.fseID)
+
+		return
+	default:
+		return
+	}
+
+	qfi, ok := s.police(p, pkt.uplink, len(pkt.data), time.Now())
+	if !ok {
+		return
+	}
+
+	if err := dp.send(f, qfi, pkt.data); err != nil {
+		logger.PfcpLog.Debugln("failed to forward packet of", f, ":", err)
+		return
+	}
+
+	if c := s.counters[p.pdrID]; c != nil {
+		c.packets.Add(1)
+		c.bytes.Add(uint64(len(pkt.data)))
+	}
+}
+
+// send forwards the packet to the data network, or to the GTP-U tunnel of
+// the FAR.
+func (dp *godp) send(f far, qfi uint8, data []byte) error {
+	if f.tunnelIP4Dst == 0 {
+		return dp.n6.write(data)
+	}
+
+	peer := &net.UDPAddr{IP: int2ip(f.tunnelIP4Dst), Port: int(tunnelPort(f))}
+	_, err := dp.n3.WriteToUDP(encapGTPU(f.tunnelTEID, qfi, data), peer)
+
+	return err
+}
+
+// bufferLimit returns the number of packets buffered for the FAR.
+func bufferLimit(f far) int {
+	if f.bufferLimited && int(f.bufferPackets) < godpBufferSize {
+		return int(f.bufferPackets)
+	}
+
+	return godpBufferSize
+}
+
+// buffer keeps a copy of the packet until the FAR stops buffering. Packets
+// beyond the limit of the FAR are dropped.
+func (dp *godp) buffer(f far, pkt godpPacket) {
+	dp.bufMu.Lock()
+	defer dp.bufMu.Unlock()
+
+	key := godpFARKey{fseID: f.fseID, farID: f.farID}
+	if len(dp.buffers[key]) >= bufferLimit(f) {
+		return
+	}
+
+	pkt.data = slices.Clone(pkt.data)
+	dp.buffers[key] = append(dp.buffers[key], pkt)
+}
+
+// trimBuffer drops the packets buffered beyond the current limit of the FAR.
+func (dp *godp) trimBuffer(f far) {
+	dp.bufMu.Lock()
+	defer dp.bufMu.Unlock()
+
+	key := godpFARKey{fseID: f.fseID, farID: f.farID}
+	if limit := bufferLimit(f); len(dp.buffers[key]) > limit {
+		dp.buffers[key] = dp.buffers[key][:limit]
+	}
+}
+
+// unbuffer removes the packets buffered by the FAR.
+func (dp *godp) unbuffer(key godpFARKey) []godpPacket {
+	dp.bufMu.Lock()
+	defer dp.bufMu.Unlock()
+
+	pkts := dp.buffers[key]
+	delete(dp.buffers, key)
+
+	return pkts
+}
+
+// pdrIndex returns the index of the PDR, and its key in the index.
+func (dp *godp) pdrIndex(p pdr) (map[uint32][]pdrCounterKey, uint32) {
+	if p.IsUplink() {
+		return dp.uplink, p.tunnelTEID
+	}
+
+	return dp.downlink, p.ueAddress
+}
+
+func (dp *godp) index(p pdr) {
+	index, key := dp.pdrIndex(p)
+	index[key] = append(index[key], pdrCounterKey{fseID: p.fseID, pdrID: p.pdrID})
+}
+
+func (dp *godp) unindex(p pdr) {
+	index, key := dp.pdrIndex(p)
+
+	index[key] = slices.DeleteFunc(index[key], func(k pdrCounterKey) bool {
+		return k.fseID == p.fseID && k.pdrID == p.pdrID
+	})
+
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+// session returns the rules of the session, created if unknown.
+func (dp *godp) session(fseID uint64) *godpSession {
+	s, ok := dp.sessions[fseID]
+	if !ok {
+		s = &godpSession{
+			pdrs:     make(map[uint32]pdr),
+			fars:     make(map[uint32]far),
+			qers:     make(map[uint32]qer),
+			counters: make(map[uint32]*godpCounters),
+			meters:   make(map[godpMeterKey]*godpMeter),
+		}
+		dp.sessions[fseID] = s
+	}
+
+	return s
+}
+
+func (dp *godp) forget(fseID uint64) {
+	if s, ok := dp.sessions[fseID]; ok && s.empty() {
+		delete(dp.sessions, fseID)
+	}
+}
+
+// CreatePDR installs the PDR.
+func (dp *godp) CreatePDR(ctx context.Context, p pdr) error {
+	return dp.UpdatePDR(ctx, pdr{}, p)
+}
+
+// UpdatePDR replaces the PDR, its counters are kept.
+func (dp *godp) UpdatePDR(ctx context.Context, _, p pdr) error {
+	switch {
+	case !p.IsUplink() && !p.IsDownlink():
+		return ErrUnsupported("PDR source interface", p.srcIface)
+	case !p.hasIPv4():
+		return ErrUnsupported("PDR without IPv4 UE address", p.pdrID)
+	case p.IsUplink() && p.tunnelTEID == 0:
+		return ErrUnsupported("uplink PDR without F-TEID", p.pdrID)
+	case p.IsDownlink() && p.ueAddress == 0:
+		return ErrUnsupported("PDR without IPv4 UE address", p.pdrID)
+	}
+
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	s := dp.session(p.fseID)
+
+	if old, ok := s.pdrs[p.pdrID]; ok {
+		dp.unindex(old)
+	}
+
+	s.pdrs[p.pdrID] = p
+	dp.index(p)
+
+	if s.counters[p.pdrID] == nil {
+		s.counters[p.pdrID] = &godpCounters{}
+	}
+
+	return nil
+}
+
+// DeletePDR removes the PDR and its counters.
+func (dp *godp) DeletePDR(ctx context.Context, p pdr) error {
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	s, ok := dp.sessions[p.fseID]
+	if !ok {
+		return nil
+	}
+
+	if old, ok := s.pdrs[p.pdrID]; ok {
+		dp.unindex(old)
+		delete(s.pdrs, p.pdrID)
+		delete(s.counters, p.pdrID)
+	}
+
+	dp.forget(p.fseID)
+
+	return nil
+}
+
+// CreateFAR installs the FAR.
+func (dp *godp) CreateFAR(ctx context.Context, f far) error {
+	return dp.UpdateFAR(ctx, f)
+}
+
+// UpdateFAR replaces the FAR. The packets it buffered are processed again
+// once it stops buffering.
+func (dp *godp) UpdateFAR(ctx context.Context, f far) error {
+	if f.hasIPv6Tunnel() {
+		return ErrUnsupported("FAR with IPv6 tunnel", f.farID)
+	}
+
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	dp.session(f.fseID).fars[f.farID] = f
+
+	if f.Buffers() {
+		dp.trimBuffer(f)
+		return nil
+	}
+
+	// The buffered packets are sent before the packets received from then
+	// on, which wait for the lock.
+	for _, pkt := range dp.unbuffer(godpFARKey{fseID: f.fseID, farID: f.farID}) {
+		dp.processLocked(pkt)
+	}
+
+	return nil
+}
+
+// DeleteFAR removes the FAR and drops the packets it buffered.
+func (dp *godp) DeleteFAR(ctx context.Context, f far) error {
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	if s, ok := dp.sessions[f.fseID]; ok {
+		delete(s.fars, f.farID)
+		dp.forget(f.fseID)
+	}
+
+	dp.unbuffer(godpFARKey{fseID: f.fseID, farID: f.farID})
+
+	return nil
+}
+
+// CreateQER installs the QER.
+func (dp *godp) CreateQER(ctx context.Context, q qer) error {
+	return dp.UpdateQER(ctx, q)
+}
+
+// UpdateQER replaces the QER and resets its meters.
+func (dp *godp) UpdateQER(ctx context.Context, q qer) error {
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	s := dp.session(q.fseID)
+	s.qers[q.qerID] = q
+	s.meters[godpMeterKey{qerID: q.qerID, uplink: true}] = newGodpMeter(q.ulMbr)
+	s.meters[godpMeterKey{qerID: q.qerID, uplink: false}] = newGodpMeter(q.dlMbr)
+
+	return nil
+}
+
+// DeleteQER removes the QER and its meters.
+func (dp *godp) DeleteQER(ctx context.Context, q qer) error {
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	if s, ok := dp.sessions[q.fseID]; ok {
+		delete(s.qers, q.qerID)
+		delete(s.meters, godpMeterKey{qerID: q.qerID, uplink: true})
+		delete(s.meters, godpMeterKey{qerID: q.qerID, uplink: false})
+		dp.forget(q.fseID)
+	}
+
+	return nil
+}
+
+func (dp *godp) IsConnected(accessIP *net.IP) bool {
+	return dp.n3 != nil && dp.n6 != nil
+}
+
+// UsageCounters returns the packets and bytes forwarded by every PDR since it was installed.
+func (dp *godp) UsageCounters() (map[pdrCounterKey]pdrCounters, error) {
+	dp.mu.RLock()
+	defer dp.mu.RUnlock()
+
+	counters := make(map[pdrCounterKey]pdrCounters)
+
+	for fseID, s := range dp.sessions {
+		for pdrID, c := range s.counters {
+			counters[pdrCounterKey{fseID: fseID, pdrID: pdrID}] = pdrCounters{
+				packets: c.packets.Load(),
+				bytes:   c.bytes.Load(),
+			}
+		}
+	}
+
+	return counters, nil
+}
+
+// GtpuPathCounters returns the echo responses received from the GTP-U peers
+// of the FARs.
+func (dp *godp) GtpuPathCounters() (map[uint32]uint64, error) {
+	peers := dp.tunnelPeers()
+
+	dp.echoMu.Lock()
+	defer dp.echoMu.Unlock()
+
+	counters := make(map[uint32]uint64, len(peers))
+	for ip := range peers {
+		counters[ip] = dp.echoes[ip]
+	}
+
+	return counters, nil
+}
+
+// SendEndMarkers sends the GTP-U End Markers to the IPv4 peers they are
+// addressed to.
+func (dp *godp) SendEndMarkers(endMarkerList *[][]byte) error {
+	for _, packet := range *endMarkerList {
+		if len(packet) < endMarkerSize || binary.BigEndian.Uint16(packet[ethTypeOffset:]) != 0x0800 {
+			logger.PfcpLog.Warnln("Go datapath only sends IPv4 end markers")
+			continue
+		}
+
+		peer := &net.UDPAddr{IP: int2ip(binary.BigEndian.Uint32(packet[ipDstOffset:])), Port: tunnelGTPUPort}
+		if _, err := dp.n3.WriteToUDP(packet[gtpOffset:], peer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (dp *godp) AddSliceInfo(sliceInfo *SliceInfo) error {
+	return ErrUnsupported("slice rate limits", "Go datapath")
+}
+
+// SetQciQosConfig does nothing, the Go datapath meters the MBRs of the QERs
+// with bursts of its own rather than those of the QCIs.
+func (dp *godp) SetQciQosConfig(conf *Conf) error {
+	return nil
+}
+
+func (dp *godp) SetSliceMeterConfig(meterConfig SliceMeterConfig) error {
+	return ErrUnsupported("slice rate limits", "Go datapath")
+}
+
+func (dp *godp) SummaryLatencyJitter(uc *upfCollector, ch chan<- prometheus.Metric) {}
+
+func (dp *godp) PortStats(uc *upfCollector, ch chan<- prometheus.Metric) {}
+
+func (dp *godp) SummaryGtpuLatency(uc *upfCollector, ch chan<- prometheus.Metric) {}
+
+func (dp *godp) SessionStats(pc *PfcpNodeCollector, ch chan<- prometheus.Metric) error {
+	return nil
+}
+
+// ReadRules reports that the rules are not audited, as they are not held
+// outside the PFCP agent.
+func (dp *godp) ReadRules() []datapathTable {
+	return []datapathTable{{name: "godp", err: ErrUnsupported("datapath audit", "Go datapath")}}
+}
+
+// ExpectedRules returns no rules, the rules are neither audited nor accounted
+// against the datapath capacity.
+func (dp *godp) ExpectedRules(rules PacketForwardingRules) []datapathRule {
+	return nil
+}
+
+func (dp *godp) DeleteRule(r datapathRule) error {
+	return ErrUnsupported("datapath audit", "Go datapath")
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"net"
+)
+
+// N6 modes of the Go datapath.
+const (
+	godpN6ModeTun = "tun"
+	godpN6ModeUDP = "udp"
+)
+
+// godpN6 exchanges the IP packets of the UEs with the data network.
+type godpN6 interface {
+	read(b []byte) (int, error)
+	write(b []byte) error
+	close() error
+}
+
+// godpUDPN6 carries every IP packet in a UDP datagram, sent to a single peer
+// and received from any.
+type godpUDPN6 struct {
+	conn *net.UDPConn
+	peer *net.UDPAddr
+}
+
+func newUDPN6(local, peer string) (*godpUDPN6, error) {
+	localAddr, err := net.ResolveUDPAddr("udp", local)
+	if err != nil {
+		return nil, err
+	}
+
+	peerAddr, err := net.ResolveUDPAddr("udp", peer)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp", localAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &godpUDPN6{conn: conn, peer: peerAddr}, nil
+}
+
+func (n *godpUDPN6) read(b []byte) (int, error) {
+	return n.conn.Read(b)
+}
+
+func (n *godpUDPN6) write(b []byte) error {
+	_, err := n.conn.WriteToUDP(b, n.peer)
+	return err
+}
+
+func (n *godpUDPN6) close() error {
+	return n.conn.Close()
+}
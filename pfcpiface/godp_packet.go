@@ -0,0 +1,270 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"encoding/binary"
+)
+
+// GTP-U header flags, message types and IEs, see 3GPP TS 29.281.
+const (
+	gtpuFlagsV1 = 0x30 // Version 1, PT=1
+	gtpuFlagE   = 0x04
+	gtpuFlagS   = 0x02
+	gtpuFlagPN  = 0x01
+
+	gtpuEchoRequest     = 1
+	gtpuEchoResponse    = 2
+	gtpuErrorIndication = 26
+	gtpuGPDU            = 255
+
+	gtpuExtPDUSessionContainer = 0x85
+
+	gtpuIERecovery    = 14
+	gtpuIETEIDDataI   = 16
+	gtpuIEPeerAddress = 133
+
+	// gtpuOptionalSize is the size of the sequence number, N-PDU number and
+	// next extension header type fields.
+	gtpuOptionalSize = 4
+)
+
+// gtpuHeader holds the fields of a GTP-U header used by the Go datapath.
+type gtpuHeader struct {
+	msgType uint8
+	teid    uint32
+	seq     uint16
+	// qfi is the QFI of the PDU Session Container, 0 if there is none.
+	qfi uint8
+}
+
+// parseGTPU returns the header and the payload of a GTP-U message.
+func parseGTPU(b []byte) (gtpuHeader, []byte, error) {
+	var h gtpuHeader
+
+	if len(b) < gtpHeaderSize {
+		return h, nil, ErrInvalidArgumentWithReason("GTP-U message", len(b), "truncated header")
+	}
+
+	if b[0]&0xf0 != gtpuFlagsV1 {
+		return h, nil, ErrUnsupported("GTP-U flags", b[0])
+	}
+
+	h.msgType = b[1]
+	h.teid = binary.BigEndian.Uint32(b[4:8])
+
+	end := gtpHeaderSize + int(binary.BigEndian.Uint16(b[2:4]))
+	if end > len(b) {
+		return h, nil, ErrInvalidArgumentWithReason("GTP-U message", len(b), "shorter than its length field")
+	}
+
+	b = b[:end]
+	off := gtpHeaderSize
+
+	if b[0]&(gtpuFlagE|gtpuFlagS|gtpuFlagPN) == 0 {
+		return h, b[off:], nil
+	}
+
+	if end < off+gtpuOptionalSize {
+		return h, nil, ErrInvalidArgumentWithReason("GTP-U message", len(b), "truncated optional fields")
+	}
+
+	h.seq = binary.BigEndian.Uint16(b[8:10])
+
+	next := b[11]
+	if b[0]&gtpuFlagE == 0 {
+		next = 0
+	}
+
+	off += gtpuOptionalSize
+
+	// Every extension header is a multiple of 4 bytes long, and ends with
+	// the type of the next one.
+	for next != 0 {
+		if off >= end || b[off] == 0 || off+int(b[off])*4 > end {
+			return h, nil, ErrInvalidArgumentWithReason("GTP-U message", len(b), "truncated extension header")
+		}
+
+		n := int(b[off]) * 4
+
+		if next == gtpuExtPDUSessionContainer {
+			h.qfi = b[off+2] & 0x3f
+		}
+
+		next = b[off+n-1]
+		off += n
+	}
+
+	return h, b[off:], nil
+}
+
+// encapGTPU returns the G-PDU carrying payload to teid. A downlink PDU
+// Session Container carries the QFI, if not 0.
+func encapGTPU(teid uint32, qfi uint8, payload []byte) []byte {
+	size := gtpHeaderSize
+	if qfi != 0 {
+		size += gtpuOptionalSize + 4
+	}
+
+	b := make([]byte, size, size+len(payload))
+	b[0] = gtpuFlagsV1
+	b[1] = gtpuGPDU
+	binary.BigEndian.PutUint16(b[2:], uint16(size-gtpHeaderSize+len(payload)))
+	binary.BigEndian.PutUint32(b[4:], teid)
+
+	if qfi != 0 {
+		b[0] |= gtpuFlagE
+		b[11] = gtpuExtPDUSessionContainer
+		b[12] = 1 // Length in 4 bytes units
+		b[13] = 0 // PDU Type: DL PDU Session Information
+		b[14] = qfi & 0x3f
+		b[15] = 0 // No next extension header
+	}
+
+	return append(b, payload...)
+}
+
+// gtpuSignalling returns the GTP-U signalling message with the given
+// sequence number and IEs.
+func gtpuSignalling(msgType uint8, seq uint16, ies []byte) []byte {
+	b := make([]byte, gtpHeaderSize+gtpuOptionalSize, gtpHeaderSize+gtpuOptionalSize+len(ies))
+	b[0] = gtpuFlagsV1 | gtpuFlagS
+	b[1] = msgType
+	binary.BigEndian.PutUint16(b[2:], uint16(gtpuOptionalSize+len(ies)))
+	binary.BigEndian.PutUint16(b[8:], seq)
+
+	return append(b, ies...)
+}
+
+// echoResponse returns the Echo Response to the Echo Request with the given
+// sequence number.
+func echoResponse(seq uint16) []byte {
+	// The Recovery IE is mandatory, its restart counter is always 0.
+	return gtpuSignalling(gtpuEchoResponse, seq, []byte{gtpuIERecovery, 0})
+}
+
+// errorIndication returns the Error Indication of a G-PDU received for an
+// unknown TEID on the local address peerIP.
+func errorIndication(teid, peerIP uint32) []byte {
+	ies := make([]byte, 12)
+	ies[0] = gtpuIETEIDDataI
+	binary.BigEndian.PutUint32(ies[1:], teid)
+	ies[5] = gtpuIEPeerAddress
+	binary.BigEndian.PutUint16(ies[6:], 4)
+	binary.BigEndian.PutUint32(ies[8:], peerIP)
+
+	return gtpuSignalling(gtpuErrorIndication, 0, ies)
+}
+
+// parseErrorIndication returns the remote F-TEID reported by an Error
+// Indication.
+func parseErrorIndication(ies []byte) (teid, peerIP uint32, err error) {
+	var hasTEID, hasPeer bool
+
+	for len(ies) > 0 {
+		t := ies[0]
+
+		// TV IEs have a fixed length, TLV IEs have types of 128 and above.
+		var n int
+
+		switch {
+		case t == gtpuIERecovery:
+			n = 2
+		case t == gtpuIETEIDDataI:
+			n = 5
+		case t >= 128 && len(ies) >= 3:
+			n = 3 + int(binary.BigEndian.Uint16(ies[1:3]))
+		default:
+			return 0, 0, ErrUnsupported("GTP-U IE", t)
+		}
+
+		if n > len(ies) {
+			return 0, 0, ErrInvalidArgumentWithReason("GTP-U IE", t, "truncated")
+		}
+
+		switch {
+		case t == gtpuIETEIDDataI:
+			teid, hasTEID = binary.BigEndian.Uint32(ies[1:5]), true
+		case t == gtpuIEPeerAddress && n == 3+4:
+			peerIP, hasPeer = binary.BigEndian.Uint32(ies[3:7]), true
+		}
+
+		ies = ies[n:]
+	}
+
+	if !hasTEID || !hasPeer {
+		return 0, 0, ErrNotFound("TEID Data I or IPv4 GTP-U Peer Address IE")
+	}
+
+	return teid, peerIP, nil
+}
+
+// ipv4Flow holds the fields of an IPv4 packet matched by the PDRs.
+type ipv4Flow struct {
+	src     uint32
+	dst     uint32
+	proto   uint8
+	srcPort uint16
+	dstPort uint16
+	// hasPorts is set if the packet carries the L4 ports.
+	hasPorts bool
+}
+
+// parseIPv4 returns the flow of an IPv4 packet.
+func parseIPv4(b []byte) (ipv4Flow, error) {
+	var flow ipv4Flow
+
+	if len(b) < ipv4HeaderSize {
+		return flow, ErrInvalidArgumentWithReason("IPv4 packet", len(b), "truncated header")
+	}
+
+	if b[0]>>4 != 4 {
+		return flow, ErrUnsupported("IP version", b[0]>>4)
+	}
+
+	ihl := int(b[0]&0x0f) * 4
+	if ihl < ipv4HeaderSize || ihl > len(b) {
+		return flow, ErrInvalidArgumentWithReason("IPv4 header length", ihl, "invalid")
+	}
+
+	flow.proto = b[9]
+	flow.src = binary.BigEndian.Uint32(b[12:16])
+	flow.dst = binary.BigEndian.Uint32(b[16:20])
+
+	// Only the first fragment has the L4 header.
+	fragOffset := binary.BigEndian.Uint16(b[6:8]) & 0x1fff
+
+	switch flow.proto {
+	case 6, 17, 132: // TCP, UDP, SCTP
+		if fragOffset == 0 && len(b) >= ihl+4 {
+			flow.srcPort = binary.BigEndian.Uint16(b[ihl:])
+			flow.dstPort = binary.BigEndian.Uint16(b[ihl+2:])
+			flow.hasPorts = true
+		}
+	}
+
+	return flow, nil
+}
+
+func (pr portRange) matches(port uint16, hasPort bool) bool {
+	if pr.isWildcardMatch() {
+		return true
+	}
+
+	return hasPort && pr.low <= port && port <= pr.high
+}
+
+// matches returns true if the packet of the flow matches the application
+// filter. The filter holds the addresses and ports of the packets, as sent.
+func (af applicationFilter) matches(flow ipv4Flow) bool {
+	if af.ipv6Only {
+		return false
+	}
+
+	return flow.src&af.srcIPMask == af.srcIP&af.srcIPMask &&
+		flow.dst&af.dstIPMask == af.dstIP&af.dstIPMask &&
+		flow.proto&af.protoMask == af.proto&af.protoMask &&
+		af.srcPortRange.matches(flow.srcPort, flow.hasPorts) &&
+		af.dstPortRange.matches(flow.dstPort, flow.hasPorts)
+}
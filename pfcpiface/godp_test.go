@@ -0,0 +1,288 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+// godpPeers are the sockets of a gNB and a data network connected to a Go
+// datapath.
+type godpPeers struct {
+	gnb  *net.UDPConn
+	dn   *net.UDPConn
+	n3   *net.UDPAddr
+	n6   *net.UDPAddr
+	upf  *upf
+	port uint16
+}
+
+func listenUDP(t *testing.T) *net.UDPConn {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// newTestGodp returns a Go datapath exchanging its N6 packets over UDP.
+func newTestGodp(t *testing.T) (*godp, godpPeers) {
+	t.Helper()
+
+	peers := godpPeers{
+		gnb: listenUDP(t),
+		dn:  listenUDP(t),
+		upf: &upf{reportNotifyChan: make(chan uint64, 8), errorIndicationChan: make(chan uint64, 8)},
+	}
+
+	conf := &Conf{
+		EnableGoDatapath: true,
+		GoDatapath: GoDatapathInfo{
+			N3Addr: "127.0.0.1:0",
+			N6Mode: godpN6ModeUDP,
+			N6Addr: "127.0.0.1:0",
+			N6Peer: peers.dn.LocalAddr().String(),
+		},
+	}
+
+	dp := &godp{}
+	dp.SetUpfInfo(peers.upf, conf)
+
+	if !dp.IsConnected(nil) {
+		t.Fatal("Go datapath not connected")
+	}
+
+	t.Cleanup(dp.Exit)
+
+	peers.n3 = dp.n3.LocalAddr().(*net.UDPAddr)
+	peers.n6 = dp.n6.(*godpUDPN6).conn.LocalAddr().(*net.UDPAddr)
+	peers.port = uint16(peers.gnb.LocalAddr().(*net.UDPAddr).Port)
+
+	return dp, peers
+}
+
+// udpPacket returns an IPv4 packet carrying a UDP datagram.
+func udpPacket(src, dst string, srcPort, dstPort uint16, payload string) []byte {
+	b := make([]byte, ipv4HeaderSize+udpHeaderSize, ipv4HeaderSize+udpHeaderSize+len(payload))
+	b[0] = 0x45
+	binary.BigEndian.PutUint16(b[2:], uint16(cap(b)))
+	b[8] = 64
+	b[9] = 17
+	copy(b[12:16], net.ParseIP(src).To4())
+	copy(b[16:20], net.ParseIP(dst).To4())
+	binary.BigEndian.PutUint16(b[20:], srcPort)
+	binary.BigEndian.PutUint16(b[22:], dstPort)
+	binary.BigEndian.PutUint16(b[24:], uint16(udpHeaderSize+len(payload)))
+
+	return append(b, payload...)
+}
+
+func send(t *testing.T, conn *net.UDPConn, to *net.UDPAddr, b []byte) {
+	t.Helper()
+
+	if _, err := conn.WriteToUDP(b, to); err != nil {
+		t.Fatalf("failed to send to %v: %v", to, err)
+	}
+}
+
+func receive(t *testing.T, conn *net.UDPConn) []byte {
+	t.Helper()
+
+	buf := make([]byte, godpMaxPacketSize)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("expected a packet on %v: %v", conn.LocalAddr(), err)
+	}
+
+	return buf[:n]
+}
+
+func expectNothing(t *testing.T, conn *net.UDPConn) {
+	t.Helper()
+
+	buf := make([]byte, godpMaxPacketSize)
+
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+
+	if n, err := conn.Read(buf); err == nil {
+		t.Fatalf("expected no packet on %v, got %x", conn.LocalAddr(), buf[:n])
+	}
+}
+
+func receiveGPDU(t *testing.T, conn *net.UDPConn) (gtpuHeader, []byte) {
+	t.Helper()
+
+	h, payload, err := parseGTPU(receive(t, conn))
+	if err != nil {
+		t.Fatalf("failed to parse GTP-U message: %v", err)
+	}
+
+	return h, payload
+}
+
+func TestGodpSession(t *testing.T) {
+	dp, peers := newTestGodp(t)
+
+	rules := up4Rules(1, "10.250.0.1", "127.0.0.1", 0x10)
+	rules.fars[1].tunnelPort = peers.port
+
+	if err := writeRules(dp, upfMsgTypeAdd, PacketForwardingRules{}, rules); err != nil {
+		t.Fatalf("failed to install session: %v", err)
+	}
+
+	t.Run("uplink", func(t *testing.T) {
+		pkt := udpPacket("10.250.0.1", "192.0.2.1", 1000, 80, "uplink")
+		send(t, peers.gnb, peers.n3, encapGTPU(0x10, 9, pkt))
+
+		if got := receive(t, peers.dn); !bytes.Equal(got, pkt) {
+			t.Errorf("expected %x on N6, got %x", pkt, got)
+		}
+	})
+
+	t.Run("uplink application", func(t *testing.T) {
+		pkt := udpPacket("10.250.0.1", "8.8.8.8", 1000, 53, "dns")
+		send(t, peers.gnb, peers.n3, encapGTPU(0x10, 0, pkt))
+		receive(t, peers.dn)
+
+		counters, _ := dp.UsageCounters()
+		if c := counters[pdrCounterKey{fseID: 1, pdrID: 2}]; c.packets != 1 || c.bytes != uint64(len(pkt)) {
+			t.Errorf("unexpected counters of PDR 2: %v", c)
+		}
+
+		if c := counters[pdrCounterKey{fseID: 1, pdrID: 1}]; c.packets != 1 {
+			t.Errorf("unexpected counters of PDR 1: %v", c)
+		}
+	})
+
+	t.Run("downlink", func(t *testing.T) {
+		pkt := udpPacket("192.0.2.1", "10.250.0.1", 80, 1000, "downlink")
+		send(t, peers.dn, peers.n6, pkt)
+
+		h, payload := receiveGPDU(t, peers.gnb)
+		if h.msgType != gtpuGPDU || h.teid != 0x11 || h.qfi != 9 || !bytes.Equal(payload, pkt) {
+			t.Errorf("unexpected G-PDU %+v carrying %x", h, payload)
+		}
+	})
+
+	t.Run("buffering", func(t *testing.T) {
+		buffer := rules.fars[1]
+		buffer.applyAction = ActionBuffer | ActionNotify
+
+		if err := dp.UpdateFAR(context.Background(), buffer); err != nil {
+			t.Fatalf("failed to update FAR: %v", err)
+		}
+
+		pkt := udpPacket("192.0.2.1", "10.250.0.1", 80, 1000, "buffered")
+		send(t, peers.dn, peers.n6, pkt)
+
+		select {
+		case fseID := <-peers.upf.reportNotifyChan:
+			if fseID != 1 {
+				t.Errorf("expected notification of session 1, got %v", fseID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("expected a downlink data notification")
+		}
+
+		expectNothing(t, peers.gnb)
+
+		if err := dp.UpdateFAR(context.Background(), rules.fars[1]); err != nil {
+			t.Fatalf("failed to update FAR: %v", err)
+		}
+
+		if _, payload := receiveGPDU(t, peers.gnb); !bytes.Equal(payload, pkt) {
+			t.Errorf("expected buffered packet %x, got %x", pkt, payload)
+		}
+	})
+
+	t.Run("closed gate", func(t *testing.T) {
+		closed := rules.qers[0]
+		closed.dlStatus = ie.GateStatusClosed
+
+		if err := dp.UpdateQER(context.Background(), closed); err != nil {
+			t.Fatalf("failed to update QER: %v", err)
+		}
+
+		send(t, peers.dn, peers.n6, udpPacket("192.0.2.1", "10.250.0.1", 80, 1000, "dropped"))
+		expectNothing(t, peers.gnb)
+
+		if err := dp.UpdateQER(context.Background(), rules.qers[0]); err != nil {
+			t.Fatalf("failed to update QER: %v", err)
+		}
+	})
+
+	t.Run("echo", func(t *testing.T) {
+		send(t, peers.gnb, peers.n3, gtpuSignalling(gtpuEchoRequest, 7, nil))
+
+		if h, _ := receiveGPDU(t, peers.gnb); h.msgType != gtpuEchoResponse || h.seq != 7 {
+			t.Errorf("unexpected echo response %+v", h)
+		}
+	})
+
+	t.Run("unknown TEID", func(t *testing.T) {
+		send(t, peers.gnb, peers.n3, encapGTPU(0x99, 0, udpPacket("10.250.0.1", "192.0.2.1", 1000, 80, "")))
+
+		h, ies := receiveGPDU(t, peers.gnb)
+		if h.msgType != gtpuErrorIndication {
+			t.Fatalf("expected an error indication, got %+v", h)
+		}
+
+		if teid, peerIP, err := parseErrorIndication(ies); err != nil || teid != 0x99 || int2ip(peerIP).String() != "127.0.0.1" {
+			t.Errorf("unexpected error indication of TEID %x from %v: %v", teid, int2ip(peerIP), err)
+		}
+	})
+
+	t.Run("deletion", func(t *testing.T) {
+		if err := writeRules(dp, upfMsgTypeDel, PacketForwardingRules{}, rules); err != nil {
+			t.Fatalf("failed to delete session: %v", err)
+		}
+
+		if len(dp.sessions) != 0 || len(dp.uplink) != 0 || len(dp.downlink) != 0 {
+			t.Errorf("expected no state, got %v sessions, %v uplink and %v downlink PDRs",
+				len(dp.sessions), len(dp.uplink), len(dp.downlink))
+		}
+
+		send(t, peers.dn, peers.n6, udpPacket("192.0.2.1", "10.250.0.1", 80, 1000, "dropped"))
+		expectNothing(t, peers.gnb)
+	})
+}
+
+func TestGodpBufferLimit(t *testing.T) {
+	dp := &godp{sessions: make(map[uint64]*godpSession), buffers: make(map[godpFARKey][]godpPacket)}
+	f := far{farID: 1, fseID: 1, applyAction: ActionBuffer, bufferPackets: 2, bufferLimited: true}
+	key := godpFARKey{fseID: 1, farID: 1}
+
+	for i := 0; i < 3; i++ {
+		dp.buffer(f, godpPacket{data: []byte{byte(i)}})
+	}
+
+	if n := len(dp.buffers[key]); n != 2 {
+		t.Fatalf("expected 2 buffered packets, got %d", n)
+	}
+
+	// A zero DL Buffering Duration discards the buffered packets.
+	f.bufferPackets = 0
+	if err := dp.UpdateFAR(context.Background(), f); err != nil {
+		t.Fatalf("failed to update FAR: %v", err)
+	}
+
+	if n := len(dp.buffers[key]); n != 0 {
+		t.Errorf("expected no buffered packets, got %d", n)
+	}
+}
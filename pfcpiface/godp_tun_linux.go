@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// godpTunN6 exchanges the IP packets with the kernel through a TUN device.
+// The device is brought up and routed by the operator.
+type godpTunN6 struct {
+	file *os.File
+}
+
+func newTunN6(name string) (godpN6, error) {
+	// Non-blocking, so that the file is closed while being read.
+	fd, err := unix.Open("/dev/net/tun", unix.O_RDWR|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ifr, err := unix.NewIfreq(name)
+	if err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+
+	ifr.SetUint16(unix.IFF_TUN | unix.IFF_NO_PI)
+
+	if err := unix.IoctlIfreq(fd, unix.TUNSETIFF, ifr); err != nil {
+		unix.Close(fd)
+		return nil, ErrOperationFailedWithReason("TUN device creation", err.Error())
+	}
+
+	return &godpTunN6{file: os.NewFile(uintptr(fd), "/dev/net/tun")}, nil
+}
+
+func (n *godpTunN6) read(b []byte) (int, error) {
+	return n.file.Read(b)
+}
+
+func (n *godpTunN6) write(b []byte) error {
+	_, err := n.file.Write(b)
+	return err
+}
+
+func (n *godpTunN6) close() error {
+	return n.file.Close()
+}
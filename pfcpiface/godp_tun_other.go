@@ -0,0 +1,14 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+//go:build !linux
+
+package pfcpiface
+
+import (
+	"runtime"
+)
+
+func newTunN6(name string) (godpN6, error) {
+	return nil, ErrUnsupported("TUN N6 interface", runtime.GOOS)
+}
@@ -265,13 +265,19 @@ func (u *upf) sim(mode simMode, s *SimModeInfo) {
 			qers: qers,
 		}
 
+		var err error
+
 		if mode.create() {
-			u.SendMsgToUPF(upfMsgTypeAdd, allRules, PacketForwardingRules{})
+			err = u.installRules(allRules)
 		} else if mode.delete() {
-			u.SendMsgToUPF(upfMsgTypeDel, allRules, PacketForwardingRules{})
+			err = u.removeRules(allRules)
 		} else {
 			logger.PfcpLog.Fatalln("unsupported method", mode)
 		}
+
+		if err != nil {
+			logger.PfcpLog.Errorf("failed to write simulated session %v: %v", i, err)
+		}
 	}
 
 	logger.PfcpLog.Infoln("sessions/s:", float64(s.MaxSessions)/time.Since(start).Seconds())
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import "fmt"
+
+const (
+	healthOK          = "ok"
+	healthUnavailable = "unavailable"
+)
+
+// healthCheck is the result of one of the checks of the state of the UPF.
+// Only the failure of a required check makes the UPF unhealthy.
+type healthCheck struct {
+	Name     string `json:"name"`
+	Healthy  bool   `json:"healthy"`
+	Required bool   `json:"required"`
+	Message  string `json:"message"`
+}
+
+// healthReport is the result of the liveness or readiness checks served by
+// /healthz and /readyz.
+type healthReport struct {
+	Status string        `json:"status"`
+	Checks []healthCheck `json:"checks"`
+}
+
+func (r *healthReport) add(name string, healthy, required bool, format string, args ...any) {
+	r.Checks = append(r.Checks, healthCheck{
+		Name:     name,
+		Healthy:  healthy,
+		Required: required,
+		Message:  fmt.Sprintf(format, args...),
+	})
+
+	if required && !healthy {
+		r.Status = healthUnavailable
+	}
+}
+
+// health checks the state of the UPF. The UPF is live as long as it accepts
+// PFCP connections; it is ready once its datapath is connected and until it
+// drains, shuts down or runs out of UE IP addresses. No association is
+// required, so that the N4 traffic of the SMF can be steered to a UPF not yet
+// associated.
+func (node *PFCPNode) health(readiness bool) healthReport {
+	r := healthReport{Status: healthOK, Checks: make([]healthCheck, 0)}
+
+	if node.listening.Load() {
+		r.add("pfcp_listener", true, true, "accepting PFCP connections on %v", node.LocalAddr())
+	} else {
+		r.add("pfcp_listener", false, true, "not accepting PFCP connections")
+	}
+
+	switch {
+	case node.ctx.Err() != nil:
+		r.add("shutdown", false, readiness, "shutting down")
+	case node.draining():
+		r.add("shutdown", false, readiness, "draining")
+	default:
+		r.add("shutdown", true, readiness, "running")
+	}
+
+	if node.upf.isConnected() {
+		r.add("datapath", true, readiness, "connected")
+	} else {
+		r.add("datapath", false, readiness, "not connected")
+	}
+
+	n := len(node.associatedConns())
+	r.add("associations", n > 0, false, "%d associations established", n)
+
+	if node.upf.enableUeIPAlloc && node.upf.ippool != nil {
+		addrs, prefixes6, ipv6 := node.upf.ippool.available()
+
+		if ipv6 {
+			r.add("ue_ip_pool", addrs > 0 && prefixes6 > 0, readiness,
+				"%d IPv4 addresses and %d IPv6 prefixes left", addrs, prefixes6)
+		} else {
+			r.add("ue_ip_pool", addrs > 0, readiness, "%d IPv4 addresses left", addrs)
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthHandlers(t *testing.T) {
+	pool, err := NewIPPool("10.250.0.0/30")
+	if err != nil {
+		t.Fatalf("failed to create IP pool: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	node := &PFCPNode{
+		ctx:        ctx,
+		cancel:     cancel,
+		PacketConn: listenUDP(t),
+		upf:        &upf{datapath: &fakeDP{}, enableUeIPAlloc: true, ippool: pool},
+	}
+
+	mux := http.NewServeMux()
+	setupHealthHandlers(mux, node)
+
+	// probe checks the status of the probe and returns its checks by name.
+	probe := func(t *testing.T, target string, status int) map[string]healthCheck {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+		if w.Code != status {
+			t.Fatalf("expected status %v, got %v: %s", status, w.Code, w.Body)
+		}
+
+		var report healthReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("failed to decode %s: %v", w.Body, err)
+		}
+
+		checks := make(map[string]healthCheck)
+		for _, c := range report.Checks {
+			checks[c.Name] = c
+		}
+
+		return checks
+	}
+
+	t.Run("not listening", func(t *testing.T) {
+		probe(t, "/healthz", http.StatusServiceUnavailable)
+		probe(t, "/readyz", http.StatusServiceUnavailable)
+	})
+
+	node.listening.Store(true)
+
+	t.Run("ready without association", func(t *testing.T) {
+		checks := probe(t, "/readyz", http.StatusOK)
+
+		if c := checks["associations"]; c.Healthy || c.Required {
+			t.Errorf("expected an optional failed association check, got %+v", c)
+		}
+
+		if c := checks["ue_ip_pool"]; !c.Healthy || c.Message != "2 IPv4 addresses left" {
+			t.Errorf("unexpected UE IP pool check %+v", c)
+		}
+	})
+
+	t.Run("UE IP pool exhausted", func(t *testing.T) {
+		for seid := uint64(1); seid <= 2; seid++ {
+			if _, err := pool.LookupOrAllocIP(seid); err != nil {
+				t.Fatalf("failed to allocate IP: %v", err)
+			}
+		}
+
+		probe(t, "/healthz", http.StatusOK)
+
+		if c := probe(t, "/readyz", http.StatusServiceUnavailable)["ue_ip_pool"]; c.Healthy {
+			t.Errorf("expected UE IP pool check to fail, got %+v", c)
+		}
+
+		if err := pool.DeallocIP(1); err != nil {
+			t.Fatalf("failed to release IP: %v", err)
+		}
+	})
+
+	t.Run("shutting down", func(t *testing.T) {
+		cancel()
+
+		probe(t, "/healthz", http.StatusOK)
+
+		if c := probe(t, "/readyz", http.StatusServiceUnavailable)["shutdown"]; c.Healthy {
+			t.Errorf("expected shutdown check to fail, got %+v", c)
+		}
+	})
+}
@@ -5,6 +5,8 @@ package pfcpiface
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"net"
 	"strings"
 	"sync"
@@ -17,6 +19,16 @@ type IPPool struct {
 	freePool []net.IP
 	// inventory keeps track of allocated sessions and their IPs.
 	inventory map[uint64]net.IP
+
+	// IPv6 prefixes are allocated lazily, as IPv6 pools are too large to be
+	// enumerated upfront.
+	subnet6      *net.IPNet
+	prefixLen6   int
+	numPrefixes6 uint64
+	nextPrefix6  uint64
+	freePool6    []*net.IPNet
+	// inventory6 keeps track of allocated sessions and their IPv6 prefixes.
+	inventory6 map[uint64]*net.IPNet
 }
 
 // NewIPPool creates a new pool of IP addresses with the given subnet.
@@ -74,23 +86,223 @@ func (i *IPPool) LookupOrAllocIP(seid uint64) (net.IP, error) {
 	return ipVal, nil
 }
 
+// SetIPv6Pool enables the allocation of IPv6 prefixes of length prefixLen
+// from the given subnet.
+func (i *IPPool) SetIPv6Pool(poolSubnet string, prefixLen int) error {
+	_, ipnet, err := net.ParseCIDR(poolSubnet)
+	if err != nil {
+		return err
+	}
+
+	ones, bits := ipnet.Mask.Size()
+	if bits != net.IPv6len*8 {
+		return ErrInvalidArgumentWithReason("SetIPv6Pool", poolSubnet, "pool subnet is not an IPv6 subnet")
+	}
+
+	if prefixLen < ones || prefixLen > bits {
+		return ErrInvalidArgumentWithReason("SetIPv6Pool", prefixLen, "prefix length does not fit in the pool subnet")
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.subnet6 = ipnet
+	i.prefixLen6 = prefixLen
+	i.numPrefixes6 = math.MaxUint64
+
+	if prefixLen-ones < 64 {
+		i.numPrefixes6 = 1 << (prefixLen - ones)
+	}
+
+	i.nextPrefix6 = 0
+	i.freePool6 = nil
+	i.inventory6 = make(map[uint64]*net.IPNet)
+
+	return nil
+}
+
+// nthPrefix6 returns the n-th prefix of the IPv6 pool.
+func (i *IPPool) nthPrefix6(n uint64) *net.IPNet {
+	offset := new(big.Int).Lsh(new(big.Int).SetUint64(n), uint(net.IPv6len*8-i.prefixLen6))
+	addr := new(big.Int).Add(new(big.Int).SetBytes(i.subnet6.IP), offset)
+
+	ip := make(net.IP, net.IPv6len)
+	addr.FillBytes(ip)
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(i.prefixLen6, net.IPv6len*8)}
+}
+
+// LookupOrAllocIPv6Prefix returns the IPv6 prefix allocated to the session,
+// allocating a new one if needed.
+func (i *IPPool) LookupOrAllocIPv6Prefix(seid uint64) (*net.IPNet, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.subnet6 == nil {
+		return nil, ErrOperationFailedWithReason("IPv6 prefix allocation", "no IPv6 pool configured")
+	}
+
+	prefix, found := i.inventory6[seid]
+	if found {
+		logger.PfcpLog.Debugln("found existing session", seid, "IPv6 prefix", prefix)
+		return copyIPNet(prefix), nil
+	}
+
+	switch {
+	case len(i.freePool6) > 0:
+		prefix = i.freePool6[0]
+		i.freePool6 = i.freePool6[1:]
+	case i.nextPrefix6 < i.numPrefixes6:
+		prefix = i.nthPrefix6(i.nextPrefix6)
+		i.nextPrefix6++
+	default:
+		return nil, ErrOperationFailedWithReason("IPv6 prefix allocation", "ip pool empty")
+	}
+
+	i.inventory6[seid] = prefix
+	logger.PfcpLog.Debugln("allocated new session", seid, "IPv6 prefix", prefix)
+
+	return copyIPNet(prefix), nil
+}
+
+// ReserveIP allocates the given IPv4 address to the session, e.g. to restore
+// the allocations of the sessions kept across restarts.
+func (i *IPPool) ReserveIP(seid uint64, ip net.IP) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if cur, found := i.inventory[seid]; found {
+		if cur.Equal(ip) {
+			return nil
+		}
+
+		return ErrInvalidArgumentWithReason("seid", seid, "session already has an IP")
+	}
+
+	for n, free := range i.freePool {
+		if free.Equal(ip) {
+			i.freePool = append(i.freePool[:n], i.freePool[n+1:]...)
+			i.inventory[seid] = free
+			logger.PfcpLog.Debugln("reserved session", seid, "IP", free)
+
+			return nil
+		}
+	}
+
+	return ErrNotFoundWithParam("free IP", "IP", ip)
+}
+
+// ReserveIPv6Prefix allocates the given IPv6 prefix to the session.
+func (i *IPPool) ReserveIPv6Prefix(seid uint64, prefix *net.IPNet) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.subnet6 == nil {
+		return ErrOperationFailedWithReason("IPv6 prefix reservation", "no IPv6 pool configured")
+	}
+
+	if ones, _ := prefix.Mask.Size(); ones != i.prefixLen6 || !i.subnet6.Contains(prefix.IP) {
+		return ErrInvalidArgumentWithReason("prefix", prefix, "prefix is not part of the IPv6 pool")
+	}
+
+	if _, found := i.inventory6[seid]; found {
+		return ErrInvalidArgumentWithReason("seid", seid, "session already has an IPv6 prefix")
+	}
+
+	offset := new(big.Int).Sub(new(big.Int).SetBytes(prefix.IP.To16()), new(big.Int).SetBytes(i.subnet6.IP))
+	n := offset.Rsh(offset, uint(net.IPv6len*8-i.prefixLen6)).Uint64()
+
+	if n >= i.nextPrefix6 {
+		// Prefixes skipped by the reservation remain available.
+		for ; i.nextPrefix6 < n; i.nextPrefix6++ {
+			i.freePool6 = append(i.freePool6, i.nthPrefix6(i.nextPrefix6))
+		}
+
+		i.nextPrefix6 = n + 1
+		i.inventory6[seid] = i.nthPrefix6(n)
+
+		return nil
+	}
+
+	for k, free := range i.freePool6 {
+		if free.IP.Equal(prefix.IP) {
+			i.freePool6 = append(i.freePool6[:k], i.freePool6[k+1:]...)
+			i.inventory6[seid] = free
+
+			return nil
+		}
+	}
+
+	return ErrNotFoundWithParam("free IPv6 prefix", "prefix", prefix)
+}
+
+// DeallocIP releases the IPv4 address and IPv6 prefix allocated to the session.
 func (i *IPPool) DeallocIP(seid uint64) error {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
 	ip, ok := i.inventory[seid]
-	if !ok {
+	prefix, ok6 := i.inventory6[seid]
+
+	if !ok && !ok6 {
 		logger.PfcpLog.Warnln("attempt to dealloc non-existent session", seid)
 		return ErrInvalidArgumentWithReason("seid", seid, "can't dealloc non-existent session")
 	}
 
-	delete(i.inventory, seid)
-	i.freePool = append(i.freePool, ip) // Simply append to enqueue.
-	logger.PfcpLog.Debugln("deallocated session", seid, "IP", ip)
+	if ok {
+		delete(i.inventory, seid)
+		i.freePool = append(i.freePool, ip) // Simply append to enqueue.
+		logger.PfcpLog.Debugln("deallocated session", seid, "IP", ip)
+	}
+
+	if ok6 {
+		delete(i.inventory6, seid)
+		i.freePool6 = append(i.freePool6, prefix)
+		logger.PfcpLog.Debugln("deallocated session", seid, "IPv6 prefix", prefix)
+	}
 
 	return nil
 }
 
+// Allocated tells whether an IPv4 address or an IPv6 prefix is allocated to
+// the session.
+func (i *IPPool) Allocated(seid uint64) bool {
+	if i == nil {
+		return false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	_, ok := i.inventory[seid]
+	_, ok6 := i.inventory6[seid]
+
+	return ok || ok6
+}
+
+func copyIPNet(n *net.IPNet) *net.IPNet {
+	ip := make(net.IP, len(n.IP))
+	copy(ip, n.IP)
+
+	mask := make(net.IPMask, len(n.Mask))
+	copy(mask, n.Mask)
+
+	return &net.IPNet{IP: ip, Mask: mask}
+}
+
+// available returns the number of IPv4 addresses left in the pool, and the
+// number of IPv6 prefixes left if an IPv6 pool is configured.
+func (i *IPPool) available() (addrs int, prefixes6 uint64, ipv6 bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.subnet6 != nil {
+		prefixes6, ipv6 = i.numPrefixes6-i.nextPrefix6+uint64(len(i.freePool6)), true
+	}
+
+	return len(i.freePool), prefixes6, ipv6
+}
+
 func (i *IPPool) String() string {
 	i.mu.Lock()
 	defer i.mu.Unlock()
@@ -102,7 +314,16 @@ func (i *IPPool) String() string {
 		fmt.Fprintf(&sb, "{F-SEID %v -> %+v} ", s, e)
 	}
 
+	for s, e := range i.inventory6 {
+		fmt.Fprintf(&sb, "{F-SEID %v -> %v} ", s, e)
+	}
+
 	fmt.Fprintf(&sb, "Number of free IP addresses left: %d", len(i.freePool))
 
+	if i.subnet6 != nil {
+		fmt.Fprintf(&sb, ", number of free IPv6 prefixes left: %d",
+			i.numPrefixes6-i.nextPrefix6+uint64(len(i.freePool6)))
+	}
+
 	return sb.String()
 }
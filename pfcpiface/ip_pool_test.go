@@ -191,3 +191,140 @@ func TestIPPool_DeallocIP(t *testing.T) {
 		}
 	})
 }
+
+func TestIPPool_LookupOrAllocIPv6Prefix(t *testing.T) {
+	t.Run("no IPv6 pool", func(t *testing.T) {
+		pool, err := NewIPPool(ipSubnetCIDR)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err = pool.LookupOrAllocIPv6Prefix(1); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+
+	t.Run("invalid IPv6 pools", func(t *testing.T) {
+		pool, err := NewIPPool(ipSubnetCIDR)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err = pool.SetIPv6Pool("10.0.0.0/8", 64); err == nil {
+			t.Error("expected an error for an IPv4 subnet, but got nil")
+		}
+		if err = pool.SetIPv6Pool("2001:db8::/64", 48); err == nil {
+			t.Error("expected an error for a prefix larger than the pool, but got nil")
+		}
+	})
+
+	t.Run("allocate, lookup and dealloc", func(t *testing.T) {
+		pool, err := NewIPPool(ipSubnetCIDR)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err = pool.SetIPv6Pool("2001:db8:1::/32", 64); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		first, err := pool.LookupOrAllocIPv6Prefix(1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if first.String() != "2001:db8::/64" {
+			t.Errorf("unexpected first prefix %v", first)
+		}
+
+		second, err := pool.LookupOrAllocIPv6Prefix(2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if second.String() != "2001:db8:0:1::/64" {
+			t.Errorf("unexpected second prefix %v", second)
+		}
+
+		again, err := pool.LookupOrAllocIPv6Prefix(1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if again.String() != first.String() {
+			t.Errorf("expected %v for existing session, got %v", first, again)
+		}
+
+		// Dual-stack sessions release both the IPv4 address and IPv6 prefix.
+		if _, err = pool.LookupOrAllocIP(1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err = pool.DeallocIP(1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err = pool.DeallocIP(1); err == nil {
+			t.Error("expected an error for a released session, but got nil")
+		}
+
+		reused, err := pool.LookupOrAllocIPv6Prefix(3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reused.String() != first.String() {
+			t.Errorf("expected released prefix %v to be reused, got %v", first, reused)
+		}
+	})
+
+	t.Run("exhausted pool", func(t *testing.T) {
+		pool, err := NewIPPool(ipSubnetCIDR)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err = pool.SetIPv6Pool("2001:db8::/63", 64); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for seid := uint64(0); seid < 2; seid++ {
+			if _, err = pool.LookupOrAllocIPv6Prefix(seid); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if _, err = pool.LookupOrAllocIPv6Prefix(2); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
+
+func TestIPPool_Reserve(t *testing.T) {
+	pool, err := NewIPPool(ipSubnetCIDR)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = pool.SetIPv6Pool("2001:db8::/48", 64); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ip := net.ParseIP("10.0.0.5").To4()
+	if err = pool.ReserveIP(1, ip); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = pool.ReserveIP(2, ip); err == nil {
+		t.Error("expected an error for a reserved IP, but got nil")
+	}
+	if got, err := pool.LookupOrAllocIP(1); err != nil || !got.Equal(ip) {
+		t.Errorf("expected reserved IP %v, got %v (err: %v)", ip, got, err)
+	}
+
+	_, prefix, _ := net.ParseCIDR("2001:db8:0:2::/64")
+	if err = pool.ReserveIPv6Prefix(1, prefix); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Prefixes skipped by the reservation are still allocated.
+	for i, want := range []string{"2001:db8::/64", "2001:db8:0:1::/64", "2001:db8:0:3::/64"} {
+		seid := uint64(i + 2)
+
+		got, err := pool.LookupOrAllocIPv6Prefix(seid)
+		if err != nil || got.String() != want {
+			t.Errorf("expected prefix %v for session %v, got %v (err: %v)", want, seid, got, err)
+		}
+	}
+
+	_, outside, _ := net.ParseCIDR("2001:db9::/64")
+	if err = pool.ReserveIPv6Prefix(5, outside); err == nil {
+		t.Error("expected an error for a prefix outside the pool, but got nil")
+	}
+}
@@ -102,6 +102,18 @@ func (pConn *PFCPConn) HandlePFCPMsg(buf []byte) {
 	}
 
 	msgType := msg.MessageTypeName()
+
+	cacheReply := cachedRequestTypes[msg.MessageType()]
+	if cacheReply {
+		if cached, ok := pConn.responses.get(msg.Sequence(), msg.MessageType(), time.Now()); ok {
+			logger.PfcpLog.Infof("retransmitted %s from %s, seq: %d, resending previous response",
+				msgType, addr, msg.Sequence())
+			pConn.SendPFCPMsg(cached)
+
+			return
+		}
+	}
+
 	m := metrics.NewMessage(msgType, "Incoming")
 
 	switch msg.MessageType() {
@@ -115,7 +127,6 @@ func (pConn *PFCPConn) HandlePFCPMsg(buf []byte) {
 		if reply != nil && err == nil && pConn.upf.enableHBTimer {
 			go pConn.startHeartBeatMonitor()
 		}
-		// TODO: Cleanup sessions
 
 	case message.MsgTypeAssociationReleaseRequest:
 		reply, err = pConn.handleAssociationReleaseRequest(msg)
@@ -133,7 +144,9 @@ func (pConn *PFCPConn) HandlePFCPMsg(buf []byte) {
 
 	// Incoming response messages
 	// TODO: Session Report Request
-	case message.MsgTypeAssociationSetupResponse, message.MsgTypeHeartbeatResponse:
+	case message.MsgTypeAssociationSetupResponse, message.MsgTypeHeartbeatResponse,
+		message.MsgTypeNodeReportResponse, message.MsgTypeAssociationUpdateResponse,
+		message.MsgTypeAssociationReleaseResponse:
 		pConn.handleIncomingResponse(msg)
 
 	default:
@@ -141,7 +154,7 @@ func (pConn *PFCPConn) HandlePFCPMsg(buf []byte) {
 		return
 	}
 
-	nodeID := pConn.nodeID.remote
+	nodeID := pConn.remoteNodeID()
 	// Check for errors in handling the message
 	if err != nil {
 		m.Finish(nodeID, "Failure")
@@ -154,13 +167,24 @@ func (pConn *PFCPConn) HandlePFCPMsg(buf []byte) {
 	pConn.SaveMessages(m)
 
 	if reply != nil {
+		if cacheReply {
+			pConn.responses.put(msg.Sequence(), msg.MessageType(), reply, time.Now(), pConn.retransmissionWindow())
+		}
+
 		pConn.SendPFCPMsg(reply)
 	}
 }
 
 func (pConn *PFCPConn) SendPFCPMsg(msg message.Message) {
+	// The sessions restored from the session store have no association until
+	// their CP function sets up a new one, their reports are dropped.
+	if pConn.Conn == nil {
+		logger.PfcpLog.Warnf("dropping %v for %v without PFCP association", msg.MessageTypeName(), pConn.remoteNodeID())
+		return
+	}
+
 	addr := pConn.RemoteAddr().String()
-	nodeID := pConn.nodeID.remote
+	nodeID := pConn.remoteNodeID()
 	msgType := msg.MessageTypeName()
 
 	m := metrics.NewMessage(msgType, "Outgoing")
@@ -187,19 +211,26 @@ func (pConn *PFCPConn) SendPFCPMsg(msg message.Message) {
 }
 
 func (pConn *PFCPConn) sendPFCPRequestMessage(r *Request) (message.Message, bool) {
+	if pConn.Conn == nil {
+		pConn.SendPFCPMsg(r.msg)
+		return nil, true
+	}
+
 	pConn.pendingReqs.Store(r.msg.Sequence(), r)
 
 	pConn.SendPFCPMsg(r.msg)
-	retriesLeft := pConn.upf.maxReqRetries
+	respTimeout, retriesLeft := pConn.upf.requestTimers()
 
 	for {
-		if reply, rc := r.GetResponse(pConn.shutdown, pConn.upf.respTimeout); rc {
+		if reply, rc := r.GetResponse(pConn.shutdown, respTimeout); rc {
 			logger.PfcpLog.Debugln("request timeout, retries left:", retriesLeft)
 
 			if retriesLeft > 0 {
 				pConn.SendPFCPMsg(r.msg)
 				retriesLeft--
 			} else {
+				// Late responses must not block the reader.
+				pConn.pendingReqs.Delete(r.msg.Sequence())
 				return nil, true
 			}
 		} else {
@@ -6,6 +6,7 @@ package pfcpiface
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/omec-project/upf-epc/logger"
 	"github.com/wmnsk/go-pfcp/ie"
@@ -66,6 +67,8 @@ func (pConn *PFCPConn) handleHeartbeatRequest(msg message.Message) (message.Mess
 		return nil, errUnmarshal(errMsgUnexpectedType)
 	}
 
+	pConn.lastHeartbeat.Store(time.Now().UnixNano())
+
 	if pConn.upf.enableHBTimer {
 		// reset heartbeat expiry timer
 		// non-blocking write to channel
@@ -78,7 +81,11 @@ func (pConn *PFCPConn) handleHeartbeatRequest(msg message.Message) (message.Mess
 		}
 	}
 
-	// TODO: Check and update remote recovery timestamp
+	if hbreq.RecoveryTimeStamp != nil {
+		if ts, err := hbreq.RecoveryTimeStamp.RecoveryTimeStamp(); err == nil {
+			pConn.checkPeerRecovery(ts, "heartbeat Request")
+		}
+	}
 
 	// Build response message
 	hbres := message.NewHeartbeatResponse(hbreq.SequenceNumber,
@@ -88,6 +95,23 @@ func (pConn *PFCPConn) handleHeartbeatRequest(msg message.Message) (message.Mess
 	return hbres, nil
 }
 
+func (pConn *PFCPConn) handleHeartbeatResponse(msg message.Message) {
+	hbres, ok := msg.(*message.HeartbeatResponse)
+	if !ok {
+		return
+	}
+
+	pConn.lastHeartbeat.Store(time.Now().UnixNano())
+
+	if hbres.RecoveryTimeStamp == nil {
+		return
+	}
+
+	if ts, err := hbres.RecoveryTimeStamp.RecoveryTimeStamp(); err == nil {
+		pConn.checkPeerRecovery(ts, "heartbeat Response")
+	}
+}
+
 func (pConn *PFCPConn) handleIncomingResponse(msg message.Message) {
 	req, ok := pConn.pendingReqs.Load(msg.Sequence())
 
@@ -100,12 +124,25 @@ func (pConn *PFCPConn) handleIncomingResponse(msg message.Message) {
 func (pConn *PFCPConn) associationIEs() []*ie.IE {
 	upf := pConn.upf
 	networkInstance := string(ie.NewNetworkInstanceFQDN(upf.dnn).Payload)
-	flags := uint8(0x41)
+	// 0x40 = Spare (0) | Assoc Src Inst (1) | Assoc Net Inst (0) | Tied Range (000) | IPV6 (0) | IPV4 (0)
+	flags := uint8(0x40)
 
 	if len(upf.dnn) != 0 {
 		logger.PfcpLog.Infoln("association Setup with DNN:", upf.dnn)
 		// add ASSONI flag to set network instance.
-		flags = uint8(0x61)
+		flags |= 0x20
+	}
+
+	var accessIP4, accessIP6 string
+
+	if upf.accessIP.To4() != nil {
+		flags |= 0x01
+		accessIP4 = upf.accessIP.String()
+	}
+
+	if upf.accessIP6 != nil {
+		flags |= 0x02
+		accessIP6 = upf.accessIP6.String()
 	}
 
 	features := make([]uint8, 4)
@@ -123,9 +160,7 @@ func (pConn *PFCPConn) associationIEs() []*ie.IE {
 	ies := []*ie.IE{
 		ie.NewRecoveryTimeStamp(pConn.ts.local),
 		pConn.nodeID.localIE,
-		// 0x41 = Spare (0) | Assoc Src Inst (1) | Assoc Net Inst (0) | Tied Range (000) | IPV6 (0) | IPV4 (1)
-		//      = 01000001
-		ie.NewUserPlaneIPResourceInformation(flags, 0, upf.accessIP.String(), "", networkInstance, ie.SrcInterfaceAccess),
+		ie.NewUserPlaneIPResourceInformation(flags, 0, accessIP4, accessIP6, networkInstance, ie.SrcInterfaceAccess),
 		// ie.NewUserPlaneIPResourceInformation(0x41, 0, coreIP, "", "", ie.SrcInterfaceCore),
 		ie.NewUPFunctionFeatures(features...),
 	}
@@ -177,22 +212,15 @@ func (pConn *PFCPConn) handleAssociationSetupRequest(msg message.Message) (messa
 		return asres, errProcess(errDatapathDown)
 	}
 
-	if pConn.ts.remote.IsZero() {
-		pConn.ts.remote = ts
-		logger.PfcpLog.Infoln("association Setup Request from", addr,
-			"with recovery timestamp:", ts)
-	} else if ts.After(pConn.ts.remote) {
-		old := pConn.ts.remote
-		pConn.ts.remote = ts
-		logger.PfcpLog.Warnln("association Setup Request from", addr,
-			"with newer recovery timestamp:", ts, "older:", old)
-	}
+	pConn.checkPeerRecovery(ts, "association Setup Request")
 
-	pConn.nodeID.remote = nodeID
+	pConn.setRemoteNodeID(nodeID)
 	asres.Cause = ie.NewCause(ie.CauseRequestAccepted)
 
+	pConn.adoptOrphanedSessions()
+
 	logger.PfcpLog.Infoln("association setup done between nodes",
-		"local:", pConn.nodeID.local, "remote:", pConn.nodeID.remote)
+		"local:", pConn.nodeID.local, "remote:", pConn.remoteNodeID())
 
 	return asres, nil
 }
@@ -236,20 +264,13 @@ func (pConn *PFCPConn) handleAssociationSetupResponse(msg message.Message) error
 		return errUnmarshal(err)
 	}
 
-	if pConn.ts.remote.IsZero() {
-		pConn.ts.remote = ts
-		logger.PfcpLog.Infoln(msgAssociationSetupResponseFrom, addr,
-			"with recovery timestamp:", ts)
-	} else if ts.After(pConn.ts.remote) {
-		old := pConn.ts.remote
-		pConn.ts.remote = ts
-		logger.PfcpLog.Warnln(msgAssociationSetupResponseFrom, addr,
-			"with newer recovery timestamp:", ts, "older:", old)
-	}
+	pConn.checkPeerRecovery(ts, "association Setup Response")
 
-	pConn.nodeID.remote = nodeID
+	pConn.setRemoteNodeID(nodeID)
 	logger.PfcpLog.Infoln("association setup done between nodes",
-		"local:", pConn.nodeID.local, "remote:", pConn.nodeID.remote)
+		"local:", pConn.nodeID.local, "remote:", pConn.remoteNodeID())
+
+	pConn.adoptOrphanedSessions()
 
 	return nil
 }
@@ -270,6 +291,94 @@ func (pConn *PFCPConn) handleAssociationReleaseRequest(msg message.Message) (mes
 	return arres, nil
 }
 
+// gracefulReleasePeriod rounds the period down to a value that the Graceful
+// Release Period IE can encode, i.e. up to 31 times one of its timer units.
+func gracefulReleasePeriod(period time.Duration) time.Duration {
+	for _, unit := range []time.Duration{2 * time.Second, time.Minute, 10 * time.Minute, time.Hour, 10 * time.Hour} {
+		if period/unit <= 31 {
+			return period.Truncate(unit)
+		}
+	}
+
+	return 31 * 10 * time.Hour
+}
+
+// requestAssociationRelease asks the peer to release the association, with an
+// Association Update Request having the PFCP Association Release Request flag.
+// The peer is expected to follow with an Association Release Request, once its
+// sessions are deleted if a graceful release period is given.
+func (pConn *PFCPConn) requestAssociationRelease(gracePeriod time.Duration) error {
+	ies := []*ie.IE{pConn.nodeID.localIE, ie.NewPFCPAssociationReleaseRequest(1, 0)}
+	if period := gracefulReleasePeriod(gracePeriod); period > 0 {
+		ies = append(ies, ie.NewGracefulReleasePeriod(period))
+	}
+
+	aureq := message.NewAssociationUpdateRequest(pConn.getSeqNum(), ies...)
+
+	reply, timeout := pConn.sendPFCPRequestMessage(newRequest(aureq))
+	if timeout {
+		return ErrOperationFailedWithReason("association release", "no response from peer")
+	}
+
+	aures, ok := reply.(*message.AssociationUpdateResponse)
+	if !ok {
+		return ErrOperationFailedWithReason("association release", "association shut down")
+	}
+
+	if aures.Cause == nil {
+		return errUnmarshal(ErrCauseMissing)
+	}
+
+	cause, err := aures.Cause.Cause()
+	if err != nil {
+		return errUnmarshal(err)
+	}
+
+	if cause != ie.CauseRequestAccepted {
+		return ErrOperationFailedWithParam("association release", "cause", cause)
+	}
+
+	logger.PfcpLog.Infoln("requested association release to", pConn.remoteNodeID())
+
+	return nil
+}
+
+// releaseAssociation releases the association with an Association Release
+// Request. The connection is shut down and its sessions purged even if the
+// peer does not accept the release.
+func (pConn *PFCPConn) releaseAssociation() error {
+	defer pConn.Shutdown()
+
+	arreq := message.NewAssociationReleaseRequest(pConn.getSeqNum(), pConn.nodeID.localIE)
+
+	reply, timeout := pConn.sendPFCPRequestMessage(newRequest(arreq))
+	if timeout {
+		return ErrOperationFailedWithReason("association release", "no response from peer")
+	}
+
+	arres, ok := reply.(*message.AssociationReleaseResponse)
+	if !ok {
+		return ErrOperationFailedWithReason("association release", "association shut down")
+	}
+
+	if arres.Cause == nil {
+		return errUnmarshal(ErrCauseMissing)
+	}
+
+	cause, err := arres.Cause.Cause()
+	if err != nil {
+		return errUnmarshal(err)
+	}
+
+	if cause != ie.CauseRequestAccepted {
+		return ErrOperationFailedWithParam("association release", "cause", cause)
+	}
+
+	logger.PfcpLog.Infoln("released association with", pConn.remoteNodeID())
+
+	return nil
+}
+
 // parsePFDContents wraps pfdContent.PFDContents() to convert the go-pfcp ≤ v0.0.24
 // slice-bounds panic into an ordinary error. PFDContentsFields.UnmarshalBinary checks
 // bounds before reading the length fields, so the check always uses the zero value and
@@ -5,6 +5,7 @@ package pfcpiface
 
 import (
 	"bytes"
+	"context"
 	"net"
 	"os"
 	"path/filepath"
@@ -19,18 +20,31 @@ import (
 // fakeDP implements the datapath interface with no-op methods for testing.
 type fakeDP struct{}
 
-func (f *fakeDP) Exit()                                        {}
-func (f *fakeDP) SetUpfInfo(u *upf, conf *Conf)                {}
-func (f *fakeDP) AddSliceInfo(sliceInfo *SliceInfo) error      { return nil }
-func (f *fakeDP) SendEndMarkers(endMarkerList *[][]byte) error { return nil }
-func (f *fakeDP) SendMsgToUPF(method upfMsgType, all PacketForwardingRules, newRules PacketForwardingRules) uint8 {
-	return 1
-}
+func (f *fakeDP) Exit()                                                                 {}
+func (f *fakeDP) SetUpfInfo(u *upf, conf *Conf)                                         {}
+func (f *fakeDP) AddSliceInfo(sliceInfo *SliceInfo) error                               { return nil }
+func (f *fakeDP) SendEndMarkers(endMarkerList *[][]byte) error                          { return nil }
+func (f *fakeDP) CreatePDR(ctx context.Context, p pdr) error                            { return nil }
+func (f *fakeDP) UpdatePDR(ctx context.Context, old, p pdr) error                       { return nil }
+func (f *fakeDP) DeletePDR(ctx context.Context, p pdr) error                            { return nil }
+func (f *fakeDP) CreateFAR(ctx context.Context, fa far) error                           { return nil }
+func (f *fakeDP) UpdateFAR(ctx context.Context, fa far) error                           { return nil }
+func (f *fakeDP) DeleteFAR(ctx context.Context, fa far) error                           { return nil }
+func (f *fakeDP) CreateQER(ctx context.Context, q qer) error                            { return nil }
+func (f *fakeDP) UpdateQER(ctx context.Context, q qer) error                            { return nil }
+func (f *fakeDP) DeleteQER(ctx context.Context, q qer) error                            { return nil }
 func (f *fakeDP) IsConnected(accessIP *net.IP) bool                                     { return true }
 func (f *fakeDP) SummaryLatencyJitter(uc *upfCollector, ch chan<- prometheus.Metric)    {}
 func (f *fakeDP) PortStats(uc *upfCollector, ch chan<- prometheus.Metric)               {}
 func (f *fakeDP) SummaryGtpuLatency(uc *upfCollector, ch chan<- prometheus.Metric)      {}
 func (f *fakeDP) SessionStats(pc *PfcpNodeCollector, ch chan<- prometheus.Metric) error { return nil }
+func (f *fakeDP) UsageCounters() (map[pdrCounterKey]pdrCounters, error)                 { return nil, nil }
+func (f *fakeDP) GtpuPathCounters() (map[uint32]uint64, error)                          { return nil, nil }
+func (f *fakeDP) ReadRules() []datapathTable                                            { return nil }
+func (f *fakeDP) ExpectedRules(rules PacketForwardingRules) []datapathRule              { return nil }
+func (f *fakeDP) DeleteRule(r datapathRule) error                                       { return nil }
+func (f *fakeDP) SetQciQosConfig(conf *Conf) error                                      { return nil }
+func (f *fakeDP) SetSliceMeterConfig(meterConfig SliceMeterConfig) error                { return nil }
 
 // Test that a truncated (simulated unexpected EOF) Association Setup Request
 // is handled without causing a panic in the PFCP message handler.
@@ -161,3 +175,41 @@ func TestParsePFDContents_MalformedPayload_ReturnsError(t *testing.T) {
 		t.Fatal("expected an error for a malformed PFD Contents IE, got nil")
 	}
 }
+
+func TestFSEIDAddress(t *testing.T) {
+	v4 := net.ParseIP("10.0.0.1").To4()
+	v6 := net.ParseIP("2001:db8::1")
+
+	tests := []struct {
+		name    string
+		fseid   *ie.IE
+		want    net.IP
+		wantErr bool
+	}{
+		{name: "IPv4", fseid: ie.NewFSEID(1, v4, nil), want: v4},
+		{name: "IPv6 only", fseid: ie.NewFSEID(1, nil, v6), want: v6},
+		{name: "dual-stack prefers IPv4", fseid: ie.NewFSEID(1, v4, v6), want: v4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fseid, err := tt.fseid.FSEID()
+			if err != nil {
+				t.Fatalf("failed to parse F-SEID: %v", err)
+			}
+
+			got, err := fseidAddress(fseid)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fseidAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := fseidAddress(&ie.FSEIDFields{SEID: 1}); err == nil {
+		t.Error("expected F-SEID without address to be rejected")
+	}
+}
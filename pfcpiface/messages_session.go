@@ -6,7 +6,9 @@ package pfcpiface
 import (
 	"errors"
 	"net"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/omec-project/upf-epc/logger"
 	"github.com/wmnsk/go-pfcp/ie"
@@ -26,6 +28,9 @@ var (
 func (pConn *PFCPConn) handleSessionEstablishmentRequest(msg message.Message) (message.Message, error) {
 	upf := pConn.upf
 
+	upf.sessionMu.Lock()
+	defer upf.sessionMu.Unlock()
+
 	sereq, ok := msg.(*message.SessionEstablishmentRequest)
 	if !ok {
 		return nil, errUnmarshal(errMsgUnexpectedType)
@@ -83,36 +88,66 @@ func (pConn *PFCPConn) handleSessionEstablishmentRequest(msg message.Message) (m
 	}
 
 	remoteSEID := fseid.SEID
-	fseidIP := ip2int(fseid.IPv4Address)
 
-	errProcessReply := func(err error, cause uint8) (message.Message, error) {
+	remoteIP, err := fseidAddress(fseid)
+	if err != nil {
+		return errUnmarshalReply(err, sereq.CPFSEID)
+	}
+
+	// The rules only carry an IPv4 CP F-SEID address, 0 for IPv6 only ones.
+	fseidIP := ip2int(remoteIP.To4())
+
+	errProcessReply := func(err error, cause uint8, ies ...*ie.IE) (message.Message, error) {
 		// Build response message
 		seres := message.NewSessionEstablishmentResponse(0, /* MO?? <-- what's this */
 			0,                    /* FO <-- what's this? */
 			remoteSEID,           /* seid */
 			sereq.SequenceNumber, /* seq # */
 			0,                    /* priority */
-			pConn.nodeID.localIE,
-			ie.NewCause(cause),
+			append([]*ie.IE{pConn.nodeID.localIE, ie.NewCause(cause)}, ies...)...,
 		)
 
 		return seres, errProcess(err)
 	}
 
-	if strings.Compare(nodeID, pConn.nodeID.remote) != 0 {
+	if strings.Compare(nodeID, pConn.remoteNodeID()) != 0 {
 		logger.PfcpLog.Warnln("association not found for Establishment request",
-			"with nodeID:", nodeID, ", association NodeID:", pConn.nodeID.remote)
+			"with nodeID:", nodeID, ", association NodeID:", pConn.remoteNodeID())
 		return errProcessReply(ErrAssocNotFound, ie.CauseNoEstablishedPFCPAssociation)
 	}
 
+	if pConn.node != nil && pConn.node.draining() {
+		logger.PfcpLog.Warnln("rejecting Establishment request from nodeID:", nodeID, "while draining")
+		return errProcessReply(ErrDraining, ie.CauseNoResourcesAvailable)
+	}
+
 	session, ok := pConn.NewPFCPSession(remoteSEID)
 	if !ok {
 		return errProcessReply(ErrAllocateSession,
 			ie.CauseNoResourcesAvailable)
 	}
 
+	session.remoteIP = remoteIP
+
+	established, purged := false, false
+
+	defer func() {
+		if established {
+			return
+		}
+
+		// Release what was allocated to a session that was never stored,
+		// including the UE IP of a PDR that failed to parse.
+		if !purged {
+			upf.releaseNewAllocations(session.localSEID, PacketForwardingRules{}, session.PacketForwardingRules, false)
+		}
+
+		if pConn.node != nil {
+			pConn.node.releaseSEID(session.localSEID)
+		}
+	}()
+
 	addPDRs := make([]pdr, 0, MaxItems)
-	addFARs := make([]far, 0, MaxItems)
 	addQERs := make([]qer, 0, MaxItems)
 
 	for _, cPDR := range sereq.CreatePDR {
@@ -121,16 +156,8 @@ func (pConn *PFCPConn) handleSessionEstablishmentRequest(msg message.Message) (m
 			return errProcessReply(err, ie.CauseRequestRejected)
 		}
 
-		if p.UPAllocateFteid {
-			var fteid uint32
-			fteid, err = pConn.upf.fteidGenerator.Allocate()
-			if err != nil {
-				return errProcessReply(err, ie.CauseNoResourcesAvailable)
-			}
-			p.tunnelTEID = fteid
-			p.tunnelTEIDMask = 0xFFFFFFFF
-			p.tunnelIP4Dst = ip2int(upf.accessIP)
-			p.tunnelIP4DstMask = 0xFFFFFFFF
+		if err = upf.assignPDRResources(&p, nil); err != nil {
+			return errProcessReply(err, ie.CauseNoResourcesAvailable)
 		}
 
 		p.fseidIP = fseidIP
@@ -146,7 +173,6 @@ func (pConn *PFCPConn) handleSessionEstablishmentRequest(msg message.Message) (m
 
 		f.fseidIP = fseidIP
 		session.CreateFAR(f)
-		addFARs = append(addFARs, f)
 	}
 
 	for _, cQER := range sereq.CreateQER {
@@ -160,30 +186,63 @@ func (pConn *PFCPConn) handleSessionEstablishmentRequest(msg message.Message) (m
 		addQERs = append(addQERs, q)
 	}
 
+	for _, cURR := range sereq.CreateURR {
+		var u urr
+		if err = u.parseURR(cURR, session.localSEID, create); err != nil {
+			return errProcessReply(err, ie.CauseRequestRejected)
+		}
+
+		u.fseidIP = fseidIP
+		session.CreateURR(u)
+	}
+
+	if sereq.CreateBAR != nil {
+		var b bar
+		if err = b.parseBAR(sereq.CreateBAR, session.localSEID); err != nil {
+			return errProcessReply(err, ie.CauseRequestRejected)
+		}
+
+		session.CreateBAR(b)
+	}
+
+	session.applyBufferLimits()
+
 	session.MarkSessionQer(session.qers)
 	// FIXME: since PacketForwardingRules doesn't store pointers,
 	//  we must also mark session QERs in addQERs.
 	//  We need a kind of refactoring to clean it up.
 	session.MarkSessionQer(addQERs)
 
-	// session.PacketForwardingRules stores all PFCP rules that has been installed so far,
-	// while 'updated' stores only the PFCP rules that have been provided in this particular message.
-	updated := PacketForwardingRules{
-		pdrs: addPDRs,
-		fars: addFARs,
-		qers: addQERs,
+	if err = upf.reserveCapacity(session.localSEID, session.PacketForwardingRules); err != nil {
+		return errProcessReply(err, ie.CauseNoResourcesAvailable)
 	}
 
-	cause := upf.SendMsgToUPF(upfMsgTypeAdd, session.PacketForwardingRules, updated)
-	if cause == ie.CauseRequestRejected {
-		pConn.RemoveSession(session)
-		return errProcessReply(ErrWriteToDatapath,
-			ie.CauseRequestRejected)
+	err = upf.installRules(session.PacketForwardingRules)
+	if err != nil {
+		// Remove the rules the datapath did apply.
+		pConn.purgeSession(session)
+		purged = true
+
+		cause, ies := datapathFailure(err)
+
+		return errProcessReply(errWriteToDatapath(err), cause, ies...)
 	}
 
 	err = pConn.store.PutSession(session)
 	if err != nil {
-		logger.PfcpLog.Errorf("failed to put PFCP session to store: %v", err)
+		pConn.purgeSession(session)
+		purged = true
+
+		return errProcessReply(err, ie.CauseSystemFailure)
+	}
+
+	pConn.indexSession(session)
+
+	established = true
+
+	now := time.Now()
+	for _, u := range session.urrs {
+		upf.usage.track(session.localSEID, u, now)
 	}
 
 	var localFSEID *ie.IE
@@ -205,7 +264,7 @@ func (pConn *PFCPConn) handleSessionEstablishmentRequest(msg message.Message) (m
 		ie.NewCause(ie.CauseRequestAccepted), /* accept it blindly for the time being */
 		localFSEID,
 	)
-	addPdrInfo(seres, addPDRs)
+	addPdrInfo(&seres.CreatedPDR, addPDRs)
 
 	return seres, nil
 }
@@ -213,6 +272,9 @@ func (pConn *PFCPConn) handleSessionEstablishmentRequest(msg message.Message) (m
 func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (message.Message, error) {
 	upf := pConn.upf
 
+	upf.sessionMu.Lock()
+	defer upf.sessionMu.Unlock()
+
 	smreq, ok := msg.(*message.SessionModificationRequest)
 	if !ok {
 		return nil, errUnmarshal(errMsgUnexpectedType)
@@ -223,12 +285,19 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 	sendError := func(err error) (message.Message, error) {
 		logger.PfcpLog.Errorln(err)
 
+		cause, ies := uint8(ie.CauseRequestRejected), []*ie.IE(nil)
+		if errors.Is(err, ErrWriteToDatapath) {
+			cause, ies = datapathFailure(err)
+		} else if errors.Is(err, ErrCapacityExceeded) {
+			cause = ie.CauseNoResourcesAvailable
+		}
+
 		smres := message.NewSessionModificationResponse(0, /* MO?? <-- what's this */
-			0,                                    /* FO <-- what's this? */
-			remoteSEID,                           /* seid */
-			smreq.SequenceNumber,                 /* seq # */
-			0,                                    /* priority */
-			ie.NewCause(ie.CauseRequestRejected), /* accept it blindly for the time being */
+			0,                    /* FO <-- what's this? */
+			remoteSEID,           /* seid */
+			smreq.SequenceNumber, /* seq # */
+			0,                    /* priority */
+			append([]*ie.IE{ie.NewCause(cause)}, ies...)...,
 		)
 
 		return smres, err
@@ -241,13 +310,25 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 		return sendError(ErrNotFoundWithParam("PFCP session", "localSEID", localSEID))
 	}
 
+	// The CP function still knows about the session after restarting.
+	pConn.restart.confirm(localSEID)
+
+	// The changes are staged on a copy of the rules, so that the stored
+	// session is left untouched until the datapath applies them.
+	prev := session.PacketForwardingRules
+	session.PacketForwardingRules = prev.clone()
+
 	var fseidIP uint32
 
 	if smreq.CPFSEID != nil {
 		fseid, err := smreq.CPFSEID.FSEID()
 		if err == nil {
+			if remoteIP, err := fseidAddress(fseid); err == nil {
+				session.remoteIP = remoteIP
+				fseidIP = ip2int(remoteIP.To4())
+			}
+
 			session.remoteSEID = fseid.SEID
-			fseidIP = ip2int(fseid.IPv4Address)
 
 			logger.PfcpLog.Debugln("updated FSEID from session modification request")
 		}
@@ -255,6 +336,16 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 
 	remoteSEID = session.remoteSEID
 
+	committed := false
+	heldIP := upf.ippool.Allocated(localSEID)
+
+	defer func() {
+		// What was allocated to the staged rules is only kept once stored.
+		if !committed {
+			upf.releaseNewAllocations(localSEID, prev, session.PacketForwardingRules, heldIP)
+		}
+	}()
+
 	addPDRs := make([]pdr, 0, MaxItems)
 	addFARs := make([]far, 0, MaxItems)
 	addQERs := make([]qer, 0, MaxItems)
@@ -266,6 +357,10 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 			return sendError(err)
 		}
 
+		if err := upf.assignPDRResources(&p, nil); err != nil {
+			return sendError(err)
+		}
+
 		p.fseidIP = fseidIP
 
 		session.CreatePDR(p)
@@ -273,6 +368,8 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 	}
 	logger.PfcpLog.Debugln("PDRs added:", addPDRs)
 
+	createdPDRs := addPDRs
+
 	for _, cFAR := range smreq.CreateFAR {
 		var f far
 		if err := f.parseFAR(cFAR, localSEID, upf, create); err != nil {
@@ -297,6 +394,20 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 		addQERs = append(addQERs, q)
 	}
 
+	addURRs := make([]urr, 0, MaxItems)
+
+	for _, cURR := range smreq.CreateURR {
+		var u urr
+		if err := u.parseURR(cURR, localSEID, create); err != nil {
+			return sendError(err)
+		}
+
+		u.fseidIP = fseidIP
+
+		session.CreateURR(u)
+		addURRs = append(addURRs, u)
+	}
+
 	for _, uPDR := range smreq.UpdatePDR {
 		var (
 			p   pdr
@@ -309,12 +420,20 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 
 		p.fseidIP = fseidIP
 
-		err = session.UpdatePDR(p)
+		old, err := session.getPDR(p.pdrID)
 		if err != nil {
 			logger.PfcpLog.Errorln("session PDR update failed", err)
 			continue
 		}
 
+		if err = upf.assignPDRResources(&p, &old); err != nil {
+			return sendError(err)
+		}
+
+		if err = session.UpdatePDR(p); err != nil {
+			return sendError(err)
+		}
+
 		addPDRs = append(addPDRs, p)
 	}
 
@@ -360,6 +479,117 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 		addQERs = append(addQERs, q)
 	}
 
+	type urrUpdate struct {
+		old, new     urr
+		quotaRenewed bool
+	}
+
+	updURRs := make([]urrUpdate, 0, MaxItems)
+
+	for _, uURR := range smreq.UpdateURR {
+		urrID, err := uURR.URRID()
+		if err != nil {
+			return sendError(err)
+		}
+
+		old, err := session.getURR(urrID)
+		if err != nil {
+			logger.PfcpLog.Errorln("session URR update failed", err)
+			continue
+		}
+
+		u := old
+		if err = u.parseURR(uURR, localSEID, update); err != nil {
+			return sendError(err)
+		}
+
+		if fseidIP != 0 {
+			u.fseidIP = fseidIP
+		}
+
+		if err = session.UpdateURR(u); err != nil {
+			logger.PfcpLog.Errorln("session URR update failed", err)
+			continue
+		}
+
+		updURRs = append(updURRs, urrUpdate{old: old, new: u, quotaRenewed: hasQuota(uURR)})
+	}
+
+	if smreq.CreateBAR != nil {
+		var b bar
+		if err := b.parseBAR(smreq.CreateBAR, localSEID); err != nil {
+			return sendError(err)
+		}
+
+		session.CreateBAR(b)
+	}
+
+	if smreq.UpdateBAR != nil {
+		barID, err := smreq.UpdateBAR.BARID()
+		if err != nil {
+			return sendError(err)
+		}
+
+		b, err := session.getBAR(barID)
+		if err != nil {
+			return sendError(err)
+		}
+
+		if err = b.parseBAR(smreq.UpdateBAR, localSEID); err != nil {
+			return sendError(err)
+		}
+
+		if err = session.UpdateBAR(b); err != nil {
+			return sendError(err)
+		}
+	}
+
+	// Usage reports of removed and queried URRs are returned in the response.
+	var (
+		queryURRIDs  = make([]uint32, 0, len(smreq.QueryURR))
+		removeURRIDs = make([]uint32, 0, len(smreq.RemoveURR))
+	)
+
+	for _, qURR := range smreq.QueryURR {
+		urrID, err := urrIDFromIE(qURR)
+		if err != nil {
+			return sendError(err)
+		}
+
+		queryURRIDs = append(queryURRIDs, urrID)
+	}
+
+	for _, rURR := range smreq.RemoveURR {
+		urrID, err := urrIDFromIE(rURR)
+		if err != nil {
+			return sendError(err)
+		}
+
+		removeURRIDs = append(removeURRIDs, urrID)
+	}
+
+	// Traffic stays blocked while a quota is exhausted, a new quota or
+	// removing the URR lets it through again.
+	quotaReset := slices.Clone(removeURRIDs)
+
+	for _, u := range updURRs {
+		if u.quotaRenewed {
+			quotaReset = append(quotaReset, u.new.urrID)
+		}
+	}
+
+	addFARs = mergeFARs(addFARs, session.applyQuotaBlocks(func(urrID uint32) bool {
+		return !slices.Contains(quotaReset, urrID) && upf.usage.exhausted(localSEID, urrID)
+	}))
+
+	// The DL buffering parameters of Session Report Responses only apply
+	// until the session stops buffering.
+	if !session.isBuffering() {
+		session.resetDLBuffering()
+	}
+
+	addFARs = mergeFARs(addFARs, session.applyBufferLimits())
+
 	session.MarkSessionQer(session.qers)
 	// FIXME: since PacketForwardingRules doesn't store pointers,
 	//  we must also mark session QERs in addQERs.
@@ -372,9 +602,25 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 		qers: addQERs,
 	}
 
-	cause := upf.SendMsgToUPF(upfMsgTypeMod, session.PacketForwardingRules, updated)
-	if cause == ie.CauseRequestRejected {
-		return sendError(ErrWriteToDatapath)
+	var deleted PacketForwardingRules
+
+	// Both versions of the updated rules are in the datapath until the
+	// previous ones are removed.
+	if err := upf.reserveCapacity(localSEID, prev, session.PacketForwardingRules); err != nil {
+		return sendError(err)
+	}
+
+	// Once the datapath is written, any failure brings it back to the
+	// previous rules of the session.
+	abort := func(err error) (message.Message, error) {
+		pConn.rollbackRules(localSEID, prev, updated, deleted)
+		upf.trackCapacity(localSEID, prev)
+
+		return sendError(err)
+	}
+
+	if err := upf.updateRules(prev, updated); err != nil {
+		return abort(errWriteToDatapath(err))
 	}
 
 	if upf.enableEndMarker {
@@ -384,6 +630,15 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 		}
 	}
 
+	var usageReports []usageReport
+
+	if len(queryURRIDs) > 0 || len(removeURRIDs) > 0 {
+		pConn.refreshUsage(&session)
+
+		usageReports = append(usageReports, pConn.usageReportsNow(&session, queryURRIDs, triggerIMMER)...)
+		usageReports = append(usageReports, pConn.usageReportsNow(&session, removeURRIDs, triggerTERMR)...)
+	}
+
 	delPDRs := make([]pdr, 0, MaxItems)
 	delFARs := make([]far, 0, MaxItems)
 	delQERs := make([]qer, 0, MaxItems)
@@ -391,12 +646,12 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 	for _, rPDR := range smreq.RemovePDR {
 		pdrID, err := rPDR.PDRID()
 		if err != nil {
-			return sendError(err)
+			return abort(err)
 		}
 
 		p, err := session.RemovePDR(uint32(pdrID))
 		if err != nil {
-			return sendError(err)
+			return abort(err)
 		}
 
 		delPDRs = append(delPDRs, *p)
@@ -405,12 +660,12 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 	for _, dFAR := range smreq.RemoveFAR {
 		farID, err := dFAR.FARID()
 		if err != nil {
-			return sendError(err)
+			return abort(err)
 		}
 
 		f, err := session.RemoveFAR(farID)
 		if err != nil {
-			return sendError(err)
+			return abort(err)
 		}
 
 		delFARs = append(delFARs, *f)
@@ -419,31 +674,76 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 	for _, dQER := range smreq.RemoveQER {
 		qerID, err := dQER.QERID()
 		if err != nil {
-			return sendError(err)
+			return abort(err)
 		}
 
 		q, err := session.RemoveQER(qerID)
 		if err != nil {
-			return sendError(err)
+			return abort(err)
 		}
 
 		delQERs = append(delQERs, *q)
 	}
 
-	deleted := PacketForwardingRules{
+	for _, urrID := range removeURRIDs {
+		if _, err := session.RemoveURR(urrID); err != nil {
+			return abort(err)
+		}
+	}
+
+	if smreq.RemoveBAR != nil {
+		barID, err := smreq.RemoveBAR.BARID()
+		if err != nil {
+			return abort(err)
+		}
+
+		if _, err = session.RemoveBAR(barID); err != nil {
+			return abort(err)
+		}
+	}
+
+	deleted = PacketForwardingRules{
 		pdrs: delPDRs,
 		fars: delFARs,
 		qers: delQERs,
 	}
 
-	cause = upf.SendMsgToUPF(upfMsgTypeDel, deleted, PacketForwardingRules{})
-	if cause == ie.CauseRequestRejected {
-		return sendError(ErrWriteToDatapath)
+	if err := upf.removeRules(deleted); err != nil {
+		return abort(errWriteToDatapath(err))
 	}
 
-	err := pConn.store.PutSession(session)
-	if err != nil {
-		logger.PfcpLog.Errorf("failed to put PFCP session to store: %v", err)
+	if err := pConn.store.PutSession(session); err != nil {
+		return abort(err)
+	}
+
+	committed = true
+
+	pConn.indexSession(session)
+
+	upf.trackCapacity(localSEID, session.PacketForwardingRules)
+	releaseAllocatedCounters(upf.counterIDs, &PFCPSession{PacketForwardingRules: deleted})
+
+	now := time.Now()
+
+	for _, u := range addURRs {
+		upf.usage.track(localSEID, u, now)
+	}
+
+	for _, u := range updURRs {
+		upf.usage.update(localSEID, u.old, u.new, u.quotaRenewed, now)
+	}
+
+	for _, urrID := range removeURRIDs {
+		upf.usage.untrack(localSEID, urrID)
+	}
+
+	for _, p := range delPDRs {
+		upf.usage.forgetPDR(p)
+	}
+
+	// A new buffering episode starts with a fresh notification.
+	if !session.isBuffering() {
+		pConn.buffering.reset(localSEID)
 	}
 
 	// Build response message
@@ -455,24 +755,38 @@ func (pConn *PFCPConn) handleSessionModificationRequest(msg message.Message) (me
 		ie.NewCause(ie.CauseRequestAccepted), /* accept it blindly for the time being */
 	)
 
+	addPdrInfo(&smres.CreatedPDR, createdPDRs)
+
+	for _, r := range usageReports {
+		smres.UsageReport = append(smres.UsageReport, ie.NewUsageReportWithinSessionModificationResponse(r.ies()...))
+	}
+
 	return smres, nil
 }
 
 func (pConn *PFCPConn) handleSessionDeletionRequest(msg message.Message) (message.Message, error) {
 	upf := pConn.upf
 
+	upf.sessionMu.Lock()
+	defer upf.sessionMu.Unlock()
+
 	sdreq, ok := msg.(*message.SessionDeletionRequest)
 	if !ok {
 		return nil, errUnmarshal(errMsgUnexpectedType)
 	}
 
 	sendError := func(err error) (message.Message, error) {
+		cause := uint8(ie.CauseRequestRejected)
+		if errors.Is(err, ErrWriteToDatapath) {
+			cause, _ = datapathFailure(err)
+		}
+
 		smres := message.NewSessionDeletionResponse(0, /* MO?? <-- what's this */
-			0,                                    /* FO <-- what's this? */
-			0,                                    /* seid */
-			sdreq.SequenceNumber,                 /* seq # */
-			0,                                    /* priority */
-			ie.NewCause(ie.CauseRequestRejected), /* accept it blindly for the time being */
+			0,                    /* FO <-- what's this? */
+			0,                    /* seid */
+			sdreq.SequenceNumber, /* seq # */
+			0,                    /* priority */
+			ie.NewCause(cause),
 		)
 
 		return smres, err
@@ -486,15 +800,24 @@ func (pConn *PFCPConn) handleSessionDeletionRequest(msg message.Message) (messag
 		return sendError(ErrNotFoundWithParam("PFCP session", "localSEID", localSEID))
 	}
 
-	cause := upf.SendMsgToUPF(upfMsgTypeDel, session.PacketForwardingRules, PacketForwardingRules{})
-	if cause == ie.CauseRequestRejected {
-		return sendError(ErrWriteToDatapath)
+	// Collect the final usage before the datapath counters go away.
+	pConn.refreshUsage(&session)
+	usageReports := pConn.usageReportsNow(&session, nil, triggerTERMR)
+
+	if err := upf.removeRules(session.PacketForwardingRules); err != nil {
+		// The session is kept, so the rules already removed are re-installed.
+		pConn.rollbackRules(localSEID, session.PacketForwardingRules, PacketForwardingRules{}, session.PacketForwardingRules)
+
+		return sendError(errWriteToDatapath(err))
 	}
 
 	if err := releaseAllocatedIPs(upf.ippool, &session); err != nil {
 		return sendError(ErrOperationFailedWithReason("session IP dealloc", err.Error()))
 	}
 
+	releaseAllocatedTEIDs(upf.fteidGenerator, &session)
+	releaseAllocatedCounters(upf.counterIDs, &session)
+
 	/* delete sessionRecord */
 	pConn.RemoveSession(session)
 
@@ -507,6 +830,10 @@ func (pConn *PFCPConn) handleSessionDeletionRequest(msg message.Message) (messag
 		ie.NewCause(ie.CauseRequestAccepted), /* accept it blindly for the time being */
 	)
 
+	for _, r := range usageReports {
+		smres.UsageReport = append(smres.UsageReport, ie.NewUsageReportWithinSessionDeletionResponse(r.ies()...))
+	}
+
 	return smres, nil
 }
 
@@ -517,16 +844,6 @@ func (pConn *PFCPConn) handleDigestReport(fseid uint64) {
 		return
 	}
 
-	seq := pConn.getSeqNum()
-	srreq := message.NewSessionReportRequest(0, /* MO?? <-- what's this */
-		0,                            /* FO <-- what's this? */
-		0,                            /* seid */
-		seq,                          /* seq # */
-		0,                            /* priority */
-		ie.NewReportType(0, 0, 0, 1), /*upir, erir, usar, dldr int*/
-	)
-	srreq.Header.SEID = session.remoteSEID
-
 	var pdrID uint32
 
 	var farID uint32
@@ -541,12 +858,16 @@ func (pConn *PFCPConn) handleDigestReport(fseid uint64) {
 		}
 	}
 
+	var b *bar
+
 	for _, far := range session.fars {
 		if far.farID == farID {
 			if far.applyAction&ActionNotify == 0 {
 				logger.PfcpLog.Errorln("packet received for forwarding far. discard")
 				return
 			}
+
+			b = session.barForFAR(far)
 		}
 	}
 
@@ -556,10 +877,47 @@ func (pConn *PFCPConn) handleDigestReport(fseid uint64) {
 		return
 	}
 
+	action := pConn.buffering.decide(fseid, time.Now())
+	if action != ddnNotify {
+		logger.PfcpLog.With("F-SEID", fseid, "PDR ID", pdrID, "action", action).Debugln(
+			"not sending Downlink Data Report")
+
+		return
+	}
+
+	if b != nil && b.ddnDelay > 0 {
+		time.AfterFunc(b.ddnDelay, func() {
+			if pConn.IsShutdown() {
+				return
+			}
+
+			// The session may have been removed in the meantime.
+			if session, ok := pConn.store.GetSession(fseid); ok && session.isBuffering() {
+				pConn.sendDownlinkDataReport(session, pdrID)
+			}
+		})
+
+		return
+	}
+
+	pConn.sendDownlinkDataReport(session, pdrID)
+}
+
+func (pConn *PFCPConn) sendDownlinkDataReport(session PFCPSession, pdrID uint32) {
+	seq := pConn.getSeqNum()
+	srreq := message.NewSessionReportRequest(0, /* MO?? <-- what's this */
+		0,                            /* FO <-- what's this? */
+		0,                            /* seid */
+		seq,                          /* seq # */
+		0,                            /* priority */
+		ie.NewReportType(0, 0, 0, 1), /*upir, erir, usar, dldr int*/
+	)
+	srreq.Header.SEID = session.remoteSEID
+
 	srreq.DownlinkDataReport = ie.NewDownlinkDataReport(
 		ie.NewPDRID(uint16(pdrID)))
 
-	logger.PfcpLog.With("F-SEID", fseid, "PDR ID", pdrID).Debugln("sending Downlink Data Report")
+	logger.PfcpLog.With("F-SEID", session.localSEID, "PDR ID", pdrID).Debugln("sending Downlink Data Report")
 
 	pConn.SendPFCPMsg(srreq)
 }
@@ -585,6 +943,10 @@ func (pConn *PFCPConn) handleSessionReportResponse(msg message.Message) error {
 	}
 
 	if cause == ie.CauseRequestAccepted {
+		if srres.UpdateBAR != nil {
+			return pConn.handleReportResponseUpdateBAR(srres.SEID(), srres.UpdateBAR)
+		}
+
 		return nil
 	}
 
@@ -593,6 +955,9 @@ func (pConn *PFCPConn) handleSessionReportResponse(msg message.Message) error {
 	seid := srres.SEID()
 
 	if cause == ie.CauseSessionContextNotFound {
+		upf.sessionMu.Lock()
+		defer upf.sessionMu.Unlock()
+
 		sessItem, ok := pConn.store.GetSession(seid)
 		if !ok {
 			return errProcess(ErrNotFoundWithParam("PFCP session context", "SEID", seid))
@@ -600,17 +965,73 @@ func (pConn *PFCPConn) handleSessionReportResponse(msg message.Message) error {
 
 		logger.PfcpLog.Warnln("context not found, deleting session locally")
 
-		pConn.RemoveSession(sessItem)
-
-		cause := upf.SendMsgToUPF(
-			upfMsgTypeDel, sessItem.PacketForwardingRules, PacketForwardingRules{})
-		if cause == ie.CauseRequestRejected {
-			return errProcess(
-				ErrOperationFailedWithParam("delete session from datapath", "seid", seid))
-		}
+		pConn.purgeSession(sessItem)
 
 		return nil
 	}
 
 	return nil
 }
+
+// handleReportResponseUpdateBAR applies the Update BAR IE of a Session Report
+// Response: the BAR parameters are stored in the session, the DL Buffering
+// Duration starts a buffering period and, with the DL Buffering Suggested
+// Packet Count, limits the packets buffered by the datapath.
+func (pConn *PFCPConn) handleReportResponseUpdateBAR(seid uint64, updateBAR *ie.IE) error {
+	upf := pConn.upf
+
+	upf.sessionMu.Lock()
+	defer upf.sessionMu.Unlock()
+
+	session, ok := pConn.store.GetSession(seid)
+	if !ok {
+		return errProcess(ErrNotFoundWithParam("PFCP session context", "SEID", seid))
+	}
+
+	barID, err := updateBAR.BARID()
+	if err != nil {
+		return errUnmarshal(err)
+	}
+
+	prev := session.PacketForwardingRules
+	session.PacketForwardingRules = prev.clone()
+
+	b, err := session.getBAR(barID)
+	if err != nil {
+		return errProcess(err)
+	}
+
+	if err = b.parseBAR(updateBAR, seid); err != nil {
+		return errUnmarshal(err)
+	}
+
+	duration, durationErr := updateBAR.DLBufferingDuration()
+	if durationErr == nil {
+		// The packet count is optional, zero means no limit.
+		b.dlPktLimit, _ = updateBAR.DLBufferingSuggestedPacketCount()
+		b.discard = duration == 0
+	}
+
+	if err = session.UpdateBAR(b); err != nil {
+		return errProcess(err)
+	}
+
+	limited := PacketForwardingRules{fars: session.applyBufferLimits()}
+	if err = upf.updateRules(prev, limited); err != nil {
+		pConn.rollbackRules(seid, prev, limited, PacketForwardingRules{})
+		return errProcess(errWriteToDatapath(err))
+	}
+
+	if err = pConn.store.PutSession(session); err != nil {
+		logger.PfcpLog.Errorf("failed to put PFCP session to store: %v", err)
+	}
+
+	if durationErr == nil {
+		logger.PfcpLog.With("F-SEID", seid, "duration", duration, "packets", b.dlPktLimit).Debugln(
+			"starting DL buffering period")
+
+		pConn.buffering.startDuration(seid, duration, time.Now())
+	}
+
+	return nil
+}
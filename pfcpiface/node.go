@@ -7,27 +7,51 @@ import (
 	"context"
 	"errors"
 	"net"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	reuse "github.com/libp2p/go-reuseport"
 	"github.com/omec-project/upf-epc/logger"
 	"github.com/omec-project/upf-epc/pfcpiface/metrics"
 )
 
+var errPeerExists = errors.New("N4 peer already added")
+
 // PFCPNode represents a PFCP endpoint of the UPF.
 type PFCPNode struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 	// listening socket for new "PFCP connections"
 	net.PacketConn
+	// set while new PFCP connections are accepted
+	listening atomic.Bool
 	// done is closed to signal shutdown complete
 	done chan struct{}
 	// WaitGroup to track active connections
 	connWg sync.WaitGroup
 	// map of existing connections
 	pConns sync.Map
+	// map of local SEIDs to the PFCPConn owning the session
+	sessions sync.Map
+	// local SEIDs of the sessions by remote F-TEID of their FARs
+	remoteFTEIDs remoteFTEIDIndex
+	// guards the N4 peers of the upf, which can be added at runtime
+	peersMu sync.Mutex
+	// lost associations by remote node ID, whose sessions may be adopted
+	orphans   map[string]*orphanedAssociation
+	orphansMu sync.Mutex
 	// upf
 	upf *upf
+	// reachability of the remote GTP-U peers
+	pathMonitor *gtpuPathMonitor
+	// progress of the replay of the sessions to the datapath
+	resync datapathResync
+	// result of the last audit of the datapath rules
+	audit datapathAudit
+	// drain of the sessions and associations before shutdown
+	drain nodeDrain
 	// metrics for PFCP messages and sessions
 	metrics metrics.InstrumentPFCP
 }
@@ -35,7 +59,7 @@ type PFCPNode struct {
 // NewPFCPNode create a new PFCPNode listening on local address.
 func NewPFCPNode(upf *upf) *PFCPNode {
 	conn, err := reuse.ListenPacket("udp",
-		upf.n4addr+":"+PFCPPort)
+		net.JoinHostPort(upf.n4addr, PFCPPort))
 	if err != nil {
 		logger.PfcpLog.Fatalln("listen UDP failed", err)
 	}
@@ -48,41 +72,89 @@ func NewPFCPNode(upf *upf) *PFCPNode {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &PFCPNode{
-		ctx:        ctx,
-		cancel:     cancel,
-		PacketConn: conn,
-		done:       make(chan struct{}),
-		upf:        upf,
-		metrics:    metrics,
+		ctx:         ctx,
+		cancel:      cancel,
+		PacketConn:  conn,
+		done:        make(chan struct{}),
+		upf:         upf,
+		pathMonitor: newGtpuPathMonitor(upf.gtpuPathFailureTimeout),
+		metrics:     metrics,
 	}
 }
 
 func (node *PFCPNode) tryConnectToN4Peers(lAddrStr string) {
-	for _, peer := range node.upf.peers {
-		var d net.Dialer
-		conn, err := d.DialContext(node.ctx, "udp", peer+":"+PFCPPort)
-		if err != nil {
+	node.peersMu.Lock()
+	peers := slices.Clone(node.upf.peers)
+	node.peersMu.Unlock()
+
+	for _, peer := range peers {
+		if err := node.connectToN4Peer(lAddrStr, peer); err != nil {
 			logger.PfcpLog.Warnln("failed to establish PFCP connection to peer", peer)
-			continue
 		}
+	}
+}
+
+// connectToN4Peer creates the PFCPConn to the peer and sends it an
+// Association Setup Request.
+func (node *PFCPNode) connectToN4Peer(lAddrStr, peer string) error {
+	var d net.Dialer
+
+	conn, err := d.DialContext(node.ctx, "udp", net.JoinHostPort(peer, PFCPPort))
+	if err != nil {
+		return err
+	}
 
-		remoteAddr := conn.RemoteAddr().(*net.UDPAddr)
-		conn.Close()
-		n4DstIP := remoteAddr.IP
+	remoteAddr := conn.RemoteAddr().(*net.UDPAddr)
+	conn.Close()
+	n4DstIP := remoteAddr.IP
 
-		logger.PfcpLog.Infof("Establishing PFCP Conn with CP node. SPGWC/SMF host: %s, CP node: %s", peer, n4DstIP.String())
+	logger.PfcpLog.Infof("Establishing PFCP Conn with CP node. SPGWC/SMF host: %s, CP node: %s", peer, n4DstIP.String())
 
-		pfcpConn := node.NewPFCPConn(lAddrStr, n4DstIP.String()+":"+PFCPPort, nil)
-		if pfcpConn != nil {
-			go pfcpConn.sendAssociationRequest()
-		}
+	pfcpConn := node.NewPFCPConn(lAddrStr, net.JoinHostPort(n4DstIP.String(), PFCPPort), nil)
+	if pfcpConn != nil {
+		go pfcpConn.sendAssociationRequest()
 	}
+
+	return nil
+}
+
+// addN4Peer adds the peer to the N4 peers and connects to it.
+func (node *PFCPNode) addN4Peer(peer string) error {
+	node.peersMu.Lock()
+
+	if slices.Contains(node.upf.peers, peer) {
+		node.peersMu.Unlock()
+		return errPeerExists
+	}
+
+	node.upf.peers = append(node.upf.peers, peer)
+	node.peersMu.Unlock()
+
+	if err := node.connectToN4Peer(node.LocalAddr().String(), peer); err != nil {
+		node.removeN4Peer(peer)
+
+		return err
+	}
+
+	return nil
+}
+
+// removeN4Peer removes the peer from the N4 peers, without releasing its
+// association.
+func (node *PFCPNode) removeN4Peer(peer string) {
+	node.peersMu.Lock()
+	defer node.peersMu.Unlock()
+
+	node.upf.peers = slices.DeleteFunc(node.upf.peers, func(p string) bool { return p == peer })
 }
 
 func (node *PFCPNode) handleNewPeers() {
 	lAddrStr := node.LocalAddr().String()
 	logger.PfcpLog.Infoln("listening for new PFCP connections on", lAddrStr)
 
+	node.listening.Store(true)
+	defer node.listening.Store(false)
+
 	node.tryConnectToN4Peers(lAddrStr)
 
 	for {
@@ -111,19 +183,55 @@ func (node *PFCPNode) handleNewPeers() {
 
 // Serve listens for the first packet from a new PFCP peer and creates PFCPConn.
 func (node *PFCPNode) Serve() {
+	if node.upf.restoreSessions {
+		node.restoreSessions()
+	}
+
 	go node.handleNewPeers()
 
+	usageTicker := time.NewTicker(node.upf.usagePollInterval)
+	defer usageTicker.Stop()
+
+	var gtpuPathTicks <-chan time.Time
+
+	if node.upf.enableGtpuMonitor {
+		gtpuPathTicker := time.NewTicker(gtpuPathCheckInterval)
+		defer gtpuPathTicker.Stop()
+
+		gtpuPathTicks = gtpuPathTicker.C
+	}
+
+	var auditTicks <-chan time.Time
+
+	if node.upf.auditInterval > 0 {
+		auditTicker := time.NewTicker(node.upf.auditInterval)
+		defer auditTicker.Stop()
+
+		auditTicks = auditTicker.C
+	}
+
 	shutdown := false
 
 	for !shutdown {
 		select {
+		case <-usageTicker.C:
+			node.reportUsage()
+		case <-gtpuPathTicks:
+			node.checkGtpuPaths()
 		case fseid := <-node.upf.reportNotifyChan:
-			// TODO: Logic to distinguish PFCPConn based on SEID
-			node.pConns.Range(func(key, value interface{}) bool {
-				pConn := value.(*PFCPConn)
-				pConn.handleDigestReport(fseid)
-				return false
-			})
+			pConn, ok := node.sessionOwner(fseid)
+			if !ok {
+				logger.PfcpLog.Warnln("no PFCP association found for fseid:", fseid)
+				continue
+			}
+
+			pConn.handleDigestReport(fseid)
+		case key := <-node.upf.errorIndicationChan:
+			node.handleErrorIndication(key)
+		case <-node.upf.datapathResyncChan:
+			go node.resyncDatapath()
+		case <-auditTicks:
+			go node.auditDatapath(node.upf.auditRepair)
 		case <-node.ctx.Done():
 			shutdown = true
 
@@ -146,13 +254,74 @@ func (node *PFCPNode) Serve() {
 			node.connWg.Wait()
 			logger.PfcpLog.Infoln("done waiting for PFCPConn completions")
 
+			if node.upf.restoreSessions {
+				node.releaseOrphans()
+			} else {
+				node.purgeOrphans()
+			}
+
 			node.upf.Exit()
+
+			if node.upf.sessionDB != nil {
+				if err := node.upf.sessionDB.Close(); err != nil {
+					logger.PfcpLog.Errorln("error closing session store", err)
+				}
+			}
 		}
 	}
 
 	close(node.done)
 }
 
+// reserveSEID registers pConn as the owner of the local SEID, unless the SEID
+// is already in use by any association of the node.
+func (node *PFCPNode) reserveSEID(seid uint64, pConn *PFCPConn) bool {
+	_, loaded := node.sessions.LoadOrStore(seid, pConn)
+	return !loaded
+}
+
+// releaseSEID makes the local SEID available again.
+func (node *PFCPNode) releaseSEID(seid uint64) {
+	node.sessions.Delete(seid)
+}
+
+// sessionOwner returns the PFCPConn of the association that created the session.
+func (node *PFCPNode) sessionOwner(seid uint64) (*PFCPConn, bool) {
+	v, ok := node.sessions.Load(seid)
+	if !ok {
+		return nil, false
+	}
+
+	return v.(*PFCPConn), true
+}
+
+// reportUsage reads the datapath counters once and lets every association
+// send the usage reports that are due for its sessions.
+func (node *PFCPNode) reportUsage() {
+	var (
+		counters map[pdrCounterKey]pdrCounters
+		err      error
+	)
+
+	// Volume measurement relies on the flow measurement of the datapath,
+	// time based triggers work without it.
+	if node.upf.enableFlowMeasure && node.upf.usage.active() {
+		counters, err = node.upf.UsageCounters()
+		if err != nil {
+			logger.PfcpLog.Debugln("failed to read usage counters:", err)
+		}
+	}
+
+	now := time.Now()
+
+	node.pConns.Range(func(key, value any) bool {
+		pConn := value.(*PFCPConn)
+		pConn.reportUsage(counters, now)
+
+		return true
+	})
+}
+
 func (node *PFCPNode) Stop() {
 	node.cancel()
 
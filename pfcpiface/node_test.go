@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/omec-project/upf-epc/pfcpiface/metrics"
+)
+
+type noopPFCPMetrics struct{}
+
+func (noopPFCPMetrics) SaveMessages(msg *metrics.Message)  {}
+func (noopPFCPMetrics) SaveSessions(sess *metrics.Session) {}
+func (noopPFCPMetrics) Stop() error                        { return nil }
+
+func newTestPFCPConn(node *PFCPNode, seed int64) *PFCPConn {
+	return &PFCPConn{
+		rng:            rand.New(rand.NewSource(seed)), // #nosec G404
+		maxRetries:     10,
+		store:          NewInMemoryStore(),
+		upf:            node.upf,
+		node:           node,
+		shutdown:       make(chan struct{}),
+		InstrumentPFCP: noopPFCPMetrics{},
+	}
+}
+
+func TestPFCPNode_SessionOwner(t *testing.T) {
+	node := &PFCPNode{upf: &upf{datapath: &fakeDP{}, usage: newUsageTracker()}}
+
+	// Same seed, so both associations draw the same SEIDs.
+	first := newTestPFCPConn(node, 1)
+	second := newTestPFCPConn(node, 1)
+
+	s1, ok := first.NewPFCPSession(1)
+	if !ok {
+		t.Fatal("failed to allocate session")
+	}
+
+	s2, ok := second.NewPFCPSession(2)
+	if !ok {
+		t.Fatal("failed to allocate session")
+	}
+
+	if s1.localSEID == s2.localSEID {
+		t.Fatalf("SEID %v allocated twice", s1.localSEID)
+	}
+
+	for _, tc := range []struct {
+		seid  uint64
+		owner *PFCPConn
+	}{
+		{s1.localSEID, first},
+		{s2.localSEID, second},
+	} {
+		owner, ok := node.sessionOwner(tc.seid)
+		if !ok || owner != tc.owner {
+			t.Errorf("wrong owner for SEID %v", tc.seid)
+		}
+	}
+
+	second.RemoveSession(s2)
+
+	if _, ok := node.sessionOwner(s2.localSEID); ok {
+		t.Errorf("SEID %v still owned after session removal", s2.localSEID)
+	}
+}
@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/omec-project/upf-epc/logger"
+	p4config "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4 "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// p4rtElectionID is the election ID of the agent. There is a single agent per
+// P4 switch, so it is always the primary controller.
+var p4rtElectionID = &p4.Uint128{High: 0, Low: 1}
+
+// p4rtClient is a P4Runtime client of a device, connected as the primary
+// controller.
+type p4rtClient struct {
+	conn     *grpc.ClientConn
+	client   p4.P4RuntimeClient
+	deviceID uint64
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	primary bool
+}
+
+func newP4RTClient(address string, deviceID uint64) (*p4rtClient, error) {
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &p4rtClient{
+		conn:     conn,
+		client:   p4.NewP4RuntimeClient(conn),
+		deviceID: deviceID,
+	}, nil
+}
+
+// isPrimary returns true while the stream channel is open and the client is
+// the primary controller of the device.
+func (c *p4rtClient) isPrimary() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.primary && c.conn.GetState() == connectivity.Ready
+}
+
+func (c *p4rtClient) setPrimary(primary bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.primary = primary
+}
+
+// arbitrate opens the stream channel and becomes the primary controller of
+// the device.
+func (c *p4rtClient) arbitrate(ctx context.Context) error {
+	c.mu.Lock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	// The stream outlives ctx, it is closed by the next arbitration.
+	streamCtx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.primary = false
+	c.mu.Unlock()
+
+	stream, err := c.client.StreamChannel(streamCtx)
+	if err != nil {
+		return err
+	}
+
+	err = stream.Send(&p4.StreamMessageRequest{
+		Update: &p4.StreamMessageRequest_Arbitration{Arbitration: &p4.MasterArbitrationUpdate{
+			DeviceId:   c.deviceID,
+			ElectionId: p4rtElectionID,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	resps := make(chan *p4.StreamMessageResponse, 1)
+	errs := make(chan error, 1)
+
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				c.setPrimary(false)
+
+				if !errors.Is(err, io.EOF) && status.Code(err) != codes.Canceled {
+					logger.PfcpLog.Warnln("P4Runtime stream channel closed:", err)
+				}
+
+				errs <- err
+
+				return
+			}
+
+			if resp.GetArbitration() == nil {
+				continue
+			}
+
+			select {
+			case resps <- resp:
+			default:
+				// The arbitration already completed, the update tells
+				// whether the client is still the primary.
+				c.setPrimary(codes.Code(resp.GetArbitration().GetStatus().GetCode()) == codes.OK)
+			}
+		}
+	}()
+
+	select {
+	case resp := <-resps:
+		st := resp.GetArbitration().GetStatus()
+		if codes.Code(st.GetCode()) != codes.OK {
+			return ErrOperationFailedWithReason("P4Runtime arbitration", "not the primary controller: "+st.GetMessage())
+		}
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	c.setPrimary(true)
+
+	return nil
+}
+
+// getP4Info returns the P4Info of the pipeline running on the device.
+func (c *p4rtClient) getP4Info(ctx context.Context) (*p4config.P4Info, error) {
+	resp, err := c.client.GetForwardingPipelineConfig(ctx, &p4.GetForwardingPipelineConfigRequest{
+		DeviceId:     c.deviceID,
+		ResponseType: p4.GetForwardingPipelineConfigRequest_P4INFO_AND_COOKIE,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	info := resp.GetConfig().GetP4Info()
+	if info == nil {
+		return nil, ErrNotFound("P4Info of the device pipeline")
+	}
+
+	return info, nil
+}
+
+// write applies the updates to the device, and returns the result of each
+// update. A failed update is returned as a gRPC status error with the code
+// reported by the device.
+func (c *p4rtClient) write(ctx context.Context, updates []*p4.Update) []error {
+	errs := make([]error, len(updates))
+	if len(updates) == 0 {
+		return errs
+	}
+
+	_, err := c.client.Write(ctx, &p4.WriteRequest{
+		DeviceId:   c.deviceID,
+		ElectionId: p4rtElectionID,
+		Updates:    updates,
+	})
+	if err == nil {
+		return errs
+	}
+
+	// The device reports the result of every update as a p4.Error detail.
+	details := status.Convert(err).Details()
+	if len(details) != len(updates) {
+		for i := range errs {
+			errs[i] = err
+		}
+
+		return errs
+	}
+
+	for i, d := range details {
+		p4err, ok := d.(*p4.Error)
+		if !ok {
+			errs[i] = err
+			continue
+		}
+
+		if codes.Code(p4err.CanonicalCode) != codes.OK {
+			errs[i] = status.Error(codes.Code(p4err.CanonicalCode), p4err.Message)
+		}
+	}
+
+	return errs
+}
+
+// read returns the entities of the device matching the given ones.
+func (c *p4rtClient) read(ctx context.Context, entities ...*p4.Entity) ([]*p4.Entity, error) {
+	stream, err := c.client.Read(ctx, &p4.ReadRequest{DeviceId: c.deviceID, Entities: entities})
+	if err != nil {
+		return nil, err
+	}
+
+	var read []*p4.Entity
+
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return read, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		read = append(read, resp.Entities...)
+	}
+}
+
+func (c *p4rtClient) close() {
+	c.mu.Lock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.mu.Unlock()
+
+	c.conn.Close()
+}
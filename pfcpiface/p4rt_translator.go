@@ -0,0 +1,323 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	p4config "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4 "github.com/p4lang/p4runtime/go/p4/v1"
+)
+
+// p4Match is the value of a match field of a table entry.
+type p4Match struct {
+	field string
+	kind  p4config.MatchField_MatchType
+	value uint64
+	// mask is the mask of ternary matches, and the prefix length of LPM ones.
+	mask uint64
+	// high is the upper bound of range matches.
+	high uint64
+}
+
+func exactMatch(field string, value uint64) p4Match {
+	return p4Match{field: field, kind: p4config.MatchField_EXACT, value: value}
+}
+
+func lpmMatch(field string, value uint64, prefixLen int) p4Match {
+	return p4Match{field: field, kind: p4config.MatchField_LPM, value: value, mask: uint64(prefixLen)}
+}
+
+func ternaryMatch(field string, value, mask uint64) p4Match {
+	return p4Match{field: field, kind: p4config.MatchField_TERNARY, value: value, mask: mask}
+}
+
+func rangeMatch(field string, low, high uint64) p4Match {
+	return p4Match{field: field, kind: p4config.MatchField_RANGE, value: low, high: high}
+}
+
+// p4Param is the value of an action parameter.
+type p4Param struct {
+	name  string
+	value uint64
+}
+
+// p4Translator builds the P4Runtime entities of a pipeline from the names of
+// its tables, actions, meters and counters.
+type p4Translator struct {
+	tables   map[string]*p4config.Table
+	actions  map[string]*p4config.Action
+	meters   map[string]*p4config.Meter
+	counters map[string]*p4config.Counter
+}
+
+func newP4Translator(info *p4config.P4Info) *p4Translator {
+	t := &p4Translator{
+		tables:   make(map[string]*p4config.Table),
+		actions:  make(map[string]*p4config.Action),
+		meters:   make(map[string]*p4config.Meter),
+		counters: make(map[string]*p4config.Counter),
+	}
+
+	for _, tb := range info.Tables {
+		t.tables[tb.Preamble.Name] = tb
+	}
+
+	for _, a := range info.Actions {
+		t.actions[a.Preamble.Name] = a
+	}
+
+	for _, m := range info.Meters {
+		t.meters[m.Preamble.Name] = m
+	}
+
+	for _, c := range info.Counters {
+		t.counters[c.Preamble.Name] = c
+	}
+
+	return t
+}
+
+// encodeValue returns the canonical binary string of value: the shortest
+// big-endian representation, which must fit in bitwidth bits.
+func encodeValue(value uint64, bitwidth int32) ([]byte, error) {
+	if bitwidth < 64 && value>>uint(bitwidth) != 0 {
+		return nil, ErrInvalidArgumentWithReason("value", value, fmt.Sprintf("exceeds %d bits", bitwidth))
+	}
+
+	b := new(big.Int).SetUint64(value).Bytes()
+	if len(b) == 0 {
+		return []byte{0}, nil
+	}
+
+	return b, nil
+}
+
+// checkNames returns an error unless the pipeline has the tables, actions,
+// meters and counters with the given names.
+func (t *p4Translator) checkNames(tables, actions, meters, counters []string) error {
+	for _, n := range tables {
+		if t.tables[n] == nil {
+			return ErrNotFoundWithParam("P4 table", "name", n)
+		}
+	}
+
+	for _, n := range actions {
+		if t.actions[n] == nil {
+			return ErrNotFoundWithParam("P4 action", "name", n)
+		}
+	}
+
+	for _, n := range meters {
+		if t.meters[n] == nil {
+			return ErrNotFoundWithParam("P4 meter", "name", n)
+		}
+	}
+
+	for _, n := range counters {
+		if t.counters[n] == nil {
+			return ErrNotFoundWithParam("P4 counter", "name", n)
+		}
+	}
+
+	return nil
+}
+
+// fieldBitwidth returns the width of a match field of a table, 0 if the
+// table has no such field.
+func (t *p4Translator) fieldBitwidth(table, field string) int32 {
+	for _, mf := range t.tables[table].GetMatchFields() {
+		if mf.Name == field {
+			return mf.Bitwidth
+		}
+	}
+
+	return 0
+}
+
+// tableSize returns the maximum number of entries of a table.
+func (t *p4Translator) tableSize(table string) int64 {
+	return t.tables[table].GetSize()
+}
+
+// meterSize returns the number of cells of a meter.
+func (t *p4Translator) meterSize(meter string) int64 {
+	return t.meters[meter].GetSize()
+}
+
+// counterSize returns the number of cells of a counter.
+func (t *p4Translator) counterSize(counter string) int64 {
+	return t.counters[counter].GetSize()
+}
+
+func (t *p4Translator) fieldMatch(table *p4config.Table, m p4Match) (*p4.FieldMatch, error) {
+	var mf *p4config.MatchField
+
+	for _, f := range table.MatchFields {
+		if f.Name == m.field {
+			mf = f
+		}
+	}
+
+	if mf == nil || mf.GetMatchType() != m.kind {
+		return nil, ErrNotFoundWithParam(fmt.Sprintf("%v match field of table %s", m.kind, table.Preamble.Name), "name", m.field)
+	}
+
+	value, err := encodeValue(m.value, mf.Bitwidth)
+	if err != nil {
+		return nil, err
+	}
+
+	fm := &p4.FieldMatch{FieldId: mf.Id}
+
+	switch m.kind {
+	case p4config.MatchField_EXACT:
+		fm.FieldMatchType = &p4.FieldMatch_Exact_{Exact: &p4.FieldMatch_Exact{Value: value}}
+	case p4config.MatchField_LPM:
+		fm.FieldMatchType = &p4.FieldMatch_Lpm{Lpm: &p4.FieldMatch_LPM{Value: value, PrefixLen: int32(m.mask)}}
+	case p4config.MatchField_TERNARY:
+		mask, err := encodeValue(m.mask, mf.Bitwidth)
+		if err != nil {
+			return nil, err
+		}
+
+		fm.FieldMatchType = &p4.FieldMatch_Ternary_{Ternary: &p4.FieldMatch_Ternary{Value: value, Mask: mask}}
+	case p4config.MatchField_RANGE:
+		high, err := encodeValue(m.high, mf.Bitwidth)
+		if err != nil {
+			return nil, err
+		}
+
+		fm.FieldMatchType = &p4.FieldMatch_Range_{Range: &p4.FieldMatch_Range{Low: value, High: high}}
+	default:
+		return nil, ErrUnsupported("match type", m.kind)
+	}
+
+	return fm, nil
+}
+
+// isDontCare returns true if the match accepts any value, in which case
+// P4Runtime requires it to be omitted.
+func (m p4Match) isDontCare(bitwidth int32) bool {
+	switch m.kind {
+	case p4config.MatchField_LPM:
+		return m.mask == 0
+	case p4config.MatchField_TERNARY:
+		return m.mask == 0
+	case p4config.MatchField_RANGE:
+		return m.value == 0 && (bitwidth >= 64 || m.high == 1<<uint(bitwidth)-1)
+	}
+
+	return false
+}
+
+// tableEntry returns the entry of the table with the given matches and
+// action. Matches accepting any value are omitted.
+func (t *p4Translator) tableEntry(table string, matches []p4Match, priority int32,
+	action string, params ...p4Param,
+) (*p4.TableEntry, error) {
+	tb := t.tables[table]
+	if tb == nil {
+		return nil, ErrNotFoundWithParam("P4 table", "name", table)
+	}
+
+	entry := &p4.TableEntry{TableId: tb.Preamble.Id, Priority: priority}
+
+	for _, m := range matches {
+		if m.isDontCare(t.fieldBitwidth(table, m.field)) {
+			continue
+		}
+
+		fm, err := t.fieldMatch(tb, m)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Match = append(entry.Match, fm)
+	}
+
+	a := t.actions[action]
+	if a == nil {
+		return nil, ErrNotFoundWithParam("P4 action", "name", action)
+	}
+
+	pa := &p4.Action{ActionId: a.Preamble.Id}
+
+	for _, p := range params {
+		var ap *p4config.Action_Param
+
+		for _, candidate := range a.Params {
+			if candidate.Name == p.name {
+				ap = candidate
+			}
+		}
+
+		if ap == nil {
+			return nil, ErrNotFoundWithParam("parameter of action "+action, "name", p.name)
+		}
+
+		value, err := encodeValue(p.value, ap.Bitwidth)
+		if err != nil {
+			return nil, err
+		}
+
+		pa.Params = append(pa.Params, &p4.Action_Param{ParamId: ap.Id, Value: value})
+	}
+
+	entry.Action = &p4.TableAction{Type: &p4.TableAction_Action{Action: pa}}
+
+	return entry, nil
+}
+
+// meterEntry returns the cell of the meter at index, configured with the
+// given rates in bytes per second and bursts in bytes, or reset if config is
+// nil.
+func (t *p4Translator) meterEntry(meter string, index int64, config *p4.MeterConfig) (*p4.MeterEntry, error) {
+	m := t.meters[meter]
+	if m == nil {
+		return nil, ErrNotFoundWithParam("P4 meter", "name", meter)
+	}
+
+	return &p4.MeterEntry{MeterId: m.Preamble.Id, Index: &p4.Index{Index: index}, Config: config}, nil
+}
+
+// counterEntry returns the cell of the counter at index. A nil index selects
+// all the cells of the counter.
+func (t *p4Translator) counterEntry(counter string, index *p4.Index, data *p4.CounterData) (*p4.CounterEntry, error) {
+	c := t.counters[counter]
+	if c == nil {
+		return nil, ErrNotFoundWithParam("P4 counter", "name", counter)
+	}
+
+	return &p4.CounterEntry{CounterId: c.Preamble.Id, Index: index, Data: data}, nil
+}
+
+// entryKey identifies the table entries with the same table, matches and
+// priority.
+func entryKey(e *p4.TableEntry) string {
+	var key strings.Builder
+
+	fmt.Fprintf(&key, "%x", e.TableId)
+
+	for _, m := range e.Match {
+		fmt.Fprintf(&key, "/%d=", m.FieldId)
+
+		switch v := m.FieldMatchType.(type) {
+		case *p4.FieldMatch_Exact_:
+			fmt.Fprintf(&key, "%x", v.Exact.Value)
+		case *p4.FieldMatch_Lpm:
+			fmt.Fprintf(&key, "%x/%d", v.Lpm.Value, v.Lpm.PrefixLen)
+		case *p4.FieldMatch_Ternary_:
+			fmt.Fprintf(&key, "%x&%x", v.Ternary.Value, v.Ternary.Mask)
+		case *p4.FieldMatch_Range_:
+			fmt.Fprintf(&key, "%x-%x", v.Range.Low, v.Range.High)
+		}
+	}
+
+	fmt.Fprintf(&key, "/p%d", e.Priority)
+
+	return key.String()
+}
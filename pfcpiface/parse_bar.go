@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+type bar struct {
+	barID uint8
+	fseID uint64
+
+	// Downlink Data Notification Delay, applied before sending a DDN.
+	ddnDelay time.Duration
+	// Suggested Buffering Packets Count, zero means no limit.
+	suggestedPktCount uint8
+
+	// DL Buffering Suggested Packet Count of the last Session Report
+	// Response, overriding suggestedPktCount while the session buffers.
+	// discard is set by a zero DL Buffering Duration.
+	dlPktLimit uint16
+	discard    bool
+}
+
+// bufferLimit returns the number of downlink packets that may be buffered,
+// and false if the BAR sets no limit.
+func (b bar) bufferLimit() (uint16, bool) {
+	switch {
+	case b.discard:
+		return 0, true
+	case b.dlPktLimit != 0:
+		return b.dlPktLimit, true
+	case b.suggestedPktCount != 0:
+		return uint16(b.suggestedPktCount), true
+	}
+
+	return 0, false
+}
+
+func (b bar) String() string {
+	return fmt.Sprintf("BAR(id=%v, F-SEID=%v, notificationDelay=%v, suggestedBufferingPackets=%v)",
+		b.barID, b.fseID, b.ddnDelay, b.suggestedPktCount)
+}
+
+// parseBAR parses a Create BAR or Update BAR IE. For updates, only the fields
+// present in the IE are overwritten, so the existing rule should be parsed into.
+func (b *bar) parseBAR(barIE *ie.IE, seid uint64) error {
+	b.fseID = seid
+
+	barID, err := barIE.BARID()
+	if err != nil {
+		logger.PfcpLog.Errorln("could not read BAR ID")
+		return err
+	}
+
+	b.barID = barID
+
+	if delay, err := barIE.DownlinkDataNotificationDelay(); err == nil {
+		b.ddnDelay = delay
+	}
+
+	if count, err := barIE.SuggestedBufferingPacketsCount(); err == nil {
+		b.suggestedPktCount = count
+	}
+
+	return nil
+}
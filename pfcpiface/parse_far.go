@@ -5,6 +5,7 @@ package pfcpiface
 
 import (
 	"fmt"
+	"net"
 
 	"github.com/omec-project/upf-epc/logger"
 	"github.com/wmnsk/go-pfcp/ie"
@@ -43,16 +44,41 @@ type far struct {
 	tunnelIP4Dst  uint32
 	tunnelTEID    uint32
 	tunnelPort    uint16
+
+	// IPv6 tunnel endpoints, tunnelIP6Dst is nil for IPv4 tunnels.
+	tunnelIP6Src net.IP
+	tunnelIP6Dst net.IP
+
+	// BAR applied when the FAR buffers downlink packets.
+	barID  uint8
+	hasBAR bool
+	// number of downlink packets the datapath may buffer, if limited by the BAR.
+	bufferPackets uint16
+	bufferLimited bool
+
+	// apply action of the CP function, saved while the FAR drops packets
+	// because a quota of its PDRs is exhausted. Zero if not blocked.
+	quotaAction uint8
 }
 
 func (f far) String() string {
+	tunnelSrc, tunnelDst := int2ip(f.tunnelIP4Src), int2ip(f.tunnelIP4Dst)
+	if f.hasIPv6Tunnel() {
+		tunnelSrc, tunnelDst = f.tunnelIP6Src, f.tunnelIP6Dst
+	}
+
 	return fmt.Sprintf("FAR(id=%v, F-SEID=%v, F-SEID IPv4=%v, dstInterface=%v, tunnelType=%v, "+
-		"tunnelIPv4Src=%v, tunnelIPv4Dst=%v, tunnelTEID=%v, tunnelSrcPort=%v, "+
+		"tunnelSrc=%v, tunnelDst=%v, tunnelTEID=%v, tunnelSrcPort=%v, "+
 		"sendEndMarker=%v, drops=%v, forwards=%v, buffers=%v)", f.farID, f.fseID, int2ip(f.fseidIP), f.dstIntf,
-		f.tunnelType, int2ip(f.tunnelIP4Src), int2ip(f.tunnelIP4Dst), f.tunnelTEID, f.tunnelPort, f.sendEndMarker,
+		f.tunnelType, tunnelSrc, tunnelDst, f.tunnelTEID, f.tunnelPort, f.sendEndMarker,
 		f.Drops(), f.Forwards(), f.Buffers())
 }
 
+// hasIPv6Tunnel returns true if the FAR encapsulates packets in GTP-U over IPv6.
+func (f far) hasIPv6Tunnel() bool {
+	return f.tunnelIP6Dst != nil
+}
+
 func (f *far) Drops() bool {
 	return f.applyAction&ActionDrop != 0
 }
@@ -73,6 +99,11 @@ func (f *far) parseFAR(farIE *ie.IE, fseid uint64, upf *upf, op operation) error
 		return err
 	}
 
+	if barID, err := farIE.BARID(); err == nil {
+		f.barID = barID
+		f.hasBAR = true
+	}
+
 	f.sendEndMarker = false
 
 	var fields Bits
@@ -90,7 +121,16 @@ func (f *far) parseFAR(farIE *ie.IE, fseid uint64, upf *upf, op operation) error
 			}
 
 			f.tunnelTEID = ohcFields.TEID
-			f.tunnelIP4Dst = ip2int(ohcFields.IPv4Address)
+
+			// Prefer IPv4 if the CP function lets the UPF choose.
+			if ohcFields.HasIPv4() {
+				f.tunnelIP4Dst = ip2int(ohcFields.IPv4Address)
+				f.tunnelIP6Dst = nil
+			} else if ohcFields.HasIPv6() {
+				f.tunnelIP4Dst = 0
+				f.tunnelIP6Dst = ohcFields.IPv6Address
+			}
+
 			f.tunnelType = uint8(1) // Preserve the existing tunnel type encoding for GTP-U forwarding.
 			f.tunnelPort = tunnelGTPUPort
 		case ie.DestinationInterface:
@@ -105,8 +145,10 @@ func (f *far) parseFAR(farIE *ie.IE, fseid uint64, upf *upf, op operation) error
 			switch f.dstIntf {
 			case ie.DstInterfaceAccess:
 				f.tunnelIP4Src = ip2int(upf.accessIP)
+				f.tunnelIP6Src = upf.accessIP6
 			case ie.DstInterfaceCore:
 				f.tunnelIP4Src = ip2int(upf.coreIP)
+				f.tunnelIP6Src = upf.coreIP6
 			}
 		case ie.PFCPSMReqFlags:
 			fields = Set(fields, FwdIEPfcpSMReqFlags)
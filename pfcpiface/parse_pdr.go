@@ -266,6 +266,14 @@ type applicationFilter struct {
 	srcIPMask uint32
 	dstIPMask uint32
 	protoMask uint8
+
+	// IPv6 addresses, set when the PDR matches IPv6 traffic.
+	srcIP6     net.IP
+	dstIP6     net.IP
+	srcIP6Mask net.IPMask
+	dstIP6Mask net.IPMask
+	// ipv6Only is set when the PDR does not match IPv4 traffic.
+	ipv6Only bool
 }
 
 type pdr struct {
@@ -275,6 +283,12 @@ type pdr struct {
 	tunnelTEID      uint32
 	ueAddress       uint32
 
+	// IPv6 F-TEID address and UE prefix, nil for IPv4-only PDRs.
+	tunnelIP6Dst net.IP
+	ueAddress6   *net.IPNet
+	// IP versions of the F-TEID to allocate, as in the F-TEID IE flags.
+	fteidFlags uint8
+
 	srcIfaceMask     uint8
 	tunnelIP4DstMask uint32
 	tunnelTEIDMask   uint32
@@ -288,37 +302,100 @@ type pdr struct {
 	ctrID       uint32
 	farID       uint32
 	qerIDList   []uint32
+	urrIDList   []uint32
 	needDecap   uint8
 	allocIPFlag bool
 }
 
+// defaultIPv6PrefixLen is the length of the IPv6 prefix of a UE when the
+// UE IP Address IE does not carry one.
+const defaultIPv6PrefixLen = 64
+
+// needAllocIP returns true if the UPF must allocate the UE IPv4 address.
 func needAllocIP(ueIPaddr *ie.UEIPAddressFields) bool {
-	if has2ndBit(ueIPaddr.Flags) && !has5thBit(ueIPaddr.Flags) {
+	if has5thBit(ueIPaddr.Flags) {
+		return true
+	}
+
+	// The CP function provided the UE address.
+	if has2ndBit(ueIPaddr.Flags) || has1stBit(ueIPaddr.Flags) {
 		return false
 	}
 
 	return true
 }
 
+// needAllocIPv6 returns true if the UPF must allocate the UE IPv6 prefix.
+func needAllocIPv6(ueIPaddr *ie.UEIPAddressFields) bool {
+	return has6thBit(ueIPaddr.Flags)
+}
+
+// chooseIPv6PrefixLen returns the length of the UE IPv6 prefix requested or
+// provided by the CP function.
+func chooseIPv6PrefixLen(ueIPaddr *ie.UEIPAddressFields) int {
+	switch {
+	case has7thBit(ueIPaddr.Flags):
+		return int(ueIPaddr.IPv6PrefixLength)
+	case has4thBit(ueIPaddr.Flags):
+		return defaultIPv6PrefixLen - int(ueIPaddr.IPv6PrefixDelegationBits)
+	default:
+		return defaultIPv6PrefixLen
+	}
+}
+
+// ueIPv6Prefix returns the UE IPv6 prefix provided by the CP function.
+func ueIPv6Prefix(ueIPaddr *ie.UEIPAddressFields) *net.IPNet {
+	mask := net.CIDRMask(chooseIPv6PrefixLen(ueIPaddr), net.IPv6len*8)
+	if mask == nil {
+		return nil
+	}
+
+	return &net.IPNet{IP: ueIPaddr.IPv6Address.Mask(mask), Mask: mask}
+}
+
 func (af applicationFilter) String() string {
-	return fmt.Sprintf("ApplicationFilter(srcIP=%v/%x, dstIP=%v/%x, proto=%v/%x, srcPort=%v, dstPort=%v)",
+	s := fmt.Sprintf("ApplicationFilter(srcIP=%v/%x, dstIP=%v/%x, proto=%v/%x, srcPort=%v, dstPort=%v",
 		int2ip(af.srcIP), af.srcIPMask, int2ip(af.dstIP), af.dstIPMask, af.proto,
 		af.protoMask, af.srcPortRange, af.dstPortRange)
+
+	if af.srcIP6 != nil {
+		s += fmt.Sprintf(", srcIPv6=%v/%v, dstIPv6=%v/%v, IPv6 only=%v",
+			af.srcIP6, af.srcIP6Mask, af.dstIP6, af.dstIP6Mask, af.ipv6Only)
+	}
+
+	return s + ")"
 }
 
 func (p pdr) String() string {
-	return fmt.Sprintf("PDR(id=%v, F-SEID=%v, srcIface=%v, tunnelIPv4Dst=%v/%x, "+
-		"tunnelTEID=%v/%x, ueAddress=%v, applicationFilter=%v, precedence=%v, F-SEID IP=%v, "+
-		"counterID=%v, farID=%v, qerIDs=%v, needDecap=%v, allocIPFlag=%v)",
-		p.pdrID, p.fseID, p.srcIface, int2ip(p.tunnelIP4Dst), p.tunnelIP4DstMask,
-		p.tunnelTEID, p.tunnelTEIDMask, int2ip(p.ueAddress), p.appFilter, p.precedence,
-		p.fseidIP, p.ctrID, p.farID, p.qerIDList, p.needDecap, p.allocIPFlag)
+	return fmt.Sprintf("PDR(id=%v, F-SEID=%v, srcIface=%v, tunnelIPv4Dst=%v/%x, tunnelIPv6Dst=%v, "+
+		"tunnelTEID=%v/%x, ueAddress=%v, ueAddressIPv6=%v, applicationFilter=%v, precedence=%v, F-SEID IP=%v, "+
+		"counterID=%v, farID=%v, qerIDs=%v, urrIDs=%v, needDecap=%v, allocIPFlag=%v)",
+		p.pdrID, p.fseID, p.srcIface, int2ip(p.tunnelIP4Dst), p.tunnelIP4DstMask, p.tunnelIP6Dst,
+		p.tunnelTEID, p.tunnelTEIDMask, int2ip(p.ueAddress), p.ueAddress6, p.appFilter, p.precedence,
+		p.fseidIP, p.ctrID, p.farID, p.qerIDList, p.urrIDList, p.needDecap, p.allocIPFlag)
 }
 
 func (p pdr) IsAppFilterEmpty() bool {
 	return p.appFilter.proto == 0 &&
-		((p.IsUplink() && p.appFilter.dstIP == 0 && p.appFilter.dstPortRange.isWildcardMatch()) ||
-			(p.IsDownlink() && p.appFilter.srcIP == 0 && p.appFilter.srcPortRange.isWildcardMatch()))
+		((p.IsUplink() && p.appFilter.dstIP == 0 && isIPv6Wildcard(p.appFilter.dstIP6Mask) &&
+			p.appFilter.dstPortRange.isWildcardMatch()) ||
+			(p.IsDownlink() && p.appFilter.srcIP == 0 && isIPv6Wildcard(p.appFilter.srcIP6Mask) &&
+				p.appFilter.srcPortRange.isWildcardMatch()))
+}
+
+func isIPv6Wildcard(mask net.IPMask) bool {
+	ones, _ := mask.Size()
+	return ones == 0
+}
+
+// hasIPv4 returns true if the PDR matches IPv4 traffic.
+func (p pdr) hasIPv4() bool {
+	return !p.appFilter.ipv6Only
+}
+
+// hasIPv6 returns true if the PDR matches IPv6 traffic.
+func (p pdr) hasIPv6() bool {
+	return p.appFilter.srcIP6 != nil
 }
 
 func (p pdr) IsUplink() bool {
@@ -337,8 +414,39 @@ func (p *pdr) parseUEAddressIE(ueAddrIE *ie.IE, ippool *IPPool) error {
 		return err
 	}
 
+	if needAllocIPv6(ueIPaddr) {
+		logger.PfcpLog.Infof("UPF should alloc UE IPv6 prefix for SEID %v. CHV6 flag set", p.fseID)
+
+		if ippool == nil {
+			return ErrOperationFailedWithReason("parse UE Address IE", "UE IP allocation is disabled")
+		}
+
+		// The pool only hands out prefixes of the default length.
+		if prefixLen := chooseIPv6PrefixLen(ueIPaddr); prefixLen != defaultIPv6PrefixLen {
+			return ErrUnsupported("allocated UE IPv6 prefix length", prefixLen)
+		}
+
+		p.ueAddress6, err = ippool.LookupOrAllocIPv6Prefix(p.fseID)
+		if err != nil {
+			logger.PfcpLog.Errorln("failed to allocate UE IPv6 prefix")
+			return err
+		}
+
+		p.allocIPFlag = true
+	} else if has1stBit(ueIPaddr.Flags) {
+		p.ueAddress6 = ueIPv6Prefix(ueIPaddr)
+		if p.ueAddress6 == nil || len(ueIPaddr.IPv6Address) != net.IPv6len {
+			return ErrOperationFailedWithParam("parse UE Address IE",
+				"IPv6 address", ueIPaddr.IPv6Address)
+		}
+	}
+
+	// Only IPv6 is used by the session.
+	if !has2ndBit(ueIPaddr.Flags) && !has5thBit(ueIPaddr.Flags) && p.ueAddress6 != nil {
+		return nil
+	}
+
 	if needAllocIP(ueIPaddr) {
-		/* alloc IPV6 if CHV6 is enabled : TBD */
 		logger.PfcpLog.Infof("UPF should alloc UE IP for SEID %v. CHV4 flag set", p.fseID)
 
 		ueIP4, err = ippool.LookupOrAllocIP(p.fseID)
@@ -394,13 +502,44 @@ func (p *pdr) parseFTEID(teidIE *ie.IE) error {
 	teid := fteid.TEID
 	if fteid.HasCh() {
 		p.UPAllocateFteid = true
+		p.fteidFlags = fteid.Flags
 	} else if teid != 0 {
 		p.tunnelTEID = teid
 		p.tunnelTEIDMask = 0xFFFFFFFF
-		p.tunnelIP4Dst = ip2int(fteid.IPv4Address)
+
+		if fteid.HasIPv4() {
+			p.tunnelIP4Dst = ip2int(fteid.IPv4Address)
+			p.tunnelIP4DstMask = 0xFFFFFFFF
+		}
+
+		// IPv6 F-TEIDs are matched on the TEID only.
+		if fteid.HasIPv6() {
+			p.tunnelIP6Dst = fteid.IPv6Address
+		}
+	}
+
+	return nil
+}
+
+// setLocalFTEIDAddress sets the addresses of an F-TEID allocated by the UPF,
+// of the IP versions requested by the CP function.
+func (p *pdr) setLocalFTEIDAddress(accessIP, accessIP6 net.IP) error {
+	wantIPv6 := has2ndBit(p.fteidFlags)
+	wantIPv4 := has1stBit(p.fteidFlags) || !wantIPv6
+
+	if wantIPv4 && accessIP.To4() != nil {
+		p.tunnelIP4Dst = ip2int(accessIP)
 		p.tunnelIP4DstMask = 0xFFFFFFFF
 	}
 
+	if wantIPv6 && accessIP6 != nil {
+		p.tunnelIP6Dst = accessIP6
+	}
+
+	if p.tunnelIP4DstMask == 0 && p.tunnelIP6Dst == nil {
+		return ErrOperationFailedWithReason("F-TEID allocation", "no access address of the requested IP version")
+	}
+
 	return nil
 }
 
@@ -423,11 +562,16 @@ func (p *pdr) parseApplicationID(ie *ie.IE, appPFDs map[string]appPFD) error {
 		logger := logger.PfcpLog.With("Application ID", apfd.appID, "Flow Description", flowDesc)
 		logger.Debug("Parsing flow description of Application ID IE")
 
-		ipf, err := parseFlowDesc(flowDesc, int2ip(p.ueAddress).String())
+		ipf4, ipf6, err := p.parseFlowDescByFamily(flowDesc)
 		if err != nil {
 			return errBadFilterDesc
 		}
 
+		ipf := ipf4
+		if ipf == nil {
+			ipf = ipf6
+		}
+
 		if (p.srcIface == access && ipf.direction == "out") ||
 			(p.srcIface == core && ipf.direction == "in") {
 			logger.Debug("Found a matching flow description")
@@ -437,10 +581,7 @@ func (p *pdr) parseApplicationID(ie *ie.IE, appPFDs map[string]appPFD) error {
 				p.appFilter.protoMask = math.MaxUint8
 			}
 			// TODO: Verify assumption that flow description in case of PFD is to be taken as-is
-			p.appFilter.dstIP = ip2int(ipf.dst.IPNet.IP)
-			p.appFilter.dstIPMask = ipMask2int(ipf.dst.IPNet.Mask)
-			p.appFilter.srcIP = ip2int(ipf.src.IPNet.IP)
-			p.appFilter.srcIPMask = ipMask2int(ipf.src.IPNet.Mask)
+			p.setAppFilterAddresses(ipf4, ipf6, false)
 			p.appFilter.dstPortRange = ipf.dst.ports
 			p.appFilter.srcPortRange = ipf.src.ports
 
@@ -476,22 +617,25 @@ func (p *pdr) parseSDFFilter(ie *ie.IE) error {
 
 	logger.PfcpLog.With("Flow Description", flowDesc).Debugln("parsing Flow Description from SDF Filter")
 
-	ipf, err := parseFlowDesc(flowDesc, int2ip(p.ueAddress).String())
+	ipf4, ipf6, err := p.parseFlowDescByFamily(flowDesc)
 	if err != nil {
 		return errBadFilterDesc
 	}
 
+	ipf := ipf4
+	if ipf == nil {
+		ipf = ipf6
+	}
+
 	if ipf.proto != reservedProto {
 		p.appFilter.proto = ipf.proto
 		p.appFilter.protoMask = math.MaxUint8
 	}
 
+	p.setAppFilterAddresses(ipf4, ipf6, p.srcIface == access)
+
 	switch p.srcIface {
 	case core:
-		p.appFilter.dstIP = ip2int(ipf.dst.IPNet.IP)
-		p.appFilter.dstIPMask = ipMask2int(ipf.dst.IPNet.Mask)
-		p.appFilter.srcIP = ip2int(ipf.src.IPNet.IP)
-		p.appFilter.srcIPMask = ipMask2int(ipf.src.IPNet.Mask)
 		p.appFilter.dstPortRange = ipf.dst.ports
 		p.appFilter.srcPortRange = ipf.src.ports
 
@@ -502,10 +646,6 @@ func (p *pdr) parseSDFFilter(ie *ie.IE) error {
 			p.appFilter.dstPortRange = newWildcardPortRange()
 		}
 	case access:
-		p.appFilter.srcIP = ip2int(ipf.dst.IPNet.IP)
-		p.appFilter.srcIPMask = ipMask2int(ipf.dst.IPNet.Mask)
-		p.appFilter.dstIP = ip2int(ipf.src.IPNet.IP)
-		p.appFilter.dstIPMask = ipMask2int(ipf.src.IPNet.Mask)
 		// Ports are flipped for access PDRs
 		p.appFilter.dstPortRange = ipf.src.ports
 		p.appFilter.srcPortRange = ipf.dst.ports
@@ -521,6 +661,71 @@ func (p *pdr) parseSDFFilter(ie *ie.IE) error {
 	return nil
 }
 
+// parseFlowDescByFamily parses the flow description for each IP version of
+// the PDR. A flow description with explicit addresses only applies to their
+// IP version.
+func (p *pdr) parseFlowDescByFamily(flowDesc string) (ipf4, ipf6 *ipFilterRule, err error) {
+	if p.ueAddress != 0 || p.ueAddress6 == nil {
+		ipf4, err = parseFlowDesc(flowDesc, int2ip(p.ueAddress).String())
+		if err == nil && ipf4.isIPv6() {
+			ipf4, ipf6 = nil, ipf4
+		}
+	}
+
+	if p.ueAddress6 != nil && ipf6 == nil {
+		ipf, err6 := parseFlowDesc(flowDesc, p.ueAddress6.String())
+		if err6 == nil && ipf.isIPv6() {
+			ipf6 = ipf
+		} else if err == nil && ipf4 == nil {
+			err = err6
+		}
+	}
+
+	if ipf4 == nil && ipf6 == nil {
+		if err == nil {
+			err = errBadFilterDesc
+		}
+
+		return nil, nil, err
+	}
+
+	return ipf4, ipf6, nil
+}
+
+// setAppFilterAddresses sets the addresses of the application filter from
+// the flow descriptions of each IP version, with source and destination
+// swapped if flip is set.
+func (p *pdr) setAppFilterAddresses(ipf4, ipf6 *ipFilterRule, flip bool) {
+	if ipf4 != nil {
+		src, dst := ipf4.src.IPNet, ipf4.dst.IPNet
+		if flip {
+			src, dst = dst, src
+		}
+
+		p.appFilter.srcIP = ip2int(src.IP)
+		p.appFilter.srcIPMask = ipMask2int(src.Mask)
+		p.appFilter.dstIP = ip2int(dst.IP)
+		p.appFilter.dstIPMask = ipMask2int(dst.Mask)
+	}
+
+	p.appFilter.ipv6Only = ipf4 == nil
+
+	if ipf6 == nil {
+		p.appFilter.srcIP6, p.appFilter.srcIP6Mask = nil, nil
+		p.appFilter.dstIP6, p.appFilter.dstIP6Mask = nil, nil
+
+		return
+	}
+
+	src, dst := ipf6.src.IPNet, ipf6.dst.IPNet
+	if flip {
+		src, dst = dst, src
+	}
+
+	p.appFilter.srcIP6, p.appFilter.srcIP6Mask = src.IP, src.Mask
+	p.appFilter.dstIP6, p.appFilter.dstIP6Mask = dst.IP, dst.Mask
+}
+
 func (p *pdr) parsePDI(pdiIEs []*ie.IE, appPFDs map[string]appPFD, ippool *IPPool) error {
 	for _, pdiIE := range pdiIEs {
 		switch pdiIE.Type {
@@ -552,6 +757,19 @@ func (p *pdr) parsePDI(pdiIEs []*ie.IE, appPFDs map[string]appPFD, ippool *IPPoo
 		p.appFilter.srcIPMask = math.MaxUint32 // /32
 	}
 
+	if p.ueAddress6 != nil {
+		p.appFilter.srcIP6, p.appFilter.srcIP6Mask = net.IPv6zero, net.CIDRMask(0, net.IPv6len*8)
+		p.appFilter.dstIP6, p.appFilter.dstIP6Mask = net.IPv6zero, net.CIDRMask(0, net.IPv6len*8)
+
+		if p.IsDownlink() {
+			p.appFilter.dstIP6, p.appFilter.dstIP6Mask = p.ueAddress6.IP, p.ueAddress6.Mask
+		} else if p.IsUplink() {
+			p.appFilter.srcIP6, p.appFilter.srcIP6Mask = p.ueAddress6.IP, p.ueAddress6.Mask
+		}
+
+		p.appFilter.ipv6Only = p.ueAddress == 0
+	}
+
 	// make another iteration because Application ID and SDF Filter depend on UE IP Address IE
 	for _, ie2 := range pdiIEs {
 		switch ie2.Type {
@@ -575,6 +793,7 @@ func (p *pdr) parsePDR(ie1 *ie.IE, seid uint64, appPFDs map[string]appPFD, ippoo
 	/* reset outerHeaderRemoval to begin with */
 	outerHeaderRemoval := uint8(0)
 	p.qerIDList = make([]uint32, 0)
+	p.urrIDList = nil
 	p.fseID = seid
 
 	pdrID, err := ie1.PDRID()
@@ -596,8 +815,11 @@ func (p *pdr) parsePDR(ie1 *ie.IE, seid uint64, appPFDs map[string]appPFD, ippoo
 	}
 
 	res, err := ie1.OuterHeaderRemovalDescription()
-	if res == 0 && err == nil { // 0 == GTP-U/UDP/IPv4
-		outerHeaderRemoval = 1
+	if err == nil {
+		switch res {
+		case 0, 1, 6: // GTP-U/UDP/IPv4, GTP-U/UDP/IPv6, GTP-U/UDP/IP
+			outerHeaderRemoval = 1
+		}
 	}
 
 	err = p.parsePDI(pdi, appPFDs, ippool)
@@ -611,7 +833,7 @@ func (p *pdr) parsePDR(ie1 *ie.IE, seid uint64, appPFDs map[string]appPFD, ippoo
 		return err
 	}
 
-	/* Multiple instances of QERID can be present in CreatePDR/UpdatePDR
+	/* Multiple instances of QERID and URRID can be present in CreatePDR/UpdatePDR
 	   go-pfcp currently support API to return list of QERIDs. So, we
 	   are parsing the IE list in Application code.*/
 	var ies []*ie.IE
@@ -641,6 +863,16 @@ func (p *pdr) parsePDR(ie1 *ie.IE, seid uint64, appPFDs map[string]appPFD, ippoo
 				p.qerIDList = append(p.qerIDList, qerID)
 			}
 		}
+
+		if x.Type == ie.URRID {
+			urrID, errRead := x.URRID()
+			if errRead != nil {
+				logger.PfcpLog.Errorln("urrID read failed")
+				continue
+			}
+
+			p.urrIDList = append(p.urrIDList, urrID)
+		}
 	}
 	/*qerID, err := ie1.QERID()
 	if err != nil {
@@ -30,6 +30,7 @@ func Test_parsePDR(t *testing.T) {
 	pdrID := uint16(999)
 	precedence := uint32(1)
 	qerID := uint32(4)
+	urrID := uint32(7)
 	farID := uint32(2)
 	teid := uint32(1234)
 
@@ -114,6 +115,34 @@ func Test_parsePDR(t *testing.T) {
 			},
 			description: "Valid downlink Create PDR input",
 		},
+		{
+			input: ie.NewCreatePDR(
+				ie.NewPDRID(pdrID),
+				ie.NewPrecedence(0),
+				ie.NewPDI(
+					ie.NewSourceInterface(ie.SrcInterfaceCore),
+					ie.NewUEIPAddress(0x2, UEAddress.String(), "", 0, 0),
+				),
+				ie.NewFARID(farID),
+				ie.NewQERID(qerID),
+				ie.NewURRID(urrID),
+			),
+			expected: &pdr{
+				pdrID:        uint32(pdrID),
+				fseID:        FSEID,
+				farID:        farID,
+				srcIface:     core,
+				srcIfaceMask: 0xff,
+				ueAddress:    ip2int(UEAddress),
+				qerIDList:    []uint32{qerID},
+				urrIDList:    []uint32{urrID},
+				appFilter: applicationFilter{
+					dstIPMask: math.MaxUint32,
+					dstIP:     ip2int(UEAddress),
+				},
+			},
+			description: "Valid downlink Create PDR input with URR",
+		},
 	} {
 		t.Run(scenario.description, func(t *testing.T) {
 			mockMapPFD := make(map[string]appPFD)
@@ -708,6 +737,17 @@ func Test_pdr_parseSDFFilter(t *testing.T) {
 
 func Test_pdr_parsePDI(t *testing.T) {
 	ueAddress := ipAddrPrimary
+	ueAddress6 := "2001:db8::"
+	any6 := mustParseCIDRNet("::/0")
+
+	ippool6, err := NewIPPool("10.0.0.0/24")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = ippool6.SetIPv6Pool(ueAddress6+"/48", 64); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
 	type args struct {
 		pdiIEs  []*ie.IE
@@ -760,6 +800,117 @@ func Test_pdr_parsePDI(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "uplink PDR - IPv6 UE prefix",
+			args: args{
+				pdiIEs: []*ie.IE{
+					ie.NewUEIPAddress(0x1, "", ueAddress6, 0, 0),
+					ie.NewSourceInterface(ie.SrcInterfaceAccess),
+				},
+			},
+			wantPDR: pdr{
+				srcIface:     access,
+				srcIfaceMask: math.MaxUint8,
+				ueAddress6:   mustParseCIDRNet(ueAddress6 + "/64"),
+				appFilter: applicationFilter{
+					srcIP6:     mustParseCIDRNet(ueAddress6 + "/64").IP,
+					srcIP6Mask: mustParseCIDRNet(ueAddress6 + "/64").Mask,
+					dstIP6:     any6.IP,
+					dstIP6Mask: any6.Mask,
+					ipv6Only:   true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "downlink PDR - dual-stack UE with IPv6 prefix delegation",
+			args: args{
+				pdiIEs: []*ie.IE{
+					ie.NewUEIPAddress(0x0b, ueAddress, ueAddress6, 4, 0),
+					ie.NewSourceInterface(ie.SrcInterfaceCore),
+				},
+			},
+			wantPDR: pdr{
+				srcIface:     core,
+				srcIfaceMask: math.MaxUint8,
+				ueAddress:    ip2int(net.ParseIP(ueAddress)),
+				ueAddress6:   mustParseCIDRNet(ueAddress6 + "/60"),
+				appFilter: applicationFilter{
+					dstIP:      ip2int(net.ParseIP(ueAddress)),
+					dstIPMask:  math.MaxUint32,
+					srcIP6:     any6.IP,
+					srcIP6Mask: any6.Mask,
+					dstIP6:     mustParseCIDRNet(ueAddress6 + "/60").IP,
+					dstIP6Mask: mustParseCIDRNet(ueAddress6 + "/60").Mask,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "downlink PDR - dual-stack UE with IPv6 SDF Filter",
+			args: args{
+				pdiIEs: []*ie.IE{
+					ie.NewUEIPAddress(0x3, ueAddress, ueAddress6, 0, 0),
+					ie.NewSourceInterface(ie.SrcInterfaceCore),
+					ie.NewSDFFilter("permit out udp from 2001:db8:ffff::1 to assigned", "", "", "", 1),
+				},
+			},
+			wantPDR: pdr{
+				srcIface:     core,
+				srcIfaceMask: math.MaxUint8,
+				ueAddress:    ip2int(net.ParseIP(ueAddress)),
+				ueAddress6:   mustParseCIDRNet(ueAddress6 + "/64"),
+				appFilter: applicationFilter{
+					dstIP:        ip2int(net.ParseIP(ueAddress)),
+					dstIPMask:    math.MaxUint32,
+					srcPortRange: newWildcardPortRange(),
+					dstPortRange: newWildcardPortRange(),
+					proto:        17,
+					protoMask:    math.MaxUint8,
+					srcIP6:       mustParseCIDRNet("2001:db8:ffff::1/128").IP,
+					srcIP6Mask:   mustParseCIDRNet("2001:db8:ffff::1/128").Mask,
+					dstIP6:       mustParseCIDRNet(ueAddress6 + "/64").IP,
+					dstIP6Mask:   mustParseCIDRNet(ueAddress6 + "/64").Mask,
+					ipv6Only:     true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "downlink PDR - UPF allocated IPv6 prefix",
+			args: args{
+				pdiIEs: []*ie.IE{
+					ie.NewUEIPAddress(0x21, "", "", 0, 0),
+					ie.NewSourceInterface(ie.SrcInterfaceCore),
+				},
+				ippool: ippool6,
+			},
+			wantPDR: pdr{
+				srcIface:     core,
+				srcIfaceMask: math.MaxUint8,
+				ueAddress6:   mustParseCIDRNet(ueAddress6 + "/64"),
+				allocIPFlag:  true,
+				appFilter: applicationFilter{
+					srcIP6:     any6.IP,
+					srcIP6Mask: any6.Mask,
+					dstIP6:     mustParseCIDRNet(ueAddress6 + "/64").IP,
+					dstIP6Mask: mustParseCIDRNet(ueAddress6 + "/64").Mask,
+					ipv6Only:   true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "downlink PDR - UPF allocated IPv6 prefix of unsupported length",
+			args: args{
+				pdiIEs: []*ie.IE{
+					ie.NewUEIPAddress(0x61, "", "", 0, 56),
+					ie.NewSourceInterface(ie.SrcInterfaceCore),
+				},
+				ippool: ippool6,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -777,3 +928,103 @@ func Test_pdr_parsePDI(t *testing.T) {
 		})
 	}
 }
+
+func Test_pdr_parseFTEID(t *testing.T) {
+	accessIP := net.ParseIP("10.0.0.1")
+	accessIP6 := net.ParseIP("2001:db8::1")
+
+	t.Run("IPv6 F-TEID", func(t *testing.T) {
+		p := pdr{}
+		if err := p.parseFTEID(ie.NewFTEID(0x02, 0x1234, nil, net.ParseIP("2001:db8::2"), 0)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if p.tunnelTEID != 0x1234 || p.tunnelTEIDMask != math.MaxUint32 {
+			t.Errorf("unexpected TEID %v/%x", p.tunnelTEID, p.tunnelTEIDMask)
+		}
+
+		// IPv6 F-TEIDs are not matched on the IPv4 tunnel address.
+		if p.tunnelIP4DstMask != 0 || !p.tunnelIP6Dst.Equal(net.ParseIP("2001:db8::2")) {
+			t.Errorf("unexpected tunnel addresses %v/%x, %v", p.tunnelIP4Dst, p.tunnelIP4DstMask, p.tunnelIP6Dst)
+		}
+	})
+
+	for _, tt := range []struct {
+		name     string
+		flags    uint8
+		accessIP net.IP
+		want4    bool
+		want6    bool
+		wantErr  bool
+	}{
+		{name: "CHOOSE IPv4", flags: 0x05, accessIP: accessIP, want4: true},
+		{name: "CHOOSE IPv6", flags: 0x06, accessIP: accessIP, want6: true},
+		{name: "CHOOSE dual-stack", flags: 0x07, accessIP: accessIP, want4: true, want6: true},
+		{name: "CHOOSE IPv4 on IPv6-only access", flags: 0x05, accessIP: accessIP6, wantErr: true},
+		{name: "CHOOSE IPv6 on IPv6-only access", flags: 0x06, accessIP: accessIP6, want6: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := pdr{}
+			if err := p.parseFTEID(ie.NewFTEID(tt.flags, 0, nil, nil, 0)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !p.UPAllocateFteid {
+				t.Fatal("expected F-TEID allocation by the UPF")
+			}
+
+			err := p.setLocalFTEIDAddress(tt.accessIP, accessIP6)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setLocalFTEIDAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := p.tunnelIP4DstMask != 0; got != tt.want4 {
+				t.Errorf("expected IPv4 address %v, got %v", tt.want4, int2ip(p.tunnelIP4Dst))
+			}
+
+			if got := p.tunnelIP6Dst != nil; got != tt.want6 {
+				t.Errorf("expected IPv6 address %v, got %v", tt.want6, p.tunnelIP6Dst)
+			}
+		})
+	}
+}
+
+func TestAssignCounterID(t *testing.T) {
+	u := &upf{counterIDs: NewFTEIDGenerator()}
+
+	// URR IDs are only unique within a session.
+	a := pdr{pdrID: 1, fseID: 1, urrIDList: []uint32{1}}
+	b := pdr{pdrID: 1, fseID: 2, urrIDList: []uint32{1}}
+	noURR := pdr{pdrID: 2, fseID: 2}
+
+	for _, p := range []*pdr{&a, &b, &noURR} {
+		if err := u.assignCounterID(p, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if a.ctrID == 0 || a.ctrID == b.ctrID {
+		t.Errorf("expected distinct counter indexes, got %v and %v", a.ctrID, b.ctrID)
+	}
+
+	if noURR.ctrID != 0 {
+		t.Errorf("expected no counter index without URRs, got %v", noURR.ctrID)
+	}
+
+	updated := pdr{pdrID: 1, fseID: 2, urrIDList: []uint32{1, 2}}
+	if err := u.assignCounterID(&updated, &b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if updated.ctrID != b.ctrID {
+		t.Errorf("expected updated PDR to keep counter index %v, got %v", b.ctrID, updated.ctrID)
+	}
+
+	staged := PacketForwardingRules{pdrs: []pdr{a, {pdrID: 3, fseID: 1, ctrID: 99}}}
+	u.counterIDs.Reserve(99)
+	releaseNewCounters(u.counterIDs, PacketForwardingRules{pdrs: []pdr{a}}, staged)
+
+	if !u.counterIDs.IsAllocated(a.ctrID) || u.counterIDs.IsAllocated(99) {
+		t.Error("expected only the counter index of the staged PDR to be released")
+	}
+}
@@ -17,6 +17,7 @@ import (
 const (
 	reservedProto         = uint8(0xff)
 	Ipv4WildcardNetString = "0.0.0.0/0"
+	Ipv6WildcardNetString = "::/0"
 )
 
 var errBadFilterDesc = errors.New("unsupported Filter Description format")
@@ -31,7 +32,11 @@ func (ep *endpoint) parseNet(ipnet string) error {
 
 	switch len(ipNetFields) {
 	case 1:
-		ipnet = ipNetFields[0] + "/32"
+		if isIPv6String(ipNetFields[0]) {
+			ipnet = ipNetFields[0] + "/128"
+		} else {
+			ipnet = ipNetFields[0] + "/32"
+		}
 	case 2:
 	default:
 		return ErrInvalidArgument("network string", len(ipNetFields))
@@ -89,6 +94,37 @@ func newIpFilterRule() *ipFilterRule {
 	}
 }
 
+// isIPv6 returns true if the rule matches IPv6 traffic.
+func (ipf *ipFilterRule) isIPv6() bool {
+	for _, ep := range []endpoint{ipf.src, ipf.dst} {
+		if ep.IPNet != nil {
+			return ep.IPNet.IP.To4() == nil
+		}
+	}
+
+	return false
+}
+
+func isIPv6String(ip string) bool {
+	return strings.Contains(ip, ":")
+}
+
+// wildcardNetString returns the wildcard network of the IP version used by
+// the explicit addresses of the flow description, or else by the UE address.
+func wildcardNetString(fields []string, ueIP string) string {
+	for i := 3; i+1 < len(fields); i++ {
+		if (fields[i] == "from" || fields[i] == "to") && isIPv6String(fields[i+1]) {
+			return Ipv6WildcardNetString
+		}
+	}
+
+	if isIPv6String(ueIP) {
+		return Ipv6WildcardNetString
+	}
+
+	return Ipv4WildcardNetString
+}
+
 func (ipf *ipFilterRule) String() string {
 	return fmt.Sprintf("FlowDescription{action=%v, direction=%v, proto=%v, "+
 		"srcIP=%v, srcPort=%v, dstIP=%v, dstPort=%v}",
@@ -123,18 +159,20 @@ func parseFlowDesc(flowDesc, ueIP string) (*ipFilterRule, error) {
 	}
 	ipf.proto = proto
 
+	wildcard := wildcardNetString(fields, ueIP)
+
 	// bring to common intermediate representation
 	xform := func(i int) {
 		switch fields[i] {
 		case "any":
-			fields[i] = Ipv4WildcardNetString
+			fields[i] = wildcard
 		case "assigned":
-			if ueIP == "0.0.0.0" {
-				fields[i] = Ipv4WildcardNetString
+			if ueIP == "0.0.0.0" || ueIP == "::" {
+				fields[i] = wildcard
 			} else if ueIP != "" && ueIP != "<nil>" {
 				fields[i] = ueIP
 			} else {
-				fields[i] = Ipv4WildcardNetString
+				fields[i] = wildcard
 			}
 		}
 	}
@@ -188,6 +226,12 @@ func parseFlowDesc(flowDesc, ueIP string) (*ipFilterRule, error) {
 		}
 	}
 
+	if ipf.src.IPNet != nil && ipf.dst.IPNet != nil &&
+		(ipf.src.IPNet.IP.To4() == nil) != (ipf.dst.IPNet.IP.To4() == nil) {
+		parseLog.Errorln("source and destination IP versions differ")
+		return nil, errBadFilterDesc
+	}
+
 	parseLog = parseLog.With("ip-filter", ipf)
 	parseLog.Debugln("flow description parsed successfully")
 
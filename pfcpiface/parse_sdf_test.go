@@ -496,6 +496,54 @@ func Test_parseFlowDesc(t *testing.T) {
 				},
 			}, wantErr: false,
 		},
+		{
+			name: "IPv6 catch-all",
+			args: args{
+				flowDesc: "permit out ip from any to assigned",
+				ueIP:     "2001:db8::/64",
+			},
+			want: &ipFilterRule{
+				action:    "permit",
+				direction: "out",
+				proto:     reservedProto,
+				src: endpoint{
+					IPNet: mustParseCIDRNet("::/0"),
+					ports: newWildcardPortRange(),
+				},
+				dst: endpoint{
+					IPNet: mustParseCIDRNet("2001:db8::/64"),
+					ports: newWildcardPortRange(),
+				},
+			}, wantErr: false,
+		},
+		{
+			name: "from IPv6 host to unknown assigned UE IP",
+			args: args{
+				flowDesc: "permit out udp from 2001:db8:ffff::1 80 to assigned",
+				ueIP:     "0.0.0.0",
+			},
+			want: &ipFilterRule{
+				action:    "permit",
+				direction: "out",
+				proto:     udpProto,
+				src: endpoint{
+					IPNet: mustParseCIDRNet("2001:db8:ffff::1/128"),
+					ports: newExactMatchPortRange(80),
+				},
+				dst: endpoint{
+					IPNet: mustParseCIDRNet("::/0"),
+					ports: newWildcardPortRange(),
+				},
+			}, wantErr: false,
+		},
+		{
+			name: "IPv6 host to IPv4 UE",
+			args: args{
+				flowDesc: "permit out ip from 2001:db8:ffff::1 to assigned",
+				ueIP:     ueIpString,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(
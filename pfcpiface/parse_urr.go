@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+// reportingTriggers holds the octets 5 to 7 of the Reporting Triggers IE
+// (and the Usage Report Trigger IE), octet 5 being the least significant byte.
+type reportingTriggers uint32
+
+// Reporting triggers supported by the UPF, see 3GPP TS 29.244 8.2.19.
+const (
+	triggerPERIO reportingTriggers = 0x01
+	triggerVOLTH reportingTriggers = 0x02
+	triggerTIMTH reportingTriggers = 0x04
+	triggerVOLQU reportingTriggers = 0x01 << 8
+	triggerTIMQU reportingTriggers = 0x02 << 8
+)
+
+// Usage report triggers that only appear in Usage Report IEs, see 3GPP TS 29.244 8.2.41.
+const (
+	triggerIMMER reportingTriggers = 0x80
+	triggerTERMR reportingTriggers = 0x08 << 8
+)
+
+// volumeLimit is a volume threshold or quota in bytes. Zero means not set.
+type volumeLimit struct {
+	total    uint64
+	uplink   uint64
+	downlink uint64
+}
+
+func (v volumeLimit) isSet() bool {
+	return v.total != 0 || v.uplink != 0 || v.downlink != 0
+}
+
+// reachedBy returns true if any of the configured volumes is reached by vol.
+func (v volumeLimit) reachedBy(vol usageVolume) bool {
+	return (v.total != 0 && vol.ulBytes+vol.dlBytes >= v.total) ||
+		(v.uplink != 0 && vol.ulBytes >= v.uplink) ||
+		(v.downlink != 0 && vol.dlBytes >= v.downlink)
+}
+
+func (v volumeLimit) String() string {
+	return fmt.Sprintf("{total=%v, uplink=%v, downlink=%v}", v.total, v.uplink, v.downlink)
+}
+
+type urr struct {
+	urrID   uint32
+	fseID   uint64
+	fseidIP uint32
+
+	measureVolume   bool
+	measureDuration bool
+	triggers        reportingTriggers
+
+	measurementPeriod time.Duration
+	volumeThreshold   volumeLimit
+	volumeQuota       volumeLimit
+	timeThreshold     time.Duration
+	timeQuota         time.Duration
+}
+
+func (u urr) String() string {
+	return fmt.Sprintf("URR(id=%v, F-SEID=%v, F-SEID IP=%v, volume=%v, duration=%v, triggers=%#x, "+
+		"period=%v, volumeThreshold=%v, volumeQuota=%v, timeThreshold=%v, timeQuota=%v)",
+		u.urrID, u.fseID, u.fseidIP, u.measureVolume, u.measureDuration, uint32(u.triggers),
+		u.measurementPeriod, u.volumeThreshold, u.volumeQuota, u.timeThreshold, u.timeQuota)
+}
+
+func (u urr) hasTrigger(t reportingTriggers) bool {
+	return u.triggers&t != 0
+}
+
+// parseURR parses a Create URR or Update URR IE. For updates, only the fields
+// present in the IE are overwritten, so the existing rule should be parsed into.
+func (u *urr) parseURR(ie1 *ie.IE, seid uint64, op operation) error {
+	u.fseID = seid
+
+	urrID, err := ie1.URRID()
+	if err != nil {
+		logger.PfcpLog.Errorln("could not read URR ID")
+		return err
+	}
+
+	u.urrID = urrID
+
+	method, err := ie1.MeasurementMethod()
+	if err == nil {
+		u.measureVolume = method&0x02 != 0
+		u.measureDuration = method&0x01 != 0
+	} else if op == create {
+		logger.PfcpLog.Errorln("could not read Measurement Method")
+		return err
+	}
+
+	triggers, err := ie1.ReportingTriggers()
+	if err == nil {
+		u.triggers = 0
+		for i := 0; i < len(triggers) && i < 3; i++ {
+			u.triggers |= reportingTriggers(triggers[i]) << (8 * i)
+		}
+	} else if op == create {
+		logger.PfcpLog.Errorln("could not read Reporting Triggers")
+		return err
+	}
+
+	if period, err := ie1.MeasurementPeriod(); err == nil {
+		u.measurementPeriod = period
+	}
+
+	if threshold, err := ie1.VolumeThreshold(); err == nil {
+		u.volumeThreshold = volumeLimit{}
+		if threshold.HasTOVOL() {
+			u.volumeThreshold.total = threshold.TotalVolume
+		}
+
+		if threshold.HasULVOL() {
+			u.volumeThreshold.uplink = threshold.UplinkVolume
+		}
+
+		if threshold.HasDLVOL() {
+			u.volumeThreshold.downlink = threshold.DownlinkVolume
+		}
+	}
+
+	if quota, err := ie1.VolumeQuota(); err == nil {
+		u.volumeQuota = volumeLimit{}
+		if quota.HasTOVOL() {
+			u.volumeQuota.total = quota.TotalVolume
+		}
+
+		if quota.HasULVOL() {
+			u.volumeQuota.uplink = quota.UplinkVolume
+		}
+
+		if quota.HasDLVOL() {
+			u.volumeQuota.downlink = quota.DownlinkVolume
+		}
+	}
+
+	if threshold, err := ie1.TimeThreshold(); err == nil {
+		u.timeThreshold = threshold
+	}
+
+	if quota, err := ie1.TimeQuota(); err == nil {
+		u.timeQuota = quota
+	}
+
+	if u.hasTrigger(triggerPERIO) && u.measurementPeriod == 0 {
+		return ErrInvalidArgumentWithReason("URR", urrID, "periodic reporting without Measurement Period")
+	}
+
+	return nil
+}
+
+// hasQuota returns true if a Create/Update URR IE carries a Volume or Time Quota.
+func hasQuota(urrIE *ie.IE) bool {
+	_, errVolume := urrIE.VolumeQuota()
+	_, errTime := urrIE.TimeQuota()
+
+	return errVolume == nil || errTime == nil
+}
+
+// urrIDFromIE reads the URR ID out of a grouped Remove URR or Query URR IE,
+// which go-pfcp does not look into.
+func urrIDFromIE(ie1 *ie.IE) (uint32, error) {
+	var (
+		ies []*ie.IE
+		err error
+	)
+
+	switch ie1.Type {
+	case ie.RemoveURR:
+		ies, err = ie1.RemoveURR()
+	case ie.QueryURR:
+		ies, err = ie1.QueryURR()
+	default:
+		return ie1.URRID()
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, x := range ies {
+		if x.Type == ie.URRID {
+			return x.URRID()
+		}
+	}
+
+	return 0, ErrNotFound("URR ID")
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+func TestParseURR(t *testing.T) {
+	const (
+		urrID = uint32(5)
+		seid  = uint64(100)
+	)
+
+	for _, scenario := range []struct {
+		input       *ie.IE
+		existing    urr
+		op          operation
+		expected    urr
+		description string
+	}{
+		{
+			input: ie.NewCreateURR(
+				ie.NewURRID(urrID),
+				ie.NewMeasurementMethod(0, 1, 1),
+				ie.NewReportingTriggers(0x03, 0x01),
+				ie.NewMeasurementPeriod(60*time.Second),
+				ie.NewVolumeThreshold(0x01, 1000, 0, 0),
+				ie.NewVolumeQuota(0x06, 0, 2000, 3000),
+			),
+			op: create,
+			expected: urr{
+				urrID:             urrID,
+				fseID:             seid,
+				measureVolume:     true,
+				measureDuration:   true,
+				triggers:          triggerPERIO | triggerVOLTH | triggerVOLQU,
+				measurementPeriod: 60 * time.Second,
+				volumeThreshold:   volumeLimit{total: 1000},
+				volumeQuota:       volumeLimit{uplink: 2000, downlink: 3000},
+			},
+			description: "Create URR with volume measurement",
+		},
+		{
+			input: ie.NewCreateURR(
+				ie.NewURRID(urrID),
+				ie.NewMeasurementMethod(0, 0, 1),
+				ie.NewReportingTriggers(0x04, 0x02),
+				ie.NewTimeThreshold(30*time.Second),
+				ie.NewTimeQuota(90*time.Second),
+			),
+			op: create,
+			expected: urr{
+				urrID:           urrID,
+				fseID:           seid,
+				measureDuration: true,
+				triggers:        triggerTIMTH | triggerTIMQU,
+				timeThreshold:   30 * time.Second,
+				timeQuota:       90 * time.Second,
+			},
+			description: "Create URR with time measurement",
+		},
+		{
+			input: ie.NewUpdateURR(
+				ie.NewURRID(urrID),
+				ie.NewVolumeQuota(0x01, 5000, 0, 0),
+			),
+			existing: urr{
+				urrID:         urrID,
+				measureVolume: true,
+				triggers:      triggerVOLQU,
+				volumeQuota:   volumeLimit{total: 1000},
+			},
+			op: update,
+			expected: urr{
+				urrID:         urrID,
+				fseID:         seid,
+				measureVolume: true,
+				triggers:      triggerVOLQU,
+				volumeQuota:   volumeLimit{total: 5000},
+			},
+			description: "Update URR only overwrites the present fields",
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			u := scenario.existing
+
+			err := u.parseURR(scenario.input, seid, scenario.op)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if u != scenario.expected {
+				t.Errorf("expected %v, got %v", scenario.expected, u)
+			}
+		})
+	}
+}
+
+func TestParseURRShouldError(t *testing.T) {
+	for _, scenario := range []struct {
+		input       *ie.IE
+		description string
+	}{
+		{
+			input: ie.NewCreateURR(
+				ie.NewMeasurementMethod(0, 1, 0),
+				ie.NewReportingTriggers(0x02, 0x00),
+			),
+			description: "Create URR without URR ID",
+		},
+		{
+			input: ie.NewCreateURR(
+				ie.NewURRID(1),
+				ie.NewReportingTriggers(0x02, 0x00),
+			),
+			description: "Create URR without Measurement Method",
+		},
+		{
+			input: ie.NewCreateURR(
+				ie.NewURRID(1),
+				ie.NewMeasurementMethod(0, 1, 0),
+				ie.NewReportingTriggers(0x01, 0x00),
+			),
+			description: "periodic Create URR without Measurement Period",
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			var u urr
+
+			if err := u.parseURR(scenario.input, 1, create); err == nil {
+				t.Errorf("expected error, got %v", u)
+			}
+		})
+	}
+}
+
+func TestURRIDFromIE(t *testing.T) {
+	for _, input := range []*ie.IE{
+		ie.NewRemoveURR(ie.NewURRID(9)),
+		ie.NewQueryURR(ie.NewURRID(9)),
+	} {
+		id, err := urrIDFromIE(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if id != 9 {
+			t.Errorf("expected URR ID 9, got %v", id)
+		}
+	}
+}
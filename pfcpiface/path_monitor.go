@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+	"github.com/wmnsk/go-pfcp/ie"
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+// Node Report Type flags, see 3GPP TS 29.244 8.2.69.
+const (
+	nodeReportUPFR = 0x01 // User Plane Path Failure Report
+	nodeReportUPRR = 0x02 // User Plane Path Recovery Report
+)
+
+// gtpuPathCheckInterval is the period at which the GTP-U path monitoring
+// counters are read.
+const gtpuPathCheckInterval = time.Second
+
+type gtpuPeer struct {
+	echoes   uint64
+	lastEcho time.Time
+	failed   bool
+}
+
+// gtpuPathMonitor tracks the reachability of the remote GTP-U peers from the
+// echo responses counted by the datapath.
+type gtpuPathMonitor struct {
+	// a peer is unreachable if it does not respond for timeout.
+	timeout time.Duration
+	peers   map[uint32]*gtpuPeer
+}
+
+func newGtpuPathMonitor(timeout time.Duration) *gtpuPathMonitor {
+	return &gtpuPathMonitor{
+		timeout: timeout,
+		peers:   make(map[uint32]*gtpuPeer),
+	}
+}
+
+// update processes the cumulative echo responses of the monitored peers and
+// returns the peers whose path failed or recovered since the last update.
+func (m *gtpuPathMonitor) update(echoes map[uint32]uint64, now time.Time) (failed, recovered []uint32) {
+	for ip, count := range echoes {
+		p, ok := m.peers[ip]
+		if !ok {
+			// Give new peers the time to respond.
+			m.peers[ip] = &gtpuPeer{echoes: count, lastEcho: now}
+			continue
+		}
+
+		if count != p.echoes {
+			p.echoes = count
+			p.lastEcho = now
+
+			if p.failed {
+				p.failed = false
+
+				recovered = append(recovered, ip)
+			}
+
+			continue
+		}
+
+		if !p.failed && now.Sub(p.lastEcho) >= m.timeout {
+			p.failed = true
+
+			failed = append(failed, ip)
+		}
+	}
+
+	// Peers no longer used by any FAR are not monitored anymore.
+	for ip := range m.peers {
+		if _, ok := echoes[ip]; !ok {
+			delete(m.peers, ip)
+		}
+	}
+
+	return failed, recovered
+}
+
+// checkGtpuPaths reads the GTP-U path monitoring counters and notifies the
+// associated CP functions of the path failures and recoveries.
+func (node *PFCPNode) checkGtpuPaths() {
+	echoes, err := node.upf.GtpuPathCounters()
+	if err != nil {
+		logger.PfcpLog.Debugln("failed to read GTP-U path counters:", err)
+		return
+	}
+
+	failed, recovered := node.pathMonitor.update(echoes, time.Now())
+	if len(failed) == 0 && len(recovered) == 0 {
+		return
+	}
+
+	for _, ip := range failed {
+		logger.PfcpLog.Warnln("user plane path failure for remote GTP-U peer", int2ip(ip))
+	}
+
+	for _, ip := range recovered {
+		logger.PfcpLog.Infoln("user plane path recovered for remote GTP-U peer", int2ip(ip))
+	}
+
+	node.pConns.Range(func(key, value any) bool {
+		pConn := value.(*PFCPConn)
+		if pConn.remoteNodeID() != "" && !pConn.IsShutdown() {
+			go pConn.sendNodeReportRequest(failed, recovered)
+		}
+
+		return true
+	})
+}
+
+func remoteGTPUPeers(ips []uint32) []*ie.IE {
+	peers := make([]*ie.IE, 0, len(ips))
+	for _, ip := range ips {
+		// 0x02: V4 flag
+		peers = append(peers, ie.NewRemoteGTPUPeer(0x02, int2ip(ip).String(), "", 0, ""))
+	}
+
+	return peers
+}
+
+func (pConn *PFCPConn) getNodeReportRequest(failed, recovered []uint32) *Request {
+	var reportType uint8
+
+	ies := []*ie.IE{pConn.nodeID.localIE}
+
+	if len(failed) > 0 {
+		reportType |= nodeReportUPFR
+
+		ies = append(ies, ie.NewGroupedIE(ie.UserPlanePathFailureReport, remoteGTPUPeers(failed)...))
+	}
+
+	if len(recovered) > 0 {
+		reportType |= nodeReportUPRR
+
+		ies = append(ies, ie.NewGroupedIE(ie.UserPlanePathRecoveryReport, remoteGTPUPeers(recovered)...))
+	}
+
+	ies = append(ies, ie.NewNodeReportType(reportType))
+
+	return newRequest(message.NewNodeReportRequest(pConn.getSeqNum(), ies...))
+}
+
+func (pConn *PFCPConn) sendNodeReportRequest(failed, recovered []uint32) {
+	r := pConn.getNodeReportRequest(failed, recovered)
+
+	reply, timeout := pConn.sendPFCPRequestMessage(r)
+	if timeout {
+		logger.PfcpLog.Warnln("no Node Report Response from", pConn.remoteNodeID())
+		return
+	}
+
+	nrres, ok := reply.(*message.NodeReportResponse)
+	if !ok || nrres.Cause == nil {
+		return
+	}
+
+	if cause, err := nrres.Cause.Cause(); err == nil && cause != ie.CauseRequestAccepted {
+		logger.PfcpLog.Warnln("Node Report Request rejected by", pConn.remoteNodeID(), "with cause:", cause)
+	}
+}
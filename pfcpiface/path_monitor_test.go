@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wmnsk/go-pfcp/ie"
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+func TestGtpuPathMonitor(t *testing.T) {
+	gnb1 := ip2int(net.ParseIP("192.168.1.1"))
+	gnb2 := ip2int(net.ParseIP("192.168.1.2"))
+
+	m := newGtpuPathMonitor(10 * time.Second)
+	start := time.Now()
+
+	for _, step := range []struct {
+		name      string
+		after     time.Duration
+		echoes    map[uint32]uint64
+		failed    []uint32
+		recovered []uint32
+	}{
+		{"new peers", 0, map[uint32]uint64{gnb1: 5, gnb2: 0}, nil, nil},
+		{"responding peers", 5 * time.Second, map[uint32]uint64{gnb1: 10, gnb2: 5}, nil, nil},
+		{"silent peer", 15 * time.Second, map[uint32]uint64{gnb1: 15, gnb2: 5}, []uint32{gnb2}, nil},
+		{"failure reported once", 20 * time.Second, map[uint32]uint64{gnb1: 20, gnb2: 5}, nil, nil},
+		{"peer responds again", 25 * time.Second, map[uint32]uint64{gnb1: 25, gnb2: 6}, nil, []uint32{gnb2}},
+		{"peer removed", 30 * time.Second, map[uint32]uint64{gnb2: 7}, nil, nil},
+	} {
+		failed, recovered := m.update(step.echoes, start.Add(step.after))
+
+		if !reflect.DeepEqual(failed, step.failed) {
+			t.Errorf("%s: expected failed %v, got %v", step.name, step.failed, failed)
+		}
+
+		if !reflect.DeepEqual(recovered, step.recovered) {
+			t.Errorf("%s: expected recovered %v, got %v", step.name, step.recovered, recovered)
+		}
+	}
+
+	if _, ok := m.peers[gnb1]; ok {
+		t.Error("removed peer still monitored")
+	}
+}
+
+func TestGetNodeReportRequest(t *testing.T) {
+	p := &PFCPConn{}
+	p.nodeID.localIE = ie.NewNodeID("", "", "upf")
+
+	failed := []uint32{ip2int(net.ParseIP("192.168.1.1")), ip2int(net.ParseIP("192.168.1.2"))}
+	recovered := []uint32{ip2int(net.ParseIP("192.168.1.3"))}
+
+	nrreq, ok := p.getNodeReportRequest(failed, recovered).msg.(*message.NodeReportRequest)
+	if !ok {
+		t.Fatal("expected a Node Report Request")
+	}
+
+	reportType, err := nrreq.NodeReportType.NodeReportType()
+	if err != nil || reportType != nodeReportUPFR|nodeReportUPRR {
+		t.Errorf("unexpected Node Report Type %#x, err: %v", reportType, err)
+	}
+
+	peers, err := nrreq.UserPlanePathFailureReport.UserPlanePathFailureReport()
+	if err != nil {
+		t.Fatalf("failed to parse User Plane Path Failure Report: %v", err)
+	}
+
+	if len(peers) != len(failed) {
+		t.Fatalf("expected %d remote GTP-U peers, got %d", len(failed), len(peers))
+	}
+
+	for i, peer := range peers {
+		fields, err := peer.RemoteGTPUPeer()
+		if err != nil {
+			t.Fatalf("failed to parse Remote GTP-U Peer: %v", err)
+		}
+
+		if ip2int(fields.IPv4Address) != failed[i] {
+			t.Errorf("expected peer %v, got %v", int2ip(failed[i]), fields.IPv4Address)
+		}
+	}
+
+	if nrreq.UserPlanePathRecoveryReport == nil {
+		t.Error("expected a User Plane Path Recovery Report")
+	}
+}
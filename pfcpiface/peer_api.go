@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"sort"
+	"time"
+)
+
+// peerView is the state of the association with an N4 peer served by the
+// REST API.
+type peerView struct {
+	NodeID           string     `json:"node_id"`
+	Address          string     `json:"address"`
+	Associated       bool       `json:"associated"`
+	LocalRecoveryTS  time.Time  `json:"local_recovery_ts"`
+	RemoteRecoveryTS *time.Time `json:"remote_recovery_ts,omitempty"`
+	HeartbeatEnabled bool       `json:"heartbeat_enabled"`
+	LastHeartbeat    *time.Time `json:"last_heartbeat,omitempty"`
+	PendingRequests  int        `json:"pending_requests"`
+	Sessions         int        `json:"sessions"`
+}
+
+func (pConn *PFCPConn) peerView(sessions int) peerView {
+	v := peerView{
+		NodeID:           pConn.remoteNodeID(),
+		Address:          pConn.RemoteAddr().String(),
+		Associated:       pConn.remoteNodeID() != "",
+		LocalRecoveryTS:  pConn.ts.local,
+		HeartbeatEnabled: pConn.upf.enableHBTimer,
+		Sessions:         sessions,
+	}
+
+	pConn.restart.mu.Lock()
+	if remote := pConn.ts.remote; !remote.IsZero() {
+		v.RemoteRecoveryTS = &remote
+	}
+	pConn.restart.mu.Unlock()
+
+	if ns := pConn.lastHeartbeat.Load(); ns != 0 {
+		last := time.Unix(0, ns)
+		v.LastHeartbeat = &last
+	}
+
+	pConn.pendingReqs.Range(func(_, _ any) bool {
+		v.PendingRequests++
+		return true
+	})
+
+	return v
+}
+
+// peerConns returns the connections to the N4 peers, with their number of
+// sessions.
+func (node *PFCPNode) peerConns() map[*PFCPConn]int {
+	conns := make(map[*PFCPConn]int)
+
+	node.pConns.Range(func(_, value any) bool {
+		conns[value.(*PFCPConn)] = 0
+		return true
+	})
+
+	node.sessions.Range(func(_, value any) bool {
+		pConn := value.(*PFCPConn)
+		if _, ok := conns[pConn]; ok {
+			conns[pConn]++
+		}
+
+		return true
+	})
+
+	return conns
+}
+
+// peerViews returns the state of the connections to all the N4 peers, by
+// node ID and address.
+func (node *PFCPNode) peerViews() []peerView {
+	views := make([]peerView, 0)
+
+	for pConn, sessions := range node.peerConns() {
+		views = append(views, pConn.peerView(sessions))
+	}
+
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].NodeID != views[j].NodeID {
+			return views[i].NodeID < views[j].NodeID
+		}
+
+		return views[i].Address < views[j].Address
+	})
+
+	return views
+}
+
+// peerConn returns the connection associated with the node ID, if any.
+func (node *PFCPNode) peerConn(nodeID string) (*PFCPConn, int, bool) {
+	for pConn, sessions := range node.peerConns() {
+		if pConn.remoteNodeID() == nodeID && !pConn.IsShutdown() {
+			return pConn, sessions, true
+		}
+	}
+
+	return nil, 0, false
+}
@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wmnsk/go-pfcp/ie"
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+func TestPeersHandler(t *testing.T) {
+	node := &PFCPNode{upf: &upf{
+		datapath:      &fakeDP{},
+		usage:         newUsageTracker(),
+		peers:         []string{"smf"},
+		enableHBTimer: true,
+		respTimeout:   time.Second,
+	}}
+
+	smf := listenUDP(t)
+
+	conn, err := net.DialUDP("udp", nil, smf.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatalf("failed to connect to SMF: %v", err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	pConn := newTestPFCPConn(node, 1)
+	pConn.Conn = conn
+	pConn.ts.local = time.Now()
+	pConn.nodeID.remote = "smf"
+	pConn.nodeID.localIE = ie.NewNodeID("", "", "upf")
+	node.pConns.Store(conn.RemoteAddr().String(), pConn)
+
+	if _, ok := pConn.NewPFCPSession(1); !ok {
+		t.Fatal("failed to allocate session")
+	}
+
+	smfRecovery := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if _, err := pConn.handleHeartbeatRequest(message.NewHeartbeatRequest(1, ie.NewRecoveryTimeStamp(smfRecovery), nil)); err != nil {
+		t.Fatalf("failed to handle heartbeat: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	setupPeersHandler(mux, node)
+
+	serve := func(t *testing.T, method, target, body string, status int, v any) {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+		if w.Code != status {
+			t.Fatalf("expected status %v, got %v: %s", status, w.Code, w.Body)
+		}
+
+		if v != nil {
+			if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+				t.Fatalf("failed to decode %s: %v", w.Body, err)
+			}
+		}
+	}
+
+	t.Run("list", func(t *testing.T) {
+		var peers []peerView
+
+		serve(t, http.MethodGet, "/v1/peers", "", http.StatusOK, &peers)
+
+		if len(peers) != 1 {
+			t.Fatalf("expected 1 peer, got %+v", peers)
+		}
+
+		p := peers[0]
+		if p.NodeID != "smf" || !p.Associated || p.Sessions != 1 || p.PendingRequests != 0 || p.LastHeartbeat == nil {
+			t.Errorf("unexpected peer %+v", p)
+		}
+
+		if p.RemoteRecoveryTS == nil || !p.RemoteRecoveryTS.Equal(smfRecovery) {
+			t.Errorf("expected remote recovery timestamp %v, got %v", smfRecovery, p.RemoteRecoveryTS)
+		}
+	})
+
+	t.Run("by node ID", func(t *testing.T) {
+		var p peerView
+
+		serve(t, http.MethodGet, "/v1/peers/smf", "", http.StatusOK, &p)
+
+		if p.Address != smf.LocalAddr().String() {
+			t.Errorf("expected address %v, got %v", smf.LocalAddr(), p.Address)
+		}
+
+		serve(t, http.MethodGet, "/v1/peers/other", "", http.StatusNotFound, nil)
+	})
+
+	// answer replies to the next Association Update Request with cause.
+	answer := func(t *testing.T, cause uint8) {
+		buf := make([]byte, 1500)
+
+		n, err := smf.Read(buf)
+		if err != nil {
+			t.Errorf("expected a request: %v", err)
+			return
+		}
+
+		msg, err := message.Parse(buf[:n])
+		if err != nil {
+			t.Errorf("failed to parse request: %v", err)
+			return
+		}
+
+		aureq, ok := msg.(*message.AssociationUpdateRequest)
+		if !ok || aureq.PFCPAssociationReleaseRequest == nil || !aureq.PFCPAssociationReleaseRequest.HasSARR() {
+			t.Errorf("expected an Association Update Request with release request, got %v", msg)
+			return
+		}
+
+		aures := message.NewAssociationUpdateResponse(aureq.SequenceNumber, ie.NewNodeID("", "", "smf"), ie.NewCause(cause))
+
+		b, err := aures.Marshal()
+		if err != nil {
+			t.Errorf("failed to marshal response: %v", err)
+			return
+		}
+
+		pConn.HandlePFCPMsg(b)
+	}
+
+	t.Run("release", func(t *testing.T) {
+		go answer(t, ie.CauseRequestAccepted)
+		serve(t, http.MethodPost, "/v1/peers/smf/release", "", http.StatusAccepted, nil)
+
+		go answer(t, ie.CauseRequestRejected)
+		serve(t, http.MethodPost, "/v1/peers/smf/release", "", http.StatusBadGateway, nil)
+	})
+
+	t.Run("add", func(t *testing.T) {
+		serve(t, http.MethodPost, "/v1/peers", `{"peer": ""}`, http.StatusBadRequest, nil)
+		serve(t, http.MethodPost, "/v1/peers", `{"peer": "smf"}`, http.StatusConflict, nil)
+	})
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"sync"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+)
+
+// peerRestart keeps the sessions that existed when the PFCP peer restarted.
+// Under the keep policy, they are purged at the end of the grace period unless
+// the CP function modified them in the meantime.
+type peerRestart struct {
+	// mu also guards the remote Recovery Time Stamp of the PFCPConn.
+	mu sync.Mutex
+	// gen identifies the last restart, so that an older grace timer is ignored.
+	gen   uint64
+	seids map[uint64]struct{}
+}
+
+// mark replaces the stale sessions with seids and returns the restart generation.
+func (st *peerRestart) mark(seids []uint64) uint64 {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.gen++
+	st.seids = make(map[uint64]struct{}, len(seids))
+
+	for _, seid := range seids {
+		st.seids[seid] = struct{}{}
+	}
+
+	return st.gen
+}
+
+// confirm drops the session from the stale ones, e.g. when the CP function
+// modifies it after restarting.
+func (st *peerRestart) confirm(seid uint64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	delete(st.seids, seid)
+}
+
+// take returns and forgets the stale sessions of the restart generation gen.
+func (st *peerRestart) take(gen uint64) []uint64 {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if gen != st.gen {
+		return nil
+	}
+
+	seids := make([]uint64, 0, len(st.seids))
+	for seid := range st.seids {
+		seids = append(seids, seid)
+	}
+
+	st.seids = nil
+
+	return seids
+}
+
+// updateRemoteRecoveryTS records the Recovery Time Stamp received from the peer
+// and returns true if it reveals that the peer restarted.
+func (pConn *PFCPConn) updateRemoteRecoveryTS(ts time.Time) bool {
+	pConn.restart.mu.Lock()
+	defer pConn.restart.mu.Unlock()
+
+	old := pConn.ts.remote
+
+	if !old.IsZero() && !ts.After(old) {
+		return false
+	}
+
+	pConn.ts.remote = ts
+
+	return !old.IsZero()
+}
+
+// checkPeerRecovery handles the Recovery Time Stamp received in msgType and
+// applies the configured policy if the peer restarted.
+func (pConn *PFCPConn) checkPeerRecovery(ts time.Time, msgType string) {
+	addr := pConn.RemoteAddr().String()
+
+	if !pConn.updateRemoteRecoveryTS(ts) {
+		logger.PfcpLog.Debugln(msgType, "from", addr, "with recovery timestamp:", ts)
+		return
+	}
+
+	logger.PfcpLog.Warnln(msgType, "from", addr, "with newer recovery timestamp:", ts,
+		", the peer restarted")
+
+	pConn.handlePeerRestart()
+}
+
+// handlePeerRestart applies the peer restart policy to the existing sessions.
+func (pConn *PFCPConn) handlePeerRestart() {
+	sessions := pConn.store.GetAllSessions()
+	if len(sessions) == 0 {
+		return
+	}
+
+	grace := pConn.upf.peerRestartGrace
+
+	if pConn.upf.peerRestartPolicy != peerRestartPolicyKeep {
+		logger.PfcpLog.Infof("purging %d sessions of restarted peer %v", len(sessions), pConn.remoteNodeID())
+		pConn.purgeAllSessions()
+
+		return
+	}
+
+	if grace == 0 {
+		logger.PfcpLog.Infof("keeping %d sessions of restarted peer %v", len(sessions), pConn.remoteNodeID())
+		return
+	}
+
+	seids := make([]uint64, 0, len(sessions))
+	for _, s := range sessions {
+		seids = append(seids, s.localSEID)
+	}
+
+	gen := pConn.restart.mark(seids)
+
+	logger.PfcpLog.Infof("keeping %d sessions of restarted peer %v for %v",
+		len(sessions), pConn.remoteNodeID(), grace)
+
+	time.AfterFunc(grace, func() {
+		pConn.purgeStaleSessions(gen)
+	})
+}
+
+// purgeStaleSessions purges the sessions the CP function did not modify during
+// the grace period following its restart.
+func (pConn *PFCPConn) purgeStaleSessions(gen uint64) {
+	if pConn.IsShutdown() {
+		return
+	}
+
+	seids := pConn.restart.take(gen)
+
+	pConn.upf.sessionMu.Lock()
+	defer pConn.upf.sessionMu.Unlock()
+
+	purged := 0
+
+	for _, seid := range seids {
+		session, ok := pConn.store.GetSession(seid)
+		if !ok {
+			continue
+		}
+
+		pConn.purgeSession(session)
+		purged++
+	}
+
+	if purged > 0 {
+		logger.PfcpLog.Infof("purged %d stale sessions of peer %v after grace period", purged, pConn.remoteNodeID())
+	}
+}
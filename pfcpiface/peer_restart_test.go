@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/wmnsk/go-pfcp/ie"
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+func newRestartTestConn(t *testing.T, policy string, grace time.Duration) *PFCPConn {
+	t.Helper()
+
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8805})
+	if err != nil {
+		t.Fatalf("failed to create udp socket: %v", err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	ippool, err := NewIPPool("10.250.0.0/24")
+	if err != nil {
+		t.Fatalf("failed to create IP pool: %v", err)
+	}
+
+	node := &PFCPNode{upf: &upf{
+		datapath:          &fakeDP{},
+		usage:             newUsageTracker(),
+		ippool:            ippool,
+		fteidGenerator:    NewFTEIDGenerator(),
+		peerRestartPolicy: policy,
+		peerRestartGrace:  grace,
+	}}
+
+	p := newTestPFCPConn(node, 1)
+	p.Conn = conn
+	p.nodeID.remote = "smf"
+
+	return p
+}
+
+// newRestartTestSession stores a session with a UE IP and an F-TEID allocated by the UPF.
+func newRestartTestSession(t *testing.T, p *PFCPConn) PFCPSession {
+	t.Helper()
+
+	s, ok := p.NewPFCPSession(1)
+	if !ok {
+		t.Fatal("failed to allocate session")
+	}
+
+	ueIP, err := p.upf.ippool.LookupOrAllocIP(s.localSEID)
+	if err != nil {
+		t.Fatalf("failed to allocate UE IP: %v", err)
+	}
+
+	teid, err := p.upf.fteidGenerator.Allocate()
+	if err != nil {
+		t.Fatalf("failed to allocate F-TEID: %v", err)
+	}
+
+	s.CreatePDR(pdr{pdrID: 1, fseID: s.localSEID, srcIface: access, UPAllocateFteid: true, tunnelTEID: teid})
+	s.CreatePDR(pdr{pdrID: 2, fseID: s.localSEID, srcIface: core, allocIPFlag: true, ueAddress: ip2int(ueIP)})
+
+	if err = p.store.PutSession(s); err != nil {
+		t.Fatalf("failed to store session: %v", err)
+	}
+
+	return s
+}
+
+func heartbeatRequest(ts time.Time) message.Message {
+	return message.NewHeartbeatRequest(1, ie.NewRecoveryTimeStamp(ts), nil)
+}
+
+func TestPeerRestart_Purge(t *testing.T) {
+	p := newRestartTestConn(t, peerRestartPolicyPurge, 0)
+	s := newRestartTestSession(t, p)
+	start := time.Now().Truncate(time.Second)
+
+	if _, err := p.handleHeartbeatRequest(heartbeatRequest(start)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Same Recovery Time Stamp, the peer did not restart.
+	if _, err := p.handleHeartbeatRequest(heartbeatRequest(start)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := p.store.GetSession(s.localSEID); !ok {
+		t.Fatal("session purged without a peer restart")
+	}
+
+	if _, err := p.handleHeartbeatRequest(heartbeatRequest(start.Add(time.Minute))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := p.store.GetSession(s.localSEID); ok {
+		t.Error("session not purged after peer restart")
+	}
+
+	if err := p.upf.ippool.DeallocIP(s.localSEID); err == nil {
+		t.Error("UE IP not released after peer restart")
+	}
+
+	if p.upf.fteidGenerator.IsAllocated(s.pdrs[0].tunnelTEID) {
+		t.Error("F-TEID not released after peer restart")
+	}
+
+	if _, ok := p.node.sessionOwner(s.localSEID); ok {
+		t.Error("SEID not released after peer restart")
+	}
+}
+
+func TestPeerRestart_KeepWithGracePeriod(t *testing.T) {
+	p := newRestartTestConn(t, peerRestartPolicyKeep, time.Hour)
+	confirmed := newRestartTestSession(t, p)
+	stale := newRestartTestSession(t, p)
+	start := time.Now().Truncate(time.Second)
+
+	p.checkPeerRecovery(start, "test")
+	p.checkPeerRecovery(start.Add(time.Minute), "test")
+
+	if n := len(p.store.GetAllSessions()); n != 2 {
+		t.Fatalf("expected sessions to be kept during the grace period, got %d", n)
+	}
+
+	// The CP function modifies one of the sessions after restarting.
+	p.restart.confirm(confirmed.localSEID)
+
+	// Grace timers of older restarts are ignored.
+	p.purgeStaleSessions(p.restart.gen - 1)
+
+	if n := len(p.store.GetAllSessions()); n != 2 {
+		t.Fatalf("expected sessions to be kept, got %d", n)
+	}
+
+	p.purgeStaleSessions(p.restart.gen)
+
+	if _, ok := p.store.GetSession(confirmed.localSEID); !ok {
+		t.Error("session modified by the CP function was purged")
+	}
+
+	if _, ok := p.store.GetSession(stale.localSEID); ok {
+		t.Error("stale session not purged after the grace period")
+	}
+}
@@ -25,6 +25,8 @@ func init() {
 
 type PFCPIface struct {
 	conf Conf
+	// config file loaded again by the reloads, if set.
+	configPath string
 
 	node *PFCPNode
 	fp   datapath
@@ -40,11 +42,19 @@ type PFCPIface struct {
 }
 
 func NewPFCPIface(conf Conf) *PFCPIface {
+	setConfDefaults(&conf)
+
 	pfcpIface := &PFCPIface{
 		conf: conf,
 	}
 
-	pfcpIface.fp = &bess{}
+	if conf.EnableP4rt {
+		pfcpIface.fp = &UP4{}
+	} else if conf.EnableGoDatapath {
+		pfcpIface.fp = &godp{}
+	} else {
+		pfcpIface.fp = &bess{}
+	}
 
 	httpPort := "8080"
 	if conf.CPIface.HTTPPort != "" {
@@ -66,6 +76,12 @@ func (p *PFCPIface) mustInit() {
 	httpMux := http.NewServeMux()
 
 	setupConfigHandler(httpMux, p.upf)
+	setupDatapathAuditHandler(httpMux, p.node)
+	setupSessionsHandler(httpMux, p.node)
+	setupPeersHandler(httpMux, p.node)
+	setupHealthHandlers(httpMux, p.node)
+	setupDrainHandler(httpMux, p.node)
+	setupReloadHandler(httpMux, p.reloadConfig)
 
 	var err error
 
@@ -103,9 +119,34 @@ func (p *PFCPIface) Run() {
 	signal.Notify(sig, os.Interrupt)
 	signal.Notify(sig, syscall.SIGTERM)
 
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		for range hup {
+			logger.PfcpLog.Infoln("system call received: SIGHUP, reloading config")
+
+			if _, err := p.reloadConfig(); err != nil {
+				logger.PfcpLog.Errorln("failed to reload config:", err)
+			}
+		}
+	}()
+
 	go func() {
 		oscall := <-sig
 		logger.PfcpLog.Infof("system call received: %+v", oscall)
+
+		// A second signal stops the node without waiting for the drain.
+		if oscall == syscall.SIGTERM && p.upf.drainOnShutdown {
+			done, _ := p.node.startDrain()
+
+			select {
+			case <-done:
+			case oscall = <-sig:
+				logger.PfcpLog.Infof("system call received: %+v, stopping before drain completion", oscall)
+			}
+		}
+
 		p.Stop()
 	}()
 
@@ -118,6 +159,12 @@ func (p *PFCPIface) Stop() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	p.node.Stop()
+
+	// Wait for PFCP node shutdown. The HTTP server is shut down last, so that
+	// the readiness probes see the UPF shutting down.
+	p.node.Done()
+
 	ctxHttpShutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer func() {
 		cancel()
@@ -126,9 +173,4 @@ func (p *PFCPIface) Stop() {
 	if err := p.httpSrv.Shutdown(ctxHttpShutdown); err != nil {
 		logger.PfcpLog.Errorln("failed to shutdown http:", err)
 	}
-
-	p.node.Stop()
-
-	// Wait for PFCP node shutdown
-	p.node.Done()
 }
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+)
+
+// ErrNotReloadable is returned for the config changes that require a restart.
+var ErrNotReloadable = errors.New("config change requires a restart")
+
+// reloadableConf are the parts of the config, by JSON key, whose changes are
+// applied by a reload.
+var reloadableConf = []string{
+	"log_level",
+	"qci_qos_config",
+	"slice_rate_limit_config",
+	"max_req_retries",
+	"resp_timeout",
+	"heart_beat_interval",
+	"cpiface.peers",
+}
+
+// reloadResult is the result of a config reload served by the REST API.
+type reloadResult struct {
+	Applied []string `json:"applied"`
+}
+
+// confChanges returns the parts of the config that differ, by JSON key. The
+// parts of the cpiface section are compared one by one.
+func confChanges(running, loaded Conf) []string {
+	return fieldChanges(reflect.ValueOf(running), reflect.ValueOf(loaded), "")
+}
+
+func fieldChanges(running, loaded reflect.Value, prefix string) []string {
+	changes := make([]string, 0)
+
+	for i := range running.NumField() {
+		f := running.Type().Field(i)
+		key := prefix + strings.Split(f.Tag.Get("json"), ",")[0]
+
+		switch {
+		case f.Type == reflect.TypeFor[CPIfaceInfo]():
+			changes = append(changes, fieldChanges(running.Field(i), loaded.Field(i), key+".")...)
+		case !reflect.DeepEqual(running.Field(i).Interface(), loaded.Field(i).Interface()):
+			changes = append(changes, key)
+		}
+	}
+
+	return changes
+}
+
+// SetConfigPath sets the config file loaded again by the reloads.
+func (p *PFCPIface) SetConfigPath(path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.configPath = path
+}
+
+// reloadConfig loads the config file again and applies the changes of its
+// reloadable parts. Nothing is applied if any other part changed.
+func (p *PFCPIface) reloadConfig() (reloadResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.configPath == "" {
+		return reloadResult{}, ErrOperationFailedWithReason("config reload", "no config file")
+	}
+
+	loaded, err := LoadConfigFile(p.configPath)
+	if err != nil {
+		return reloadResult{}, ErrInvalidArgumentWithReason("config file", p.configPath, err.Error())
+	}
+
+	changes := confChanges(p.conf, loaded)
+
+	var rejected []string
+
+	for _, change := range changes {
+		if !slices.Contains(reloadableConf, change) {
+			rejected = append(rejected, change)
+		}
+	}
+
+	if len(rejected) > 0 {
+		return reloadResult{}, fmt.Errorf("%w: %s", ErrNotReloadable, strings.Join(rejected, ", "))
+	}
+
+	if err := validateChanges(loaded, changes); err != nil {
+		return reloadResult{}, err
+	}
+
+	applied, err := p.applyConf(loaded, changes)
+	if err != nil {
+		return reloadResult{}, err
+	}
+
+	p.conf = loaded
+
+	logger.PfcpLog.Infoln("reloaded config, applied changes:", applied)
+
+	return reloadResult{Applied: applied}, nil
+}
+
+// validateChanges checks that the datapath supports the changed parts of the
+// loaded config, so that all of them are applied or none.
+func validateChanges(loaded Conf, changes []string) error {
+	if slices.Contains(changes, "slice_rate_limit_config") {
+		switch {
+		case loaded.EnableP4rt:
+			return ErrUnsupported("slice_rate_limit_config", "P4Runtime datapath")
+		case loaded.EnableGoDatapath:
+			return ErrUnsupported("slice_rate_limit_config", "Go datapath")
+		}
+	}
+
+	return nil
+}
+
+// applyConf applies the changed parts of the loaded config, which were
+// validated, and returns those that are in use. The changes of the datapath
+// are applied first.
+func (p *PFCPIface) applyConf(loaded Conf, changes []string) ([]string, error) {
+	applied := slices.Clone(changes)
+
+	if slices.Contains(changes, "slice_rate_limit_config") {
+		if err := p.upf.SetSliceMeterConfig(loaded.SliceMeterConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if slices.Contains(changes, "qci_qos_config") {
+		if err := p.upf.SetQciQosConfig(&loaded); err != nil {
+			return nil, err
+		}
+	}
+
+	if slices.Contains(changes, "log_level") {
+		logger.SetLogLevel(loaded.LogLevel)
+	}
+
+	if slices.ContainsFunc(changes, func(c string) bool {
+		return c == "resp_timeout" || c == "max_req_retries" || c == "heart_beat_interval"
+	}) {
+		// Durations are validated by LoadConfigFile.
+		respTimeout, _ := time.ParseDuration(loaded.RespTimeout)
+
+		hbInterval := p.upf.heartbeatInterval()
+		if loaded.EnableHBTimer {
+			hbInterval, _ = time.ParseDuration(loaded.HeartBeatInterval)
+		} else if slices.Contains(changes, "heart_beat_interval") {
+			logger.PfcpLog.Infoln("heart_beat_interval is not used while the heartbeat timer is disabled")
+
+			applied = slices.DeleteFunc(applied, func(c string) bool { return c == "heart_beat_interval" })
+		}
+
+		p.upf.setTimers(respTimeout, loaded.MaxReqRetries, hbInterval)
+	}
+
+	if slices.Contains(changes, "cpiface.peers") {
+		p.node.reloadN4Peers(p.conf.CPIface.Peers, loaded.CPIface.Peers)
+	}
+
+	return applied, nil
+}
+
+// reloadN4Peers connects to the peers added to the config. The peers removed
+// are not connected to anymore, but their associations are kept.
+func (node *PFCPNode) reloadN4Peers(running, loaded []string) {
+	for _, peer := range loaded {
+		if slices.Contains(running, peer) {
+			continue
+		}
+
+		if err := node.addN4Peer(peer); err != nil && !errors.Is(err, errPeerExists) {
+			logger.PfcpLog.Warnln("failed to establish PFCP connection to peer", peer, err)
+		}
+	}
+
+	for _, peer := range running {
+		if !slices.Contains(loaded, peer) {
+			node.removeN4Peer(peer)
+		}
+	}
+}
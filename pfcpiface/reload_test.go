@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReloadConfig(t *testing.T) {
+	confPath := t.TempDir() + "/conf.jsonc"
+
+	// writeConf writes the config with the given settings on top of the base.
+	writeConf := func(t *testing.T, settings string) {
+		t.Helper()
+
+		mustWriteStringToDisk(`{
+			"mode": "dpdk",
+			"access": {"ifname": "access"},
+			"core": {"ifname": "core"},
+			"qci_qos_config": [{"qci": 0, "cbs": 50000, "ebs": 50000, "pbs": 50000}],
+			`+settings+`
+			"cpiface": {"dnn": "internet"}
+		}`, confPath)
+	}
+
+	writeConf(t, "")
+
+	running, err := LoadConfigFile(confPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	b := &bess{}
+	b.readQciQosMap(&running)
+
+	u := &upf{datapath: b}
+	u.setTimers(time.Second, running.MaxReqRetries, 0)
+
+	p := &PFCPIface{conf: running, configPath: confPath, upf: u, node: &PFCPNode{upf: u}}
+
+	mux := http.NewServeMux()
+	setupReloadHandler(mux, p.reloadConfig)
+
+	reload := func(t *testing.T, status int) reloadResult {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/config/reload", nil))
+
+		if w.Code != status {
+			t.Fatalf("expected status %v, got %v: %s", status, w.Code, w.Body)
+		}
+
+		var result reloadResult
+		if status == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("failed to decode %s: %v", w.Body, err)
+			}
+		}
+
+		return result
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		if result := reload(t, http.StatusOK); len(result.Applied) != 0 {
+			t.Errorf("expected no change, got %v", result.Applied)
+		}
+	})
+
+	t.Run("reloadable changes", func(t *testing.T) {
+		writeConf(t, `"resp_timeout": "3s", "max_req_retries": 7,
+			"qci_qos_config": [{"qci": 9, "cbs": 1000, "ebs": 1000, "pbs": 1000}],`)
+
+		result := reload(t, http.StatusOK)
+		if !slices.Equal(result.Applied, []string{"qci_qos_config", "max_req_retries", "resp_timeout"}) {
+			t.Errorf("unexpected changes applied %v", result.Applied)
+		}
+
+		if respTimeout, retries := u.requestTimers(); respTimeout != 3*time.Second || retries != 7 {
+			t.Errorf("expected timeout 3s and 7 retries, got %v and %v", respTimeout, retries)
+		}
+
+		if qos := b.qosConfig(9); qos.cbs != 1000 {
+			t.Errorf("expected CBS 1000 for QCI 9, got %v", qos.cbs)
+		}
+	})
+
+	t.Run("unused heartbeat interval", func(t *testing.T) {
+		writeConf(t, `"resp_timeout": "3s", "max_req_retries": 7, "heart_beat_interval": "7s",
+			"qci_qos_config": [{"qci": 9, "cbs": 1000, "ebs": 1000, "pbs": 1000}],`)
+
+		if result := reload(t, http.StatusOK); len(result.Applied) != 0 {
+			t.Errorf("expected no change applied with the heartbeat timer disabled, got %v", result.Applied)
+		}
+
+		if interval := u.heartbeatInterval(); interval != 0 {
+			t.Errorf("expected heartbeat interval to stay unset, got %v", interval)
+		}
+	})
+
+	t.Run("non-reloadable changes", func(t *testing.T) {
+		writeConf(t, `"resp_timeout": "4s", "max_req_retries": 7, "heart_beat_interval": "7s", "n4_addr": "127.0.0.2",
+			"qci_qos_config": [{"qci": 9, "cbs": 1000, "ebs": 1000, "pbs": 1000}],`)
+
+		_, err := p.reloadConfig()
+		if err == nil || !strings.Contains(err.Error(), "n4_addr") {
+			t.Errorf("expected n4_addr change to be rejected, got %v", err)
+		}
+
+		reload(t, http.StatusBadRequest)
+
+		if respTimeout, _ := u.requestTimers(); respTimeout != 3*time.Second {
+			t.Errorf("expected timeout to stay 3s, got %v", respTimeout)
+		}
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		mustWriteStringToDisk(`{"mode": `, confPath)
+
+		reload(t, http.StatusBadRequest)
+	})
+}
+
+func TestReloadConfigUnsupportedChanges(t *testing.T) {
+	confPath := t.TempDir() + "/conf.jsonc"
+
+	writeConf := func(t *testing.T, settings string) {
+		t.Helper()
+
+		mustWriteStringToDisk(`{
+			"enable_go_datapath": true,
+			"go_datapath": {"n3_addr": "198.18.0.1"},
+			`+settings+`
+			"cpiface": {"dnn": "internet"}
+		}`, confPath)
+	}
+
+	writeConf(t, "")
+
+	running, err := LoadConfigFile(confPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	u := &upf{datapath: &fakeDP{}}
+	u.setTimers(time.Second, running.MaxReqRetries, 0)
+
+	p := &PFCPIface{conf: running, configPath: confPath, upf: u, node: &PFCPNode{upf: u}}
+
+	writeConf(t, `"resp_timeout": "3s", "slice_rate_limit_config": {"n6_bps": 1000000},`)
+
+	if _, err := p.reloadConfig(); !errors.Is(err, errUnsupported) {
+		t.Fatalf("expected slice rate limits to be unsupported, got %v", err)
+	}
+
+	if respTimeout, _ := u.requestTimers(); respTimeout != time.Second {
+		t.Errorf("expected timeout to stay 1s, got %v", respTimeout)
+	}
+
+	if !reflect.DeepEqual(p.conf, running) {
+		t.Errorf("expected the running config to be kept, got %+v", p.conf)
+	}
+}
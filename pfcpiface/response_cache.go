@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+// cachedRequestTypes are the request messages whose responses are kept, so that
+// retransmissions get the original response instead of being processed again.
+var cachedRequestTypes = map[uint8]bool{
+	message.MsgTypeHeartbeatRequest:            true,
+	message.MsgTypePFDManagementRequest:        true,
+	message.MsgTypeAssociationSetupRequest:     true,
+	message.MsgTypeAssociationUpdateRequest:    true,
+	message.MsgTypeAssociationReleaseRequest:   true,
+	message.MsgTypeSessionEstablishmentRequest: true,
+	message.MsgTypeSessionModificationRequest:  true,
+	message.MsgTypeSessionDeletionRequest:      true,
+}
+
+type responseKey struct {
+	seq     uint32
+	msgType uint8
+}
+
+type cachedResponse struct {
+	key     responseKey
+	reply   message.Message
+	expires time.Time
+}
+
+// responseCache keeps the responses sent to a PFCP peer for the duration of the
+// peer's retransmission window (T1 x N1), as required by 3GPP TS 29.244 6.4.
+// The zero value is ready to use.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[responseKey]*cachedResponse
+	// entries in insertion order, which is also their expiry order.
+	order []*cachedResponse
+}
+
+// get returns the response sent for the request with the given sequence number
+// and type, if it is still cached.
+func (c *responseCache) get(seq uint32, msgType uint8, now time.Time) (message.Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expire(now)
+
+	e, ok := c.entries[responseKey{seq: seq, msgType: msgType}]
+	if !ok {
+		return nil, false
+	}
+
+	return e.reply, true
+}
+
+// put caches the response to the request with the given sequence number and
+// type until now + ttl.
+func (c *responseCache) put(seq uint32, msgType uint8, reply message.Message, now time.Time, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expire(now)
+
+	if c.entries == nil {
+		c.entries = make(map[responseKey]*cachedResponse)
+	}
+
+	key := responseKey{seq: seq, msgType: msgType}
+	e := &cachedResponse{key: key, reply: reply, expires: now.Add(ttl)}
+
+	c.entries[key] = e
+	c.order = append(c.order, e)
+}
+
+// len returns the number of cached responses.
+func (c *responseCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// expire drops the expired entries. Must be called with mu held.
+func (c *responseCache) expire(now time.Time) {
+	n := 0
+	for _, e := range c.order {
+		if now.Before(e.expires) {
+			break
+		}
+
+		// The entry may have been replaced by a newer response for the same key.
+		if c.entries[e.key] == e {
+			delete(c.entries, e.key)
+		}
+
+		n++
+	}
+
+	if n == 0 {
+		return
+	}
+
+	remaining := copy(c.order, c.order[n:])
+	clear(c.order[remaining:])
+	c.order = c.order[:remaining]
+}
+
+// retransmissionWindow is how long a peer may retransmit a request, i.e. T1 x N1.
+// The peer's timers are not known, so the UPF's own request timers are assumed.
+func (pConn *PFCPConn) retransmissionWindow() time.Duration {
+	respTimeout, maxReqRetries := pConn.upf.requestTimers()
+
+	return respTimeout * time.Duration(maxReqRetries+1)
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/wmnsk/go-pfcp/ie"
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+func TestResponseCache(t *testing.T) {
+	var c responseCache
+
+	now := time.Now()
+	reply := message.NewHeartbeatResponse(1, ie.NewRecoveryTimeStamp(now))
+
+	c.put(1, message.MsgTypeHeartbeatRequest, reply, now, time.Second)
+
+	if _, ok := c.get(1, message.MsgTypeSessionDeletionRequest, now); ok {
+		t.Error("response returned for a different message type")
+	}
+
+	if cached, ok := c.get(1, message.MsgTypeHeartbeatRequest, now.Add(500*time.Millisecond)); !ok || cached != reply {
+		t.Error("expected cached response within the retransmission window")
+	}
+
+	if _, ok := c.get(1, message.MsgTypeHeartbeatRequest, now.Add(time.Second)); ok {
+		t.Error("response returned after the retransmission window")
+	}
+
+	if c.len() != 0 {
+		t.Errorf("expected expired responses to be dropped, %d left", c.len())
+	}
+}
+
+func TestHandlePFCPMsg_RetransmittedEstablishmentRequest(t *testing.T) {
+	peer, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to create udp socket: %v", err)
+	}
+	defer peer.Close()
+
+	conn, err := net.DialUDP("udp", nil, peer.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatalf("failed to dial udp socket: %v", err)
+	}
+	defer conn.Close()
+
+	node := &PFCPNode{upf: &upf{
+		datapath:      &fakeDP{},
+		usage:         newUsageTracker(),
+		respTimeout:   time.Second,
+		maxReqRetries: 3,
+	}}
+
+	p := newTestPFCPConn(node, 1)
+	p.Conn = conn
+	p.nodeID.remote = "smf"
+	p.nodeID.localIE = ie.NewNodeID("", "", "upf")
+
+	req := message.NewSessionEstablishmentRequest(0, 0, 0, 7, 0,
+		ie.NewNodeID("", "", "smf"),
+		ie.NewFSEID(1, net.ParseIP("10.0.0.1"), nil),
+	)
+
+	buf := make([]byte, req.MarshalLen())
+	if err = req.MarshalTo(buf); err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+
+	responses := make([][]byte, 0, 2)
+
+	for range 2 {
+		p.HandlePFCPMsg(buf)
+
+		resp := make([]byte, 1500)
+
+		if err = peer.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+			t.Fatalf("failed to set read deadline: %v", err)
+		}
+
+		n, _, err := peer.ReadFrom(resp)
+		if err != nil {
+			t.Fatalf("no response received: %v", err)
+		}
+
+		responses = append(responses, resp[:n])
+	}
+
+	if got := len(p.store.GetAllSessions()); got != 1 {
+		t.Errorf("expected 1 session, got %d", got)
+	}
+
+	if !bytes.Equal(responses[0], responses[1]) {
+		t.Error("expected the retransmission to get the original response")
+	}
+}
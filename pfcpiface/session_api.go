@@ -0,0 +1,333 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+// sessionView is the representation of a PFCP session served by the REST API,
+// with its addresses and filters decoded.
+type sessionView struct {
+	LocalSEID  uint64    `json:"local_seid"`
+	RemoteSEID uint64    `json:"remote_seid"`
+	Peer       string    `json:"peer"`
+	UEIP       string    `json:"ue_ip,omitempty"`
+	UEIPv6     string    `json:"ue_ipv6,omitempty"`
+	PDRs       []pdrView `json:"pdrs"`
+	FARs       []farView `json:"fars"`
+	QERs       []qerView `json:"qers"`
+}
+
+type appFilterView struct {
+	Proto    string `json:"proto,omitempty"`
+	SrcIP    string `json:"src_ip,omitempty"`
+	DstIP    string `json:"dst_ip,omitempty"`
+	SrcIPv6  string `json:"src_ipv6,omitempty"`
+	DstIPv6  string `json:"dst_ipv6,omitempty"`
+	SrcPorts string `json:"src_ports,omitempty"`
+	DstPorts string `json:"dst_ports,omitempty"`
+	IPv6Only bool   `json:"ipv6_only,omitempty"`
+}
+
+type pdrView struct {
+	PDRID      uint32        `json:"pdr_id"`
+	Precedence uint32        `json:"precedence"`
+	SrcIface   string        `json:"src_iface"`
+	TunnelIP   string        `json:"tunnel_ip,omitempty"`
+	TunnelIPv6 string        `json:"tunnel_ipv6,omitempty"`
+	TEID       uint32        `json:"teid,omitempty"`
+	UEIP       string        `json:"ue_ip,omitempty"`
+	UEIPv6     string        `json:"ue_ipv6,omitempty"`
+	AppFilter  appFilterView `json:"app_filter"`
+	FARID      uint32        `json:"far_id"`
+	QERIDs     []uint32      `json:"qer_ids,omitempty"`
+	URRIDs     []uint32      `json:"urr_ids,omitempty"`
+}
+
+type farView struct {
+	FARID      uint32   `json:"far_id"`
+	Actions    []string `json:"actions"`
+	DstIface   string   `json:"dst_iface"`
+	TunnelSrc  string   `json:"tunnel_src,omitempty"`
+	TunnelDst  string   `json:"tunnel_dst,omitempty"`
+	TEID       uint32   `json:"teid,omitempty"`
+	TunnelPort uint16   `json:"tunnel_port,omitempty"`
+	BARID      *uint8   `json:"bar_id,omitempty"`
+}
+
+type qerView struct {
+	QERID     uint32 `json:"qer_id"`
+	Level     string `json:"level"`
+	QFI       uint8  `json:"qfi,omitempty"`
+	ULGate    string `json:"ul_gate"`
+	DLGate    string `json:"dl_gate"`
+	ULMbrKbps uint64 `json:"ul_mbr_kbps"`
+	DLMbrKbps uint64 `json:"dl_mbr_kbps"`
+	ULGbrKbps uint64 `json:"ul_gbr_kbps"`
+	DLGbrKbps uint64 `json:"dl_gbr_kbps"`
+}
+
+func ifaceName(iface uint8) string {
+	switch iface {
+	case access:
+		return "access"
+	case core:
+		return "core"
+	default:
+		return fmt.Sprint(iface)
+	}
+}
+
+func dstIfaceName(iface uint8) string {
+	switch iface {
+	case ie.DstInterfaceAccess:
+		return "access"
+	case ie.DstInterfaceCore:
+		return "core"
+	default:
+		return fmt.Sprint(iface)
+	}
+}
+
+func gateName(status uint8) string {
+	if status == ie.GateStatusClosed {
+		return "closed"
+	}
+
+	return "open"
+}
+
+// prefixString returns ip/mask in CIDR notation, or nothing for a wildcard.
+func prefixString(ip net.IP, mask net.IPMask) string {
+	if ip == nil {
+		return ""
+	}
+
+	// A zero mask matches any address.
+	if ones, bits := mask.Size(); ones == 0 && bits != 0 {
+		return ""
+	}
+
+	// Non-contiguous masks have no size, and are shown in hexadecimal.
+	return (&net.IPNet{IP: ip, Mask: mask}).String()
+}
+
+func portsString(pr portRange) string {
+	switch {
+	case pr.isWildcardMatch():
+		return ""
+	case pr.isExactMatch():
+		return fmt.Sprint(pr.low)
+	default:
+		return fmt.Sprintf("%v-%v", pr.low, pr.high)
+	}
+}
+
+func newAppFilterView(af applicationFilter) appFilterView {
+	v := appFilterView{
+		SrcPorts: portsString(af.srcPortRange),
+		DstPorts: portsString(af.dstPortRange),
+		IPv6Only: af.ipv6Only,
+	}
+
+	if af.protoMask != 0 {
+		v.Proto = fmt.Sprint(af.proto)
+	}
+
+	if !af.ipv6Only {
+		v.SrcIP = prefixString(int2ip(af.srcIP), uint32ToMask(af.srcIPMask))
+		v.DstIP = prefixString(int2ip(af.dstIP), uint32ToMask(af.dstIPMask))
+	}
+
+	v.SrcIPv6 = prefixString(af.srcIP6, af.srcIP6Mask)
+	v.DstIPv6 = prefixString(af.dstIP6, af.dstIP6Mask)
+
+	return v
+}
+
+func uint32ToMask(mask uint32) net.IPMask {
+	return net.IPv4Mask(byte(mask>>24), byte(mask>>16), byte(mask>>8), byte(mask))
+}
+
+func newPDRView(p pdr) pdrView {
+	v := pdrView{
+		PDRID:      p.pdrID,
+		Precedence: p.precedence,
+		SrcIface:   ifaceName(p.srcIface),
+		TEID:       p.tunnelTEID,
+		AppFilter:  newAppFilterView(p.appFilter),
+		FARID:      p.farID,
+		QERIDs:     p.qerIDList,
+		URRIDs:     p.urrIDList,
+	}
+
+	if p.tunnelIP4Dst != 0 {
+		v.TunnelIP = int2ip(p.tunnelIP4Dst).String()
+	}
+
+	if p.tunnelIP6Dst != nil {
+		v.TunnelIPv6 = p.tunnelIP6Dst.String()
+	}
+
+	if p.ueAddress != 0 {
+		v.UEIP = int2ip(p.ueAddress).String()
+	}
+
+	if p.ueAddress6 != nil {
+		v.UEIPv6 = p.ueAddress6.String()
+	}
+
+	return v
+}
+
+func newFARView(f far) farView {
+	v := farView{
+		DstIface:   dstIfaceName(f.dstIntf),
+		FARID:      f.farID,
+		TEID:       f.tunnelTEID,
+		TunnelPort: f.tunnelPort,
+		Actions:    []string{},
+	}
+
+	for _, a := range []struct {
+		flag uint8
+		name string
+	}{
+		{ActionForward, "forward"},
+		{ActionDrop, "drop"},
+		{ActionBuffer, "buffer"},
+		{ActionNotify, "notify"},
+	} {
+		if f.applyAction&a.flag != 0 {
+			v.Actions = append(v.Actions, a.name)
+		}
+	}
+
+	if f.hasIPv6Tunnel() {
+		v.TunnelSrc, v.TunnelDst = f.tunnelIP6Src.String(), f.tunnelIP6Dst.String()
+	} else if f.tunnelIP4Dst != 0 {
+		v.TunnelSrc, v.TunnelDst = int2ip(f.tunnelIP4Src).String(), int2ip(f.tunnelIP4Dst).String()
+	}
+
+	if f.hasBAR {
+		barID := f.barID
+		v.BARID = &barID
+	}
+
+	return v
+}
+
+func newQERView(q qer) qerView {
+	level, ok := qosLevelName[q.qosLevel]
+	if !ok {
+		level = "invalid"
+	}
+
+	return qerView{
+		QERID:     q.qerID,
+		Level:     level,
+		QFI:       q.qfi,
+		ULGate:    gateName(q.ulStatus),
+		DLGate:    gateName(q.dlStatus),
+		ULMbrKbps: q.ulMbr,
+		DLMbrKbps: q.dlMbr,
+		ULGbrKbps: q.ulGbr,
+		DLGbrKbps: q.dlGbr,
+	}
+}
+
+func newSessionView(s PFCPSession, peer string) sessionView {
+	v := sessionView{
+		LocalSEID:  s.localSEID,
+		RemoteSEID: s.remoteSEID,
+		Peer:       peer,
+		PDRs:       make([]pdrView, 0, len(s.pdrs)),
+		FARs:       make([]farView, 0, len(s.fars)),
+		QERs:       make([]qerView, 0, len(s.qers)),
+	}
+
+	for _, p := range s.pdrs {
+		pv := newPDRView(p)
+
+		if v.UEIP == "" {
+			v.UEIP = pv.UEIP
+		}
+
+		if v.UEIPv6 == "" {
+			v.UEIPv6 = pv.UEIPv6
+		}
+
+		v.PDRs = append(v.PDRs, pv)
+	}
+
+	for _, f := range s.fars {
+		v.FARs = append(v.FARs, newFARView(f))
+	}
+
+	for _, q := range s.qers {
+		v.QERs = append(v.QERs, newQERView(q))
+	}
+
+	return v
+}
+
+// hasUEIP returns true if the session has the UE IPv4 address, or the UE IPv6
+// prefix including ip.
+func (s PFCPSession) hasUEIP(ip net.IP) bool {
+	for _, p := range s.pdrs {
+		if ip.To4() != nil && p.ueAddress == ip2int(ip) {
+			return true
+		}
+
+		if ip.To4() == nil && p.ueAddress6 != nil && p.ueAddress6.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sessionView returns the session of the node owning the local SEID, if any.
+func (node *PFCPNode) sessionView(seid uint64) (sessionView, bool) {
+	pConn, ok := node.sessionOwner(seid)
+	if !ok {
+		return sessionView{}, false
+	}
+
+	s, ok := pConn.store.GetSession(seid)
+	if !ok {
+		return sessionView{}, false
+	}
+
+	return newSessionView(s, pConn.remoteNodeID()), true
+}
+
+// sessionViews returns the sessions of all the associations of the node,
+// including the lost ones, by local SEID. Only the sessions of the UE are
+// returned if ueIP is set.
+func (node *PFCPNode) sessionViews(ueIP net.IP) []sessionView {
+	views := make([]sessionView, 0)
+
+	node.sessions.Range(func(key, value any) bool {
+		pConn := value.(*PFCPConn)
+
+		s, ok := pConn.store.GetSession(key.(uint64))
+		if !ok || (ueIP != nil && !s.hasUEIP(ueIP)) {
+			return true
+		}
+
+		views = append(views, newSessionView(s, pConn.remoteNodeID()))
+
+		return true
+	})
+
+	sort.Slice(views, func(i, j int) bool { return views[i].LocalSEID < views[j].LocalSEID })
+
+	return views
+}
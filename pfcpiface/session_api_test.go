@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+func TestSessionsHandler(t *testing.T) {
+	node := &PFCPNode{upf: &upf{datapath: &fakeDP{}, usage: newUsageTracker()}}
+	pConn := newTestPFCPConn(node, 1)
+	pConn.nodeID.remote = "smf"
+
+	for i := uint32(1); i <= 2; i++ {
+		session, ok := pConn.NewPFCPSession(uint64(i))
+		if !ok {
+			t.Fatal("failed to allocate session")
+		}
+
+		seid := session.localSEID
+
+		uplink := pdr{
+			pdrID: 1, fseID: seid, srcIface: access, precedence: 100,
+			tunnelIP4Dst: ip2int(net.ParseIP("198.18.0.1")), tunnelTEID: i, farID: 1, qerIDList: []uint32{1},
+		}
+
+		downlink := pdr{
+			pdrID: 2, fseID: seid, srcIface: core, precedence: 100,
+			ueAddress: ip2int(net.IPv4(10, 250, 0, byte(i))), farID: 2,
+		}
+		downlink.appFilter.proto, downlink.appFilter.protoMask = 17, 0xff
+		downlink.appFilter.srcIP, downlink.appFilter.srcIPMask = ip2int(net.ParseIP("192.0.2.0")), 0xffffff00
+		downlink.appFilter.srcPortRange = newRangeMatchPortRange(80, 81)
+
+		session.CreatePDR(uplink)
+		session.CreatePDR(downlink)
+		session.CreateFAR(far{farID: 1, fseID: seid, applyAction: ActionForward, dstIntf: ie.DstInterfaceCore})
+		session.CreateFAR(far{
+			farID: 2, fseID: seid, applyAction: ActionBuffer | ActionNotify, dstIntf: ie.DstInterfaceAccess,
+			tunnelIP4Src: ip2int(net.ParseIP("198.18.0.1")), tunnelIP4Dst: ip2int(net.ParseIP("198.18.0.2")),
+			tunnelTEID: 0x20 + i, tunnelPort: tunnelGTPUPort,
+		})
+		session.CreateQER(qer{qerID: 1, fseID: seid, qfi: 9, dlStatus: ie.GateStatusClosed, ulMbr: 1000})
+
+		if err := pConn.store.PutSession(session); err != nil {
+			t.Fatalf("failed to store session: %v", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	setupSessionsHandler(mux, node)
+
+	get := func(t *testing.T, target string, status int, v any) {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+		if w.Code != status {
+			t.Fatalf("expected status %v, got %v: %s", status, w.Code, w.Body)
+		}
+
+		if v != nil {
+			if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+				t.Fatalf("failed to decode %s: %v", w.Body, err)
+			}
+		}
+	}
+
+	var sessions []sessionView
+
+	get(t, "/v1/sessions", http.StatusOK, &sessions)
+
+	if len(sessions) != 2 || sessions[0].LocalSEID > sessions[1].LocalSEID {
+		t.Fatalf("expected 2 sessions by SEID, got %+v", sessions)
+	}
+
+	t.Run("by SEID", func(t *testing.T) {
+		var s sessionView
+
+		get(t, "/v1/sessions/0x"+strconv.FormatUint(sessions[1].LocalSEID, 16), http.StatusOK, &s)
+
+		if s.Peer != "smf" || s.RemoteSEID != sessions[1].RemoteSEID || s.UEIP != sessions[1].UEIP {
+			t.Errorf("unexpected session %+v", s)
+		}
+
+		if af := s.PDRs[1].AppFilter; af.Proto != "17" || af.SrcIP != "192.0.2.0/24" || af.SrcPorts != "80-81" || af.DstIP != "" {
+			t.Errorf("unexpected application filter %+v", af)
+		}
+
+		if f := s.FARs[1]; len(f.Actions) != 2 || f.Actions[0] != "buffer" || f.DstIface != "access" ||
+			f.TunnelDst != "198.18.0.2" {
+			t.Errorf("unexpected FAR %+v", f)
+		}
+
+		if q := s.QERs[0]; q.QFI != 9 || q.ULGate != "open" || q.DLGate != "closed" || q.ULMbrKbps != 1000 {
+			t.Errorf("unexpected QER %+v", q)
+		}
+	})
+
+	t.Run("by UE IP", func(t *testing.T) {
+		var found []sessionView
+
+		get(t, "/v1/sessions?ue_ip=10.250.0.2", http.StatusOK, &found)
+
+		if len(found) != 1 || found[0].UEIP != "10.250.0.2" || found[0].RemoteSEID != 2 {
+			t.Errorf("expected the session of UE 10.250.0.2, got %+v", found)
+		}
+
+		get(t, "/v1/sessions?ue_ip=10.250.0.3", http.StatusOK, &found)
+
+		if len(found) != 0 {
+			t.Errorf("expected no session, got %+v", found)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		get(t, "/v1/sessions/42", http.StatusNotFound, nil)
+		get(t, "/v1/sessions/abc", http.StatusBadRequest, nil)
+		get(t, "/v1/sessions?ue_ip=abc", http.StatusBadRequest, nil)
+	})
+}
@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// CreateBAR appends bar to existing list of BARs in the session.
+func (s *PFCPSession) CreateBAR(b bar) {
+	s.bars = append(s.bars, b)
+}
+
+// getBAR returns a copy of the BAR with the given ID.
+func (s *PFCPSession) getBAR(id uint8) (bar, error) {
+	for _, v := range s.bars {
+		if v.barID == id {
+			return v, nil
+		}
+	}
+
+	return bar{}, ErrNotFound("BAR")
+}
+
+// UpdateBAR updates existing bar in the session.
+func (s *PFCPSession) UpdateBAR(b bar) error {
+	for idx, v := range s.bars {
+		if v.barID == b.barID {
+			s.bars[idx] = b
+			return nil
+		}
+	}
+
+	return ErrNotFound("BAR")
+}
+
+// RemoveBAR removes bar from existing list of BARs in the session.
+func (s *PFCPSession) RemoveBAR(id uint8) (*bar, error) {
+	for idx, v := range s.bars {
+		if v.barID == id {
+			s.bars = append(s.bars[:idx], s.bars[idx+1:]...)
+			return &v, nil
+		}
+	}
+
+	return nil, ErrNotFound("BAR")
+}
+
+// barForFAR returns the BAR linked to the FAR, if any.
+func (s *PFCPSession) barForFAR(f far) *bar {
+	if !f.hasBAR {
+		return nil
+	}
+
+	b, err := s.getBAR(f.barID)
+	if err != nil {
+		return nil
+	}
+
+	return &b
+}
+
+// applyBufferLimits sets the number of downlink packets the FARs may buffer
+// from their BAR, and returns the FARs changed. Only the Go datapath buffers
+// packets, BESS and UP4 notify the CP function and drop them.
+func (s *PFCPSession) applyBufferLimits() []far {
+	var changed []far
+
+	for i := range s.fars {
+		f := &s.fars[i]
+
+		var (
+			packets uint16
+			limited bool
+		)
+
+		if b := s.barForFAR(*f); b != nil {
+			packets, limited = b.bufferLimit()
+		}
+
+		if f.bufferPackets == packets && f.bufferLimited == limited {
+			continue
+		}
+
+		f.bufferPackets, f.bufferLimited = packets, limited
+		changed = append(changed, *f)
+	}
+
+	return changed
+}
+
+// resetDLBuffering forgets the DL buffering parameters of the last Session
+// Report Response, once the session stops buffering.
+func (s *PFCPSession) resetDLBuffering() {
+	for i := range s.bars {
+		s.bars[i].dlPktLimit = 0
+		s.bars[i].discard = false
+	}
+}
+
+// ddnAction is what to do when the datapath signals downlink data for a
+// session whose FAR buffers packets.
+type ddnAction int
+
+const (
+	ddnNotify ddnAction = iota
+	// the CP function is aware of the buffered data, do not notify again.
+	ddnSuppress
+	// the CP function requested to discard the data.
+	ddnDrop
+)
+
+func (a ddnAction) String() string {
+	switch a {
+	case ddnNotify:
+		return "notify"
+	case ddnSuppress:
+		return "suppress"
+	case ddnDrop:
+		return "drop"
+	default:
+		return UnknownString
+	}
+}
+
+// bufferingState is the downlink buffering state of a single session. The
+// number of buffered packets is bounded by the datapath, see applyBufferLimits.
+type bufferingState struct {
+	// DL Buffering Duration received in a Session Report Response. While it
+	// runs, no further DDNs are sent.
+	until    time.Time
+	infinite bool
+	// a zero DL Buffering Duration requests not to buffer at all.
+	discard bool
+}
+
+func (st *bufferingState) durationActive(now time.Time) bool {
+	return st.infinite || now.Before(st.until)
+}
+
+// onDownlinkData decides how to handle a downlink data event.
+func (st *bufferingState) onDownlinkData(now time.Time) ddnAction {
+	if st.discard {
+		return ddnDrop
+	}
+
+	if !st.until.IsZero() || st.infinite {
+		if st.durationActive(now) {
+			return ddnSuppress
+		}
+
+		// DL Buffering Duration expired, the CP function has to be notified again.
+		*st = bufferingState{}
+	}
+
+	return ddnNotify
+}
+
+// downlinkBuffering keeps the buffering state of the sessions of a PFCP association.
+// The zero value is ready to use.
+type downlinkBuffering struct {
+	mu       sync.Mutex
+	sessions map[uint64]*bufferingState
+}
+
+func (d *downlinkBuffering) decide(seid uint64, now time.Time) ddnAction {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sessions == nil {
+		d.sessions = make(map[uint64]*bufferingState)
+	}
+
+	st, ok := d.sessions[seid]
+	if !ok {
+		st = &bufferingState{}
+		d.sessions[seid] = st
+	}
+
+	return st.onDownlinkData(now)
+}
+
+// startDuration applies the DL Buffering Duration received in a Session
+// Report Response.
+func (d *downlinkBuffering) startDuration(seid uint64, duration time.Duration, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sessions == nil {
+		d.sessions = make(map[uint64]*bufferingState)
+	}
+
+	st := &bufferingState{}
+
+	switch {
+	case duration == time.Duration(math.MaxInt64):
+		st.infinite = true
+	case duration == 0:
+		st.discard = true
+	default:
+		st.until = now.Add(duration)
+	}
+
+	d.sessions[seid] = st
+}
+
+// reset forgets the buffering state of the session, e.g. once it stops buffering.
+func (d *downlinkBuffering) reset(seid uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.sessions, seid)
+}
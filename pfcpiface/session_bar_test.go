@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+func TestParseBAR(t *testing.T) {
+	var b bar
+
+	err := b.parseBAR(ie.NewCreateBAR(
+		ie.NewBARID(3),
+		ie.NewDownlinkDataNotificationDelay(100*time.Millisecond),
+		ie.NewSuggestedBufferingPacketsCount(10),
+	), 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := bar{barID: 3, fseID: 42, ddnDelay: 100 * time.Millisecond, suggestedPktCount: 10}
+	if b != expected {
+		t.Fatalf("expected %v, got %v", expected, b)
+	}
+
+	// Update BAR only overwrites the present fields.
+	err = b.parseBAR(ie.NewUpdateBARWithinSessionModificationRequest(
+		ie.NewBARID(3),
+		ie.NewSuggestedBufferingPacketsCount(20),
+	), 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected.suggestedPktCount = 20
+	if b != expected {
+		t.Errorf("expected %v, got %v", expected, b)
+	}
+}
+
+func TestDownlinkBuffering(t *testing.T) {
+	const seid = uint64(1)
+
+	now := time.Now()
+
+	t.Run("DL buffering duration", func(t *testing.T) {
+		var d downlinkBuffering
+
+		if got := d.decide(seid, now); got != ddnNotify {
+			t.Fatalf("expected %v, got %v", ddnNotify, got)
+		}
+
+		d.startDuration(seid, 10*time.Second, now)
+
+		for i, expected := range []ddnAction{ddnSuppress, ddnSuppress} {
+			if got := d.decide(seid, now.Add(time.Second)); got != expected {
+				t.Errorf("event %d: expected %v, got %v", i, expected, got)
+			}
+		}
+
+		// The CP function is notified again once the duration expires.
+		if got := d.decide(seid, now.Add(11*time.Second)); got != ddnNotify {
+			t.Errorf("expected %v after expiry, got %v", ddnNotify, got)
+		}
+	})
+
+	t.Run("zero and infinite DL buffering duration", func(t *testing.T) {
+		var d downlinkBuffering
+
+		d.startDuration(seid, 0, now)
+
+		if got := d.decide(seid, now.Add(time.Hour)); got != ddnDrop {
+			t.Errorf("expected %v, got %v", ddnDrop, got)
+		}
+
+		d.startDuration(seid, time.Duration(math.MaxInt64), now)
+
+		if got := d.decide(seid, now.Add(24*time.Hour)); got != ddnSuppress {
+			t.Errorf("expected %v, got %v", ddnSuppress, got)
+		}
+	})
+}
+
+func TestBufferLimits(t *testing.T) {
+	s := PFCPSession{
+		localSEID: 1,
+		PacketForwardingRules: PacketForwardingRules{
+			fars: []far{
+				{farID: 1, applyAction: ActionBuffer | ActionNotify, barID: 1, hasBAR: true},
+				{farID: 2, applyAction: ActionForward},
+			},
+			bars: []bar{{barID: 1, suggestedPktCount: 10}},
+		},
+	}
+
+	limit := func(t *testing.T, changed []far, packets uint16, limited bool) {
+		t.Helper()
+
+		if len(changed) != 1 || changed[0].farID != 1 {
+			t.Fatalf("expected FAR 1 to change, got %v", changed)
+		}
+
+		if f := s.fars[0]; f.bufferPackets != packets || f.bufferLimited != limited {
+			t.Errorf("expected buffer limit %v (%v), got %v (%v)", packets, limited, f.bufferPackets, f.bufferLimited)
+		}
+	}
+
+	limit(t, s.applyBufferLimits(), 10, true)
+
+	if changed := s.applyBufferLimits(); len(changed) != 0 {
+		t.Errorf("expected no change, got %v", changed)
+	}
+
+	// DL Buffering Suggested Packet Count of a Session Report Response.
+	s.bars[0].dlPktLimit = 300
+	limit(t, s.applyBufferLimits(), 300, true)
+
+	// Zero DL Buffering Duration.
+	s.bars[0].discard = true
+	limit(t, s.applyBufferLimits(), 0, true)
+
+	s.resetDLBuffering()
+	s.bars[0].suggestedPktCount = 0
+	limit(t, s.applyBufferLimits(), 0, false)
+}
@@ -0,0 +1,443 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"encoding/json"
+	"net"
+	"time"
+)
+
+// storedSessionVersion is the version of the storedSession schema. It is
+// bumped on incompatible changes, records of newer versions are not restored.
+// Records written before the schema was versioned have version 0 and are
+// compatible with version 1.
+const storedSessionVersion = 1
+
+// storedSession is the serialized form of a PFCPSession, together with the
+// node ID of the CP function owning it.
+type storedSession struct {
+	Version    int         `json:"version"`
+	NodeID     string      `json:"node_id"`
+	LocalSEID  uint64      `json:"local_seid"`
+	RemoteSEID uint64      `json:"remote_seid"`
+	RemoteIP   string      `json:"remote_ip,omitempty"`
+	PDRs       []storedPDR `json:"pdrs"`
+	FARs       []storedFAR `json:"fars"`
+	QERs       []storedQER `json:"qers"`
+	URRs       []storedURR `json:"urrs"`
+	BARs       []storedBAR `json:"bars"`
+}
+
+type storedAppFilter struct {
+	SrcIP      uint32     `json:"src_ip"`
+	DstIP      uint32     `json:"dst_ip"`
+	SrcPorts   [2]uint16  `json:"src_ports"`
+	DstPorts   [2]uint16  `json:"dst_ports"`
+	Proto      uint8      `json:"proto"`
+	SrcIPMask  uint32     `json:"src_ip_mask"`
+	DstIPMask  uint32     `json:"dst_ip_mask"`
+	ProtoMask  uint8      `json:"proto_mask"`
+	SrcIP6     net.IP     `json:"src_ip6,omitempty"`
+	DstIP6     net.IP     `json:"dst_ip6,omitempty"`
+	SrcIP6Mask net.IPMask `json:"src_ip6_mask,omitempty"`
+	DstIP6Mask net.IPMask `json:"dst_ip6_mask,omitempty"`
+	IPv6Only   bool       `json:"ipv6_only,omitempty"`
+}
+
+type storedPDR struct {
+	UPAllocateFteid  bool            `json:"up_allocate_fteid"`
+	SrcIface         uint8           `json:"src_iface"`
+	TunnelIP4Dst     uint32          `json:"tunnel_ip4_dst"`
+	TunnelTEID       uint32          `json:"tunnel_teid"`
+	UEAddress        uint32          `json:"ue_address"`
+	TunnelIP6Dst     net.IP          `json:"tunnel_ip6_dst,omitempty"`
+	UEAddress6       string          `json:"ue_address6,omitempty"`
+	FTEIDFlags       uint8           `json:"fteid_flags"`
+	SrcIfaceMask     uint8           `json:"src_iface_mask"`
+	TunnelIP4DstMask uint32          `json:"tunnel_ip4_dst_mask"`
+	TunnelTEIDMask   uint32          `json:"tunnel_teid_mask"`
+	AppFilter        storedAppFilter `json:"app_filter"`
+	Precedence       uint32          `json:"precedence"`
+	PDRID            uint32          `json:"pdr_id"`
+	FSEID            uint64          `json:"fseid"`
+	FSEIDIP          uint32          `json:"fseid_ip"`
+	CtrID            uint32          `json:"ctr_id"`
+	FARID            uint32          `json:"far_id"`
+	QERIDs           []uint32        `json:"qer_ids"`
+	URRIDs           []uint32        `json:"urr_ids"`
+	NeedDecap        uint8           `json:"need_decap"`
+	AllocIP          bool            `json:"alloc_ip"`
+}
+
+type storedFAR struct {
+	FARID         uint32 `json:"far_id"`
+	FSEID         uint64 `json:"fseid"`
+	FSEIDIP       uint32 `json:"fseid_ip"`
+	DstIntf       uint8  `json:"dst_intf"`
+	SendEndMarker bool   `json:"send_end_marker"`
+	ApplyAction   uint8  `json:"apply_action"`
+	QuotaAction   uint8  `json:"quota_action,omitempty"`
+	TunnelType    uint8  `json:"tunnel_type"`
+	TunnelIP4Src  uint32 `json:"tunnel_ip4_src"`
+	TunnelIP4Dst  uint32 `json:"tunnel_ip4_dst"`
+	TunnelTEID    uint32 `json:"tunnel_teid"`
+	TunnelPort    uint16 `json:"tunnel_port"`
+	TunnelIP6Src  net.IP `json:"tunnel_ip6_src,omitempty"`
+	TunnelIP6Dst  net.IP `json:"tunnel_ip6_dst,omitempty"`
+	BARID         uint8  `json:"bar_id"`
+	HasBAR        bool   `json:"has_bar"`
+	BufferPackets uint16 `json:"buffer_packets,omitempty"`
+	BufferLimited bool   `json:"buffer_limited,omitempty"`
+}
+
+type storedQER struct {
+	QERID    uint32   `json:"qer_id"`
+	QosLevel QosLevel `json:"qos_level"`
+	QFI      uint8    `json:"qfi"`
+	ULStatus uint8    `json:"ul_status"`
+	DLStatus uint8    `json:"dl_status"`
+	ULMbr    uint64   `json:"ul_mbr"`
+	DLMbr    uint64   `json:"dl_mbr"`
+	ULGbr    uint64   `json:"ul_gbr"`
+	DLGbr    uint64   `json:"dl_gbr"`
+	FSEID    uint64   `json:"fseid"`
+	FSEIDIP  uint32   `json:"fseid_ip"`
+}
+
+type storedURR struct {
+	URRID             uint32        `json:"urr_id"`
+	FSEID             uint64        `json:"fseid"`
+	FSEIDIP           uint32        `json:"fseid_ip"`
+	MeasureVolume     bool          `json:"measure_volume"`
+	MeasureDuration   bool          `json:"measure_duration"`
+	Triggers          uint32        `json:"triggers"`
+	MeasurementPeriod time.Duration `json:"measurement_period"`
+	VolumeThreshold   [3]uint64     `json:"volume_threshold"`
+	VolumeQuota       [3]uint64     `json:"volume_quota"`
+	TimeThreshold     time.Duration `json:"time_threshold"`
+	TimeQuota         time.Duration `json:"time_quota"`
+}
+
+type storedBAR struct {
+	BARID             uint8         `json:"bar_id"`
+	FSEID             uint64        `json:"fseid"`
+	DDNDelay          time.Duration `json:"ddn_delay"`
+	SuggestedPktCount uint8         `json:"suggested_pkt_count"`
+	DLPktLimit        uint16        `json:"dl_pkt_limit,omitempty"`
+	Discard           bool          `json:"discard,omitempty"`
+}
+
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+
+	return ip.String()
+}
+
+func ipNetString(n *net.IPNet) string {
+	if n == nil {
+		return ""
+	}
+
+	return n.String()
+}
+
+func parseIPNetString(s string) (*net.IPNet, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	ip, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, err
+	}
+
+	n.IP = ip
+
+	return n, nil
+}
+
+func (v volumeLimit) toArray() [3]uint64 {
+	return [3]uint64{v.total, v.uplink, v.downlink}
+}
+
+func volumeLimitFromArray(a [3]uint64) volumeLimit {
+	return volumeLimit{total: a[0], uplink: a[1], downlink: a[2]}
+}
+
+// encodeSession serializes the session owned by the CP function nodeID.
+func encodeSession(s PFCPSession, nodeID string) ([]byte, error) {
+	st := storedSession{
+		Version:    storedSessionVersion,
+		NodeID:     nodeID,
+		LocalSEID:  s.localSEID,
+		RemoteSEID: s.remoteSEID,
+		RemoteIP:   ipString(s.remoteIP),
+		PDRs:       make([]storedPDR, 0, len(s.pdrs)),
+		FARs:       make([]storedFAR, 0, len(s.fars)),
+		QERs:       make([]storedQER, 0, len(s.qers)),
+		URRs:       make([]storedURR, 0, len(s.urrs)),
+		BARs:       make([]storedBAR, 0, len(s.bars)),
+	}
+
+	for _, p := range s.pdrs {
+		f := p.appFilter
+
+		st.PDRs = append(st.PDRs, storedPDR{
+			UPAllocateFteid:  p.UPAllocateFteid,
+			SrcIface:         p.srcIface,
+			TunnelIP4Dst:     p.tunnelIP4Dst,
+			TunnelTEID:       p.tunnelTEID,
+			UEAddress:        p.ueAddress,
+			TunnelIP6Dst:     p.tunnelIP6Dst,
+			UEAddress6:       ipNetString(p.ueAddress6),
+			FTEIDFlags:       p.fteidFlags,
+			SrcIfaceMask:     p.srcIfaceMask,
+			TunnelIP4DstMask: p.tunnelIP4DstMask,
+			TunnelTEIDMask:   p.tunnelTEIDMask,
+			AppFilter: storedAppFilter{
+				SrcIP:      f.srcIP,
+				DstIP:      f.dstIP,
+				SrcPorts:   [2]uint16{f.srcPortRange.low, f.srcPortRange.high},
+				DstPorts:   [2]uint16{f.dstPortRange.low, f.dstPortRange.high},
+				Proto:      f.proto,
+				SrcIPMask:  f.srcIPMask,
+				DstIPMask:  f.dstIPMask,
+				ProtoMask:  f.protoMask,
+				SrcIP6:     f.srcIP6,
+				DstIP6:     f.dstIP6,
+				SrcIP6Mask: f.srcIP6Mask,
+				DstIP6Mask: f.dstIP6Mask,
+				IPv6Only:   f.ipv6Only,
+			},
+			Precedence: p.precedence,
+			PDRID:      p.pdrID,
+			FSEID:      p.fseID,
+			FSEIDIP:    p.fseidIP,
+			CtrID:      p.ctrID,
+			FARID:      p.farID,
+			QERIDs:     p.qerIDList,
+			URRIDs:     p.urrIDList,
+			NeedDecap:  p.needDecap,
+			AllocIP:    p.allocIPFlag,
+		})
+	}
+
+	for _, f := range s.fars {
+		st.FARs = append(st.FARs, storedFAR{
+			FARID:         f.farID,
+			FSEID:         f.fseID,
+			FSEIDIP:       f.fseidIP,
+			DstIntf:       f.dstIntf,
+			SendEndMarker: f.sendEndMarker,
+			ApplyAction:   f.applyAction,
+			QuotaAction:   f.quotaAction,
+			TunnelType:    f.tunnelType,
+			TunnelIP4Src:  f.tunnelIP4Src,
+			TunnelIP4Dst:  f.tunnelIP4Dst,
+			TunnelTEID:    f.tunnelTEID,
+			TunnelPort:    f.tunnelPort,
+			TunnelIP6Src:  f.tunnelIP6Src,
+			TunnelIP6Dst:  f.tunnelIP6Dst,
+			BARID:         f.barID,
+			HasBAR:        f.hasBAR,
+			BufferPackets: f.bufferPackets,
+			BufferLimited: f.bufferLimited,
+		})
+	}
+
+	for _, q := range s.qers {
+		st.QERs = append(st.QERs, storedQER{
+			QERID:    q.qerID,
+			QosLevel: q.qosLevel,
+			QFI:      q.qfi,
+			ULStatus: q.ulStatus,
+			DLStatus: q.dlStatus,
+			ULMbr:    q.ulMbr,
+			DLMbr:    q.dlMbr,
+			ULGbr:    q.ulGbr,
+			DLGbr:    q.dlGbr,
+			FSEID:    q.fseID,
+			FSEIDIP:  q.fseidIP,
+		})
+	}
+
+	for _, u := range s.urrs {
+		st.URRs = append(st.URRs, storedURR{
+			URRID:             u.urrID,
+			FSEID:             u.fseID,
+			FSEIDIP:           u.fseidIP,
+			MeasureVolume:     u.measureVolume,
+			MeasureDuration:   u.measureDuration,
+			Triggers:          uint32(u.triggers),
+			MeasurementPeriod: u.measurementPeriod,
+			VolumeThreshold:   u.volumeThreshold.toArray(),
+			VolumeQuota:       u.volumeQuota.toArray(),
+			TimeThreshold:     u.timeThreshold,
+			TimeQuota:         u.timeQuota,
+		})
+	}
+
+	for _, b := range s.bars {
+		st.BARs = append(st.BARs, storedBAR{
+			BARID:             b.barID,
+			FSEID:             b.fseID,
+			DDNDelay:          b.ddnDelay,
+			SuggestedPktCount: b.suggestedPktCount,
+			DLPktLimit:        b.dlPktLimit,
+			Discard:           b.discard,
+		})
+	}
+
+	return json.Marshal(st)
+}
+
+// decodeSession deserializes a session and returns the node ID of the CP
+// function owning it. The session metrics are not restored.
+func decodeSession(data []byte) (PFCPSession, string, error) {
+	var st storedSession
+
+	if err := json.Unmarshal(data, &st); err != nil {
+		return PFCPSession{}, "", err
+	}
+
+	if st.Version > storedSessionVersion {
+		return PFCPSession{}, "", ErrUnsupported("stored session version", st.Version)
+	}
+
+	s := PFCPSession{
+		localSEID:  st.LocalSEID,
+		remoteSEID: st.RemoteSEID,
+		PacketForwardingRules: PacketForwardingRules{
+			pdrs: make([]pdr, 0, MaxItems),
+			fars: make([]far, 0, MaxItems),
+			qers: make([]qer, 0, MaxItems),
+			urrs: make([]urr, 0, MaxItems),
+		},
+	}
+
+	if ip := net.ParseIP(st.RemoteIP); ip.To4() != nil {
+		s.remoteIP = ip.To4()
+	} else {
+		s.remoteIP = ip
+	}
+
+	for _, p := range st.PDRs {
+		ueAddress6, err := parseIPNetString(p.UEAddress6)
+		if err != nil {
+			return PFCPSession{}, "", ErrInvalidArgumentWithReason("UE IPv6 prefix", p.UEAddress6, err.Error())
+		}
+
+		f := p.AppFilter
+
+		s.pdrs = append(s.pdrs, pdr{
+			UPAllocateFteid:  p.UPAllocateFteid,
+			srcIface:         p.SrcIface,
+			tunnelIP4Dst:     p.TunnelIP4Dst,
+			tunnelTEID:       p.TunnelTEID,
+			ueAddress:        p.UEAddress,
+			tunnelIP6Dst:     p.TunnelIP6Dst,
+			ueAddress6:       ueAddress6,
+			fteidFlags:       p.FTEIDFlags,
+			srcIfaceMask:     p.SrcIfaceMask,
+			tunnelIP4DstMask: p.TunnelIP4DstMask,
+			tunnelTEIDMask:   p.TunnelTEIDMask,
+			appFilter: applicationFilter{
+				srcIP:        f.SrcIP,
+				dstIP:        f.DstIP,
+				srcPortRange: portRange{low: f.SrcPorts[0], high: f.SrcPorts[1]},
+				dstPortRange: portRange{low: f.DstPorts[0], high: f.DstPorts[1]},
+				proto:        f.Proto,
+				srcIPMask:    f.SrcIPMask,
+				dstIPMask:    f.DstIPMask,
+				protoMask:    f.ProtoMask,
+				srcIP6:       f.SrcIP6,
+				dstIP6:       f.DstIP6,
+				srcIP6Mask:   f.SrcIP6Mask,
+				dstIP6Mask:   f.DstIP6Mask,
+				ipv6Only:     f.IPv6Only,
+			},
+			precedence:  p.Precedence,
+			pdrID:       p.PDRID,
+			fseID:       p.FSEID,
+			fseidIP:     p.FSEIDIP,
+			ctrID:       p.CtrID,
+			farID:       p.FARID,
+			qerIDList:   p.QERIDs,
+			urrIDList:   p.URRIDs,
+			needDecap:   p.NeedDecap,
+			allocIPFlag: p.AllocIP,
+		})
+	}
+
+	for _, f := range st.FARs {
+		s.fars = append(s.fars, far{
+			farID:         f.FARID,
+			fseID:         f.FSEID,
+			fseidIP:       f.FSEIDIP,
+			dstIntf:       f.DstIntf,
+			sendEndMarker: f.SendEndMarker,
+			applyAction:   f.ApplyAction,
+			quotaAction:   f.QuotaAction,
+			tunnelType:    f.TunnelType,
+			tunnelIP4Src:  f.TunnelIP4Src,
+			tunnelIP4Dst:  f.TunnelIP4Dst,
+			tunnelTEID:    f.TunnelTEID,
+			tunnelPort:    f.TunnelPort,
+			tunnelIP6Src:  f.TunnelIP6Src,
+			tunnelIP6Dst:  f.TunnelIP6Dst,
+			barID:         f.BARID,
+			hasBAR:        f.HasBAR,
+			bufferPackets: f.BufferPackets,
+			bufferLimited: f.BufferLimited,
+		})
+	}
+
+	for _, q := range st.QERs {
+		s.qers = append(s.qers, qer{
+			qerID:    q.QERID,
+			qosLevel: q.QosLevel,
+			qfi:      q.QFI,
+			ulStatus: q.ULStatus,
+			dlStatus: q.DLStatus,
+			ulMbr:    q.ULMbr,
+			dlMbr:    q.DLMbr,
+			ulGbr:    q.ULGbr,
+			dlGbr:    q.DLGbr,
+			fseID:    q.FSEID,
+			fseidIP:  q.FSEIDIP,
+		})
+	}
+
+	for _, u := range st.URRs {
+		s.urrs = append(s.urrs, urr{
+			urrID:             u.URRID,
+			fseID:             u.FSEID,
+			fseidIP:           u.FSEIDIP,
+			measureVolume:     u.MeasureVolume,
+			measureDuration:   u.MeasureDuration,
+			triggers:          reportingTriggers(u.Triggers),
+			measurementPeriod: u.MeasurementPeriod,
+			volumeThreshold:   volumeLimitFromArray(u.VolumeThreshold),
+			volumeQuota:       volumeLimitFromArray(u.VolumeQuota),
+			timeThreshold:     u.TimeThreshold,
+			timeQuota:         u.TimeQuota,
+		})
+	}
+
+	for _, b := range st.BARs {
+		s.bars = append(s.bars, bar{
+			barID:             b.BARID,
+			fseID:             b.FSEID,
+			ddnDelay:          b.DDNDelay,
+			suggestedPktCount: b.SuggestedPktCount,
+			dlPktLimit:        b.DLPktLimit,
+			discard:           b.Discard,
+		})
+	}
+
+	return s, st.NodeID, nil
+}
@@ -5,6 +5,8 @@ package pfcpiface
 
 import (
 	"encoding/binary"
+	"net"
+	"slices"
 
 	"github.com/omec-project/upf-epc/logger"
 )
@@ -35,6 +37,17 @@ const (
 	gtpEndMarkerType = 254
 )
 
+// Packet structure constants for GTP-U end marker over IPv6
+const (
+	ipv6HeaderSize  = 40
+	endMarkerSizeV6 = ethHeaderSize + ipv6HeaderSize + udpHeaderSize + gtpHeaderSize
+
+	ipv6SrcOffset = ipOffset + 8
+	ipv6DstOffset = ipOffset + 24
+	udpOffsetV6   = ipOffset + ipv6HeaderSize
+	gtpOffsetV6   = udpOffsetV6 + udpHeaderSize
+)
+
 // Placeholder MAC addresses for end marker packets.
 // These are immutable values that will be replaced by the dataplane (BESS)
 // with actual MAC addresses based on the routing/forwarding table.
@@ -46,6 +59,11 @@ var (
 )
 
 func addEndMarker(farItem far, endMarkerList *[][]byte) {
+	if farItem.hasIPv6Tunnel() {
+		addEndMarkerIPv6(farItem, endMarkerList)
+		return
+	}
+
 	logger.PfcpLog.Infoln("adding end marker for farID:", farItem.farID)
 
 	packet := make([]byte, endMarkerSize)
@@ -85,6 +103,77 @@ func addEndMarker(farItem far, endMarkerList *[][]byte) {
 	*endMarkerList = append(*endMarkerList, packet)
 }
 
+func addEndMarkerIPv6(farItem far, endMarkerList *[][]byte) {
+	logger.PfcpLog.Infoln("adding IPv6 end marker for farID:", farItem.farID)
+
+	packet := make([]byte, endMarkerSizeV6)
+
+	// Ethernet header - placeholder MAC addresses will be replaced by dataplane
+	copy(packet[0:6], endMarkerDstMAC[:])
+	copy(packet[6:12], endMarkerSrcMAC[:])
+	binary.BigEndian.PutUint16(packet[ethTypeOffset:], 0x86DD) // IPv6
+
+	// IPv6 header
+	packet[ipOffset] = 0x60                                                      // Version 6, traffic class/flow label 0
+	binary.BigEndian.PutUint16(packet[ipOffset+4:], udpHeaderSize+gtpHeaderSize) // Payload length
+	packet[ipOffset+6] = 17                                                      // Next header: UDP
+	packet[ipOffset+7] = 64                                                      // Hop limit
+	copy(packet[ipv6SrcOffset:ipv6SrcOffset+net.IPv6len], farItem.tunnelIP6Src.To16())
+	copy(packet[ipv6DstOffset:ipv6DstOffset+net.IPv6len], farItem.tunnelIP6Dst.To16())
+
+	// UDP header
+	binary.BigEndian.PutUint16(packet[udpOffsetV6:], tunnelGTPUPort)                // Source port
+	binary.BigEndian.PutUint16(packet[udpOffsetV6+2:], tunnelGTPUPort)              // Destination port
+	binary.BigEndian.PutUint16(packet[udpOffsetV6+4:], udpHeaderSize+gtpHeaderSize) // UDP length
+
+	// GTP-U header (8 bytes - no optional fields for end marker)
+	packet[gtpOffsetV6] = 0x30                                             // Version 1, PT=1, no extension/sequence/N-PDU
+	packet[gtpOffsetV6+1] = gtpEndMarkerType                               // Message type: End Marker
+	binary.BigEndian.PutUint16(packet[gtpOffsetV6+2:], 0)                  // Message length: 0
+	binary.BigEndian.PutUint32(packet[gtpOffsetV6+4:], farItem.tunnelTEID) // TEID
+
+	// The UDP checksum is mandatory over IPv6.
+	binary.BigEndian.PutUint16(packet[udpOffsetV6+6:], calculateUDPv6Checksum(
+		packet[ipv6SrcOffset:ipv6SrcOffset+net.IPv6len],
+		packet[ipv6DstOffset:ipv6DstOffset+net.IPv6len],
+		packet[udpOffsetV6:]))
+
+	*endMarkerList = append(*endMarkerList, packet)
+}
+
+// calculateUDPv6Checksum calculates the UDP checksum of a datagram over IPv6,
+// including the pseudo-header (RFC 8200).
+func calculateUDPv6Checksum(src, dst, datagram []byte) uint16 {
+	var sum uint32
+
+	add := func(b []byte) {
+		for i := 0; i+1 < len(b); i += 2 {
+			sum += uint32(binary.BigEndian.Uint16(b[i:]))
+		}
+
+		if len(b)%2 == 1 {
+			sum += uint32(b[len(b)-1]) << 8
+		}
+	}
+
+	add(src)
+	add(dst)
+	sum += uint32(len(datagram)) + 17 // upper-layer length and next header
+
+	add(datagram)
+
+	for sum>>16 != 0 {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+
+	// A zero checksum is transmitted as all ones.
+	if checksum := ^uint16(sum); checksum != 0 {
+		return checksum
+	}
+
+	return 0xffff
+}
+
 // calculateIPv4Checksum calculates Internet Checksum for IPv4 header (RFC 1071).
 // This function handles fixed 20-byte IPv4 headers (no options) by summing all 10 16-bit words directly.
 func calculateIPv4Checksum(header []byte) uint16 {
@@ -123,6 +212,20 @@ func (s *PFCPSession) UpdateFAR(f *far, endMarkerList *[][]byte) error {
 	return ErrNotFound("FAR")
 }
 
+// mergeFARs replaces the FARs of fars with the changed ones of the same ID, and
+// appends the others.
+func mergeFARs(fars, changed []far) []far {
+	for _, f := range changed {
+		if i := slices.IndexFunc(fars, func(o far) bool { return o.farID == f.farID }); i >= 0 {
+			fars[i] = f
+		} else {
+			fars = append(fars, f)
+		}
+	}
+
+	return fars
+}
+
 // RemoveFAR removes far from existing list of FARs in the session.
 func (s *PFCPSession) RemoveFAR(id uint32) (*far, error) {
 	for idx, v := range s.fars {
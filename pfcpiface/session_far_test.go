@@ -312,6 +312,54 @@ func TestAddEndMarker_PacketStructure(t *testing.T) {
 	}
 }
 
+// TestAddEndMarker_IPv6 tests the end marker generated for GTP-U tunnels over IPv6
+func TestAddEndMarker_IPv6(t *testing.T) {
+	farItem := far{
+		farID:        30,
+		tunnelTEID:   0xCAFE,
+		tunnelIP6Src: net.ParseIP("2001:db8::1"),
+		tunnelIP6Dst: net.ParseIP("2001:db8:1::2"),
+	}
+
+	endMarkerList := make([][]byte, 0)
+	addEndMarker(farItem, &endMarkerList)
+
+	if len(endMarkerList) != 1 {
+		t.Fatalf("expected 1 end marker, got %d", len(endMarkerList))
+	}
+
+	packet := endMarkerList[0]
+	if len(packet) != endMarkerSizeV6 {
+		t.Fatalf("expected packet size %d, got %d", endMarkerSizeV6, len(packet))
+	}
+
+	if binary.BigEndian.Uint16(packet[ethTypeOffset:]) != 0x86DD {
+		t.Error("Ethernet type should be 0x86DD (IPv6)")
+	}
+
+	if packet[ipOffset]>>4 != 6 || packet[ipOffset+6] != 17 {
+		t.Errorf("unexpected IPv6 version or next header: 0x%02X, %d", packet[ipOffset], packet[ipOffset+6])
+	}
+
+	src := net.IP(packet[ipv6SrcOffset : ipv6SrcOffset+net.IPv6len])
+	dst := net.IP(packet[ipv6DstOffset : ipv6DstOffset+net.IPv6len])
+
+	if !src.Equal(farItem.tunnelIP6Src) || !dst.Equal(farItem.tunnelIP6Dst) {
+		t.Errorf("unexpected addresses %v -> %v", src, dst)
+	}
+
+	if teid := binary.BigEndian.Uint32(packet[gtpOffsetV6+4:]); teid != farItem.tunnelTEID {
+		t.Errorf("expected TEID 0x%X, got 0x%X", farItem.tunnelTEID, teid)
+	}
+
+	checksum := binary.BigEndian.Uint16(packet[udpOffsetV6+6:])
+	binary.BigEndian.PutUint16(packet[udpOffsetV6+6:], 0)
+
+	if want := calculateUDPv6Checksum(src, dst, packet[udpOffsetV6:]); checksum == 0 || checksum != want {
+		t.Errorf("expected UDP checksum 0x%04X, got 0x%04X", want, checksum)
+	}
+}
+
 // TestCalculateIPv4Checksum tests the IPv4 checksum calculation
 func TestCalculateIPv4Checksum(t *testing.T) {
 	// Create a test IPv4 header with checksum field zeroed
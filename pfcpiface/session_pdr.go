@@ -4,9 +4,10 @@
 package pfcpiface
 
 import (
+	"net"
+
 	"github.com/omec-project/upf-epc/logger"
 	"github.com/wmnsk/go-pfcp/ie"
-	"github.com/wmnsk/go-pfcp/message"
 )
 
 // Release allocated IPs.
@@ -17,35 +18,221 @@ func releaseAllocatedIPs(ippool *IPPool, session *PFCPSession) error {
 	for _, pdr := range session.pdrs {
 		if (pdr.allocIPFlag) && (pdr.srcIface == core) {
 			ueIP := int2ip(pdr.ueAddress)
-			logger.PfcpLog.Debugf("Releasing IP %s of session %d", ueIP.String(), session.localSEID)
+			logger.PfcpLog.Debugf("Releasing IP %s, IPv6 prefix %v of session %d", ueIP.String(), pdr.ueAddress6, session.localSEID)
 			return ippool.DeallocIP(session.localSEID)
 		}
 	}
 	return nil
 }
 
-func addPdrInfo(msg *message.SessionEstablishmentResponse, pdrs []pdr) {
-	logger.PfcpLog.Infoln("add PDRs with UPF alloc IPs to Establishment response")
+// Release the F-TEIDs allocated by the UPF.
+func releaseAllocatedTEIDs(gen *FTEIDGenerator, session *PFCPSession) {
+	if gen == nil {
+		return
+	}
+
+	for _, pdr := range session.pdrs {
+		if pdr.UPAllocateFteid {
+			gen.FreeID(pdr.tunnelTEID)
+		}
+	}
+}
+
+// Release the counter indexes allocated to the PDRs.
+func releaseAllocatedCounters(gen *FTEIDGenerator, session *PFCPSession) {
+	if gen == nil {
+		return
+	}
+
+	for _, pdr := range session.pdrs {
+		gen.FreeID(pdr.ctrID)
+	}
+}
+
+// releaseNewCounters releases the counter indexes of the staged PDRs that
+// aren't held by the previous rules of the session.
+func releaseNewCounters(gen *FTEIDGenerator, prev, staged PacketForwardingRules) {
+	if gen == nil {
+		return
+	}
+
+	held := make(map[uint32]bool, len(prev.pdrs))
+	for _, p := range prev.pdrs {
+		held[p.ctrID] = true
+	}
+
+	for _, p := range staged.pdrs {
+		if !held[p.ctrID] {
+			gen.FreeID(p.ctrID)
+		}
+	}
+}
+
+// releaseNewTEIDs releases the F-TEIDs allocated to the staged PDRs that
+// aren't held by the previous rules of the session.
+func releaseNewTEIDs(gen *FTEIDGenerator, prev, staged PacketForwardingRules) {
+	if gen == nil {
+		return
+	}
+
+	held := make(map[uint32]bool, len(prev.pdrs))
+	for _, p := range prev.pdrs {
+		if p.UPAllocateFteid {
+			held[p.tunnelTEID] = true
+		}
+	}
+
+	for _, p := range staged.pdrs {
+		if p.UPAllocateFteid && !held[p.tunnelTEID] {
+			gen.FreeID(p.tunnelTEID)
+		}
+	}
+}
+
+// releaseNewAllocations releases the UE IPs, F-TEIDs and counter indexes
+// allocated while staging rules that were never stored. The UE IPs are only
+// released if the session didn't hold any before staging.
+func (u *upf) releaseNewAllocations(seid uint64, prev, staged PacketForwardingRules, heldIP bool) {
+	if !heldIP && u.ippool.Allocated(seid) {
+		if err := u.ippool.DeallocIP(seid); err != nil {
+			logger.PfcpLog.Errorf("failed to release IP of session %v: %v", seid, err)
+		}
+	}
+
+	releaseNewTEIDs(u.fteidGenerator, prev, staged)
+	releaseNewCounters(u.counterIDs, prev, staged)
+}
+
+// assignPDRResources assigns the local F-TEID and the counter index of a
+// staged PDR. The F-TEID is released again if no counter index is left.
+func (u *upf) assignPDRResources(p *pdr, prev *pdr) error {
+	allocated, err := u.assignFTEID(p, prev)
+	if err != nil {
+		return err
+	}
+
+	if err = u.assignCounterID(p, prev); err != nil {
+		if allocated {
+			u.fteidGenerator.FreeID(p.tunnelTEID)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// assignFTEID allocates the local F-TEID of a PDR the CP function asked the
+// UPF to choose it for, and tells whether one was allocated. An updated PDR
+// keeps the F-TEID of its previous version.
+func (u *upf) assignFTEID(p *pdr, prev *pdr) (bool, error) {
+	if !p.UPAllocateFteid {
+		return false, nil
+	}
+
+	if prev != nil && prev.UPAllocateFteid {
+		p.tunnelIP4Dst, p.tunnelIP4DstMask = prev.tunnelIP4Dst, prev.tunnelIP4DstMask
+		p.tunnelIP6Dst = prev.tunnelIP6Dst
+		p.tunnelTEID, p.tunnelTEIDMask = prev.tunnelTEID, prev.tunnelTEIDMask
+
+		return false, nil
+	}
+
+	if err := p.setLocalFTEIDAddress(u.accessIP, u.accessIP6); err != nil {
+		return false, err
+	}
+
+	fteid, err := u.fteidGenerator.Allocate()
+	if err != nil {
+		return false, err
+	}
+
+	p.tunnelTEID = fteid
+	p.tunnelTEIDMask = 0xFFFFFFFF
+
+	return true, nil
+}
+
+// assignCounterID allocates a counter index from the UPF-wide pool to a PDR
+// with URRs, since URR IDs are only unique within a session. An updated PDR
+// keeps the index of its previous version.
+func (u *upf) assignCounterID(p *pdr, prev *pdr) error {
+	if prev != nil && prev.ctrID != 0 {
+		p.ctrID = prev.ctrID
+		return nil
+	}
+
+	if len(p.urrIDList) == 0 || u.counterIDs == nil {
+		return nil
+	}
+
+	id, err := u.counterIDs.Allocate()
+	if err != nil {
+		return ErrOperationFailedWithReason("counter index allocation", err.Error())
+	}
+
+	p.ctrID = id
+
+	return nil
+}
+
+func addPdrInfo(created *[]*ie.IE, pdrs []pdr) {
+	logger.PfcpLog.Infoln("add PDRs with UPF alloc IPs to the response")
 	logger.PfcpLog.Infoln("PDRs:", pdrs)
 	for _, pdr := range pdrs {
 		logger.PfcpLog.Infoln("pdrID:", pdr.pdrID)
 		if pdr.UPAllocateFteid {
 			logger.PfcpLog.Infoln("adding PDR with tunnel TEID:", pdr.tunnelTEID)
-			msg.CreatedPDR = append(msg.CreatedPDR,
+
+			var (
+				flags uint8
+				v4    net.IP
+			)
+
+			if pdr.tunnelIP4DstMask != 0 {
+				flags |= 0x01 // V4
+				v4 = int2ip(pdr.tunnelIP4Dst)
+			}
+
+			if pdr.tunnelIP6Dst != nil {
+				flags |= 0x02 // V6
+			}
+
+			*created = append(*created,
 				ie.NewCreatedPDR(
 					ie.NewPDRID(uint16(pdr.pdrID)),
-					ie.NewFTEID(0x01, pdr.tunnelTEID, int2ip(pdr.tunnelIP4Dst), nil, 0),
+					ie.NewFTEID(flags, pdr.tunnelTEID, v4, pdr.tunnelIP6Dst, 0),
 				))
 		}
 		if (pdr.allocIPFlag) && (pdr.srcIface == core) {
 			logger.PfcpLog.Debugln("pdrID:", pdr.pdrID)
-			var flags uint8 = 0x02
-			ueIP := int2ip(pdr.ueAddress)
-			logger.PfcpLog.Debugln("ueIP:", ueIP.String())
-			msg.CreatedPDR = append(msg.CreatedPDR,
+			var (
+				flags     uint8
+				ueIP      string
+				ueIP6     string
+				prefixLen uint8
+			)
+
+			if pdr.ueAddress != 0 {
+				flags |= 0x02 // V4
+				ueIP = int2ip(pdr.ueAddress).String()
+			}
+
+			if pdr.ueAddress6 != nil {
+				flags |= 0x01 // V6
+				ueIP6 = pdr.ueAddress6.IP.String()
+
+				if ones, _ := pdr.ueAddress6.Mask.Size(); ones != defaultIPv6PrefixLen {
+					flags |= 0x40 // IP6PL
+					prefixLen = uint8(ones)
+				}
+			}
+
+			logger.PfcpLog.Debugln("ueIP:", ueIP, "ueIPv6:", pdr.ueAddress6)
+			*created = append(*created,
 				ie.NewCreatedPDR(
 					ie.NewPDRID(uint16(pdr.pdrID)),
-					ie.NewUEIPAddress(flags, ueIP.String(), "", 0, 0),
+					ie.NewUEIPAddress(flags, ueIP, ueIP6, 0, prefixLen),
 				))
 		}
 	}
@@ -56,6 +243,17 @@ func (s *PFCPSession) CreatePDR(p pdr) {
 	s.pdrs = append(s.pdrs, p)
 }
 
+// getPDR returns a copy of the PDR with the given ID.
+func (s *PFCPSession) getPDR(id uint32) (pdr, error) {
+	for _, v := range s.pdrs {
+		if v.pdrID == id {
+			return v, nil
+		}
+	}
+
+	return pdr{}, ErrNotFound("PDR")
+}
+
 // UpdatePDR updates existing pdr in the session.
 func (s *PFCPSession) UpdatePDR(p pdr) error {
 	for idx, v := range s.pdrs {
@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+	"github.com/omec-project/upf-epc/pfcpiface/metrics"
+)
+
+// restoreSessions restores the sessions kept in the session store by the
+// previous pfcp-agent instance and re-programs them in the datapath. They are
+// held as lost associations until their CP functions set up new associations
+// and adopt them, or the restore grace period expires.
+func (node *PFCPNode) restoreSessions() {
+	sessions, err := node.upf.sessionDB.loadSessions()
+	if err != nil {
+		logger.PfcpLog.Errorf("failed to load sessions from session store: %v", err)
+		return
+	}
+
+	for nodeID, list := range sessions {
+		pConn := node.newRestoredPFCPConn(nodeID)
+		restored := 0
+
+		for _, s := range list {
+			if err := pConn.restoreSession(s); err != nil {
+				logger.PfcpLog.Errorf("failed to restore session %v of %v: %v", s.localSEID, nodeID, err)
+				continue
+			}
+
+			restored++
+		}
+
+		logger.PfcpLog.Infof("restored %d of %d sessions of %v", restored, len(list), nodeID)
+
+		node.orphanSessions(pConn, node.upf.restoreGrace)
+	}
+
+	// The datapath is not cleared when restoring, the rules left over by the
+	// sessions that were deleted before the restart, or could not be restored,
+	// are removed.
+	node.auditDatapath(true)
+}
+
+// newRestoredPFCPConn returns a PFCPConn without connection, holding the
+// restored sessions of the CP function nodeID.
+func (node *PFCPNode) newRestoredPFCPConn(nodeID string) *PFCPConn {
+	p := &PFCPConn{
+		ctx:            node.ctx,
+		rng:            rand.New(rand.NewSource(time.Now().UnixNano())), // #nosec G404
+		maxRetries:     100,
+		store:          NewBoltStore(node.upf.sessionDB, func() string { return nodeID }),
+		upf:            node.upf,
+		node:           node,
+		shutdown:       make(chan struct{}),
+		InstrumentPFCP: node.metrics,
+		hbReset:        make(chan struct{}, 100),
+	}
+
+	p.setRemoteNodeID(nodeID)
+
+	return p
+}
+
+// reserveSessionResources claims the UE IPs and F-TEIDs allocated to the
+// restored session.
+func reserveSessionResources(upf *upf, s *PFCPSession) {
+	for _, p := range s.pdrs {
+		if p.UPAllocateFteid && upf.fteidGenerator != nil {
+			// PDRs of a session may share the same F-TEID.
+			if !upf.fteidGenerator.Reserve(p.tunnelTEID) && !upf.fteidGenerator.IsAllocated(p.tunnelTEID) {
+				logger.PfcpLog.Warnf("failed to reserve F-TEID %v of session %v", p.tunnelTEID, s.localSEID)
+			}
+		}
+
+		if p.ctrID != 0 && upf.counterIDs != nil && !upf.counterIDs.Reserve(p.ctrID) {
+			logger.PfcpLog.Warnf("failed to reserve counter index %v of session %v", p.ctrID, s.localSEID)
+		}
+
+		if !p.allocIPFlag || p.srcIface != core || upf.ippool == nil {
+			continue
+		}
+
+		// The CP function may have provided one of the IP versions.
+		if p.ueAddress != 0 {
+			if err := upf.ippool.ReserveIP(s.localSEID, int2ip(p.ueAddress)); err != nil {
+				logger.PfcpLog.Debugf("UE IP of session %v not reserved: %v", s.localSEID, err)
+			}
+		}
+
+		if p.ueAddress6 != nil {
+			if err := upf.ippool.ReserveIPv6Prefix(s.localSEID, p.ueAddress6); err != nil {
+				logger.PfcpLog.Debugf("UE IPv6 prefix of session %v not reserved: %v", s.localSEID, err)
+			}
+		}
+	}
+}
+
+// restoreSession takes over the resources of the session and programs it in
+// the datapath.
+func (pConn *PFCPConn) restoreSession(s PFCPSession) error {
+	upf := pConn.upf
+
+	if !pConn.node.reserveSEID(s.localSEID, pConn) {
+		return ErrInvalidArgumentWithReason("F-SEID", s.localSEID, "SEID already in use")
+	}
+
+	reserveSessionResources(upf, &s)
+
+	s.metrics = metrics.NewSession(pConn.remoteNodeID())
+	pConn.SaveSessions(s.metrics)
+
+	now := time.Now()
+	for _, u := range s.urrs {
+		upf.usage.track(s.localSEID, u, now)
+	}
+
+	if err := pConn.store.PutSession(s); err != nil {
+		pConn.purgeSession(s)
+		return err
+	}
+
+	pConn.indexSession(s)
+
+	// The restored sessions are kept even if the capacities were reduced.
+	upf.trackCapacity(s.localSEID, s.PacketForwardingRules)
+
+	if err := upf.installRules(s.PacketForwardingRules); err != nil {
+		pConn.purgeSession(s)
+		return ErrOperationFailedWithReason("restore session", err.Error())
+	}
+
+	return nil
+}
+
+// keepSessionsForRestore returns true if the sessions of the association must
+// be kept in the session store and the datapath while pfcp-agent is stopping,
+// to be restored on the next start.
+func (pConn *PFCPConn) keepSessionsForRestore() bool {
+	return pConn.upf.restoreSessions && pConn.node != nil &&
+		pConn.node.ctx != nil && pConn.node.ctx.Err() != nil
+}
+
+// releaseOrphans stops the grace periods of the lost associations without
+// purging their sessions, which remain in the session store.
+func (node *PFCPNode) releaseOrphans() {
+	node.orphansMu.Lock()
+	orphans := node.orphans
+	node.orphans = nil
+	node.orphansMu.Unlock()
+
+	for _, o := range orphans {
+		o.timer.Stop()
+	}
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"slices"
+
+	"github.com/omec-project/upf-epc/logger"
+)
+
+// clone returns a copy of the rules that can be changed without altering p.
+// The rules of the sessions are staged on a copy until the datapath applies
+// them, since the sessions read from the store share their rules with it.
+func (p PacketForwardingRules) clone() PacketForwardingRules {
+	c := PacketForwardingRules{
+		pdrs: slices.Clone(p.pdrs),
+		fars: slices.Clone(p.fars),
+		qers: slices.Clone(p.qers),
+		urrs: slices.Clone(p.urrs),
+		bars: slices.Clone(p.bars),
+	}
+
+	for i := range c.pdrs {
+		c.pdrs[i].qerIDList = slices.Clone(c.pdrs[i].qerIDList)
+		c.pdrs[i].urrIDList = slices.Clone(c.pdrs[i].urrIDList)
+	}
+
+	return c
+}
+
+// matching returns the PDRs, FARs and QERs of p with the IDs of the rules in
+// any of sets.
+func (p PacketForwardingRules) matching(sets ...PacketForwardingRules) PacketForwardingRules {
+	var m PacketForwardingRules
+
+	for _, r := range p.pdrs {
+		if slices.ContainsFunc(sets, func(s PacketForwardingRules) bool {
+			return slices.ContainsFunc(s.pdrs, func(o pdr) bool { return o.pdrID == r.pdrID })
+		}) {
+			m.pdrs = append(m.pdrs, r)
+		}
+	}
+
+	for _, r := range p.fars {
+		if slices.ContainsFunc(sets, func(s PacketForwardingRules) bool {
+			return slices.ContainsFunc(s.fars, func(o far) bool { return o.farID == r.farID })
+		}) {
+			m.fars = append(m.fars, r)
+		}
+	}
+
+	for _, r := range p.qers {
+		if slices.ContainsFunc(sets, func(s PacketForwardingRules) bool {
+			return slices.ContainsFunc(s.qers, func(o qer) bool { return o.qerID == r.qerID })
+		}) {
+			m.qers = append(m.qers, r)
+		}
+	}
+
+	return m
+}
+
+// rollbackRules brings the datapath back to the rules prev of a session after
+// it failed to apply the installed and removed rules, any of which may have
+// been applied. The installed rules are removed, then the previous versions
+// of the installed and removed rules are re-installed.
+func (pConn *PFCPConn) rollbackRules(fseID uint64, prev, installed, removed PacketForwardingRules) {
+	upf := pConn.upf
+
+	if err := upf.removeRules(installed); err != nil {
+		logger.PfcpLog.Errorf("rollback: failed to remove the new rules of session %v: %v", fseID, err)
+	}
+
+	restored := prev.matching(installed, removed)
+	if err := upf.installRules(restored); err != nil {
+		logger.PfcpLog.Errorf("rollback: failed to restore the rules of session %v: %v", fseID, err)
+		return
+	}
+
+	logger.PfcpLog.Infof("rolled back the rules of session %v: %v", fseID, restored)
+}
@@ -0,0 +1,353 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/wmnsk/go-pfcp/ie"
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+// ruleDP holds the PDRs and FARs written to it, and fails the next write of
+// the FAR failFAR.
+type ruleDP struct {
+	fakeDP
+	mu      sync.Mutex
+	rules   map[string]string
+	failFAR uint32
+}
+
+func (d *ruleDP) put(key string, rule fmt.Stringer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.rules[key] = rule.String()
+}
+
+func (d *ruleDP) delete(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.rules, key)
+}
+
+func (d *ruleDP) fail(f far) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if f.farID != d.failFAR {
+		return nil
+	}
+
+	d.failFAR = 0
+
+	return &moduleError{module: "farLookup", code: int32(syscall.ENOSPC)}
+}
+
+func pdrKey(p pdr) string { return fmt.Sprintf("%v/PDR %v", p.fseID, p.pdrID) }
+func farKey(f far) string { return fmt.Sprintf("%v/FAR %v", f.fseID, f.farID) }
+
+func (d *ruleDP) CreatePDR(ctx context.Context, p pdr) error      { d.put(pdrKey(p), p); return nil }
+func (d *ruleDP) UpdatePDR(ctx context.Context, old, p pdr) error { d.put(pdrKey(p), p); return nil }
+func (d *ruleDP) DeletePDR(ctx context.Context, p pdr) error      { d.delete(pdrKey(p)); return nil }
+
+func (d *ruleDP) CreateFAR(ctx context.Context, f far) error { return d.UpdateFAR(ctx, f) }
+
+func (d *ruleDP) UpdateFAR(ctx context.Context, f far) error {
+	if err := d.fail(f); err != nil {
+		return err
+	}
+
+	d.put(farKey(f), f)
+
+	return nil
+}
+
+func (d *ruleDP) DeleteFAR(ctx context.Context, f far) error {
+	if err := d.fail(f); err != nil {
+		return err
+	}
+
+	d.delete(farKey(f))
+
+	return nil
+}
+
+func TestSessionRollback(t *testing.T) {
+	dp := &ruleDP{rules: make(map[string]string)}
+	node := &PFCPNode{upf: &upf{datapath: dp, usage: newUsageTracker()}}
+	pConn := newTestPFCPConn(node, 1)
+	pConn.nodeID.remote = "smf"
+	pConn.nodeID.localIE = ie.NewNodeID("", "", "upf")
+
+	session, ok := pConn.NewPFCPSession(1)
+	if !ok {
+		t.Fatal("failed to allocate session")
+	}
+
+	seid := session.localSEID
+
+	for i := uint32(1); i <= 2; i++ {
+		session.CreatePDR(pdr{pdrID: i, fseID: seid, srcIface: access, farID: i})
+		session.CreateFAR(far{farID: i, fseID: seid, applyAction: ActionForward, dstIntf: 1})
+	}
+
+	if err := pConn.store.PutSession(session); err != nil {
+		t.Fatalf("failed to store session: %v", err)
+	}
+
+	if err := writeRules(dp, upfMsgTypeAdd, PacketForwardingRules{}, session.PacketForwardingRules); err != nil {
+		t.Fatalf("failed to install session: %v", err)
+	}
+
+	installed := maps.Clone(dp.rules)
+	stored := session.String()
+
+	unchanged := func(t *testing.T) {
+		t.Helper()
+
+		s, ok := pConn.store.GetSession(seid)
+		if !ok {
+			t.Fatal("session removed from store")
+		}
+
+		if s.String() != stored {
+			t.Errorf("stored session changed to %v, expected %v", s, stored)
+		}
+
+		if !maps.Equal(dp.rules, installed) {
+			t.Errorf("datapath rules changed to %v, expected %v", dp.rules, installed)
+		}
+	}
+
+	dropFAR := func(id uint32) *ie.IE {
+		return ie.NewUpdateFAR(ie.NewFARID(id), ie.NewApplyAction(ActionDrop))
+	}
+
+	for _, tc := range []struct {
+		name    string
+		failFAR uint32
+		ies     []*ie.IE
+	}{
+		{
+			name:    "failed creation",
+			failFAR: 3,
+			ies: []*ie.IE{
+				ie.NewCreateFAR(ie.NewFARID(3), ie.NewApplyAction(ActionDrop)),
+				dropFAR(1),
+			},
+		},
+		{
+			name:    "failed removal",
+			failFAR: 2,
+			ies:     []*ie.IE{dropFAR(1), ie.NewRemovePDR(ie.NewPDRID(2)), ie.NewRemoveFAR(ie.NewFARID(2))},
+		},
+		{
+			name: "unknown rule",
+			ies:  []*ie.IE{dropFAR(1), ie.NewRemoveFAR(ie.NewFARID(9))},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dp.failFAR = tc.failFAR
+
+			req := message.NewSessionModificationRequest(0, 0, seid, 1, 0, tc.ies...)
+
+			if _, err := pConn.handleSessionModificationRequest(req); err == nil {
+				t.Fatal("expected modification to fail")
+			}
+
+			unchanged(t)
+		})
+	}
+
+	t.Run("failed deletion", func(t *testing.T) {
+		dp.failFAR = 2
+
+		req := message.NewSessionDeletionRequest(0, 0, seid, 2, 0)
+
+		resp, err := pConn.handleSessionDeletionRequest(req)
+		if err == nil {
+			t.Fatal("expected deletion to fail")
+		}
+
+		if cause, _ := resp.(*message.SessionDeletionResponse).Cause.Cause(); cause != ie.CauseNoResourcesAvailable {
+			t.Errorf("expected cause %d, got %d", ie.CauseNoResourcesAvailable, cause)
+		}
+
+		unchanged(t)
+	})
+
+	t.Run("failed establishment", func(t *testing.T) {
+		dp.failFAR = 5
+
+		req := message.NewSessionEstablishmentRequest(0, 0, 0, 3, 0,
+			ie.NewNodeID("", "", "smf"),
+			ie.NewFSEID(2, net.ParseIP("10.0.0.1"), nil),
+			ie.NewCreateFAR(ie.NewFARID(4), ie.NewApplyAction(ActionDrop)),
+			ie.NewCreateFAR(ie.NewFARID(5), ie.NewApplyAction(ActionDrop)),
+		)
+
+		if _, err := pConn.handleSessionEstablishmentRequest(req); err == nil {
+			t.Fatal("expected establishment to fail")
+		}
+
+		if n := len(pConn.store.GetAllSessions()); n != 1 {
+			t.Errorf("expected 1 stored session, got %d", n)
+		}
+
+		unchanged(t)
+	})
+}
+
+func TestFailedRequestsReleaseAllocations(t *testing.T) {
+	pool, err := NewIPPool("10.250.0.0/29")
+	if err != nil {
+		t.Fatalf("failed to create IP pool: %v", err)
+	}
+
+	dp := &ruleDP{rules: make(map[string]string)}
+	upf := &upf{
+		datapath:       dp,
+		usage:          newUsageTracker(),
+		ippool:         pool,
+		fteidGenerator: NewFTEIDGenerator(),
+		accessIP:       net.ParseIP("198.18.0.1"),
+	}
+	pConn := newTestPFCPConn(&PFCPNode{upf: upf}, 1)
+	pConn.Conn = listenUDP(t)
+	pConn.nodeID.remote = "smf"
+	pConn.nodeID.localIE = ie.NewNodeID("", "", "upf")
+
+	uplinkPDR := func(id uint16) *ie.IE {
+		return ie.NewCreatePDR(ie.NewPDRID(id), ie.NewPrecedence(100),
+			ie.NewPDI(ie.NewSourceInterface(ie.SrcInterfaceAccess), ie.NewFTEID(0x05, 0, nil, nil, 0)),
+			ie.NewFARID(1))
+	}
+
+	downlinkPDI := ie.NewPDI(ie.NewSourceInterface(ie.SrcInterfaceCore), ie.NewUEIPAddress(0x12, "", "", 0, 0))
+
+	downlinkPDR := func(id uint16) *ie.IE {
+		return ie.NewCreatePDR(ie.NewPDRID(id), ie.NewPrecedence(100), downlinkPDI, ie.NewFARID(1))
+	}
+
+	// The UE IP is allocated before the missing FAR ID is found.
+	brokenPDR := ie.NewCreatePDR(ie.NewPDRID(9), ie.NewPrecedence(100), downlinkPDI)
+
+	allocations := func(t *testing.T, ips, teids int) {
+		t.Helper()
+
+		pool.mu.Lock()
+		gotIPs := len(pool.inventory)
+		pool.mu.Unlock()
+
+		upf.fteidGenerator.lock.Lock()
+		gotTEIDs := len(upf.fteidGenerator.usedMap)
+		upf.fteidGenerator.lock.Unlock()
+
+		if gotIPs != ips || gotTEIDs != teids {
+			t.Errorf("expected %d UE IPs and %d F-TEIDs allocated, got %d and %d", ips, teids, gotIPs, gotTEIDs)
+		}
+	}
+
+	establish := func(pdrs ...*ie.IE) (*message.SessionEstablishmentResponse, error) {
+		ies := append([]*ie.IE{
+			ie.NewNodeID("", "", "smf"),
+			ie.NewFSEID(1, net.ParseIP("10.0.0.1"), nil),
+			ie.NewCreateFAR(ie.NewFARID(1), ie.NewApplyAction(ActionDrop)),
+		}, pdrs...)
+
+		resp, err := pConn.handleSessionEstablishmentRequest(message.NewSessionEstablishmentRequest(0, 0, 0, 1, 0, ies...))
+
+		return resp.(*message.SessionEstablishmentResponse), err
+	}
+
+	t.Run("failed establishment parsing", func(t *testing.T) {
+		if _, err := establish(uplinkPDR(1), downlinkPDR(2), brokenPDR); err == nil {
+			t.Fatal("expected establishment to fail")
+		}
+
+		allocations(t, 0, 0)
+	})
+
+	t.Run("failed establishment write", func(t *testing.T) {
+		dp.failFAR = 1
+
+		if _, err := establish(uplinkPDR(1), downlinkPDR(2)); err == nil {
+			t.Fatal("expected establishment to fail")
+		}
+
+		allocations(t, 0, 0)
+	})
+
+	resp, err := establish(uplinkPDR(1))
+	if err != nil {
+		t.Fatalf("failed to establish session: %v", err)
+	}
+
+	fseid, err := resp.UPFSEID.FSEID()
+	if err != nil {
+		t.Fatalf("failed to read UP F-SEID: %v", err)
+	}
+
+	allocations(t, 0, 1)
+
+	modify := func(ies ...*ie.IE) (*message.SessionModificationResponse, error) {
+		resp, err := pConn.handleSessionModificationRequest(message.NewSessionModificationRequest(0, 0, fseid.SEID, 2, 0, ies...))
+
+		return resp.(*message.SessionModificationResponse), err
+	}
+
+	t.Run("failed modification parsing", func(t *testing.T) {
+		if _, err := modify(uplinkPDR(2), downlinkPDR(3), brokenPDR); err == nil {
+			t.Fatal("expected modification to fail")
+		}
+
+		allocations(t, 0, 1)
+	})
+
+	t.Run("aborted modification", func(t *testing.T) {
+		dp.failFAR = 2
+
+		if _, err := modify(uplinkPDR(2), downlinkPDR(3),
+			ie.NewCreateFAR(ie.NewFARID(2), ie.NewApplyAction(ActionDrop))); err == nil {
+			t.Fatal("expected modification to fail")
+		}
+
+		allocations(t, 0, 1)
+	})
+
+	t.Run("modification", func(t *testing.T) {
+		resp, err := modify(uplinkPDR(2), downlinkPDR(3))
+		if err != nil {
+			t.Fatalf("failed to modify session: %v", err)
+		}
+
+		if n := len(resp.CreatedPDR); n != 2 {
+			t.Errorf("expected 2 Created PDR IEs, got %d", n)
+		}
+
+		allocations(t, 1, 2)
+	})
+
+	t.Run("session lost by the SMF", func(t *testing.T) {
+		err := pConn.handleSessionReportResponse(message.NewSessionReportResponse(0, 0, fseid.SEID, 3, 0,
+			ie.NewCause(ie.CauseSessionContextNotFound)))
+		if err != nil {
+			t.Fatalf("failed to handle Session Report Response: %v", err)
+		}
+
+		if _, ok := pConn.store.GetSession(fseid.SEID); ok {
+			t.Error("expected session to be deleted")
+		}
+
+		allocations(t, 0, 0)
+	})
+}
@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"slices"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+	"github.com/wmnsk/go-pfcp/ie"
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+// CreateURR appends urr to existing list of URRs in the session.
+func (s *PFCPSession) CreateURR(u urr) {
+	s.urrs = append(s.urrs, u)
+}
+
+// getURR returns a copy of the URR with the given ID.
+func (s *PFCPSession) getURR(id uint32) (urr, error) {
+	for _, v := range s.urrs {
+		if v.urrID == id {
+			return v, nil
+		}
+	}
+
+	return urr{}, ErrNotFound("URR")
+}
+
+// UpdateURR updates existing urr in the session.
+func (s *PFCPSession) UpdateURR(u urr) error {
+	for idx, v := range s.urrs {
+		if v.urrID == u.urrID {
+			s.urrs[idx] = u
+			return nil
+		}
+	}
+
+	return ErrNotFound("URR")
+}
+
+// RemoveURR removes urr from existing list of URRs in the session.
+func (s *PFCPSession) RemoveURR(id uint32) (*urr, error) {
+	for idx, v := range s.urrs {
+		if v.urrID == id {
+			s.urrs = append(s.urrs[:idx], s.urrs[idx+1:]...)
+			return &v, nil
+		}
+	}
+
+	return nil, ErrNotFound("URR")
+}
+
+// refreshUsage accounts the latest datapath counters to the URRs of the
+// session, so that reports sent in responses are up to date.
+func (pConn *PFCPConn) refreshUsage(session *PFCPSession) {
+	if len(session.urrs) == 0 || !pConn.upf.enableFlowMeasure {
+		return
+	}
+
+	counters, err := pConn.upf.UsageCounters()
+	if err != nil {
+		logger.PfcpLog.Warnln("failed to read usage counters:", err)
+		return
+	}
+
+	pConn.upf.usage.accumulate(session, counters)
+}
+
+// usageReportsNow builds reports with the given trigger for the listed URRs
+// of the session. All URRs are reported if ids is nil.
+func (pConn *PFCPConn) usageReportsNow(session *PFCPSession, ids []uint32, trigger reportingTriggers) []usageReport {
+	now := time.Now()
+	reports := make([]usageReport, 0, len(session.urrs))
+
+	for _, u := range session.urrs {
+		if ids != nil && !contains(ids, u.urrID) {
+			continue
+		}
+
+		if r, ok := pConn.upf.usage.reportNow(session.localSEID, u, trigger, now); ok {
+			reports = append(reports, r)
+		}
+	}
+
+	return reports
+}
+
+// reportUsage accounts the datapath counters to the sessions of this
+// association and sends a Session Report Request for every session with
+// usage reports due.
+func (pConn *PFCPConn) reportUsage(counters map[pdrCounterKey]pdrCounters, now time.Time) {
+	for _, session := range pConn.store.GetAllSessions() {
+		pConn.upf.usage.accumulate(&session, counters)
+
+		if len(session.urrs) == 0 {
+			continue
+		}
+
+		reports, exhausted := pConn.upf.usage.dueReports(&session, now)
+		if exhausted {
+			pConn.enforceQuotas(session.localSEID)
+		}
+
+		if len(reports) == 0 {
+			continue
+		}
+
+		pConn.sendUsageReports(session, reports)
+	}
+}
+
+// applyQuotaBlocks makes the FARs of the PDRs using a URR with an exhausted
+// quota drop packets, and restores the apply action of the CP function on the
+// others. It returns the FARs changed.
+func (s *PFCPSession) applyQuotaBlocks(exhausted func(urrID uint32) bool) []far {
+	var changed []far
+
+	for i := range s.fars {
+		f := &s.fars[i]
+
+		blocked := slices.ContainsFunc(s.pdrs, func(p pdr) bool {
+			return p.farID == f.farID && slices.ContainsFunc(p.urrIDList, exhausted)
+		})
+
+		switch {
+		case blocked && f.quotaAction == 0:
+			f.quotaAction = f.applyAction
+			f.applyAction = ActionDrop
+		case !blocked && f.quotaAction != 0:
+			f.applyAction = f.quotaAction
+			f.quotaAction = 0
+		default:
+			continue
+		}
+
+		changed = append(changed, *f)
+	}
+
+	return changed
+}
+
+// enforceQuotas stops forwarding the traffic of the session whose quota is
+// exhausted, until the CP function provides a new quota.
+func (pConn *PFCPConn) enforceQuotas(seid uint64) {
+	upf := pConn.upf
+
+	upf.sessionMu.Lock()
+	defer upf.sessionMu.Unlock()
+
+	session, ok := pConn.store.GetSession(seid)
+	if !ok {
+		return
+	}
+
+	prev := session.PacketForwardingRules
+	session.PacketForwardingRules = prev.clone()
+
+	blocked := PacketForwardingRules{
+		fars: session.applyQuotaBlocks(func(urrID uint32) bool {
+			return upf.usage.exhausted(seid, urrID)
+		}),
+	}
+
+	if len(blocked.fars) == 0 {
+		return
+	}
+
+	logger.PfcpLog.Infof("quota of session %v exhausted, dropping the traffic of FARs %v", seid, blocked.fars)
+
+	if err := upf.updateRules(prev, blocked); err != nil {
+		logger.PfcpLog.Errorf("failed to enforce the quota of session %v: %v", seid, err)
+		pConn.rollbackRules(seid, prev, blocked, PacketForwardingRules{})
+
+		return
+	}
+
+	if err := pConn.store.PutSession(session); err != nil {
+		logger.PfcpLog.Errorf("failed to store session %v: %v", seid, err)
+		pConn.rollbackRules(seid, prev, blocked, PacketForwardingRules{})
+	}
+}
+
+func (pConn *PFCPConn) sendUsageReports(session PFCPSession, reports []usageReport) {
+	srreq := message.NewSessionReportRequest(0, /* MO?? <-- what's this */
+		0,                            /* FO <-- what's this? */
+		0,                            /* seid */
+		pConn.getSeqNum(),            /* seq # */
+		0,                            /* priority */
+		ie.NewReportType(0, 0, 1, 0), /*upir, erir, usar, dldr int*/
+	)
+	srreq.Header.SEID = session.remoteSEID
+
+	for _, r := range reports {
+		srreq.UsageReport = append(srreq.UsageReport, ie.NewUsageReportWithinSessionReportRequest(r.ies()...))
+	}
+
+	logger.PfcpLog.With("F-SEID", session.localSEID, "reports", len(reports)).Debugln("sending Usage Report")
+
+	pConn.SendPFCPMsg(srreq)
+}
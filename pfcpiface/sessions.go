@@ -5,27 +5,45 @@ package pfcpiface
 
 import (
 	"fmt"
+	"net"
 
 	"github.com/omec-project/upf-epc/logger"
 	"github.com/omec-project/upf-epc/pfcpiface/metrics"
+	"github.com/wmnsk/go-pfcp/ie"
 )
 
 type PacketForwardingRules struct {
 	pdrs []pdr
 	fars []far
 	qers []qer
+	urrs []urr
+	bars []bar
 }
 
 // PFCPSession implements one PFCP session.
 type PFCPSession struct {
 	localSEID  uint64
 	remoteSEID uint64
-	metrics    *metrics.Session
+	// remoteIP is the IPv4 or IPv6 address of the CP F-SEID.
+	remoteIP net.IP
+	metrics  *metrics.Session
 	PacketForwardingRules
 }
 
 func (p PacketForwardingRules) String() string {
-	return fmt.Sprintf("PDRs=%v, FARs=%v, QERs=%v", p.pdrs, p.fars, p.qers)
+	return fmt.Sprintf("PDRs=%v, FARs=%v, QERs=%v, URRs=%v, BARs=%v", p.pdrs, p.fars, p.qers, p.urrs, p.bars)
+}
+
+// fseidAddress returns the address of the F-SEID, the IPv4 one if it has both.
+func fseidAddress(fseid *ie.FSEIDFields) (net.IP, error) {
+	switch {
+	case fseid.HasIPv4() && fseid.IPv4Address.To4() != nil:
+		return fseid.IPv4Address.To4(), nil
+	case fseid.HasIPv6() && len(fseid.IPv6Address) == net.IPv6len:
+		return fseid.IPv6Address, nil
+	default:
+		return nil, ErrInvalidArgumentWithReason("F-SEID", fseid.Flags, "no IPv4 or IPv6 address")
+	}
 }
 
 // NewPFCPSession allocates an session with ID.
@@ -37,6 +55,11 @@ func (pConn *PFCPConn) NewPFCPSession(rseid uint64) (PFCPSession, bool) {
 			continue
 		}
 
+		// SEIDs must be unique across all the associations of the node.
+		if pConn.node != nil && !pConn.node.reserveSEID(lseid, pConn) {
+			continue
+		}
+
 		s := PFCPSession{
 			localSEID:  lseid,
 			remoteSEID: rseid,
@@ -44,9 +67,10 @@ func (pConn *PFCPConn) NewPFCPSession(rseid uint64) (PFCPSession, bool) {
 				pdrs: make([]pdr, 0, MaxItems),
 				fars: make([]far, 0, MaxItems),
 				qers: make([]qer, 0, MaxItems),
+				urrs: make([]urr, 0, MaxItems),
 			},
 		}
-		s.metrics = metrics.NewSession(pConn.nodeID.remote)
+		s.metrics = metrics.NewSession(pConn.remoteNodeID())
 
 		// Metrics update
 		pConn.SaveSessions(s.metrics)
@@ -57,13 +81,63 @@ func (pConn *PFCPConn) NewPFCPSession(rseid uint64) (PFCPSession, bool) {
 	return PFCPSession{}, false
 }
 
+// isBuffering returns true if any FAR of the session buffers downlink packets.
+func (s *PFCPSession) isBuffering() bool {
+	for _, f := range s.fars {
+		if f.Buffers() {
+			return true
+		}
+	}
+
+	return false
+}
+
 // RemoveSession removes session using lseid.
 func (pConn *PFCPConn) RemoveSession(session PFCPSession) {
 	// Metrics update
 	session.metrics.Delete()
 	pConn.SaveSessions(session.metrics)
 
+	pConn.upf.usage.removeSession(&session)
+	pConn.upf.releaseCapacity(session.localSEID)
+	pConn.buffering.reset(session.localSEID)
+	pConn.restart.confirm(session.localSEID)
+
+	if pConn.node != nil {
+		pConn.node.releaseSEID(session.localSEID)
+		pConn.node.remoteFTEIDs.remove(session.localSEID)
+	}
+
 	if err := pConn.store.DeleteSession(session.localSEID); err != nil {
 		logger.PfcpLog.Errorf("failed to delete PFCP session from store: %v", err)
 	}
 }
+
+// purgeSession deletes the session from the datapath and releases all its
+// resources, without involving the CP function.
+func (pConn *PFCPConn) purgeSession(session PFCPSession) {
+	upf := pConn.upf
+
+	if err := upf.removeRules(session.PacketForwardingRules); err != nil {
+		logger.PfcpLog.Errorf("failed to delete session %v from datapath: %v", session.localSEID, err)
+	}
+
+	if err := releaseAllocatedIPs(upf.ippool, &session); err != nil {
+		logger.PfcpLog.Errorf("failed to release IP of session %v: %v", session.localSEID, err)
+	}
+
+	releaseAllocatedTEIDs(upf.fteidGenerator, &session)
+	releaseAllocatedCounters(upf.counterIDs, &session)
+
+	pConn.RemoveSession(session)
+}
+
+// purgeAllSessions purges all the sessions of the PFCP association.
+func (pConn *PFCPConn) purgeAllSessions() {
+	pConn.upf.sessionMu.Lock()
+	defer pConn.upf.sessionMu.Unlock()
+
+	for _, sess := range pConn.store.GetAllSessions() {
+		pConn.purgeSession(sess)
+	}
+}
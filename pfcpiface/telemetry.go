@@ -127,6 +127,21 @@ type PfcpNodeCollector struct {
 	sessionRxPackets      *prometheus.Desc
 	sessionDroppedPackets *prometheus.Desc
 	sessionTxBytes        *prometheus.Desc
+
+	resyncInProgress *prometheus.Desc
+	resyncs          *prometheus.Desc
+	resyncSessions   *prometheus.Desc
+	resyncDuration   *prometheus.Desc
+
+	auditRules    *prometheus.Desc
+	audits        *prometheus.Desc
+	auditRepaired *prometheus.Desc
+
+	capacitySessions    *prometheus.Desc
+	capacityMaxSessions *prometheus.Desc
+	capacityEntries     *prometheus.Desc
+	capacityLimit       *prometheus.Desc
+	capacityRejected    *prometheus.Desc
 }
 
 func NewPFCPNodeCollector(node *PFCPNode) *PfcpNodeCollector {
@@ -156,6 +171,54 @@ func NewPFCPNodeCollector(node *PFCPNode) *PfcpNodeCollector {
 			"Shows the total number of bytes for a given session in UPF",
 			[]string{"fseid", "pdr", "ue_ip"}, nil,
 		),
+		resyncInProgress: prometheus.NewDesc(prometheus.BuildFQName("upf", "datapath_resync", "in_progress"),
+			"Shows whether the sessions are being replayed to the datapath",
+			nil, nil,
+		),
+		resyncs: prometheus.NewDesc(prometheus.BuildFQName("upf", "datapath_resync", "total"),
+			"Shows the number of times the sessions were replayed to the datapath",
+			nil, nil,
+		),
+		resyncSessions: prometheus.NewDesc(prometheus.BuildFQName("upf", "datapath_resync", "sessions"),
+			"Shows the number of sessions of the current or last datapath resync, by state",
+			[]string{"state"}, nil,
+		),
+		resyncDuration: prometheus.NewDesc(prometheus.BuildFQName("upf", "datapath_resync", "duration_seconds"),
+			"Shows the duration of the last datapath resync",
+			nil, nil,
+		),
+		auditRules: prometheus.NewDesc(prometheus.BuildFQName("upf", "datapath_audit", "rules"),
+			"Shows the number of rules of a datapath table found by the last audit, by state",
+			[]string{"table", "state"}, nil,
+		),
+		audits: prometheus.NewDesc(prometheus.BuildFQName("upf", "datapath_audit", "total"),
+			"Shows the number of audits of the datapath rules",
+			nil, nil,
+		),
+		auditRepaired: prometheus.NewDesc(prometheus.BuildFQName("upf", "datapath_audit", "repaired_total"),
+			"Shows the number of datapath rules repaired by the audits",
+			nil, nil,
+		),
+		capacitySessions: prometheus.NewDesc(prometheus.BuildFQName("upf", "capacity", "sessions"),
+			"Shows the number of sessions accounted against the maximum number of sessions",
+			nil, nil,
+		),
+		capacityMaxSessions: prometheus.NewDesc(prometheus.BuildFQName("upf", "capacity", "max_sessions"),
+			"Shows the maximum number of sessions, 0 if unlimited",
+			nil, nil,
+		),
+		capacityEntries: prometheus.NewDesc(prometheus.BuildFQName("upf", "capacity", "table_entries"),
+			"Shows the number of entries the sessions take in a datapath table",
+			[]string{"table"}, nil,
+		),
+		capacityLimit: prometheus.NewDesc(prometheus.BuildFQName("upf", "capacity", "table_limit"),
+			"Shows the capacity of a datapath table, 0 if unlimited",
+			[]string{"table"}, nil,
+		),
+		capacityRejected: prometheus.NewDesc(prometheus.BuildFQName("upf", "capacity", "rejected_total"),
+			"Shows the number of session establishments and modifications rejected for lack of capacity",
+			nil, nil,
+		),
 	}
 }
 
@@ -164,6 +227,10 @@ func (col PfcpNodeCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (col PfcpNodeCollector) Collect(ch chan<- prometheus.Metric) {
+	col.datapathResync(ch)
+	col.datapathAudit(ch)
+	col.capacity(ch)
+
 	if col.node.upf.enableFlowMeasure {
 		err := col.node.upf.SessionStats(&col, ch)
 		if err != nil {
@@ -173,6 +240,68 @@ func (col PfcpNodeCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 }
 
+func (col PfcpNodeCollector) datapathResync(ch chan<- prometheus.Metric) {
+	st := col.node.resync.status()
+
+	inProgress := 0.0
+	if st.inProgress {
+		inProgress = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(col.resyncInProgress, prometheus.GaugeValue, inProgress)
+	ch <- prometheus.MustNewConstMetric(col.resyncs, prometheus.CounterValue, float64(st.resyncs))
+	ch <- prometheus.MustNewConstMetric(col.resyncSessions, prometheus.GaugeValue, float64(st.total), "total")
+	ch <- prometheus.MustNewConstMetric(col.resyncSessions, prometheus.GaugeValue, float64(st.replayed), "replayed")
+	ch <- prometheus.MustNewConstMetric(col.resyncSessions, prometheus.GaugeValue, float64(st.failed), "failed")
+	ch <- prometheus.MustNewConstMetric(col.resyncDuration, prometheus.GaugeValue, st.duration.Seconds())
+}
+
+func (col PfcpNodeCollector) datapathAudit(ch chan<- prometheus.Metric) {
+	report, audits, repaired := col.node.audit.lastReport()
+
+	ch <- prometheus.MustNewConstMetric(col.audits, prometheus.CounterValue, float64(audits))
+	ch <- prometheus.MustNewConstMetric(col.auditRepaired, prometheus.CounterValue, float64(repaired))
+
+	if report == nil {
+		return
+	}
+
+	for _, t := range report.Tables {
+		states := map[string]int{
+			"expected":  t.Expected,
+			"installed": t.Installed,
+		}
+
+		// Tables which cannot be read back are not diffed.
+		if t.Error == "" {
+			states["missing"] = t.Missing
+			states["orphaned"] = t.Orphaned
+			states["mismatched"] = t.Mismatched
+		}
+
+		for state, n := range states {
+			ch <- prometheus.MustNewConstMetric(col.auditRules, prometheus.GaugeValue, float64(n), t.Table, state)
+		}
+	}
+}
+
+func (col PfcpNodeCollector) capacity(ch chan<- prometheus.Metric) {
+	if col.node.upf.capacity == nil {
+		return
+	}
+
+	u := col.node.upf.capacity.usage()
+
+	ch <- prometheus.MustNewConstMetric(col.capacitySessions, prometheus.GaugeValue, float64(u.Sessions))
+	ch <- prometheus.MustNewConstMetric(col.capacityMaxSessions, prometheus.GaugeValue, float64(u.MaxSessions))
+	ch <- prometheus.MustNewConstMetric(col.capacityRejected, prometheus.CounterValue, float64(u.Rejected))
+
+	for _, t := range u.Tables {
+		ch <- prometheus.MustNewConstMetric(col.capacityEntries, prometheus.GaugeValue, float64(t.Used), t.Table)
+		ch <- prometheus.MustNewConstMetric(col.capacityLimit, prometheus.GaugeValue, float64(t.Limit), t.Table)
+	}
+}
+
 func setupProm(mux *http.ServeMux, upf *upf, node *PFCPNode) (*upfCollector, *PfcpNodeCollector, error) {
 	uc := newUpfCollector(upf)
 	if err := prometheus.Register(uc); err != nil {
@@ -0,0 +1,1181 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/omec-project/upf-epc/logger"
+	p4 "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wmnsk/go-pfcp/ie"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Tables, actions, meters and counters of the UP4 pipeline.
+const (
+	p4Interfaces           = "PreQosPipe.interfaces"
+	p4SessionsUplink       = "PreQosPipe.sessions_uplink"
+	p4SessionsDownlink     = "PreQosPipe.sessions_downlink"
+	p4TerminationsUplink   = "PreQosPipe.terminations_uplink"
+	p4TerminationsDownlink = "PreQosPipe.terminations_downlink"
+	p4Applications         = "PreQosPipe.applications"
+	p4TunnelPeers          = "PreQosPipe.tunnel_peers"
+
+	p4SetSourceIface         = "PreQosPipe.set_source_iface"
+	p4SetSessionUplink       = "PreQosPipe.set_session_uplink"
+	p4SetSessionUplinkDrop   = "PreQosPipe.set_session_uplink_drop"
+	p4SetSessionDownlink     = "PreQosPipe.set_session_downlink"
+	p4SetSessionDownlinkDrop = "PreQosPipe.set_session_downlink_drop"
+	p4SetSessionDownlinkBuff = "PreQosPipe.set_session_downlink_buff"
+	p4UplinkTermFwd          = "PreQosPipe.uplink_term_fwd"
+	p4UplinkTermDrop         = "PreQosPipe.uplink_term_drop"
+	p4DownlinkTermFwd        = "PreQosPipe.downlink_term_fwd"
+	p4DownlinkTermDrop       = "PreQosPipe.downlink_term_drop"
+	p4SetAppID               = "PreQosPipe.set_app_id"
+	p4LoadTunnelParam        = "PreQosPipe.load_tunnel_param"
+
+	p4AppMeter       = "PreQosPipe.app_meter"
+	p4SessionMeter   = "PreQosPipe.session_meter"
+	p4PostQosCounter = "PostQosPipe.post_qos_counter"
+
+	// Parameters of set_source_iface.
+	p4IfaceAccess       = 1
+	p4IfaceCore         = 2
+	p4DirectionUplink   = 1
+	p4DirectionDownlink = 2
+
+	maxP4SliceID = 1<<4 - 1
+	maxP4TC      = 1<<2 - 1
+
+	// up4BurstDurationMs is the duration of the bursts allowed by the meters.
+	up4BurstDurationMs = 10
+
+	p4rtConnectTimeout = 5 * time.Second
+	p4rtWatchInterval  = time.Second
+)
+
+var (
+	up4Tables = []string{
+		p4Interfaces, p4SessionsUplink, p4SessionsDownlink, p4TerminationsUplink,
+		p4TerminationsDownlink, p4Applications, p4TunnelPeers,
+	}
+	up4Actions = []string{
+		p4SetSourceIface, p4SetSessionUplink, p4SetSessionUplinkDrop, p4SetSessionDownlink,
+		p4SetSessionDownlinkDrop, p4SetSessionDownlinkBuff, p4UplinkTermFwd, p4UplinkTermDrop,
+		p4DownlinkTermFwd, p4DownlinkTermDrop, p4SetAppID, p4LoadTunnelParam,
+	}
+	up4Meters   = []string{p4AppMeter, p4SessionMeter}
+	up4Counters = []string{p4PostQosCounter}
+)
+
+var errP4Disconnected = status.Error(codes.Unavailable, "not connected to the P4 switch")
+
+// up4IDPool allocates the IDs in [1, max] of the P4 entities shared by the
+// rules with the same key.
+type up4IDPool struct {
+	name string
+	max  uint32
+	ids  map[string]uint32
+	used map[uint32]bool
+	// refs counts the entities installed with the ID of every key.
+	refs map[string]int
+	next uint32
+}
+
+func newUP4IDPool(name string, max int64) *up4IDPool {
+	return &up4IDPool{
+		name: name,
+		max:  uint32(min(max, math.MaxUint32)),
+		ids:  make(map[string]uint32),
+		used: make(map[uint32]bool),
+		refs: make(map[string]int),
+		next: 1,
+	}
+}
+
+// get returns the ID of key, and whether it was allocated by this call. A full
+// pool is reported as ResourceExhausted.
+func (p *up4IDPool) get(key string) (uint32, bool, error) {
+	if id, ok := p.ids[key]; ok {
+		return id, false, nil
+	}
+
+	if uint32(len(p.ids)) >= p.max {
+		return 0, false, status.Errorf(codes.ResourceExhausted, "no free %s", p.name)
+	}
+
+	for p.used[p.next] {
+		p.next = p.next%p.max + 1
+	}
+
+	id := p.next
+	p.ids[key], p.used[id] = id, true
+	p.next = p.next%p.max + 1
+
+	return id, true, nil
+}
+
+func (p *up4IDPool) bind(key string) {
+	p.refs[key]++
+}
+
+// unbind releases the ID of key once no entity is installed with it.
+func (p *up4IDPool) unbind(key string) {
+	p.refs[key]--
+	if p.refs[key] <= 0 {
+		p.release(key)
+	}
+}
+
+// release frees the ID of key unless an entity is installed with it.
+func (p *up4IDPool) release(key string) {
+	if p.refs[key] > 0 {
+		return
+	}
+
+	delete(p.used, p.ids[key])
+	delete(p.ids, key)
+	delete(p.refs, key)
+}
+
+// up4Entity is a P4 entity written to the switch, shared by the PDRs with the
+// same entity key.
+type up4Entity struct {
+	entity *p4.Entity
+	refs   int
+	// pool and poolKey are set if the entity holds an ID of the pool.
+	pool    *up4IDPool
+	poolKey string
+}
+
+// up4Want is an entity a PDR needs in the switch.
+type up4Want struct {
+	key     string
+	entity  *p4.Entity
+	pool    *up4IDPool
+	poolKey string
+}
+
+// up4Session holds the rules of a session, and the entities written for
+// every PDR.
+type up4Session struct {
+	pdrs map[uint32]pdr
+	fars map[uint32]far
+	qers map[uint32]qer
+	held map[uint32]map[string]bool
+}
+
+func (s *up4Session) empty() bool {
+	return len(s.pdrs) == 0 && len(s.fars) == 0 && len(s.qers) == 0 && len(s.held) == 0
+}
+
+// pdrsWith returns the IDs of the PDRs of the session matching f.
+func (s *up4Session) pdrsWith(f func(p pdr) bool) []uint32 {
+	var ids []uint32
+
+	for id, p := range s.pdrs {
+		if f(p) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// UP4 is the datapath of a P4 switch running the UP4 pipeline, programmed
+// over P4Runtime. Every PDR is translated into the entries of the session,
+// termination, application and tunnel peer tables and the meter cells
+// required to enforce it with its FAR and QERs. A PDR whose FAR or QERs are
+// not known yet has no entries.
+type UP4 struct {
+	conf     P4rtcInfo
+	restore  bool
+	accessIP *net.IPNet
+	uePool   *net.IPNet
+	client   *p4rtClient
+	done     chan struct{}
+
+	// mu serializes the writes, as the PDRs share entities.
+	mu            sync.Mutex
+	tr            *p4Translator
+	sessions      map[uint64]*up4Session
+	entities      map[string]*up4Entity
+	tunnelPeers   *up4IDPool
+	appIDs        *up4IDPool
+	sessionMeters *up4IDPool
+	appMeters     *up4IDPool
+	counters      *up4IDPool
+}
+
+// reset forgets the entities written to the switch.
+func (up4 *UP4) reset(tr *p4Translator) {
+	up4.tr = tr
+	up4.sessions = make(map[uint64]*up4Session)
+	up4.entities = make(map[string]*up4Entity)
+	up4.tunnelPeers = newUP4IDPool("tunnel peer ID", min(tr.tableSize(p4TunnelPeers), math.MaxUint8))
+	up4.appIDs = newUP4IDPool("application ID", min(tr.tableSize(p4Applications), math.MaxUint8))
+	up4.sessionMeters = newUP4IDPool("session meter", tr.meterSize(p4SessionMeter)-1)
+	up4.appMeters = newUP4IDPool("application meter", tr.meterSize(p4AppMeter)-1)
+	up4.counters = newUP4IDPool("counter", tr.counterSize(p4PostQosCounter)-1)
+}
+
+func (up4 *UP4) Exit() {
+	logger.PfcpLog.Infoln("exit function UP4")
+
+	close(up4.done)
+	up4.client.close()
+}
+
+func (up4 *UP4) SetUpfInfo(u *upf, conf *Conf) {
+	var err error
+
+	up4.conf = conf.P4rtcIface
+	up4.restore = conf.RestoreSessions
+	up4.done = make(chan struct{})
+
+	_, up4.accessIP, err = net.ParseCIDR(up4.conf.AccessIP)
+	if err != nil {
+		logger.PfcpLog.Fatalln("invalid P4 access IP:", err)
+	}
+
+	if conf.CPIface.UEIPPool != "" {
+		_, up4.uePool, err = net.ParseCIDR(conf.CPIface.UEIPPool)
+		if err != nil {
+			logger.PfcpLog.Fatalln("invalid UE IP pool:", err)
+		}
+	}
+
+	address := net.JoinHostPort(up4.conf.P4rtcServer, up4.conf.P4rtcPort)
+	logger.PfcpLog.Infoln("P4Runtime server", address)
+
+	up4.client, err = newP4RTClient(address, up4.conf.DeviceID)
+	if err != nil {
+		logger.PfcpLog.Fatalln("did not connect:", err)
+	}
+
+	// Restored sessions are re-programmed over the existing entries.
+	if err := up4.connect(!up4.restore); err != nil {
+		logger.PfcpLog.Errorln("failed to connect to the P4 switch:", err)
+	}
+
+	go up4.watchConnection(u.datapathResyncChan)
+}
+
+// connect becomes the primary controller of the switch, loads the P4Info of
+// its pipeline and installs the interfaces. The entries of the switch are
+// removed if clear is set.
+func (up4 *UP4) connect(clear bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p4rtConnectTimeout)
+	defer cancel()
+
+	if err := up4.client.arbitrate(ctx); err != nil {
+		return err
+	}
+
+	info, err := up4.client.getP4Info(ctx)
+	if err != nil {
+		return err
+	}
+
+	tr := newP4Translator(info)
+	if err := tr.checkNames(up4Tables, up4Actions, up4Meters, up4Counters); err != nil {
+		return err
+	}
+
+	up4.mu.Lock()
+	defer up4.mu.Unlock()
+
+	up4.reset(tr)
+
+	if clear {
+		if err := up4.clearTables(ctx); err != nil {
+			return err
+		}
+	}
+
+	return up4.installInterfaces(ctx)
+}
+
+// watchConnection reconnects to the switch once the stream channel is lost.
+// The switch may have restarted with empty tables: its entries are cleared
+// and resync is signaled so that the rules of all the sessions are replayed.
+func (up4 *UP4) watchConnection(resync chan<- struct{}) {
+	ticker := time.NewTicker(p4rtWatchInterval)
+	defer ticker.Stop()
+
+	lost := false
+
+	for {
+		select {
+		case <-up4.done:
+			return
+		case <-ticker.C:
+		}
+
+		if up4.client.isPrimary() {
+			continue
+		}
+
+		if !lost {
+			logger.PfcpLog.Warnln("lost connection to the P4 switch")
+		}
+
+		lost = true
+
+		if err := up4.connect(true); err != nil {
+			logger.PfcpLog.Debugln("failed to reconnect to the P4 switch:", err)
+			continue
+		}
+
+		logger.PfcpLog.Infoln("connection to the P4 switch re-established, resyncing datapath")
+
+		lost = false
+
+		select {
+		case resync <- struct{}{}:
+		default:
+			// A resync is already pending.
+		}
+	}
+}
+
+// clearTables removes all the table entries of the switch.
+func (up4 *UP4) clearTables(ctx context.Context) error {
+	entities, err := up4.client.read(ctx, &p4.Entity{Entity: &p4.Entity_TableEntry{TableEntry: &p4.TableEntry{}}})
+	if err != nil {
+		return err
+	}
+
+	updates := make([]*p4.Update, 0, len(entities))
+	for _, e := range entities {
+		updates = append(updates, &p4.Update{Type: p4.Update_DELETE, Entity: e})
+	}
+
+	for _, err := range up4.client.write(ctx, updates) {
+		if err != nil && !isRuleNotFound(err) {
+			return err
+		}
+	}
+
+	logger.PfcpLog.Debugf("cleared %d entries of the P4 switch", len(updates))
+
+	return nil
+}
+
+// installInterfaces classifies the traffic to the N3 address of the switch as
+// uplink, and the traffic to the UE pool as downlink.
+func (up4 *UP4) installInterfaces(ctx context.Context) error {
+	type iface struct {
+		prefix     *net.IPNet
+		srcIface   uint64
+		direction  uint64
+		descriptor string
+	}
+
+	ifaces := []iface{{up4.accessIP, p4IfaceAccess, p4DirectionUplink, "access"}}
+	if up4.uePool != nil {
+		ifaces = append(ifaces, iface{up4.uePool, p4IfaceCore, p4DirectionDownlink, "UE pool"})
+	} else {
+		logger.PfcpLog.Warnln("no UE IP pool, downlink traffic is not classified by the P4 switch")
+	}
+
+	var entities []*p4.Entity
+
+	for _, i := range ifaces {
+		prefixLen, _ := i.prefix.Mask.Size()
+
+		te, err := up4.tr.tableEntry(p4Interfaces,
+			[]p4Match{lpmMatch("ipv4_dst_prefix", uint64(ip2int(i.prefix.IP)), prefixLen)}, 0,
+			p4SetSourceIface,
+			p4Param{"src_iface", i.srcIface},
+			p4Param{"direction", i.direction},
+			p4Param{"slice_id", uint64(up4.conf.SliceID)})
+		if err != nil {
+			return err
+		}
+
+		entities = append(entities, &p4.Entity{Entity: &p4.Entity_TableEntry{TableEntry: te}})
+	}
+
+	for i, err := range up4.upsert(ctx, entities, make([]bool, len(entities))) {
+		if err != nil {
+			return ErrOperationFailedWithReason("install "+ifaces[i].descriptor+" interface", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// upsert writes the entities. New table entries are inserted, and modified if
+// they already exist. Meter and counter cells are always modified.
+func (up4 *UP4) upsert(ctx context.Context, entities []*p4.Entity, exists []bool) []error {
+	updates := make([]*p4.Update, len(entities))
+
+	for i, e := range entities {
+		updates[i] = &p4.Update{Type: p4.Update_MODIFY, Entity: e}
+
+		if e.GetTableEntry() != nil && !exists[i] {
+			updates[i].Type = p4.Update_INSERT
+		}
+	}
+
+	errs := up4.client.write(ctx, updates)
+
+	// The switch may hold the entries written before a reconnection.
+	var retries []int
+
+	for i, err := range errs {
+		if status.Code(err) == codes.AlreadyExists {
+			retries = append(retries, i)
+		}
+	}
+
+	if len(retries) == 0 {
+		return errs
+	}
+
+	modify := make([]*p4.Update, len(retries))
+	for j, i := range retries {
+		modify[j] = &p4.Update{Type: p4.Update_MODIFY, Entity: entities[i]}
+	}
+
+	for j, err := range up4.client.write(ctx, modify) {
+		errs[retries[j]] = err
+	}
+
+	return errs
+}
+
+// removeUpdate returns the update removing the entity from the switch, nil if
+// the entity does not need to be removed.
+func removeUpdate(e *p4.Entity) *p4.Update {
+	switch v := e.Entity.(type) {
+	case *p4.Entity_TableEntry:
+		return &p4.Update{Type: p4.Update_DELETE, Entity: e}
+	case *p4.Entity_MeterEntry:
+		reset := &p4.MeterEntry{MeterId: v.MeterEntry.MeterId, Index: v.MeterEntry.Index}
+		return &p4.Update{Type: p4.Update_MODIFY, Entity: &p4.Entity{Entity: &p4.Entity_MeterEntry{MeterEntry: reset}}}
+	}
+
+	return nil
+}
+
+func tableEntity(te *p4.TableEntry) *p4.Entity {
+	return &p4.Entity{Entity: &p4.Entity_TableEntry{TableEntry: te}}
+}
+
+// appPriority maps the precedence of a PDR to the priority of its application
+// entry: the lower the precedence, the higher the priority.
+func appPriority(precedence uint32) int32 {
+	return math.MaxInt32 - int32(min(precedence, math.MaxInt32-1))
+}
+
+// trafficClass returns the traffic class of the QoS flow.
+func (up4 *UP4) trafficClass(qfi uint8) uint64 {
+	if tc, ok := up4.conf.QFIToTC[qfi]; ok {
+		return uint64(tc)
+	}
+
+	return uint64(up4.conf.DefaultTC)
+}
+
+// up4Alloc returns the ID of the key in the pool.
+type up4Alloc func(pool *up4IDPool, key string) (uint32, error)
+
+// meter returns the index of the meter cell enforcing the rates of the QER in
+// one direction, and the cell to write. The index is 0 if the QER does not
+// limit the rate.
+func (up4 *UP4) meter(q *qer, uplink bool, meter string, pool *up4IDPool, alloc up4Alloc) (uint32, []up4Want, error) {
+	if q == nil {
+		return 0, nil, nil
+	}
+
+	mbr, gbr, dir := q.dlMbr, q.dlGbr, "dl"
+	if uplink {
+		mbr, gbr, dir = q.ulMbr, q.ulGbr, "ul"
+	}
+
+	if mbr == 0 && gbr == 0 {
+		return 0, nil, nil
+	}
+
+	poolKey := fmt.Sprintf("%d/%d/%s", q.fseID, q.qerID, dir)
+
+	index, err := alloc(pool, poolKey)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	/* MBR/GBR is received in Kilobits/sec.
+	   CIR/PIR is sent in bytes */
+	cir := maxUint64(gbr*1000/8, 1)
+	pir := maxUint64(mbr*1000/8, cir)
+	config := &p4.MeterConfig{
+		Cir:    int64(cir),
+		Cburst: int64(maxUint64(calcBurstSizeFromRate(gbr, up4BurstDurationMs), 1)),
+		Pir:    int64(pir),
+		Pburst: int64(maxUint64(calcBurstSizeFromRate(mbr, up4BurstDurationMs), 1)),
+	}
+
+	me, err := up4.tr.meterEntry(meter, int64(index), config)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return index, []up4Want{{
+		key:     fmt.Sprintf("%s/%d", meter, index),
+		entity:  &p4.Entity{Entity: &p4.Entity_MeterEntry{MeterEntry: me}},
+		pool:    pool,
+		poolKey: poolKey,
+	}}, nil
+}
+
+// application returns the ID of the application of the PDR, and the entry
+// classifying its traffic. The ID is 0 if the PDR matches all the traffic.
+func (up4 *UP4) application(p pdr, alloc up4Alloc) (uint32, []up4Want, error) {
+	if p.IsAppFilterEmpty() {
+		return 0, nil, nil
+	}
+
+	// The application is the destination of uplink traffic and the source of
+	// downlink traffic.
+	f := p.appFilter
+	ip, mask, ports := f.dstIP, f.dstIPMask, f.dstPortRange
+
+	if p.IsDownlink() {
+		ip, mask, ports = f.srcIP, f.srcIPMask, f.srcPortRange
+	}
+
+	if ports.isWildcardMatch() {
+		ports = newWildcardPortRange()
+	}
+
+	prefixLen := bits.OnesCount32(mask)
+	priority := appPriority(p.precedence)
+	poolKey := fmt.Sprintf("%x/%d/%d-%d/%d&%d/%d", ip&mask, prefixLen, ports.low, ports.high,
+		f.proto, f.protoMask, priority)
+
+	appID, err := alloc(up4.appIDs, poolKey)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	te, err := up4.tr.tableEntry(p4Applications,
+		[]p4Match{
+			lpmMatch("app_ip_addr", uint64(ip&mask), prefixLen),
+			rangeMatch("app_l4_port", uint64(ports.low), uint64(ports.high)),
+			ternaryMatch("app_ip_proto", uint64(f.proto), uint64(f.protoMask)),
+		}, priority,
+		p4SetAppID, p4Param{"app_id", uint64(appID)})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return appID, []up4Want{{key: entryKey(te), entity: tableEntity(te), pool: up4.appIDs, poolKey: poolKey}}, nil
+}
+
+// tunnelPeer returns the ID of the tunnel peer of the FAR, and the entry
+// holding the parameters of its tunnel.
+func (up4 *UP4) tunnelPeer(f far, alloc up4Alloc) (uint32, []up4Want, error) {
+	poolKey := fmt.Sprintf("%x/%x/%d", f.tunnelIP4Src, f.tunnelIP4Dst, f.tunnelPort)
+
+	peerID, err := alloc(up4.tunnelPeers, poolKey)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	te, err := up4.tr.tableEntry(p4TunnelPeers,
+		[]p4Match{exactMatch("tunnel_peer_id", uint64(peerID))}, 0,
+		p4LoadTunnelParam,
+		p4Param{"src_addr", uint64(f.tunnelIP4Src)},
+		p4Param{"dst_addr", uint64(f.tunnelIP4Dst)},
+		p4Param{"sport", uint64(f.tunnelPort)})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return peerID, []up4Want{{key: entryKey(te), entity: tableEntity(te), pool: up4.tunnelPeers, poolKey: poolKey}}, nil
+}
+
+// pdrEntities returns the entities the PDR needs in the switch, none if its
+// FAR or QERs are not known. The session entries are shared by the PDRs of
+// the same F-TEID or UE address, and hold the values of the last PDR written.
+func (up4 *UP4) pdrEntities(s *up4Session, p pdr, alloc up4Alloc) ([]up4Want, error) {
+	if !p.IsUplink() && !p.IsDownlink() {
+		return nil, ErrUnsupported("PDR source interface", p.srcIface)
+	}
+
+	if p.ueAddress == 0 || !p.hasIPv4() {
+		return nil, ErrUnsupported("PDR without IPv4 UE address", p.pdrID)
+	}
+
+	f, ok := s.fars[p.farID]
+	if !ok {
+		return nil, nil
+	}
+
+	if f.hasIPv6Tunnel() {
+		return nil, ErrUnsupported("FAR with IPv6 tunnel", f.farID)
+	}
+
+	var sessionQER, appQER *qer
+
+	for _, id := range p.qerIDList {
+		q, ok := s.qers[id]
+		if !ok {
+			return nil, nil
+		}
+
+		switch q.qosLevel {
+		case SessionQos:
+			sessionQER = &q
+		case ApplicationQos:
+			appQER = &q
+		}
+	}
+
+	uplink := p.IsUplink()
+
+	// gateClosed returns true if the QER drops the traffic of the PDR.
+	gateClosed := func(q *qer) bool {
+		if q == nil {
+			return false
+		}
+
+		if uplink {
+			return q.ulStatus != ie.GateStatusOpen
+		}
+
+		return q.dlStatus != ie.GateStatusOpen
+	}
+
+	var qfi uint8
+
+	switch {
+	case appQER != nil:
+		qfi = appQER.qfi
+	case sessionQER != nil:
+		qfi = sessionQER.qfi
+	}
+
+	sessionMeter, want, err := up4.meter(sessionQER, uplink, p4SessionMeter, up4.sessionMeters, alloc)
+	if err != nil {
+		return nil, err
+	}
+
+	appMeter, appMeterWant, err := up4.meter(appQER, uplink, p4AppMeter, up4.appMeters, alloc)
+	if err != nil {
+		return nil, err
+	}
+
+	want = append(want, appMeterWant...)
+
+	appID, appWant, err := up4.application(p, alloc)
+	if err != nil {
+		return nil, err
+	}
+
+	want = append(want, appWant...)
+
+	ctrPoolKey := fmt.Sprintf("%d/%d", p.fseID, p.pdrID)
+
+	ctrIdx, err := alloc(up4.counters, ctrPoolKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ce, err := up4.tr.counterEntry(p4PostQosCounter, &p4.Index{Index: int64(ctrIdx)}, &p4.CounterData{})
+	if err != nil {
+		return nil, err
+	}
+
+	want = append(want, up4Want{
+		key:     fmt.Sprintf("%s/%d", p4PostQosCounter, ctrIdx),
+		entity:  &p4.Entity{Entity: &p4.Entity_CounterEntry{CounterEntry: ce}},
+		pool:    up4.counters,
+		poolKey: ctrPoolKey,
+	})
+
+	var (
+		session, termination *p4.TableEntry
+		termDrop             = f.Drops() || gateClosed(appQER)
+		ctr                  = p4Param{"ctr_idx", uint64(ctrIdx)}
+		tc                   = p4Param{"tc", up4.trafficClass(qfi)}
+		appMeterIdx          = p4Param{"app_meter_idx", uint64(appMeter)}
+		sessionMeterIdx      = p4Param{"session_meter_idx", uint64(sessionMeter)}
+		termMatch            = []p4Match{exactMatch("ue_address", uint64(p.ueAddress)), exactMatch("app_id", uint64(appID))}
+	)
+
+	if uplink {
+		if p.tunnelTEID == 0 {
+			return nil, ErrUnsupported("uplink PDR without F-TEID", p.pdrID)
+		}
+
+		sessionMatch := []p4Match{
+			exactMatch("n3_address", uint64(p.tunnelIP4Dst)),
+			exactMatch("teid", uint64(p.tunnelTEID)),
+		}
+
+		if gateClosed(sessionQER) {
+			session, err = up4.tr.tableEntry(p4SessionsUplink, sessionMatch, 0, p4SetSessionUplinkDrop)
+		} else {
+			session, err = up4.tr.tableEntry(p4SessionsUplink, sessionMatch, 0, p4SetSessionUplink, sessionMeterIdx)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if termDrop {
+			termination, err = up4.tr.tableEntry(p4TerminationsUplink, termMatch, 0, p4UplinkTermDrop, ctr)
+		} else {
+			termination, err = up4.tr.tableEntry(p4TerminationsUplink, termMatch, 0, p4UplinkTermFwd,
+				ctr, tc, appMeterIdx)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return append(want,
+			up4Want{key: entryKey(session), entity: tableEntity(session)},
+			up4Want{key: entryKey(termination), entity: tableEntity(termination)}), nil
+	}
+
+	sessionMatch := []p4Match{exactMatch("ue_address", uint64(p.ueAddress))}
+
+	switch {
+	case gateClosed(sessionQER) || f.Drops():
+		session, err = up4.tr.tableEntry(p4SessionsDownlink, sessionMatch, 0, p4SetSessionDownlinkDrop)
+	case f.Buffers():
+		session, err = up4.tr.tableEntry(p4SessionsDownlink, sessionMatch, 0, p4SetSessionDownlinkBuff, sessionMeterIdx)
+	case f.tunnelTEID != 0:
+		var peerID uint32
+
+		var peerWant []up4Want
+
+		peerID, peerWant, err = up4.tunnelPeer(f, alloc)
+		if err != nil {
+			return nil, err
+		}
+
+		want = append(want, peerWant...)
+		session, err = up4.tr.tableEntry(p4SessionsDownlink, sessionMatch, 0, p4SetSessionDownlink,
+			p4Param{"tunnel_peer_id", uint64(peerID)}, sessionMeterIdx)
+	default:
+		return nil, ErrUnsupported("downlink FAR without tunnel", f.farID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if termDrop {
+		termination, err = up4.tr.tableEntry(p4TerminationsDownlink, termMatch, 0, p4DownlinkTermDrop, ctr)
+	} else {
+		termination, err = up4.tr.tableEntry(p4TerminationsDownlink, termMatch, 0, p4DownlinkTermFwd,
+			ctr, p4Param{"teid", uint64(f.tunnelTEID)}, p4Param{"qfi", uint64(qfi)}, tc, appMeterIdx)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(want,
+		up4Want{key: entryKey(session), entity: tableEntity(session)},
+		up4Want{key: entryKey(termination), entity: tableEntity(termination)}), nil
+}
+
+// hold records that the PDR holding the keys in held needs the entity.
+func (up4 *UP4) hold(held map[string]bool, w up4Want) {
+	e := up4.entities[w.key]
+	if e == nil {
+		e = &up4Entity{pool: w.pool, poolKey: w.poolKey}
+		if w.pool != nil {
+			w.pool.bind(w.poolKey)
+		}
+
+		up4.entities[w.key] = e
+	}
+
+	e.entity = w.entity
+
+	if !held[w.key] {
+		held[w.key] = true
+		e.refs++
+	}
+}
+
+// install writes the entities wanted by a PDR that the switch does not hold
+// yet.
+func (up4 *UP4) install(ctx context.Context, held map[string]bool, want []up4Want) error {
+	var (
+		writes   []up4Want
+		entities []*p4.Entity
+		exists   []bool
+	)
+
+	for _, w := range want {
+		e := up4.entities[w.key]
+		if e != nil && proto.Equal(e.entity, w.entity) {
+			up4.hold(held, w)
+			continue
+		}
+
+		writes = append(writes, w)
+		entities = append(entities, w.entity)
+		exists = append(exists, e != nil)
+	}
+
+	var errs []error
+
+	for i, err := range up4.upsert(ctx, entities, exists) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		up4.hold(held, writes[i])
+	}
+
+	return errors.Join(errs...)
+}
+
+// remove deletes the entities held by a PDR that it no longer wants, unless
+// other PDRs hold them.
+func (up4 *UP4) remove(ctx context.Context, held map[string]bool, want []up4Want) error {
+	var (
+		keys    []string
+		updates []*p4.Update
+	)
+
+	for key := range held {
+		if slices.ContainsFunc(want, func(w up4Want) bool { return w.key == key }) {
+			continue
+		}
+
+		e := up4.entities[key]
+		if e.refs > 1 {
+			e.refs--
+			delete(held, key)
+
+			continue
+		}
+
+		u := removeUpdate(e.entity)
+		if u == nil {
+			up4.forget(held, key)
+			continue
+		}
+
+		keys = append(keys, key)
+		updates = append(updates, u)
+	}
+
+	var errs []error
+
+	for i, err := range up4.client.write(ctx, updates) {
+		if err != nil && !isRuleNotFound(err) {
+			errs = append(errs, err)
+			continue
+		}
+
+		up4.forget(held, keys[i])
+	}
+
+	return errors.Join(errs...)
+}
+
+// forget drops the entity removed from the switch.
+func (up4 *UP4) forget(held map[string]bool, key string) {
+	if e := up4.entities[key]; e.pool != nil {
+		e.pool.unbind(e.poolKey)
+	}
+
+	delete(up4.entities, key)
+	delete(held, key)
+}
+
+// syncPDR writes the entities needed by the current rules of the PDR, and
+// removes those it no longer needs.
+func (up4 *UP4) syncPDR(ctx context.Context, s *up4Session, pdrID uint32) error {
+	type allocation struct {
+		pool *up4IDPool
+		key  string
+	}
+
+	var fresh []allocation
+
+	alloc := func(pool *up4IDPool, key string) (uint32, error) {
+		id, allocated, err := pool.get(key)
+		if allocated {
+			fresh = append(fresh, allocation{pool, key})
+		}
+
+		return id, err
+	}
+
+	// The IDs allocated for entities that failed to be written are released.
+	defer func() {
+		for _, a := range fresh {
+			a.pool.release(a.key)
+		}
+	}()
+
+	var (
+		want []up4Want
+		err  error
+	)
+
+	if p, ok := s.pdrs[pdrID]; ok {
+		want, err = up4.pdrEntities(s, p, alloc)
+		if err != nil {
+			return err
+		}
+	}
+
+	held := s.held[pdrID]
+	if held == nil {
+		held = make(map[string]bool)
+		s.held[pdrID] = held
+	}
+
+	defer func() {
+		if len(held) == 0 {
+			delete(s.held, pdrID)
+		}
+	}()
+
+	// The entities are installed first, so that the switch keeps forwarding
+	// the traffic of the PDR with the old ones if they fail.
+	if err := up4.install(ctx, held, want); err != nil {
+		return err
+	}
+
+	return up4.remove(ctx, held, want)
+}
+
+// apply changes the rules of the session, and synchronizes the PDRs affected
+// by the change returned by change.
+func (up4 *UP4) apply(ctx context.Context, fseID uint64, change func(s *up4Session) []uint32) error {
+	up4.mu.Lock()
+	defer up4.mu.Unlock()
+
+	if up4.tr == nil {
+		return errP4Disconnected
+	}
+
+	s := up4.sessions[fseID]
+	if s == nil {
+		s = &up4Session{
+			pdrs: make(map[uint32]pdr),
+			fars: make(map[uint32]far),
+			qers: make(map[uint32]qer),
+			held: make(map[uint32]map[string]bool),
+		}
+		up4.sessions[fseID] = s
+	}
+
+	var errs []error
+
+	for _, id := range change(s) {
+		if err := up4.syncPDR(ctx, s, id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if s.empty() {
+		delete(up4.sessions, fseID)
+	}
+
+	return errors.Join(errs...)
+}
+
+// CreatePDR installs the entries of the PDR.
+func (up4 *UP4) CreatePDR(ctx context.Context, p pdr) error {
+	return up4.UpdatePDR(ctx, pdr{}, p)
+}
+
+// UpdatePDR replaces the entries of the PDR.
+func (up4 *UP4) UpdatePDR(ctx context.Context, _, p pdr) error {
+	return up4.apply(ctx, p.fseID, func(s *up4Session) []uint32 {
+		s.pdrs[p.pdrID] = p
+		return []uint32{p.pdrID}
+	})
+}
+
+// DeletePDR removes the entries of the PDR.
+func (up4 *UP4) DeletePDR(ctx context.Context, p pdr) error {
+	return up4.apply(ctx, p.fseID, func(s *up4Session) []uint32 {
+		delete(s.pdrs, p.pdrID)
+		return []uint32{p.pdrID}
+	})
+}
+
+// CreateFAR installs the entries of the PDRs of the FAR.
+func (up4 *UP4) CreateFAR(ctx context.Context, f far) error {
+	return up4.UpdateFAR(ctx, f)
+}
+
+// UpdateFAR replaces the entries of the PDRs of the FAR.
+func (up4 *UP4) UpdateFAR(ctx context.Context, f far) error {
+	return up4.apply(ctx, f.fseID, func(s *up4Session) []uint32 {
+		s.fars[f.farID] = f
+		return s.pdrsWith(func(p pdr) bool { return p.farID == f.farID })
+	})
+}
+
+// DeleteFAR removes the entries of the PDRs of the FAR.
+func (up4 *UP4) DeleteFAR(ctx context.Context, f far) error {
+	return up4.apply(ctx, f.fseID, func(s *up4Session) []uint32 {
+		delete(s.fars, f.farID)
+		return s.pdrsWith(func(p pdr) bool { return p.farID == f.farID })
+	})
+}
+
+// CreateQER installs the entries and meters of the PDRs of the QER.
+func (up4 *UP4) CreateQER(ctx context.Context, q qer) error {
+	return up4.UpdateQER(ctx, q)
+}
+
+// UpdateQER replaces the entries and meters of the PDRs of the QER.
+func (up4 *UP4) UpdateQER(ctx context.Context, q qer) error {
+	return up4.apply(ctx, q.fseID, func(s *up4Session) []uint32 {
+		s.qers[q.qerID] = q
+		return s.pdrsWith(func(p pdr) bool { return slices.Contains(p.qerIDList, q.qerID) })
+	})
+}
+
+// DeleteQER removes the entries and meters of the PDRs of the QER.
+func (up4 *UP4) DeleteQER(ctx context.Context, q qer) error {
+	return up4.apply(ctx, q.fseID, func(s *up4Session) []uint32 {
+		delete(s.qers, q.qerID)
+		return s.pdrsWith(func(p pdr) bool { return slices.Contains(p.qerIDList, q.qerID) })
+	})
+}
+
+func (up4 *UP4) IsConnected(accessIP *net.IP) bool {
+	if up4.client == nil || !up4.client.isPrimary() {
+		return false
+	}
+
+	up4.mu.Lock()
+	defer up4.mu.Unlock()
+
+	return up4.tr != nil
+}
+
+// UsageCounters returns the packets and bytes forwarded by every PDR since it was installed.
+func (up4 *UP4) UsageCounters() (map[pdrCounterKey]pdrCounters, error) {
+	up4.mu.Lock()
+
+	if up4.tr == nil {
+		up4.mu.Unlock()
+		return nil, errP4Disconnected
+	}
+
+	owners := make(map[int64]pdrCounterKey)
+
+	for fseID, s := range up4.sessions {
+		for pdrID := range s.held {
+			key := fmt.Sprintf("%d/%d", fseID, pdrID)
+			if up4.counters.refs[key] > 0 {
+				owners[int64(up4.counters.ids[key])] = pdrCounterKey{fseID: fseID, pdrID: pdrID}
+			}
+		}
+	}
+
+	ce, err := up4.tr.counterEntry(p4PostQosCounter, nil, nil)
+
+	up4.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	entities, err := up4.client.read(ctx, &p4.Entity{Entity: &p4.Entity_CounterEntry{CounterEntry: ce}})
+	if err != nil {
+		return nil, err
+	}
+
+	counters := make(map[pdrCounterKey]pdrCounters, len(owners))
+
+	for _, e := range entities {
+		c := e.GetCounterEntry()
+
+		key, ok := owners[c.GetIndex().GetIndex()]
+		if !ok {
+			continue
+		}
+
+		counters[key] = pdrCounters{
+			packets: uint64(c.GetData().GetPacketCount()),
+			bytes:   uint64(c.GetData().GetByteCount()),
+		}
+	}
+
+	return counters, nil
+}
+
+func (up4 *UP4) AddSliceInfo(sliceInfo *SliceInfo) error {
+	return ErrUnsupported("slice rate limits", "P4Runtime datapath")
+}
+
+// SetQciQosConfig does nothing, the P4Runtime datapath meters the QERs with
+// bursts of its own, and maps QFIs to traffic classes rather than using the
+// priorities of the QCIs.
+func (up4 *UP4) SetQciQosConfig(conf *Conf) error {
+	return nil
+}
+
+func (up4 *UP4) SetSliceMeterConfig(meterConfig SliceMeterConfig) error {
+	return ErrUnsupported("slice rate limits", "P4Runtime datapath")
+}
+
+func (up4 *UP4) SendEndMarkers(endMarkerList *[][]byte) error {
+	return ErrUnsupported("end markers", "P4Runtime datapath")
+}
+
+func (up4 *UP4) GtpuPathCounters() (map[uint32]uint64, error) {
+	return nil, ErrUnsupported("GTP-U path monitoring", "P4Runtime datapath")
+}
+
+func (up4 *UP4) SummaryLatencyJitter(uc *upfCollector, ch chan<- prometheus.Metric) {}
+
+func (up4 *UP4) PortStats(uc *upfCollector, ch chan<- prometheus.Metric) {}
+
+func (up4 *UP4) SummaryGtpuLatency(uc *upfCollector, ch chan<- prometheus.Metric) {}
+
+func (up4 *UP4) SessionStats(pc *PfcpNodeCollector, ch chan<- prometheus.Metric) error {
+	return nil
+}
+
+// ReadRules reports that the entries of the switch are not audited.
+func (up4 *UP4) ReadRules() []datapathTable {
+	return []datapathTable{{name: "up4", err: ErrUnsupported("datapath audit", "P4Runtime datapath")}}
+}
+
+// ExpectedRules returns no rules, the entries of the switch are neither
+// audited nor accounted against the datapath capacity.
+func (up4 *UP4) ExpectedRules(rules PacketForwardingRules) []datapathRule {
+	return nil
+}
+
+func (up4 *UP4) DeleteRule(r datapathRule) error {
+	return ErrUnsupported("datapath audit", "P4Runtime datapath")
+}
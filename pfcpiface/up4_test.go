@@ -0,0 +1,287 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/omec-project/upf-epc/pkg/fake_p4rt"
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+// newFakeUP4 returns a UP4 datapath connected to a fake P4Runtime server.
+func newFakeUP4(t *testing.T) (*UP4, *fake_p4rt.FakeP4RT) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+
+	addr := l.Addr().(*net.TCPAddr)
+	l.Close()
+
+	fp := fake_p4rt.NewFakeP4RT()
+
+	go func() {
+		if err := fp.Run(addr.String()); err != nil {
+			t.Errorf("fake P4Runtime server failed: %v", err)
+		}
+	}()
+
+	conf := &Conf{
+		CPIface: CPIfaceInfo{UEIPPool: "10.250.0.0/16"},
+		P4rtcIface: P4rtcInfo{
+			AccessIP:    "198.18.0.1/32",
+			P4rtcServer: addr.IP.String(),
+			P4rtcPort:   strconv.Itoa(addr.Port),
+			QFIToTC:     map[uint8]uint8{9: 1},
+		},
+	}
+
+	up4 := &UP4{}
+	up4.SetUpfInfo(&upf{datapathResyncChan: make(chan struct{}, 1)}, conf)
+
+	t.Cleanup(func() {
+		up4.Exit()
+		fp.Stop()
+	})
+
+	// Wait for the fake server to serve.
+	for i := 0; i < 50; i++ {
+		if up4.IsConnected(nil) {
+			return up4, fp
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Fatal("UP4 not connected to fake P4Runtime server")
+
+	return nil, nil
+}
+
+// up4Rules returns the rules of a session with an uplink PDR for all the
+// traffic, an uplink PDR for DNS traffic and a downlink PDR to gNB.
+func up4Rules(fseID uint64, ue, gnb string, teid uint32) PacketForwardingRules {
+	ueIP, gnbIP := ip2int(net.ParseIP(ue)), ip2int(net.ParseIP(gnb))
+	n3 := ip2int(net.ParseIP("198.18.0.1"))
+
+	dns := applicationFilter{
+		dstIP: ip2int(net.ParseIP("8.8.8.8")), dstIPMask: 0xffffffff, dstPortRange: portRange{53, 53},
+		proto: 17, protoMask: 0xff, srcPortRange: newWildcardPortRange(),
+	}
+
+	return PacketForwardingRules{
+		pdrs: []pdr{
+			{pdrID: 1, fseID: fseID, srcIface: access, tunnelIP4Dst: n3, tunnelTEID: teid, ueAddress: ueIP,
+				precedence: 100, farID: 1, qerIDList: []uint32{1}},
+			{pdrID: 2, fseID: fseID, srcIface: access, tunnelIP4Dst: n3, tunnelTEID: teid, ueAddress: ueIP,
+				precedence: 10, appFilter: dns, farID: 1, qerIDList: []uint32{1}},
+			{pdrID: 3, fseID: fseID, srcIface: core, ueAddress: ueIP, precedence: 100, farID: 2, qerIDList: []uint32{1}},
+		},
+		fars: []far{
+			{farID: 1, fseID: fseID, applyAction: ActionForward, dstIntf: ie.DstInterfaceCore},
+			{farID: 2, fseID: fseID, applyAction: ActionForward, dstIntf: ie.DstInterfaceAccess,
+				tunnelIP4Src: n3, tunnelIP4Dst: gnbIP, tunnelTEID: teid + 1, tunnelPort: tunnelGTPUPort},
+		},
+		qers: []qer{
+			{qerID: 1, fseID: fseID, qosLevel: SessionQos, qfi: 9, ulMbr: 1000, dlMbr: 2000},
+		},
+	}
+}
+
+func expectEntries(t *testing.T, fp *fake_p4rt.FakeP4RT, table string, n int) []fake_p4rt.FakeTableEntry {
+	t.Helper()
+
+	entries := fp.TableEntries(table)
+	if len(entries) != n {
+		t.Fatalf("expected %d entries in %v, got %d: %v", n, table, len(entries), entries)
+	}
+
+	return entries
+}
+
+func TestUP4Session(t *testing.T) {
+	up4, fp := newFakeUP4(t)
+
+	expectEntries(t, fp, p4Interfaces, 2)
+
+	rules := up4Rules(1, "10.250.0.1", "10.0.0.9", 0x10)
+
+	t.Run("pending PDRs", func(t *testing.T) {
+		if err := writeRules(up4, upfMsgTypeAdd, PacketForwardingRules{}, PacketForwardingRules{pdrs: rules.pdrs}); err != nil {
+			t.Fatalf("failed to create PDRs: %v", err)
+		}
+
+		expectEntries(t, fp, p4SessionsUplink, 0)
+		expectEntries(t, fp, p4TerminationsDownlink, 0)
+	})
+
+	t.Run("establishment", func(t *testing.T) {
+		if err := writeRules(up4, upfMsgTypeAdd, PacketForwardingRules{}, PacketForwardingRules{fars: rules.fars, qers: rules.qers}); err != nil {
+			t.Fatalf("failed to create FARs and QERs: %v", err)
+		}
+
+		session := expectEntries(t, fp, p4SessionsUplink, 1)[0]
+		if session.Action != p4SetSessionUplink || session.Match["teid"] != 0x10 {
+			t.Errorf("unexpected uplink session %v", session)
+		}
+
+		meter := fp.MeterConfig(p4SessionMeter, int64(session.Params["session_meter_idx"]))
+		if meter == nil || meter.Pir != 1000*1000/8 {
+			t.Errorf("unexpected uplink session meter %v", meter)
+		}
+
+		app := expectEntries(t, fp, p4Applications, 1)[0]
+		if app.Match["app_ip_addr"] != uint64(ip2int(net.ParseIP("8.8.8.8"))) || app.Match["app_l4_port"] != 53 {
+			t.Errorf("unexpected application %v", app)
+		}
+
+		appIDs := make(map[uint64]bool)
+		for _, e := range expectEntries(t, fp, p4TerminationsUplink, 2) {
+			appIDs[e.Match["app_id"]] = true
+
+			if e.Action != p4UplinkTermFwd || e.Params["tc"] != 1 {
+				t.Errorf("unexpected uplink termination %v", e)
+			}
+		}
+
+		if !appIDs[0] || !appIDs[app.Params["app_id"]] {
+			t.Errorf("expected uplink terminations of all traffic and application %v, got %v", app, appIDs)
+		}
+
+		peer := expectEntries(t, fp, p4TunnelPeers, 1)[0]
+		if peer.Params["dst_addr"] != uint64(ip2int(net.ParseIP("10.0.0.9"))) {
+			t.Errorf("unexpected tunnel peer %v", peer)
+		}
+
+		session = expectEntries(t, fp, p4SessionsDownlink, 1)[0]
+		if session.Action != p4SetSessionDownlink || session.Params["tunnel_peer_id"] != peer.Match["tunnel_peer_id"] {
+			t.Errorf("unexpected downlink session %v", session)
+		}
+
+		term := expectEntries(t, fp, p4TerminationsDownlink, 1)[0]
+		if term.Action != p4DownlinkTermFwd || term.Params["teid"] != 0x11 || term.Params["qfi"] != 9 {
+			t.Errorf("unexpected downlink termination %v", term)
+		}
+	})
+
+	t.Run("buffering", func(t *testing.T) {
+		buffer := rules.fars[1]
+		buffer.applyAction = ActionBuffer | ActionNotify
+
+		if err := up4.UpdateFAR(context.Background(), buffer); err != nil {
+			t.Fatalf("failed to update FAR: %v", err)
+		}
+
+		session := expectEntries(t, fp, p4SessionsDownlink, 1)[0]
+		if session.Action != p4SetSessionDownlinkBuff {
+			t.Errorf("expected buffering downlink session, got %v", session)
+		}
+
+		expectEntries(t, fp, p4TunnelPeers, 0)
+	})
+
+	t.Run("usage counters", func(t *testing.T) {
+		term := expectEntries(t, fp, p4TerminationsDownlink, 1)[0]
+		fp.SetCounter(p4PostQosCounter, int64(term.Params["ctr_idx"]), 10, 1000)
+
+		counters, err := up4.UsageCounters()
+		if err != nil {
+			t.Fatalf("failed to read usage counters: %v", err)
+		}
+
+		if c := counters[pdrCounterKey{fseID: 1, pdrID: 3}]; c.packets != 10 || c.bytes != 1000 {
+			t.Errorf("unexpected counters of PDR 3: %v", c)
+		}
+	})
+
+	t.Run("deletion", func(t *testing.T) {
+		if err := writeRules(up4, upfMsgTypeDel, PacketForwardingRules{}, rules); err != nil {
+			t.Fatalf("failed to delete rules: %v", err)
+		}
+
+		for _, table := range up4Tables[1:] {
+			expectEntries(t, fp, table, 0)
+		}
+
+		if meter := fp.MeterConfig(p4SessionMeter, 1); meter != nil {
+			t.Errorf("expected session meter to be reset, got %v", meter)
+		}
+
+		if len(up4.sessions) != 0 || len(up4.entities) != 0 {
+			t.Errorf("expected no state, got %v sessions and %v entities", len(up4.sessions), len(up4.entities))
+		}
+
+		for _, pool := range []*up4IDPool{up4.tunnelPeers, up4.appIDs, up4.sessionMeters, up4.appMeters, up4.counters} {
+			if len(pool.ids) != 0 {
+				t.Errorf("expected no allocated %v, got %v", pool.name, pool.ids)
+			}
+		}
+	})
+}
+
+func TestUP4SharedEntries(t *testing.T) {
+	up4, fp := newFakeUP4(t)
+
+	// Both sessions send their downlink traffic to the same gNB, and
+	// classify the same application.
+	first := up4Rules(1, "10.250.0.1", "10.0.0.9", 0x10)
+	second := up4Rules(2, "10.250.0.2", "10.0.0.9", 0x20)
+
+	for _, rules := range []PacketForwardingRules{first, second} {
+		if err := writeRules(up4, upfMsgTypeAdd, PacketForwardingRules{}, rules); err != nil {
+			t.Fatalf("failed to install session: %v", err)
+		}
+	}
+
+	expectEntries(t, fp, p4TunnelPeers, 1)
+	expectEntries(t, fp, p4Applications, 1)
+	expectEntries(t, fp, p4SessionsDownlink, 2)
+
+	if err := writeRules(up4, upfMsgTypeDel, PacketForwardingRules{}, first); err != nil {
+		t.Fatalf("failed to remove session: %v", err)
+	}
+
+	expectEntries(t, fp, p4TunnelPeers, 1)
+	expectEntries(t, fp, p4Applications, 1)
+	expectEntries(t, fp, p4SessionsDownlink, 1)
+
+	if err := writeRules(up4, upfMsgTypeDel, PacketForwardingRules{}, second); err != nil {
+		t.Fatalf("failed to remove session: %v", err)
+	}
+
+	expectEntries(t, fp, p4TunnelPeers, 0)
+	expectEntries(t, fp, p4Applications, 0)
+}
+
+func TestUP4TableFull(t *testing.T) {
+	up4, fp := newFakeUP4(t)
+	fp.SetTableSize(p4TerminationsDownlink, 0)
+
+	rules := up4Rules(1, "10.250.0.1", "10.0.0.9", 0x10)
+
+	err := writeRules(up4, upfMsgTypeAdd, PacketForwardingRules{}, rules)
+	if err == nil {
+		t.Fatal("expected installation to fail")
+	}
+
+	if cause, _ := datapathFailure(err); cause != ie.CauseNoResourcesAvailable {
+		t.Errorf("expected cause %d, got %d: %v", ie.CauseNoResourcesAvailable, cause, err)
+	}
+
+	// The entries that were installed are removed with the rules.
+	if err := writeRules(up4, upfMsgTypeDel, PacketForwardingRules{}, rules); err != nil {
+		t.Fatalf("failed to remove rules: %v", err)
+	}
+
+	for _, table := range up4Tables[1:] {
+		expectEntries(t, fp, table, 0)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/omec-project/upf-epc/logger"
@@ -44,23 +45,68 @@ type upf struct {
 	accessIface       string
 	coreIface         string
 	ippoolCidr        string
+	ippoolCidr6       string
 	n4addr            string
 	accessIP          net.IP
 	coreIP            net.IP
+	accessIP6         net.IP
+	coreIP6           net.IP
 	nodeID            string
 	ippool            *IPPool
 	peers             []string
 	dnn               string
 	reportNotifyChan  chan uint64
-	sliceInfo         *SliceInfo
-	readTimeout       time.Duration
-	fteidGenerator    *FTEIDGenerator
+	// remote F-TEIDs of the received GTP-U Error Indications
+	errorIndicationChan chan uint64
+	datapathResyncChan  chan struct{}
+	usage               *usageTracker
+	usagePollInterval   time.Duration
+	sliceInfo           *SliceInfo
+	readTimeout         time.Duration
+	fteidGenerator      *FTEIDGenerator
+	// sessionMu serializes the changes to the sessions and their rules in the
+	// datapath, whether requested by the CP function or made by the UPF.
+	sessionMu sync.Mutex
+	// counter indexes of the PDRs with URRs, unique across all sessions.
+	counterIDs *FTEIDGenerator
 
 	datapath
+	// timersMu guards the request timers and the heartbeat interval, which
+	// config reloads may change.
+	timersMu      sync.RWMutex
 	maxReqRetries uint8
 	respTimeout   time.Duration
 	enableHBTimer bool
 	hbInterval    time.Duration
+
+	// what to do with the sessions of a PFCP peer that restarted.
+	peerRestartPolicy string
+	peerRestartGrace  time.Duration
+	// how long the sessions of a lost association are kept.
+	assocLossGrace time.Duration
+	// how long a remote GTP-U peer may not respond before its path is failed.
+	gtpuPathFailureTimeout time.Duration
+
+	// persistent session store, nil if sessions are only kept in memory.
+	sessionDB *SessionDB
+	// whether the stored sessions are restored at startup, and how long they
+	// are kept until their CP functions set up new associations.
+	restoreSessions bool
+	restoreGrace    time.Duration
+
+	// period of the audit of the datapath rules, disabled if zero, and
+	// whether the drifts found are repaired.
+	auditInterval time.Duration
+	auditRepair   bool
+
+	// sessions and datapath entries accounted against the configured
+	// capacities.
+	capacity *capacityTracker
+
+	// whether the node drains before shutting down, and how long the CP
+	// functions are given to delete their sessions when draining.
+	drainOnShutdown bool
+	drainTimeout    time.Duration
 }
 
 // to be replaced with go-pfcp structs
@@ -79,6 +125,29 @@ const (
 	n9 = 0x2
 )
 
+// requestTimers returns the response timeout and the number of retries of the
+// PFCP requests.
+func (u *upf) requestTimers() (time.Duration, uint8) {
+	u.timersMu.RLock()
+	defer u.timersMu.RUnlock()
+
+	return u.respTimeout, u.maxReqRetries
+}
+
+func (u *upf) heartbeatInterval() time.Duration {
+	u.timersMu.RLock()
+	defer u.timersMu.RUnlock()
+
+	return u.hbInterval
+}
+
+func (u *upf) setTimers(respTimeout time.Duration, maxReqRetries uint8, hbInterval time.Duration) {
+	u.timersMu.Lock()
+	defer u.timersMu.Unlock()
+
+	u.respTimeout, u.maxReqRetries, u.hbInterval = respTimeout, maxReqRetries, hbInterval
+}
+
 func (u *upf) isConnected() bool {
 	return u.IsConnected(&u.accessIP)
 }
@@ -153,23 +222,33 @@ func NewUPF(conf *Conf, fp datapath) *upf {
 	}
 
 	u := &upf{
-		enableUeIPAlloc:   conf.CPIface.EnableUeIPAlloc,
-		enableEndMarker:   conf.EnableEndMarker,
-		enableFlowMeasure: conf.EnableFlowMeasure,
-		enableGtpuMonitor: conf.EnableGtpuPathMonitoring,
-		accessIface:       conf.AccessIface.IfName,
-		coreIface:         conf.CoreIface.IfName,
-		ippoolCidr:        conf.CPIface.UEIPPool,
-		nodeID:            nodeID,
-		datapath:          fp,
-		dnn:               conf.CPIface.Dnn,
-		peers:             conf.CPIface.Peers,
-		reportNotifyChan:  make(chan uint64, 1024),
-		maxReqRetries:     conf.MaxReqRetries,
-		enableHBTimer:     conf.EnableHBTimer,
-		readTimeout:       time.Second * time.Duration(conf.ReadTimeout),
-		fteidGenerator:    NewFTEIDGenerator(),
-		n4addr:            conf.N4Addr,
+		enableUeIPAlloc:     conf.CPIface.EnableUeIPAlloc,
+		enableEndMarker:     conf.EnableEndMarker,
+		enableFlowMeasure:   conf.EnableFlowMeasure,
+		enableGtpuMonitor:   conf.EnableGtpuPathMonitoring,
+		accessIface:         conf.AccessIface.IfName,
+		coreIface:           conf.CoreIface.IfName,
+		ippoolCidr:          conf.CPIface.UEIPPool,
+		ippoolCidr6:         conf.CPIface.UEIPPoolV6,
+		nodeID:              nodeID,
+		datapath:            fp,
+		dnn:                 conf.CPIface.Dnn,
+		peers:               conf.CPIface.Peers,
+		reportNotifyChan:    make(chan uint64, 1024),
+		errorIndicationChan: make(chan uint64, 1024),
+		datapathResyncChan:  make(chan struct{}, 1),
+		usage:               newUsageTracker(),
+		maxReqRetries:       conf.MaxReqRetries,
+		peerRestartPolicy:   conf.PeerRestartPolicy,
+		enableHBTimer:       conf.EnableHBTimer,
+		readTimeout:         time.Second * time.Duration(conf.ReadTimeout),
+		fteidGenerator:      NewFTEIDGenerator(),
+		counterIDs:          NewFTEIDGenerator(),
+		n4addr:              conf.N4Addr,
+		restoreSessions:     conf.RestoreSessions,
+		auditRepair:         conf.DatapathAuditRepair,
+		capacity:            newCapacityTracker(conf.MaxSessions, conf.DatapathCapacity),
+		drainOnShutdown:     conf.DrainOnShutdown,
 	}
 
 	if !setupPeersAndInterfaces(u, conf) {
@@ -178,6 +257,13 @@ func NewUPF(conf *Conf, fp datapath) *upf {
 
 	initTimersAndIPPool(u, conf)
 
+	if conf.SessionStorePath != "" {
+		u.sessionDB, err = OpenSessionDB(conf.SessionStorePath)
+		if err != nil {
+			logger.PfcpLog.Fatalf("unable to open session store: %v", err)
+		}
+	}
+
 	u.SetUpfInfo(u, conf)
 
 	return u
@@ -194,6 +280,37 @@ func setupPeersAndInterfaces(u *upf, conf *Conf) bool {
 		}
 	}
 
+	// The P4 switch terminates the tunnels on its own address, on both the
+	// access and core sides.
+	if conf.EnableP4rt {
+		u.accessIP, _, err = net.ParseCIDR(conf.P4rtcIface.AccessIP)
+		if err != nil {
+			logger.PfcpLog.Errorf("failed to parse P4 access IP %q: %v", conf.P4rtcIface.AccessIP, err)
+			return false
+		}
+
+		u.accessIP = u.accessIP.To4()
+		u.coreIP = u.accessIP
+
+		return true
+	}
+
+	// The Go datapath terminates the tunnels on its N3 address.
+	if conf.EnableGoDatapath {
+		var host string
+
+		host, _, err = net.SplitHostPort(conf.GoDatapath.N3Addr)
+		if err != nil {
+			logger.PfcpLog.Errorf("failed to parse Go datapath N3 address %q: %v", conf.GoDatapath.N3Addr, err)
+			return false
+		}
+
+		u.accessIP = net.ParseIP(host).To4()
+		u.coreIP = u.accessIP
+
+		return true
+	}
+
 	u.accessIP, err = GetUnicastAddressFromInterface(conf.AccessIface.IfName)
 	if err != nil {
 		logger.PfcpLog.Errorf("failed to get unicast address for access interface %q: %v", conf.AccessIface.IfName, err)
@@ -205,6 +322,11 @@ func setupPeersAndInterfaces(u *upf, conf *Conf) bool {
 		logger.PfcpLog.Errorf("failed to get unicast address for core interface %q: %v", conf.CoreIface.IfName, err)
 		return false
 	}
+
+	// The interfaces may not have any IPv6 address.
+	u.accessIP6, _ = GetUnicastIPv6AddressFromInterface(conf.AccessIface.IfName)
+	u.coreIP6, _ = GetUnicastIPv6AddressFromInterface(conf.CoreIface.IfName)
+
 	return true
 }
 
@@ -215,6 +337,47 @@ func initTimersAndIPPool(u *upf, conf *Conf) {
 		logger.PfcpLog.Fatalf("unable to parse resp_timeout %q: %v", conf.RespTimeout, err)
 	}
 
+	u.usagePollInterval, err = time.ParseDuration(conf.UsagePollInterval)
+	if err != nil {
+		logger.PfcpLog.Fatalf("unable to parse usage_poll_interval %q: %v", conf.UsagePollInterval, err)
+	}
+
+	u.peerRestartGrace, err = time.ParseDuration(conf.PeerRestartGracePeriod)
+	if err != nil {
+		logger.PfcpLog.Fatalf("unable to parse peer_restart_grace_period %q: %v", conf.PeerRestartGracePeriod, err)
+	}
+
+	u.assocLossGrace, err = time.ParseDuration(conf.AssociationLossGracePeriod)
+	if err != nil {
+		logger.PfcpLog.Fatalf("unable to parse association_loss_grace_period %q: %v", conf.AssociationLossGracePeriod, err)
+	}
+
+	if u.restoreSessions {
+		u.restoreGrace, err = time.ParseDuration(conf.SessionRestoreGracePeriod)
+		if err != nil {
+			logger.PfcpLog.Fatalf("unable to parse session_restore_grace_period %q: %v", conf.SessionRestoreGracePeriod, err)
+		}
+	}
+
+	u.drainTimeout, err = time.ParseDuration(conf.DrainTimeout)
+	if err != nil {
+		logger.PfcpLog.Fatalf("unable to parse drain_timeout %q: %v", conf.DrainTimeout, err)
+	}
+
+	if conf.DatapathAuditInterval != "" {
+		u.auditInterval, err = time.ParseDuration(conf.DatapathAuditInterval)
+		if err != nil {
+			logger.PfcpLog.Fatalf("unable to parse datapath_audit_interval %q: %v", conf.DatapathAuditInterval, err)
+		}
+	}
+
+	if u.enableGtpuMonitor {
+		u.gtpuPathFailureTimeout, err = time.ParseDuration(conf.GtpuPathFailureTimeout)
+		if err != nil {
+			logger.PfcpLog.Fatalf("unable to parse gtpu_path_failure_timeout %q: %v", conf.GtpuPathFailureTimeout, err)
+		}
+	}
+
 	if u.enableHBTimer {
 		if conf.HeartBeatInterval != "" {
 			u.hbInterval, err = time.ParseDuration(conf.HeartBeatInterval)
@@ -229,5 +392,11 @@ func initTimersAndIPPool(u *upf, conf *Conf) {
 		if err != nil {
 			logger.PfcpLog.Fatalf("ip pool init failed for CIDR %q: %v", u.ippoolCidr, err)
 		}
+
+		if u.ippoolCidr6 != "" {
+			if err = u.ippool.SetIPv6Pool(u.ippoolCidr6, defaultIPv6PrefixLen); err != nil {
+				logger.PfcpLog.Fatalf("ip pool init failed for IPv6 CIDR %q: %v", u.ippoolCidr6, err)
+			}
+		}
 	}
 }
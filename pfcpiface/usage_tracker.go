@@ -0,0 +1,314 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wmnsk/go-pfcp/ie"
+)
+
+// usageVolume is the traffic accounted to a URR.
+type usageVolume struct {
+	ulBytes   uint64
+	dlBytes   uint64
+	ulPackets uint64
+	dlPackets uint64
+}
+
+func (v *usageVolume) add(o usageVolume) {
+	v.ulBytes += o.ulBytes
+	v.dlBytes += o.dlBytes
+	v.ulPackets += o.ulPackets
+	v.dlPackets += o.dlPackets
+}
+
+type urrKey struct {
+	seid  uint64
+	urrID uint32
+}
+
+// urrUsage is the measurement state of a single URR.
+type urrUsage struct {
+	seqn uint32
+	// start of the current measurement, i.e. time of the last report.
+	start time.Time
+	// start of the current measurement period for periodic reporting.
+	periodStart time.Time
+	// volume measured since the last report.
+	volume usageVolume
+
+	// quota accounting, reset whenever the CP provides a new quota.
+	quotaStart     time.Time
+	quotaVolume    usageVolume
+	quotaExhausted bool
+}
+
+// usageReport is a single Usage Report to be sent to the CP function.
+type usageReport struct {
+	urrID    uint32
+	seqn     uint32
+	trigger  reportingTriggers
+	start    time.Time
+	end      time.Time
+	volume   usageVolume
+	measured urr
+}
+
+// ies returns the IEs of the grouped Usage Report IE.
+func (r usageReport) ies() []*ie.IE {
+	ies := []*ie.IE{
+		ie.NewURRID(r.urrID),
+		ie.NewURSEQN(r.seqn),
+		ie.NewUsageReportTrigger(uint8(r.trigger), uint8(r.trigger>>8), uint8(r.trigger>>16)),
+		ie.NewStartTime(r.start),
+		ie.NewEndTime(r.end),
+	}
+
+	if r.measured.measureVolume {
+		v := r.volume
+		ies = append(ies, ie.NewVolumeMeasurement(0x3f,
+			v.ulBytes+v.dlBytes, v.ulBytes, v.dlBytes,
+			v.ulPackets+v.dlPackets, v.ulPackets, v.dlPackets))
+	}
+
+	if r.measured.measureDuration {
+		ies = append(ies, ie.NewDurationMeasurement(r.end.Sub(r.start)))
+	}
+
+	return ies
+}
+
+// usageTracker turns the cumulative per-PDR datapath counters into the usage
+// measured by each URR, and decides when usage has to be reported.
+type usageTracker struct {
+	mu sync.Mutex
+	// datapath counters seen at the last accumulation, per PDR.
+	lastPDR map[pdrCounterKey]pdrCounters
+	urrs    map[urrKey]*urrUsage
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{
+		lastPDR: make(map[pdrCounterKey]pdrCounters),
+		urrs:    make(map[urrKey]*urrUsage),
+	}
+}
+
+// active returns true if any URR is being measured.
+func (t *usageTracker) active() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.urrs) > 0
+}
+
+// track starts measuring for a newly created URR.
+func (t *usageTracker) track(seid uint64, u urr, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.urrs[urrKey{seid, u.urrID}] = &urrUsage{
+		start:       now,
+		periodStart: now,
+		quotaStart:  now,
+	}
+}
+
+// update applies an Update URR. A new quota restarts quota accounting.
+func (t *usageTracker) update(seid uint64, old, u urr, quotaRenewed bool, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.urrs[urrKey{seid, u.urrID}]
+	if !ok {
+		return
+	}
+
+	if old.measurementPeriod != u.measurementPeriod {
+		st.periodStart = now
+	}
+
+	if quotaRenewed {
+		st.quotaStart = now
+		st.quotaVolume = usageVolume{}
+		st.quotaExhausted = false
+	}
+}
+
+func (t *usageTracker) untrack(seid uint64, urrID uint32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.urrs, urrKey{seid, urrID})
+}
+
+// forgetPDR drops the counter baseline of a removed PDR.
+func (t *usageTracker) forgetPDR(p pdr) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.lastPDR, pdrCounterKey{fseID: p.fseID, pdrID: p.pdrID})
+}
+
+// removeSession drops all the state of a session.
+func (t *usageTracker) removeSession(s *PFCPSession) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, p := range s.pdrs {
+		delete(t.lastPDR, pdrCounterKey{fseID: p.fseID, pdrID: p.pdrID})
+	}
+
+	for _, u := range s.urrs {
+		delete(t.urrs, urrKey{s.localSEID, u.urrID})
+	}
+}
+
+// accumulate adds the traffic counted since the last call to the URRs
+// referenced by the PDRs of the session. A PDR seen for the first time is
+// accounted from zero, which matches a freshly installed datapath rule.
+func (t *usageTracker) accumulate(s *PFCPSession, counters map[pdrCounterKey]pdrCounters) {
+	if len(counters) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, p := range s.pdrs {
+		key := pdrCounterKey{fseID: p.fseID, pdrID: p.pdrID}
+
+		cur, ok := counters[key]
+		if !ok {
+			continue
+		}
+
+		delta := cur
+		if last, ok := t.lastPDR[key]; ok && cur.bytes >= last.bytes && cur.packets >= last.packets {
+			delta.bytes -= last.bytes
+			delta.packets -= last.packets
+		}
+
+		t.lastPDR[key] = cur
+
+		var vol usageVolume
+		if p.IsUplink() {
+			vol.ulBytes, vol.ulPackets = delta.bytes, delta.packets
+		} else {
+			vol.dlBytes, vol.dlPackets = delta.bytes, delta.packets
+		}
+
+		for _, urrID := range p.urrIDList {
+			if st, ok := t.urrs[urrKey{s.localSEID, urrID}]; ok {
+				st.volume.add(vol)
+				st.quotaVolume.add(vol)
+			}
+		}
+	}
+}
+
+// exhausted returns true if the quota of the URR is used up and the CP
+// function has not provided a new one yet.
+func (t *usageTracker) exhausted(seid uint64, urrID uint32) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.urrs[urrKey{seid, urrID}]
+
+	return ok && st.quotaExhausted
+}
+
+// dueReports returns the usage reports of the session whose reporting triggers
+// are met at time now. exhausted is true if the quota of any URR got used up,
+// whether or not its reporting is requested.
+func (t *usageTracker) dueReports(s *PFCPSession, now time.Time) (reports []usageReport, exhausted bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, u := range s.urrs {
+		st, ok := t.urrs[urrKey{s.localSEID, u.urrID}]
+		if !ok {
+			continue
+		}
+
+		var trigger reportingTriggers
+
+		if u.hasTrigger(triggerPERIO) && u.measurementPeriod > 0 &&
+			now.Sub(st.periodStart) >= u.measurementPeriod {
+			trigger |= triggerPERIO
+			st.periodStart = now
+		}
+
+		if u.hasTrigger(triggerVOLTH) && u.volumeThreshold.isSet() &&
+			u.volumeThreshold.reachedBy(st.volume) {
+			trigger |= triggerVOLTH
+		}
+
+		if u.hasTrigger(triggerTIMTH) && u.timeThreshold > 0 &&
+			now.Sub(st.start) >= u.timeThreshold {
+			trigger |= triggerTIMTH
+		}
+
+		if !st.quotaExhausted {
+			volumeUsed := u.volumeQuota.isSet() && u.volumeQuota.reachedBy(st.quotaVolume)
+			timeUsed := u.timeQuota > 0 && now.Sub(st.quotaStart) >= u.timeQuota
+
+			if volumeUsed && u.hasTrigger(triggerVOLQU) {
+				trigger |= triggerVOLQU
+			}
+
+			if timeUsed && u.hasTrigger(triggerTIMQU) {
+				trigger |= triggerTIMQU
+			}
+
+			if volumeUsed || timeUsed {
+				st.quotaExhausted = true
+				exhausted = true
+			}
+		}
+
+		if trigger == 0 {
+			continue
+		}
+
+		reports = append(reports, st.report(u, trigger, now))
+	}
+
+	return reports, exhausted
+}
+
+// reportNow returns a report for the given URR regardless of its triggers,
+// e.g. in response to a Query URR or when the URR or its session is removed.
+func (t *usageTracker) reportNow(seid uint64, u urr, trigger reportingTriggers, now time.Time) (usageReport, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.urrs[urrKey{seid, u.urrID}]
+	if !ok {
+		return usageReport{}, false
+	}
+
+	return st.report(u, trigger, now), true
+}
+
+// report builds a report out of the current measurement and starts a new one.
+func (st *urrUsage) report(u urr, trigger reportingTriggers, now time.Time) usageReport {
+	r := usageReport{
+		urrID:    u.urrID,
+		seqn:     st.seqn,
+		trigger:  trigger,
+		start:    st.start,
+		end:      now,
+		volume:   st.volume,
+		measured: u,
+	}
+
+	st.seqn++
+	st.start = now
+	st.volume = usageVolume{}
+
+	return r
+}
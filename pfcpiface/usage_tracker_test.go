@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package pfcpiface
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wmnsk/go-pfcp/ie"
+	"github.com/wmnsk/go-pfcp/message"
+)
+
+func newUsageTestSession(urrs ...urr) *PFCPSession {
+	const seid = uint64(1)
+
+	ids := make([]uint32, 0, len(urrs))
+	for _, u := range urrs {
+		ids = append(ids, u.urrID)
+	}
+
+	return &PFCPSession{
+		localSEID: seid,
+		PacketForwardingRules: PacketForwardingRules{
+			pdrs: []pdr{
+				{pdrID: 1, fseID: seid, srcIface: access, urrIDList: ids},
+				{pdrID: 2, fseID: seid, srcIface: core, urrIDList: ids},
+			},
+			urrs: urrs,
+		},
+	}
+}
+
+func usageCounters(ulBytes, dlBytes uint64) map[pdrCounterKey]pdrCounters {
+	return map[pdrCounterKey]pdrCounters{
+		{fseID: 1, pdrID: 1}: {packets: ulBytes / 100, bytes: ulBytes},
+		{fseID: 1, pdrID: 2}: {packets: dlBytes / 100, bytes: dlBytes},
+	}
+}
+
+func TestUsageTracker_VolumeThreshold(t *testing.T) {
+	u := urr{urrID: 1, measureVolume: true, triggers: triggerVOLTH, volumeThreshold: volumeLimit{total: 1000}}
+	s := newUsageTestSession(u)
+	tracker := newUsageTracker()
+	start := time.Now()
+
+	tracker.track(s.localSEID, u, start)
+
+	tracker.accumulate(s, usageCounters(300, 400))
+
+	if reports, _ := tracker.dueReports(s, start.Add(time.Second)); len(reports) != 0 {
+		t.Fatalf("expected no report below threshold, got %v", reports)
+	}
+
+	tracker.accumulate(s, usageCounters(500, 600))
+
+	reports, _ := tracker.dueReports(s, start.Add(2*time.Second))
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reports))
+	}
+
+	r := reports[0]
+	if r.trigger != triggerVOLTH || r.seqn != 0 {
+		t.Errorf("unexpected trigger %#x or sequence number %v", r.trigger, r.seqn)
+	}
+
+	expected := usageVolume{ulBytes: 500, dlBytes: 600, ulPackets: 5, dlPackets: 6}
+	if r.volume != expected {
+		t.Errorf("expected volume %+v, got %+v", expected, r.volume)
+	}
+
+	// The measurement restarts after a report.
+	tracker.accumulate(s, usageCounters(600, 700))
+
+	if reports, _ = tracker.dueReports(s, start.Add(3*time.Second)); len(reports) != 0 {
+		t.Fatalf("expected no report after reset, got %v", reports)
+	}
+
+	r, ok := tracker.reportNow(s.localSEID, u, triggerTERMR, start.Add(4*time.Second))
+	if !ok {
+		t.Fatal("expected final report")
+	}
+
+	if r.seqn != 1 || r.volume.ulBytes != 100 || r.volume.dlBytes != 100 {
+		t.Errorf("unexpected final report %+v", r)
+	}
+}
+
+func TestUsageTracker_Quota(t *testing.T) {
+	u := urr{
+		urrID: 1, measureVolume: true, measureDuration: true,
+		triggers: triggerVOLQU | triggerTIMQU, volumeQuota: volumeLimit{downlink: 1000}, timeQuota: time.Minute,
+	}
+	s := newUsageTestSession(u)
+	tracker := newUsageTracker()
+	start := time.Now()
+
+	tracker.track(s.localSEID, u, start)
+	tracker.accumulate(s, usageCounters(5000, 1000))
+
+	reports, exhausted := tracker.dueReports(s, start.Add(time.Second))
+	if len(reports) != 1 || reports[0].trigger != triggerVOLQU {
+		t.Fatalf("expected a VOLQU report, got %v", reports)
+	}
+
+	if !exhausted || !tracker.exhausted(s.localSEID, u.urrID) {
+		t.Fatal("expected the quota to be exhausted")
+	}
+
+	// Exhausted quota is reported once, until a new quota is granted.
+	if reports, _ = tracker.dueReports(s, start.Add(2*time.Minute)); len(reports) != 0 {
+		t.Fatalf("expected no report for exhausted quota, got %v", reports)
+	}
+
+	renewed := start.Add(3 * time.Minute)
+	tracker.update(s.localSEID, u, u, true, renewed)
+
+	if tracker.exhausted(s.localSEID, u.urrID) {
+		t.Fatal("expected a new quota to be available")
+	}
+
+	reports, _ = tracker.dueReports(s, renewed.Add(time.Minute))
+	if len(reports) != 1 || reports[0].trigger != triggerTIMQU {
+		t.Fatalf("expected a TIMQU report, got %v", reports)
+	}
+}
+
+func TestUsageTracker_Periodic(t *testing.T) {
+	u := urr{urrID: 3, measureDuration: true, triggers: triggerPERIO, measurementPeriod: 10 * time.Second}
+	s := newUsageTestSession(u)
+	tracker := newUsageTracker()
+	start := time.Now()
+
+	tracker.track(s.localSEID, u, start)
+
+	if reports, _ := tracker.dueReports(s, start.Add(5*time.Second)); len(reports) != 0 {
+		t.Fatalf("expected no report before the period, got %v", reports)
+	}
+
+	reports, _ := tracker.dueReports(s, start.Add(10*time.Second))
+	if len(reports) != 1 || reports[0].trigger != triggerPERIO {
+		t.Fatalf("expected a PERIO report, got %v", reports)
+	}
+
+	if got := reports[0].end.Sub(reports[0].start); got != 10*time.Second {
+		t.Errorf("expected a 10s measurement, got %v", got)
+	}
+
+	tracker.removeSession(s)
+
+	if tracker.active() {
+		t.Error("expected no URR to be tracked after session removal")
+	}
+}
+
+func TestQuotaEnforcement(t *testing.T) {
+	dp := &ruleDP{rules: make(map[string]string)}
+	node := &PFCPNode{upf: &upf{datapath: dp, usage: newUsageTracker()}}
+	pConn := newTestPFCPConn(node, 1)
+	pConn.nodeID.remote = "smf"
+	pConn.nodeID.localIE = ie.NewNodeID("", "", "upf")
+
+	session, ok := pConn.NewPFCPSession(1)
+	if !ok {
+		t.Fatal("failed to allocate session")
+	}
+
+	seid := session.localSEID
+	// Exhausted quotas are enforced even if their reporting isn't requested.
+	u := urr{urrID: 1, measureVolume: true, volumeQuota: volumeLimit{total: 1000}}
+
+	session.CreatePDR(pdr{pdrID: 1, fseID: seid, srcIface: access, farID: 1, urrIDList: []uint32{1}})
+	session.CreatePDR(pdr{pdrID: 2, fseID: seid, srcIface: core, farID: 2})
+	session.CreateFAR(far{farID: 1, fseID: seid, applyAction: ActionForward, dstIntf: 1})
+	session.CreateFAR(far{farID: 2, fseID: seid, applyAction: ActionForward})
+	session.CreateURR(u)
+
+	if err := pConn.store.PutSession(session); err != nil {
+		t.Fatalf("failed to store session: %v", err)
+	}
+
+	if err := writeRules(dp, upfMsgTypeAdd, PacketForwardingRules{}, session.PacketForwardingRules); err != nil {
+		t.Fatalf("failed to install session: %v", err)
+	}
+
+	now := time.Now()
+	node.upf.usage.track(seid, u, now)
+
+	forwards := func(t *testing.T, farID uint32, want bool) {
+		t.Helper()
+
+		s, _ := pConn.store.GetSession(seid)
+		for _, f := range s.fars {
+			if f.farID != farID {
+				continue
+			}
+
+			if f.Forwards() != want || f.Drops() == want {
+				t.Errorf("expected FAR %v forwarding %v, got %v", farID, want, f)
+			}
+
+			if dp.rules[farKey(f)] != f.String() {
+				t.Errorf("datapath FAR %v differs from stored %v", dp.rules[farKey(f)], f)
+			}
+		}
+	}
+
+	pConn.reportUsage(map[pdrCounterKey]pdrCounters{{fseID: seid, pdrID: 1}: {packets: 5, bytes: 500}}, now)
+	forwards(t, 1, true)
+
+	pConn.reportUsage(map[pdrCounterKey]pdrCounters{{fseID: seid, pdrID: 1}: {packets: 10, bytes: 1000}}, now)
+	forwards(t, 1, false)
+	forwards(t, 2, true)
+
+	req := message.NewSessionModificationRequest(0, 0, seid, 1, 0,
+		ie.NewUpdateURR(ie.NewURRID(1), ie.NewVolumeQuota(0x01, 1000, 0, 0)))
+
+	if _, err := pConn.handleSessionModificationRequest(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	forwards(t, 1, true)
+}
@@ -45,12 +45,28 @@ func setEndMarkerFeature(features ...uint8) {
 	}
 }
 
+func has1stBit(f uint8) bool {
+	return f&0x01 == 1
+}
+
 func has2ndBit(f uint8) bool {
 	return (f&0x02)>>1 == 1
 }
 
+func has4thBit(f uint8) bool {
+	return (f&0x08)>>3 == 1
+}
+
 func has5thBit(f uint8) bool {
-	return (f & 0x010) == 1
+	return (f&0x10)>>4 == 1
+}
+
+func has6thBit(f uint8) bool {
+	return (f&0x20)>>5 == 1
+}
+
+func has7thBit(f uint8) bool {
+	return (f&0x40)>>6 == 1
 }
 
 func inc(ip net.IP) {
@@ -114,7 +130,8 @@ func calcBurstSizeFromRate(kbps uint64, ms uint64) uint64 {
 	return uint64((float64(kbps) * 1000 / 8) * (float64(ms) / 1000))
 }
 
-// GetUnicastAddressFromInterface returns a unicast IP address configured on the interface.
+// GetUnicastAddressFromInterface returns the first IPv4 unicast address
+// configured on the interface.
 func GetUnicastAddressFromInterface(interfaceName string) (net.IP, error) {
 	iface, err := net.InterfaceByName(interfaceName)
 	if err != nil {
@@ -126,10 +143,44 @@ func GetUnicastAddressFromInterface(interfaceName string) (net.IP, error) {
 		return nil, err
 	}
 
-	ip, _, err := net.ParseCIDR(addresses[0].String())
+	// IPv6 addresses, such as the link-local one, may come first.
+	for _, addr := range addresses {
+		ip, _, err := net.ParseCIDR(addr.String())
+		if err != nil {
+			continue
+		}
+
+		if ip.To4() != nil && !ip.IsLinkLocalUnicast() && !ip.IsMulticast() && !ip.IsUnspecified() {
+			return ip, nil
+		}
+	}
+
+	return nil, ErrNotFoundWithParam("IPv4 unicast address", "interface", interfaceName)
+}
+
+// GetUnicastIPv6AddressFromInterface returns the first global unicast IPv6
+// address of the interface, or nil if it has none.
+func GetUnicastIPv6AddressFromInterface(interfaceName string) (net.IP, error) {
+	iface, err := net.InterfaceByName(interfaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := iface.Addrs()
 	if err != nil {
 		return nil, err
 	}
 
-	return ip, nil
+	for _, addr := range addresses {
+		ip, _, err := net.ParseCIDR(addr.String())
+		if err != nil {
+			continue
+		}
+
+		if ip.To4() == nil && ip.IsGlobalUnicast() {
+			return ip, nil
+		}
+	}
+
+	return nil, nil
 }
@@ -5,9 +5,13 @@ package pfcpiface
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/omec-project/upf-epc/logger"
 )
@@ -74,6 +78,277 @@ func (c *ConfigHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// DatapathAuditHandler serves the audit of the datapath rules.
+type DatapathAuditHandler struct {
+	node *PFCPNode
+}
+
+func setupDatapathAuditHandler(mux *http.ServeMux, node *PFCPNode) {
+	mux.Handle("/v1/datapath/audit", &DatapathAuditHandler{node: node})
+}
+
+func (h *DatapathAuditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger.PfcpLog.Infoln("handle http request for /v1/datapath/audit")
+
+	switch r.Method {
+	case "GET":
+		report, _, _ := h.node.audit.lastReport()
+		if report == nil {
+			sendJSONResp(http.StatusNotFound, map[string]string{"message": "no datapath audit done yet"}, w)
+			return
+		}
+
+		sendJSONResp(http.StatusOK, report, w)
+	case "POST":
+		// The drifts found are only repaired if requested.
+		repair, err := strconv.ParseBool(r.URL.Query().Get("repair"))
+		if err != nil && r.URL.Query().Has("repair") {
+			sendJSONResp(http.StatusBadRequest, map[string]string{"message": "invalid repair parameter"}, w)
+			return
+		}
+
+		sendJSONResp(http.StatusOK, h.node.auditDatapath(repair), w)
+	default:
+		logger.PfcpLog.Infoln(w, "sorry, only GET and POST methods are supported")
+		sendHTTPResp(http.StatusMethodNotAllowed, w)
+	}
+}
+
+// SessionsHandler serves the PFCP sessions of all the associations.
+type SessionsHandler struct {
+	node *PFCPNode
+}
+
+func setupSessionsHandler(mux *http.ServeMux, node *PFCPNode) {
+	h := &SessionsHandler{node: node}
+	mux.Handle("/v1/sessions", h)
+	mux.Handle("/v1/sessions/", h)
+}
+
+func (h *SessionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger.PfcpLog.Infoln("handle http request for", r.URL.Path)
+
+	if r.Method != "GET" {
+		logger.PfcpLog.Infoln(w, "sorry, only GET method is supported")
+		sendHTTPResp(http.StatusMethodNotAllowed, w)
+
+		return
+	}
+
+	// The local SEID is decimal, or hexadecimal with the 0x prefix.
+	if id := strings.TrimPrefix(r.URL.Path, "/v1/sessions/"); id != r.URL.Path {
+		seid, err := strconv.ParseUint(id, 0, 64)
+		if err != nil {
+			sendJSONResp(http.StatusBadRequest, map[string]string{"message": "invalid SEID"}, w)
+			return
+		}
+
+		session, ok := h.node.sessionView(seid)
+		if !ok {
+			sendJSONResp(http.StatusNotFound, map[string]string{"message": "session not found"}, w)
+			return
+		}
+
+		sendJSONResp(http.StatusOK, session, w)
+
+		return
+	}
+
+	var ueIP net.IP
+
+	if r.URL.Query().Has("ue_ip") {
+		if ueIP = net.ParseIP(r.URL.Query().Get("ue_ip")); ueIP == nil {
+			sendJSONResp(http.StatusBadRequest, map[string]string{"message": "invalid UE IP"}, w)
+			return
+		}
+	}
+
+	sendJSONResp(http.StatusOK, h.node.sessionViews(ueIP), w)
+}
+
+// PeersHandler serves the associations with the N4 peers, and adds and
+// releases them.
+type PeersHandler struct {
+	node *PFCPNode
+}
+
+// n4Peer is the body of the requests adding an N4 peer.
+type n4Peer struct {
+	Peer string `json:"peer"`
+}
+
+func setupPeersHandler(mux *http.ServeMux, node *PFCPNode) {
+	h := &PeersHandler{node: node}
+	mux.Handle("/v1/peers", h)
+	mux.Handle("/v1/peers/", h)
+}
+
+func (h *PeersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger.PfcpLog.Infoln("handle http request for", r.URL.Path)
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/peers")
+	nodeID, action, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+	switch {
+	case path == "" && r.Method == "GET":
+		sendJSONResp(http.StatusOK, h.node.peerViews(), w)
+	case path == "" && r.Method == "POST":
+		h.addPeer(w, r)
+	case nodeID != "" && action == "" && r.Method == "GET":
+		pConn, sessions, ok := h.node.peerConn(nodeID)
+		if !ok {
+			sendJSONResp(http.StatusNotFound, map[string]string{"message": "peer not found"}, w)
+			return
+		}
+
+		sendJSONResp(http.StatusOK, pConn.peerView(sessions), w)
+	case nodeID != "" && action == "release" && r.Method == "POST":
+		pConn, _, ok := h.node.peerConn(nodeID)
+		if !ok {
+			sendJSONResp(http.StatusNotFound, map[string]string{"message": "peer not found"}, w)
+			return
+		}
+
+		if err := pConn.requestAssociationRelease(0); err != nil {
+			sendJSONResp(http.StatusBadGateway, map[string]string{"message": err.Error()}, w)
+			return
+		}
+
+		sendJSONResp(http.StatusAccepted, map[string]string{"message": "association release requested"}, w)
+	case nodeID != "" && (action == "" || action == "release"):
+		logger.PfcpLog.Infoln(w, "sorry, method not supported")
+		sendHTTPResp(http.StatusMethodNotAllowed, w)
+	default:
+		sendJSONResp(http.StatusNotFound, map[string]string{"message": "not found"}, w)
+	}
+}
+
+func (h *PeersHandler) addPeer(w http.ResponseWriter, r *http.Request) {
+	var peer n4Peer
+
+	if err := json.NewDecoder(r.Body).Decode(&peer); err != nil || peer.Peer == "" {
+		sendJSONResp(http.StatusBadRequest, map[string]string{"message": "invalid peer"}, w)
+		return
+	}
+
+	switch err := h.node.addN4Peer(peer.Peer); {
+	case errors.Is(err, errPeerExists):
+		sendJSONResp(http.StatusConflict, map[string]string{"message": err.Error()}, w)
+	case err != nil:
+		sendJSONResp(http.StatusBadGateway, map[string]string{"message": err.Error()}, w)
+	default:
+		sendJSONResp(http.StatusCreated, map[string]string{"message": "association setup requested"}, w)
+	}
+}
+
+// DrainHandler serves the drain of the PFCP node, and starts it.
+type DrainHandler struct {
+	node *PFCPNode
+}
+
+func setupDrainHandler(mux *http.ServeMux, node *PFCPNode) {
+	mux.Handle("/v1/drain", &DrainHandler{node: node})
+}
+
+func (h *DrainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger.PfcpLog.Infoln("handle http request for /v1/drain")
+
+	switch r.Method {
+	case "GET":
+		sendJSONResp(http.StatusOK, h.node.drainState(), w)
+	case "POST":
+		if _, ok := h.node.startDrain(); !ok {
+			sendJSONResp(http.StatusConflict, map[string]string{"message": ErrDraining.Error()}, w)
+			return
+		}
+
+		sendJSONResp(http.StatusAccepted, h.node.drainState(), w)
+	default:
+		logger.PfcpLog.Infoln(w, "sorry, only GET and POST methods are supported")
+		sendHTTPResp(http.StatusMethodNotAllowed, w)
+	}
+}
+
+// ReloadHandler reloads the config file.
+type ReloadHandler struct {
+	reload func() (reloadResult, error)
+}
+
+func setupReloadHandler(mux *http.ServeMux, reload func() (reloadResult, error)) {
+	mux.Handle("/v1/config/reload", &ReloadHandler{reload: reload})
+}
+
+func (h *ReloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger.PfcpLog.Infoln("handle http request for /v1/config/reload")
+
+	if r.Method != "POST" {
+		logger.PfcpLog.Infoln(w, "sorry, only POST method is supported")
+		sendHTTPResp(http.StatusMethodNotAllowed, w)
+
+		return
+	}
+
+	result, err := h.reload()
+
+	switch {
+	case errors.Is(err, ErrNotReloadable), errors.Is(err, errInvalidArgument), errors.Is(err, errUnsupported):
+		sendJSONResp(http.StatusBadRequest, map[string]string{"message": err.Error()}, w)
+	case err != nil:
+		sendJSONResp(http.StatusInternalServerError, map[string]string{"message": err.Error()}, w)
+	default:
+		sendJSONResp(http.StatusOK, result, w)
+	}
+}
+
+// HealthHandler serves the liveness or readiness checks of the UPF.
+type HealthHandler struct {
+	node      *PFCPNode
+	readiness bool
+}
+
+func setupHealthHandlers(mux *http.ServeMux, node *PFCPNode) {
+	mux.Handle("/healthz", &HealthHandler{node: node})
+	mux.Handle("/readyz", &HealthHandler{node: node, readiness: true})
+}
+
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Probes are frequent, their requests are not logged at info level.
+	logger.PfcpLog.Debugln("handle http request for", r.URL.Path)
+
+	if r.Method != "GET" {
+		logger.PfcpLog.Infoln(w, "sorry, only GET method is supported")
+		sendHTTPResp(http.StatusMethodNotAllowed, w)
+
+		return
+	}
+
+	report := h.node.health(h.readiness)
+	if report.Status != healthOK {
+		sendJSONResp(http.StatusServiceUnavailable, report, w)
+		return
+	}
+
+	sendJSONResp(http.StatusOK, report, w)
+}
+
+func sendJSONResp(status int, v any, w http.ResponseWriter) {
+	jsonResp, err := json.Marshal(v)
+	if err != nil {
+		logger.PfcpLog.Errorln("error happened in JSON marshal:", err)
+		sendHTTPResp(http.StatusInternalServerError, w)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, err = w.Write(jsonResp)
+	if err != nil {
+		logger.PfcpLog.Errorln("http response write failed:", err)
+	}
+}
+
 func sendHTTPResp(status int, w http.ResponseWriter) {
 	w.WriteHeader(status)
 	w.Header().Set("Content-Type", "application/json")
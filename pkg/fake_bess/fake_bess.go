@@ -51,6 +51,23 @@ func (b *FakeBESS) Stop() {
 	b.grpcServer.Stop()
 }
 
+// Pauses returns the number of times the workers were paused, and whether
+// they are paused now.
+func (b *FakeBESS) Pauses() (int, bool) {
+	b.service.mtx.Lock()
+	defer b.service.mtx.Unlock()
+
+	return b.service.pauses, b.service.paused
+}
+
+// FailResumes makes the next n calls to resume the workers fail.
+func (b *FakeBESS) FailResumes(n int) {
+	b.service.mtx.Lock()
+	defer b.service.mtx.Unlock()
+
+	b.service.failResumes = n
+}
+
 func (b *FakeBESS) GetPdrTableEntries() (entries map[uint32][]FakePdr) {
 	entries = make(map[uint32][]FakePdr)
 	msgs := b.service.GetOrAddModule(pdrLookupModuleName).GetState()
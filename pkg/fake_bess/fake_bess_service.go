@@ -27,6 +27,7 @@ const (
 	addCmd                       = "add"
 	clearCmd                     = "clear"
 	deleteCmd                    = "delete"
+	runtimeConfigCmd             = "get_runtime_config"
 )
 
 type FakePdr struct {
@@ -147,6 +148,13 @@ type fakeBessService struct {
 	bess_pb.UnimplementedBESSControlServer
 	modules map[string]module
 	mtx     sync.Mutex
+
+	// paused is set between PauseAll and ResumeAll, pauses counts the calls
+	// to PauseAll.
+	paused bool
+	pauses int
+	// failResumes is the number of the next calls to ResumeAll that fail.
+	failResumes int
 }
 
 func newFakeBESSService() *fakeBessService {
@@ -198,11 +206,50 @@ func (b *fakeBessService) GetPortStats(ctx context.Context, request *bess_pb.Get
 	return &bess_pb.GetPortStatsResponse{}, nil
 }
 
+func (b *fakeBessService) PauseAll(ctx context.Context, request *bess_pb.EmptyRequest) (*bess_pb.EmptyResponse, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.paused = true
+	b.pauses++
+
+	return &bess_pb.EmptyResponse{}, nil
+}
+
+func (b *fakeBessService) ResumeAll(ctx context.Context, request *bess_pb.EmptyRequest) (*bess_pb.EmptyResponse, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if b.failResumes > 0 {
+		b.failResumes--
+		return nil, status.Error(codes.Unavailable, "failed to resume workers")
+	}
+
+	b.paused = false
+
+	return &bess_pb.EmptyResponse{}, nil
+}
+
 func (b *fakeBessService) ModuleCommand(ctx context.Context, request *bess_pb.CommandRequest) (*bess_pb.CommandResponse, error) {
 	b.mtx.Lock()
 	defer b.mtx.Unlock()
 
 	m := b.unsafeGetOrAddModule(request.Name)
+
+	if request.Cmd == runtimeConfigCmd {
+		rm, ok := m.(runtimeConfigModule)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid command: %v", request.Cmd)
+		}
+
+		data, err := anypb.New(rm.RuntimeConfig())
+		if err != nil {
+			return nil, err
+		}
+
+		return &bess_pb.CommandResponse{Data: data}, nil
+	}
+
 	if err := m.HandleRequest(request.Cmd, request.Arg); err != nil {
 		return nil, err
 	}
@@ -301,6 +348,11 @@ type module interface {
 	GetState() []proto.Message
 }
 
+// Fake BESS module whose rules can be read back
+type runtimeConfigModule interface {
+	RuntimeConfig() proto.Message
+}
+
 type baseModule struct {
 	name string
 }
@@ -329,6 +381,14 @@ func (w *wildcardModule) GetState() (msgs []proto.Message) {
 	return msgs
 }
 
+func (w *wildcardModule) RuntimeConfig() proto.Message {
+	cfg := &bess_pb.WildcardMatchConfig{}
+	for _, e := range w.entries {
+		cfg.Rules = append(cfg.Rules, proto.Clone(e).(*bess_pb.WildcardMatchCommandAddArg))
+	}
+	return cfg
+}
+
 func (w *wildcardModule) HandleRequest(cmd string, arg *anypb.Any) (err error) {
 	if err = w.baseModule.HandleRequest(cmd, arg); err != nil {
 		return err
@@ -404,6 +464,14 @@ func (e *exactMatchModule) GetState() (msgs []proto.Message) {
 	return
 }
 
+func (e *exactMatchModule) RuntimeConfig() proto.Message {
+	cfg := &bess_pb.ExactMatchConfig{}
+	for _, em := range e.entries {
+		cfg.Rules = append(cfg.Rules, proto.Clone(em).(*bess_pb.ExactMatchCommandAddArg))
+	}
+	return cfg
+}
+
 func (e *exactMatchModule) HandleRequest(cmd string, arg *anypb.Any) (err error) {
 	if err = e.baseModule.HandleRequest(cmd, arg); err != nil {
 		return err
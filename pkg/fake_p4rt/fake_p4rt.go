@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package fake_p4rt
+
+import (
+	"context"
+	"math/big"
+	"net"
+
+	p4 "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/grpc"
+)
+
+type FakeP4RT struct {
+	grpcServer *grpc.Server
+	service    *fakeP4RTService
+}
+
+// FakeTableEntry is a table entry of the fake P4Runtime server, with its
+// match fields, action and action parameters by name.
+type FakeTableEntry struct {
+	Match    map[string]uint64
+	Action   string
+	Params   map[string]uint64
+	Priority int32
+}
+
+// NewFakeP4RT creates a new fake P4Runtime gRPC server running the UP4
+// pipeline. Its tables, meters and counters can be programmed in the same way
+// as those of a real switch and keep track of their state.
+func NewFakeP4RT() *FakeP4RT {
+	return &FakeP4RT{
+		grpcServer: grpc.NewServer(),
+		service:    newFakeP4RTService(UP4P4Info()),
+	}
+}
+
+// Run starts and runs the P4Runtime gRPC server on the given address. Blocking until Stop is called.
+func (f *FakeP4RT) Run(address string) error {
+	var lc net.ListenConfig
+	listener, err := lc.Listen(context.Background(), "tcp", address)
+	if err != nil {
+		return err
+	}
+
+	p4.RegisterP4RuntimeServer(f.grpcServer, f.service)
+
+	// Blocking
+	return f.grpcServer.Serve(listener)
+}
+
+// Stop the P4Runtime gRPC server.
+func (f *FakeP4RT) Stop() {
+	f.grpcServer.Stop()
+}
+
+// TableEntries returns the entries of the table with the given name.
+func (f *FakeP4RT) TableEntries(table string) []FakeTableEntry {
+	s := f.service
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.tableByName(table)
+	if t == nil {
+		return nil
+	}
+
+	var entries []FakeTableEntry
+
+	for _, e := range s.tables[t.Preamble.Id] {
+		fe := FakeTableEntry{
+			Match:    make(map[string]uint64),
+			Params:   make(map[string]uint64),
+			Priority: e.Priority,
+		}
+
+		for _, m := range e.Match {
+			for _, mf := range t.MatchFields {
+				if mf.Id == m.FieldId {
+					fe.Match[mf.Name] = matchValue(m)
+				}
+			}
+		}
+
+		if a := e.GetAction().GetAction(); a != nil {
+			action := s.action(a.ActionId)
+			fe.Action = action.Preamble.Name
+
+			for _, p := range a.Params {
+				for _, ap := range action.Params {
+					if ap.Id == p.ParamId {
+						fe.Params[ap.Name] = toUint(p.Value)
+					}
+				}
+			}
+		}
+
+		entries = append(entries, fe)
+	}
+
+	return entries
+}
+
+// MeterConfig returns the configuration of the meter cell at index, or nil if
+// the cell is not configured.
+func (f *FakeP4RT) MeterConfig(meter string, index int64) *p4.MeterConfig {
+	s := f.service
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range s.p4info.Meters {
+		if m.Preamble.Name == meter {
+			return s.meters[m.Preamble.Id][index]
+		}
+	}
+
+	return nil
+}
+
+// SetCounter sets the value of the counter cell at index.
+func (f *FakeP4RT) SetCounter(counter string, index int64, packets, bytes int64) {
+	s := f.service
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.p4info.Counters {
+		if c.Preamble.Name == counter {
+			s.counters[c.Preamble.Id][index] = &p4.CounterData{PacketCount: packets, ByteCount: bytes}
+		}
+	}
+}
+
+// SetTableSize changes the number of entries the table can hold.
+func (f *FakeP4RT) SetTableSize(table string, size int64) {
+	s := f.service
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t := s.tableByName(table); t != nil {
+		t.Size = size
+	}
+}
+
+// Reset removes all the table entries, meter configurations and counter
+// values, as a restart of the switch does.
+func (f *FakeP4RT) Reset() {
+	s := f.service
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset()
+}
+
+func matchValue(m *p4.FieldMatch) uint64 {
+	switch v := m.FieldMatchType.(type) {
+	case *p4.FieldMatch_Exact_:
+		return toUint(v.Exact.Value)
+	case *p4.FieldMatch_Lpm:
+		return toUint(v.Lpm.Value)
+	case *p4.FieldMatch_Ternary_:
+		return toUint(v.Ternary.Value)
+	case *p4.FieldMatch_Range_:
+		return toUint(v.Range.Low)
+	}
+
+	return 0
+}
+
+func toUint(b []byte) uint64 {
+	return new(big.Int).SetBytes(b).Uint64()
+}
@@ -0,0 +1,485 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package fake_p4rt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	p4config "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4 "github.com/p4lang/p4runtime/go/p4/v1"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+type fakeP4RTService struct {
+	p4.UnimplementedP4RuntimeServer
+	mu       sync.Mutex
+	p4info   *p4config.P4Info
+	tables   map[uint32]map[string]*p4.TableEntry
+	meters   map[uint32]map[int64]*p4.MeterConfig
+	counters map[uint32]map[int64]*p4.CounterData
+	// Election IDs of the connected controllers, the highest is the primary.
+	elections map[p4.P4Runtime_StreamChannelServer]*p4.Uint128
+}
+
+func newFakeP4RTService(info *p4config.P4Info) *fakeP4RTService {
+	s := &fakeP4RTService{
+		p4info:    info,
+		elections: make(map[p4.P4Runtime_StreamChannelServer]*p4.Uint128),
+	}
+	s.reset()
+
+	return s
+}
+
+func (s *fakeP4RTService) reset() {
+	s.tables = make(map[uint32]map[string]*p4.TableEntry)
+	s.meters = make(map[uint32]map[int64]*p4.MeterConfig)
+	s.counters = make(map[uint32]map[int64]*p4.CounterData)
+
+	for _, t := range s.p4info.Tables {
+		s.tables[t.Preamble.Id] = make(map[string]*p4.TableEntry)
+	}
+
+	for _, m := range s.p4info.Meters {
+		s.meters[m.Preamble.Id] = make(map[int64]*p4.MeterConfig)
+	}
+
+	for _, c := range s.p4info.Counters {
+		s.counters[c.Preamble.Id] = make(map[int64]*p4.CounterData)
+	}
+}
+
+func (s *fakeP4RTService) table(id uint32) *p4config.Table {
+	for _, t := range s.p4info.Tables {
+		if t.Preamble.Id == id {
+			return t
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeP4RTService) tableByName(name string) *p4config.Table {
+	for _, t := range s.p4info.Tables {
+		if t.Preamble.Name == name {
+			return t
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeP4RTService) action(id uint32) *p4config.Action {
+	for _, a := range s.p4info.Actions {
+		if a.Preamble.Id == id {
+			return a
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeP4RTService) meterSize(id uint32) int64 {
+	for _, m := range s.p4info.Meters {
+		if m.Preamble.Id == id {
+			return m.Size
+		}
+	}
+
+	return 0
+}
+
+func (s *fakeP4RTService) counterSize(id uint32) int64 {
+	for _, c := range s.p4info.Counters {
+		if c.Preamble.Id == id {
+			return c.Size
+		}
+	}
+
+	return 0
+}
+
+func (s *fakeP4RTService) primary() *p4.Uint128 {
+	var primary *p4.Uint128
+
+	for _, id := range s.elections {
+		if primary == nil || compareElectionIDs(id, primary) > 0 {
+			primary = id
+		}
+	}
+
+	return primary
+}
+
+func compareElectionIDs(a, b *p4.Uint128) int {
+	if a.GetHigh() != b.GetHigh() {
+		if a.GetHigh() > b.GetHigh() {
+			return 1
+		}
+
+		return -1
+	}
+
+	switch {
+	case a.GetLow() > b.GetLow():
+		return 1
+	case a.GetLow() < b.GetLow():
+		return -1
+	}
+
+	return 0
+}
+
+// trimBytes returns the canonical representation of a P4Runtime value,
+// without leading zero bytes.
+func trimBytes(b []byte) []byte {
+	b = bytes.TrimLeft(b, "\x00")
+	if len(b) == 0 {
+		return []byte{0}
+	}
+
+	return b
+}
+
+// entryKey identifies the table entries with the same match fields and
+// priority.
+func entryKey(e *p4.TableEntry) string {
+	matches := slices.Clone(e.Match)
+	slices.SortFunc(matches, func(a, b *p4.FieldMatch) int { return int(a.FieldId) - int(b.FieldId) })
+
+	var key strings.Builder
+
+	for _, m := range matches {
+		fmt.Fprintf(&key, "%d:", m.FieldId)
+
+		switch v := m.FieldMatchType.(type) {
+		case *p4.FieldMatch_Exact_:
+			fmt.Fprintf(&key, "%x", trimBytes(v.Exact.Value))
+		case *p4.FieldMatch_Lpm:
+			fmt.Fprintf(&key, "%x/%d", trimBytes(v.Lpm.Value), v.Lpm.PrefixLen)
+		case *p4.FieldMatch_Ternary_:
+			fmt.Fprintf(&key, "%x&%x", trimBytes(v.Ternary.Value), trimBytes(v.Ternary.Mask))
+		case *p4.FieldMatch_Range_:
+			fmt.Fprintf(&key, "%x-%x", trimBytes(v.Range.Low), trimBytes(v.Range.High))
+		}
+
+		key.WriteString(",")
+	}
+
+	fmt.Fprintf(&key, "p%d", e.Priority)
+
+	return key.String()
+}
+
+func (s *fakeP4RTService) validateEntry(t *p4config.Table, e *p4.TableEntry, withAction bool) error {
+	for _, m := range e.Match {
+		if !slices.ContainsFunc(t.MatchFields, func(mf *p4config.MatchField) bool { return mf.Id == m.FieldId }) {
+			return status.Errorf(codes.InvalidArgument, "unknown match field %d of table %s", m.FieldId, t.Preamble.Name)
+		}
+	}
+
+	if !withAction {
+		return nil
+	}
+
+	a := e.GetAction().GetAction()
+	if a == nil || !slices.ContainsFunc(t.ActionRefs, func(r *p4config.ActionRef) bool { return r.Id == a.ActionId }) {
+		return status.Errorf(codes.InvalidArgument, "invalid action of table %s", t.Preamble.Name)
+	}
+
+	return nil
+}
+
+func (s *fakeP4RTService) writeTableEntry(typ p4.Update_Type, e *p4.TableEntry) error {
+	t := s.table(e.TableId)
+	if t == nil {
+		return status.Errorf(codes.InvalidArgument, "unknown table %d", e.TableId)
+	}
+
+	if err := s.validateEntry(t, e, typ != p4.Update_DELETE); err != nil {
+		return err
+	}
+
+	entries := s.tables[e.TableId]
+	key := entryKey(e)
+	_, exists := entries[key]
+
+	switch typ {
+	case p4.Update_INSERT:
+		if exists {
+			return status.Errorf(codes.AlreadyExists, "entry already exists in table %s", t.Preamble.Name)
+		}
+
+		if int64(len(entries)) >= t.Size {
+			return status.Errorf(codes.ResourceExhausted, "table %s is full", t.Preamble.Name)
+		}
+
+		entries[key] = proto.Clone(e).(*p4.TableEntry)
+	case p4.Update_MODIFY:
+		if !exists {
+			return status.Errorf(codes.NotFound, "entry not found in table %s", t.Preamble.Name)
+		}
+
+		entries[key] = proto.Clone(e).(*p4.TableEntry)
+	case p4.Update_DELETE:
+		if !exists {
+			return status.Errorf(codes.NotFound, "entry not found in table %s", t.Preamble.Name)
+		}
+
+		delete(entries, key)
+	default:
+		return status.Errorf(codes.InvalidArgument, "invalid update type %v", typ)
+	}
+
+	return nil
+}
+
+func (s *fakeP4RTService) writeMeterEntry(typ p4.Update_Type, e *p4.MeterEntry) error {
+	cells, ok := s.meters[e.MeterId]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown meter %d", e.MeterId)
+	}
+
+	if typ != p4.Update_MODIFY {
+		return status.Errorf(codes.InvalidArgument, "meters can only be modified")
+	}
+
+	index := e.GetIndex().GetIndex()
+	if e.Index == nil || index < 0 || index >= s.meterSize(e.MeterId) {
+		return status.Errorf(codes.InvalidArgument, "invalid index of meter %d", e.MeterId)
+	}
+
+	if e.Config == nil {
+		delete(cells, index)
+		return nil
+	}
+
+	cells[index] = proto.Clone(e.Config).(*p4.MeterConfig)
+
+	return nil
+}
+
+func (s *fakeP4RTService) writeCounterEntry(typ p4.Update_Type, e *p4.CounterEntry) error {
+	cells, ok := s.counters[e.CounterId]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown counter %d", e.CounterId)
+	}
+
+	if typ != p4.Update_MODIFY {
+		return status.Errorf(codes.InvalidArgument, "counters can only be modified")
+	}
+
+	index := e.GetIndex().GetIndex()
+	if e.Index == nil || index < 0 || index >= s.counterSize(e.CounterId) {
+		return status.Errorf(codes.InvalidArgument, "invalid index of counter %d", e.CounterId)
+	}
+
+	cells[index] = proto.Clone(e.Data).(*p4.CounterData)
+
+	return nil
+}
+
+func (s *fakeP4RTService) write(u *p4.Update) error {
+	switch e := u.GetEntity().GetEntity().(type) {
+	case *p4.Entity_TableEntry:
+		return s.writeTableEntry(u.Type, e.TableEntry)
+	case *p4.Entity_MeterEntry:
+		return s.writeMeterEntry(u.Type, e.MeterEntry)
+	case *p4.Entity_CounterEntry:
+		return s.writeCounterEntry(u.Type, e.CounterEntry)
+	}
+
+	return status.Errorf(codes.Unimplemented, "unsupported entity")
+}
+
+// Write applies the updates one by one. As P4Runtime specifies, if any update
+// fails the error has the status of every update as p4.Error details.
+func (s *fakeP4RTService) Write(ctx context.Context, req *p4.WriteRequest) (*p4.WriteResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	primary := s.primary()
+	if primary == nil || compareElectionIDs(req.ElectionId, primary) != 0 {
+		return nil, status.Errorf(codes.PermissionDenied, "not the primary controller")
+	}
+
+	details := make([]protoadapt.MessageV1, 0, len(req.Updates))
+	failed := false
+
+	for _, u := range req.Updates {
+		p4err := &p4.Error{CanonicalCode: int32(codes.OK)}
+
+		if err := s.write(u); err != nil {
+			st := status.Convert(err)
+			p4err = &p4.Error{CanonicalCode: int32(st.Code()), Message: st.Message()}
+			failed = true
+		}
+
+		details = append(details, protoadapt.MessageV1Of(p4err))
+	}
+
+	if !failed {
+		return &p4.WriteResponse{}, nil
+	}
+
+	st, err := status.New(codes.Unknown, "write failure").WithDetails(details...)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, st.Err()
+}
+
+func (s *fakeP4RTService) readEntities(e *p4.Entity) []*p4.Entity {
+	var entities []*p4.Entity
+
+	switch e := e.GetEntity().(type) {
+	case *p4.Entity_TableEntry:
+		for id, entries := range s.tables {
+			if e.TableEntry.TableId != 0 && e.TableEntry.TableId != id {
+				continue
+			}
+
+			for _, te := range entries {
+				entities = append(entities, &p4.Entity{Entity: &p4.Entity_TableEntry{TableEntry: te}})
+			}
+		}
+	case *p4.Entity_MeterEntry:
+		for id, cells := range s.meters {
+			if e.MeterEntry.MeterId != 0 && e.MeterEntry.MeterId != id {
+				continue
+			}
+
+			for index, config := range cells {
+				if e.MeterEntry.Index != nil && e.MeterEntry.Index.Index != index {
+					continue
+				}
+
+				entities = append(entities, &p4.Entity{Entity: &p4.Entity_MeterEntry{MeterEntry: &p4.MeterEntry{
+					MeterId: id, Index: &p4.Index{Index: index}, Config: config,
+				}}})
+			}
+		}
+	case *p4.Entity_CounterEntry:
+		for id, cells := range s.counters {
+			if e.CounterEntry.CounterId != 0 && e.CounterEntry.CounterId != id {
+				continue
+			}
+
+			for index, data := range cells {
+				if e.CounterEntry.Index != nil && e.CounterEntry.Index.Index != index {
+					continue
+				}
+
+				entities = append(entities, &p4.Entity{Entity: &p4.Entity_CounterEntry{CounterEntry: &p4.CounterEntry{
+					CounterId: id, Index: &p4.Index{Index: index}, Data: data,
+				}}})
+			}
+		}
+	}
+
+	return entities
+}
+
+func (s *fakeP4RTService) Read(req *p4.ReadRequest, stream p4.P4Runtime_ReadServer) error {
+	s.mu.Lock()
+
+	resp := &p4.ReadResponse{}
+	for _, e := range req.Entities {
+		resp.Entities = append(resp.Entities, s.readEntities(e)...)
+	}
+
+	s.mu.Unlock()
+
+	return stream.Send(resp)
+}
+
+func (s *fakeP4RTService) GetForwardingPipelineConfig(ctx context.Context,
+	req *p4.GetForwardingPipelineConfigRequest,
+) (*p4.GetForwardingPipelineConfigResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &p4.GetForwardingPipelineConfigResponse{
+		Config: &p4.ForwardingPipelineConfig{P4Info: s.p4info},
+	}, nil
+}
+
+func (s *fakeP4RTService) SetForwardingPipelineConfig(ctx context.Context,
+	req *p4.SetForwardingPipelineConfigRequest,
+) (*p4.SetForwardingPipelineConfigResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.GetConfig().GetP4Info() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "missing P4Info")
+	}
+
+	s.p4info = req.Config.P4Info
+	s.reset()
+
+	return &p4.SetForwardingPipelineConfigResponse{}, nil
+}
+
+func (s *fakeP4RTService) Capabilities(ctx context.Context, req *p4.CapabilitiesRequest) (*p4.CapabilitiesResponse, error) {
+	return &p4.CapabilitiesResponse{P4RuntimeApiVersion: "1.5.0"}, nil
+}
+
+// arbitrate records the election ID of the controller of stream, and returns
+// whether it is the primary.
+func (s *fakeP4RTService) arbitrate(stream p4.P4Runtime_StreamChannelServer, id *p4.Uint128) *p4.Uint128 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.elections[stream] = id
+
+	return s.primary()
+}
+
+func (s *fakeP4RTService) StreamChannel(stream p4.P4Runtime_StreamChannelServer) error {
+	defer func() {
+		s.mu.Lock()
+		delete(s.elections, stream)
+		s.mu.Unlock()
+	}()
+
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		arbitration := req.GetArbitration()
+		if arbitration == nil {
+			continue
+		}
+
+		primary := s.arbitrate(stream, arbitration.ElectionId)
+
+		code := codes.OK
+		if compareElectionIDs(primary, arbitration.ElectionId) != 0 {
+			code = codes.AlreadyExists
+		}
+
+		err = stream.Send(&p4.StreamMessageResponse{
+			Update: &p4.StreamMessageResponse_Arbitration{Arbitration: &p4.MasterArbitrationUpdate{
+				DeviceId:   arbitration.DeviceId,
+				ElectionId: primary,
+				Status:     &rpcstatus.Status{Code: int32(code)},
+			}},
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
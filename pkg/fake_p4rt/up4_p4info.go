@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2026-present Open Networking Foundation
+
+package fake_p4rt
+
+import (
+	p4config "github.com/p4lang/p4runtime/go/p4/config/v1"
+)
+
+// ID prefixes of the P4Runtime entities.
+const (
+	actionIDPrefix  = 0x01000000
+	tableIDPrefix   = 0x02000000
+	counterIDPrefix = 0x12000000
+	meterIDPrefix   = 0x15000000
+
+	defaultTableSize = 1024
+)
+
+type matchField struct {
+	name  string
+	bits  int32
+	match p4config.MatchField_MatchType
+}
+
+type actionParam struct {
+	name string
+	bits int32
+}
+
+var up4Actions = []struct {
+	name   string
+	params []actionParam
+}{
+	{"PreQosPipe.set_source_iface", []actionParam{{"src_iface", 8}, {"direction", 8}, {"slice_id", 4}}},
+	{"PreQosPipe.set_session_uplink", []actionParam{{"session_meter_idx", 32}}},
+	{"PreQosPipe.set_session_uplink_drop", nil},
+	{"PreQosPipe.set_session_downlink", []actionParam{{"tunnel_peer_id", 8}, {"session_meter_idx", 32}}},
+	{"PreQosPipe.set_session_downlink_drop", nil},
+	{"PreQosPipe.set_session_downlink_buff", []actionParam{{"session_meter_idx", 32}}},
+	{"PreQosPipe.uplink_term_fwd", []actionParam{{"ctr_idx", 32}, {"tc", 2}, {"app_meter_idx", 32}}},
+	{"PreQosPipe.uplink_term_drop", []actionParam{{"ctr_idx", 32}}},
+	{"PreQosPipe.downlink_term_fwd", []actionParam{{"ctr_idx", 32}, {"teid", 32}, {"qfi", 6}, {"tc", 2}, {"app_meter_idx", 32}}},
+	{"PreQosPipe.downlink_term_drop", []actionParam{{"ctr_idx", 32}}},
+	{"PreQosPipe.set_app_id", []actionParam{{"app_id", 8}}},
+	{"PreQosPipe.load_tunnel_param", []actionParam{{"src_addr", 32}, {"dst_addr", 32}, {"sport", 16}}},
+}
+
+var up4Tables = []struct {
+	name    string
+	fields  []matchField
+	actions []string
+	size    int64
+}{
+	{
+		name:    "PreQosPipe.interfaces",
+		fields:  []matchField{{"ipv4_dst_prefix", 32, p4config.MatchField_LPM}},
+		actions: []string{"PreQosPipe.set_source_iface"},
+		size:    64,
+	},
+	{
+		name: "PreQosPipe.sessions_uplink",
+		fields: []matchField{
+			{"n3_address", 32, p4config.MatchField_EXACT},
+			{"teid", 32, p4config.MatchField_EXACT},
+		},
+		actions: []string{"PreQosPipe.set_session_uplink", "PreQosPipe.set_session_uplink_drop"},
+	},
+	{
+		name:   "PreQosPipe.sessions_downlink",
+		fields: []matchField{{"ue_address", 32, p4config.MatchField_EXACT}},
+		actions: []string{
+			"PreQosPipe.set_session_downlink", "PreQosPipe.set_session_downlink_drop",
+			"PreQosPipe.set_session_downlink_buff",
+		},
+	},
+	{
+		name: "PreQosPipe.terminations_uplink",
+		fields: []matchField{
+			{"ue_address", 32, p4config.MatchField_EXACT},
+			{"app_id", 8, p4config.MatchField_EXACT},
+		},
+		actions: []string{"PreQosPipe.uplink_term_fwd", "PreQosPipe.uplink_term_drop"},
+	},
+	{
+		name: "PreQosPipe.terminations_downlink",
+		fields: []matchField{
+			{"ue_address", 32, p4config.MatchField_EXACT},
+			{"app_id", 8, p4config.MatchField_EXACT},
+		},
+		actions: []string{"PreQosPipe.downlink_term_fwd", "PreQosPipe.downlink_term_drop"},
+	},
+	{
+		name: "PreQosPipe.applications",
+		fields: []matchField{
+			{"app_ip_addr", 32, p4config.MatchField_LPM},
+			{"app_l4_port", 16, p4config.MatchField_RANGE},
+			{"app_ip_proto", 8, p4config.MatchField_TERNARY},
+		},
+		actions: []string{"PreQosPipe.set_app_id"},
+		size:    256,
+	},
+	{
+		name:    "PreQosPipe.tunnel_peers",
+		fields:  []matchField{{"tunnel_peer_id", 8, p4config.MatchField_EXACT}},
+		actions: []string{"PreQosPipe.load_tunnel_param"},
+		size:    256,
+	},
+}
+
+var (
+	up4Meters   = []string{"PreQosPipe.app_meter", "PreQosPipe.session_meter"}
+	up4Counters = []string{"PreQosPipe.pre_qos_counter", "PostQosPipe.post_qos_counter"}
+)
+
+// UP4P4Info returns the P4Info of the UP4 pipeline served by the fake
+// P4Runtime server.
+func UP4P4Info() *p4config.P4Info {
+	info := &p4config.P4Info{}
+	actionIDs := make(map[string]uint32)
+
+	for i, a := range up4Actions {
+		action := &p4config.Action{
+			Preamble: &p4config.Preamble{Id: actionIDPrefix + uint32(i) + 1, Name: a.name},
+		}
+
+		for j, p := range a.params {
+			action.Params = append(action.Params, &p4config.Action_Param{Id: uint32(j) + 1, Name: p.name, Bitwidth: p.bits})
+		}
+
+		actionIDs[a.name] = action.Preamble.Id
+		info.Actions = append(info.Actions, action)
+	}
+
+	for i, t := range up4Tables {
+		table := &p4config.Table{
+			Preamble: &p4config.Preamble{Id: tableIDPrefix + uint32(i) + 1, Name: t.name},
+			Size:     t.size,
+		}
+
+		if table.Size == 0 {
+			table.Size = defaultTableSize
+		}
+
+		for j, f := range t.fields {
+			table.MatchFields = append(table.MatchFields, &p4config.MatchField{
+				Id:       uint32(j) + 1,
+				Name:     f.name,
+				Bitwidth: f.bits,
+				Match:    &p4config.MatchField_MatchType_{MatchType: f.match},
+			})
+		}
+
+		for _, a := range t.actions {
+			table.ActionRefs = append(table.ActionRefs, &p4config.ActionRef{Id: actionIDs[a]})
+		}
+
+		info.Tables = append(info.Tables, table)
+	}
+
+	for i, m := range up4Meters {
+		info.Meters = append(info.Meters, &p4config.Meter{
+			Preamble: &p4config.Preamble{Id: meterIDPrefix + uint32(i) + 1, Name: m},
+			Spec:     &p4config.MeterSpec{Unit: p4config.MeterSpec_BYTES},
+			Size:     defaultTableSize,
+		})
+	}
+
+	for i, c := range up4Counters {
+		info.Counters = append(info.Counters, &p4config.Counter{
+			Preamble: &p4config.Preamble{Id: counterIDPrefix + uint32(i) + 1, Name: c},
+			Spec:     &p4config.CounterSpec{Unit: p4config.CounterSpec_BOTH},
+			Size:     defaultTableSize,
+		})
+	}
+
+	return info
+}